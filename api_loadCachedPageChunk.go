@@ -37,9 +37,10 @@ type RecordMap struct {
 	Activities      map[string]*Record `json:"activity"`
 	Blocks          map[string]*Record `json:"block"`
 	Spaces          map[string]*Record `json:"space"`
+	SpaceViews      map[string]*Record `json:"space_view"`
 	NotionUsers     map[string]*Record `json:"notion_user"`
 	UsersRoot       map[string]*Record `json:"user_root"`
-	UserSettings    map[string]*Record `json:"user_setting"`
+	UserSettings    map[string]*Record `json:"user_settings"`
 	Collections     map[string]*Record `json:"collection"`
 	CollectionViews map[string]*Record `json:"collection_view"`
 	Comments        map[string]*Record `json:"comment"`
@@ -96,6 +97,12 @@ func ParseRecordMap(recordMap *RecordMap) error {
 		}
 	}
 
+	for _, r := range recordMap.SpaceViews {
+		if err := parseRecord(TableSpaceView, r); err != nil {
+			return err
+		}
+	}
+
 	for _, r := range recordMap.NotionUsers {
 		if err := parseRecord(TableNotionUser, r); err != nil {
 			return err
@@ -1,58 +1,267 @@
 package notionapi
 
-import "encoding/json"
+import (
+	"sort"
+)
 
-type LoadUserResponse struct {
-	ID    string `json:"id"`
-	Table string `json:"table"`
-	Role  string `json:"role"`
+// LoadUserContentResponse is a response to /api/v3/loadUserContent api
+type LoadUserContentResponse struct {
+	RecordMap *RecordMap `json:"recordMap"`
 
-	Value json.RawMessage `json:"value"`
-
-	Block *Block      `json:"-"`
-	Space *Space      `json:"-"`
-	User  *NotionUser `json:"-"`
+	// calculated by us from RecordMap
+	Users        []*NotionUser `json:"-"`
+	Spaces       []*Space      `json:"-"`
+	SpaceViews   []*SpaceView  `json:"-"`
+	Blocks       []*Block      `json:"-"`
+	UserRoot     *UserRoot     `json:"-"`
+	UserSettings *UserSettings `json:"-"`
 
 	RawJSON map[string]interface{} `json:"-"`
 }
 
-func (c *Client) LoadUserContent() (*LoadUserResponse, error) {
-	req := struct{}{}
+// Workspace describes a space (workspace) the user has access to
+// and its top-level pages
+type Workspace struct {
+	Space *Space
+	// per-user info about the space, can be nil
+	SpaceView *SpaceView
+	// ids of top-level pages of this space. Those are shared pages
+	// of the workspace (Space.Pages) followed by user's private pages
+	PageIDs []string
+	// Pages are blocks for PageIDs, in the same order.
+	// A block is nil if we don't have it
+	Pages []*Block
+}
 
-	var rsp struct {
-		RecordMap map[string]map[string]*LoadUserResponse `json:"recordMap"`
+func sortedRecordIDs(m map[string]*Record) []string {
+	var ids []string
+	for id := range m {
+		ids = append(ids, id)
 	}
-	apiURL := "/api/v3/loadUserContent"
-	result := LoadUserResponse{}
+	sort.Strings(ids)
+	return ids
+}
 
-	err := c.doNotionAPI(apiURL, req, &rsp, &result.RawJSON)
-	if err != nil {
-		return nil, err
+func (r *LoadUserContentResponse) resolve() error {
+	rm := r.RecordMap
+	if rm == nil {
+		return nil
 	}
-
-	for table, values := range rsp.RecordMap {
-		for _, value := range values {
-			var obj interface{}
-			if table == TableNotionUser {
-				result.User = &NotionUser{}
-				obj = result.User
-			}
-			if table == TableBlock {
-				result.Block = &Block{}
-				obj = result.Block
-			}
-			if table == TableSpace {
-				result.Space = &Space{}
-				obj = result.Space
+	// iterate in a fixed order so that results are stable
+	for _, id := range sortedRecordIDs(rm.NotionUsers) {
+		if u := rm.NotionUsers[id].NotionUser; u != nil {
+			r.Users = append(r.Users, u)
+		}
+	}
+	for _, id := range sortedRecordIDs(rm.Spaces) {
+		if s := rm.Spaces[id].Space; s != nil {
+			r.Spaces = append(r.Spaces, s)
+		}
+	}
+	for _, id := range sortedRecordIDs(rm.SpaceViews) {
+		if sv := rm.SpaceViews[id].SpaceView; sv != nil {
+			r.SpaceViews = append(r.SpaceViews, sv)
+		}
+	}
+	for _, id := range sortedRecordIDs(rm.Blocks) {
+		if b := rm.Blocks[id].Block; b != nil {
+			if err := parseProperties(b); err != nil {
+				return err
 			}
-			if obj == nil {
+			r.Blocks = append(r.Blocks, b)
+		}
+	}
+	// there's only one of those, for the current user
+	for _, id := range sortedRecordIDs(rm.UsersRoot) {
+		if ur := rm.UsersRoot[id].UserRoot; ur != nil {
+			r.UserRoot = ur
+		}
+	}
+	for _, id := range sortedRecordIDs(rm.UserSettings) {
+		if us := rm.UserSettings[id].UserSettings; us != nil {
+			r.UserSettings = us
+		}
+	}
+	return nil
+}
+
+// TimeZone returns user's time zone, e.g. "America/Los_Angeles"
+// Returns "" if not known.
+func (r *LoadUserContentResponse) TimeZone() string {
+	if r.UserSettings == nil {
+		return ""
+	}
+	return r.UserSettings.Settings.TimeZone
+}
+
+// SpaceByID returns a space with a given id
+func (r *LoadUserContentResponse) SpaceByID(id string) *Space {
+	id = ToDashID(id)
+	for _, s := range r.Spaces {
+		if s.ID == id {
+			return s
+		}
+	}
+	return nil
+}
+
+// BlockByID returns a block with a given id, if returned by loadUserContent
+func (r *LoadUserContentResponse) BlockByID(id string) *Block {
+	id = ToDashID(id)
+	for _, b := range r.Blocks {
+		if b.ID == id {
+			return b
+		}
+	}
+	return nil
+}
+
+func (r *LoadUserContentResponse) spaceViewByID(id string) *SpaceView {
+	for _, sv := range r.SpaceViews {
+		if sv.ID == id {
+			return sv
+		}
+	}
+	return nil
+}
+
+func (r *LoadUserContentResponse) spaceViewForSpace(spaceID string) *SpaceView {
+	if r.UserRoot != nil {
+		for _, p := range r.UserRoot.SpaceViewPointers {
+			if p.SpaceID != spaceID {
 				continue
 			}
-			if err := jsonit.Unmarshal(value.Value, &obj); err != nil {
-				return nil, err
+			if sv := r.spaceViewByID(p.ID); sv != nil {
+				return sv
 			}
 		}
 	}
+	for _, sv := range r.SpaceViews {
+		if sv.SpaceID == spaceID {
+			return sv
+		}
+	}
+	return nil
+}
 
-	return &result, nil
+func appendUniqueIDs(res []string, seen map[string]bool, ids []string) []string {
+	for _, id := range ids {
+		id = ToDashID(id)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		res = append(res, id)
+	}
+	return res
+}
+
+func (r *LoadUserContentResponse) makeWorkspace(space *Space) *Workspace {
+	ws := &Workspace{
+		Space:     space,
+		SpaceView: r.spaceViewForSpace(space.ID),
+	}
+	seen := map[string]bool{}
+	ws.PageIDs = appendUniqueIDs(ws.PageIDs, seen, space.Pages)
+	if sv := ws.SpaceView; sv != nil {
+		ws.PageIDs = appendUniqueIDs(ws.PageIDs, seen, sv.PrivatePages)
+		ws.PageIDs = appendUniqueIDs(ws.PageIDs, seen, sv.SharedPages)
+	}
+	for _, id := range ws.PageIDs {
+		ws.Pages = append(ws.Pages, r.BlockByID(id))
+	}
+	return ws
+}
+
+// Workspaces returns all spaces the user has access to with their
+// top-level pages. Spaces are in the order of UserRoot.SpaceViewPointers
+// (which is the order in which Notion shows them).
+// Pages are only those that were returned by loadUserContent.
+// Use Client.GetWorkspaces to get all pages.
+func (r *LoadUserContentResponse) Workspaces() []*Workspace {
+	var res []*Workspace
+	seen := map[string]bool{}
+	add := func(space *Space) {
+		if space == nil || seen[space.ID] {
+			return
+		}
+		seen[space.ID] = true
+		res = append(res, r.makeWorkspace(space))
+	}
+	if r.UserRoot != nil {
+		for _, p := range r.UserRoot.SpaceViewPointers {
+			add(r.SpaceByID(p.SpaceID))
+		}
+	}
+	for _, space := range r.Spaces {
+		add(space)
+	}
+	return res
+}
+
+// LoadUserContent executes a raw API call /api/v3/loadUserContent
+// It returns information about current user (identified by AuthToken),
+// spaces (workspaces) user has access to and their top-level pages
+func (c *Client) LoadUserContent() (*LoadUserContentResponse, error) {
+	req := struct{}{}
+
+	var rsp LoadUserContentResponse
+	var err error
+	apiURL := "/api/v3/loadUserContent"
+	if err = c.doNotionAPI(apiURL, req, &rsp, &rsp.RawJSON); err != nil {
+		return nil, err
+	}
+	if rsp.RecordMap != nil {
+		if err = ParseRecordMap(rsp.RecordMap); err != nil {
+			return nil, err
+		}
+	}
+	if err = rsp.resolve(); err != nil {
+		return nil, err
+	}
+	return &rsp, nil
+}
+
+// GetWorkspaces returns all spaces the user has access to with
+// all their top-level pages. This allows discovering all pages
+// to crawl without knowing root page ids.
+func (c *Client) GetWorkspaces() ([]*Workspace, error) {
+	rsp, err := c.LoadUserContent()
+	if err != nil {
+		return nil, err
+	}
+	res := rsp.Workspaces()
+	var missing []string
+	for _, ws := range res {
+		for i, b := range ws.Pages {
+			if b == nil {
+				missing = append(missing, ws.PageIDs[i])
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return res, nil
+	}
+	blocks, err := c.GetBlockRecords(missing)
+	if err != nil {
+		return nil, err
+	}
+	idToBlock := map[string]*Block{}
+	for _, b := range blocks {
+		if b == nil {
+			continue
+		}
+		if err := parseProperties(b); err != nil {
+			return nil, err
+		}
+		idToBlock[b.ID] = b
+	}
+	for _, ws := range res {
+		for i, b := range ws.Pages {
+			if b == nil {
+				ws.Pages[i] = idToBlock[ws.PageIDs[i]]
+			}
+		}
+	}
+	return res, nil
 }
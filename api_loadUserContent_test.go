@@ -0,0 +1,144 @@
+package notionapi
+
+import (
+	"testing"
+
+	"github.com/kjk/common/require"
+)
+
+const (
+	loadUserContentJSON1 = `
+{
+  "recordMap": {
+    "notion_user": {
+      "bb760e2d-d679-4b64-b2a9-03005b21870a": {
+        "role": "editor",
+        "value": {
+          "id": "bb760e2d-d679-4b64-b2a9-03005b21870a",
+          "version": 12,
+          "email": "kkowalczyk@gmail.com",
+          "given_name": "Krzysztof",
+          "family_name": "Kowalczyk"
+        }
+      }
+    },
+    "user_root": {
+      "bb760e2d-d679-4b64-b2a9-03005b21870a": {
+        "role": "editor",
+        "value": {
+          "id": "bb760e2d-d679-4b64-b2a9-03005b21870a",
+          "version": 3,
+          "space_views": ["5d8c2ae5-a1cc-4a2b-9f0f-5a1e4ba8d3a6"],
+          "space_view_pointers": [
+            {
+              "id": "5d8c2ae5-a1cc-4a2b-9f0f-5a1e4ba8d3a6",
+              "table": "space_view",
+              "spaceId": "bc202e06-6caa-4e3f-81eb-f226ab5deef7"
+            }
+          ]
+        }
+      }
+    },
+    "user_settings": {
+      "bb760e2d-d679-4b64-b2a9-03005b21870a": {
+        "role": "editor",
+        "value": {
+          "id": "bb760e2d-d679-4b64-b2a9-03005b21870a",
+          "version": 5,
+          "settings": {
+            "type": "personal",
+            "time_zone": "Europe/Warsaw"
+          }
+        }
+      }
+    },
+    "space": {
+      "bc202e06-6caa-4e3f-81eb-f226ab5deef7": {
+        "role": "editor",
+        "value": {
+          "id": "bc202e06-6caa-4e3f-81eb-f226ab5deef7",
+          "name": "kjk",
+          "pages": ["0367c2db-381a-4f8b-9ce3-60f388a6b2e3"]
+        }
+      },
+      "da1b2f0c-0c3e-4d2e-9b4a-7d6f1a8e9c10": {
+        "role": "reader",
+        "value": {
+          "id": "da1b2f0c-0c3e-4d2e-9b4a-7d6f1a8e9c10",
+          "name": "other"
+        }
+      }
+    },
+    "space_view": {
+      "5d8c2ae5-a1cc-4a2b-9f0f-5a1e4ba8d3a6": {
+        "role": "editor",
+        "value": {
+          "id": "5d8c2ae5-a1cc-4a2b-9f0f-5a1e4ba8d3a6",
+          "space_id": "bc202e06-6caa-4e3f-81eb-f226ab5deef7",
+          "parent_id": "bb760e2d-d679-4b64-b2a9-03005b21870a",
+          "parent_table": "user_root",
+          "alive": true,
+          "private_pages": [
+            "c3039398-9ae5-49c3-a39f-21ca5a681d72",
+            "0367c2db-381a-4f8b-9ce3-60f388a6b2e3"
+          ]
+        }
+      }
+    },
+    "block": {
+      "0367c2db-381a-4f8b-9ce3-60f388a6b2e3": {
+        "role": "editor",
+        "value": {
+          "alive": true,
+          "id": "0367c2db-381a-4f8b-9ce3-60f388a6b2e3",
+          "parent_id": "bc202e06-6caa-4e3f-81eb-f226ab5deef7",
+          "parent_table": "space",
+          "properties": {
+            "title": [["Test pages for notionapi"]]
+          },
+          "space_id": "bc202e06-6caa-4e3f-81eb-f226ab5deef7",
+          "type": "page",
+          "version": 366
+        }
+      }
+    }
+  }
+}
+`
+)
+
+func TestLoadUserContent1(t *testing.T) {
+	var rsp LoadUserContentResponse
+	err := jsonit.Unmarshal([]byte(loadUserContentJSON1), &rsp)
+	require.NoError(t, err)
+	err = ParseRecordMap(rsp.RecordMap)
+	require.NoError(t, err)
+	err = rsp.resolve()
+	require.NoError(t, err)
+
+	require.Equal(t, 1, len(rsp.Users))
+	require.Equal(t, "Krzysztof", rsp.Users[0].GivenName)
+	require.Equal(t, 2, len(rsp.Spaces))
+	require.Equal(t, "Europe/Warsaw", rsp.TimeZone())
+	require.Equal(t, 1, len(rsp.UserRoot.SpaceViewPointers))
+
+	wss := rsp.Workspaces()
+	require.Equal(t, 2, len(wss))
+	// first is the one from space_view_pointers
+	ws := wss[0]
+	require.Equal(t, "kjk", ws.Space.Name)
+	require.NotNil(t, ws.SpaceView)
+	// de-duplicated, space pages first
+	exp := []string{
+		"0367c2db-381a-4f8b-9ce3-60f388a6b2e3",
+		"c3039398-9ae5-49c3-a39f-21ca5a681d72",
+	}
+	require.Equal(t, exp, ws.PageIDs)
+	require.Equal(t, "Test pages for notionapi", ws.Pages[0].Title)
+	require.Nil(t, ws.Pages[1])
+
+	ws = wss[1]
+	require.Equal(t, "other", ws.Space.Name)
+	require.Nil(t, ws.SpaceView)
+	require.Equal(t, 0, len(ws.PageIDs))
+}
@@ -0,0 +1,290 @@
+package notionapi
+
+import (
+	"strings"
+)
+
+const (
+	// SearchSortRelevance sorts search results by relevance (default)
+	SearchSortRelevance = "Relevance"
+	// SearchSortLastEditedNewest sorts by last edited time, newest first
+	SearchSortLastEditedNewest = "LastEditedNewest"
+	// SearchSortLastEditedOldest sorts by last edited time, oldest first
+	SearchSortLastEditedOldest = "LastEditedOldest"
+	// SearchSortCreatedNewest sorts by created time, newest first
+	SearchSortCreatedNewest = "CreatedNewest"
+	// SearchSortCreatedOldest sorts by created time, oldest first
+	SearchSortCreatedOldest = "CreatedOldest"
+)
+
+const (
+	// Notion marks matched text in SearchHighlight.Text with this
+	// pseudo-tag e.g. "foo <gzkNfoUU>bar</gzkNfoUU>"
+	searchHighlightStart = "<gzkNfoUU>"
+	searchHighlightEnd   = "</gzkNfoUU>"
+
+	defaultSearchLimit = 20
+)
+
+// SearchTimeRange limits search results to a time range.
+// Times are in milliseconds, like Block.CreatedTime
+type SearchTimeRange struct {
+	StartTime int64 `json:"starting,omitempty"`
+	EndTime   int64 `json:"ending,omitempty"`
+}
+
+type searchFilters struct {
+	IsDeletedOnly          bool             `json:"isDeletedOnly"`
+	ExcludeTemplates       bool             `json:"excludeTemplates"`
+	IsNavigableOnly        bool             `json:"isNavigableOnly"`
+	RequireEditPermissions bool             `json:"requireEditPermissions"`
+	Ancestors              []string         `json:"ancestors"`
+	CreatedBy              []string         `json:"createdBy"`
+	EditedBy               []string         `json:"editedBy"`
+	LastEditedTime         *SearchTimeRange `json:"lastEditedTime"`
+	CreatedTime            *SearchTimeRange `json:"createdTime"`
+}
+
+// /api/v3/search request
+type searchRequest struct {
+	Type    string        `json:"type"`
+	Query   string        `json:"query"`
+	SpaceID string        `json:"spaceId"`
+	Limit   int           `json:"limit"`
+	Filters searchFilters `json:"filters"`
+	Sort    string        `json:"sort"`
+	Source  string        `json:"source"`
+}
+
+// SearchOptions describes optional arguments to Client.Search
+type SearchOptions struct {
+	// maximum number of results to return. 20 if not given
+	Limit int
+	// SearchSortRelevance etc. SearchSortRelevance if not given
+	Sort string
+	// only return blocks that are descendants of those pages
+	AncestorIDs []string
+	// only return blocks created by those users
+	CreatedByIDs []string
+	// only return blocks last edited by those users
+	EditedByIDs []string
+	// only return blocks last edited in this time range
+	LastEditedTime *SearchTimeRange
+	// only return blocks created in this time range
+	CreatedTime *SearchTimeRange
+	// if true, only returns blocks that are in trash
+	InTrash bool
+	// if true, only returns pages (as opposed to e.g. text blocks
+	// inside pages)
+	PagesOnly bool
+	// if true, excludes template pages
+	ExcludeTemplates bool
+}
+
+// SearchHighlight is text of a search result with matches marked
+type SearchHighlight struct {
+	// text of the block, matched parts are surrounded by a Notion specific
+	// tag. Use Fragments() to get it parsed
+	Text string `json:"text"`
+	// text of the path of parent pages
+	PathText string `json:"pathText"`
+}
+
+// SearchHighlightFragment is a part of highlighted search result
+type SearchHighlightFragment struct {
+	Text    string
+	IsMatch bool
+}
+
+// SearchResult describes a single result of a search
+type SearchResult struct {
+	ID          string           `json:"id"`
+	IsNavigable bool             `json:"isNavigable"`
+	Score       float64          `json:"score"`
+	Highlight   *SearchHighlight `json:"highlight"`
+
+	// set by us from RecordMap in the response, can be nil
+	Block *Block `json:"-"`
+}
+
+// SearchResponse is a response to /api/v3/search api
+type SearchResponse struct {
+	Results   []*SearchResult `json:"results"`
+	Total     int             `json:"total"`
+	RecordMap *RecordMap      `json:"recordMap"`
+
+	RawJSON map[string]interface{} `json:"-"`
+}
+
+// ParseSearchHighlight splits highlighted text returned by search
+// into matched and not matched fragments
+func ParseSearchHighlight(s string) []*SearchHighlightFragment {
+	var res []*SearchHighlightFragment
+	add := func(text string, isMatch bool) {
+		if text == "" {
+			return
+		}
+		res = append(res, &SearchHighlightFragment{
+			Text:    text,
+			IsMatch: isMatch,
+		})
+	}
+	for len(s) > 0 {
+		start := strings.Index(s, searchHighlightStart)
+		if start < 0 {
+			add(s, false)
+			break
+		}
+		add(s[:start], false)
+		s = s[start+len(searchHighlightStart):]
+		end := strings.Index(s, searchHighlightEnd)
+		if end < 0 {
+			add(s, true)
+			break
+		}
+		add(s[:end], true)
+		s = s[end+len(searchHighlightEnd):]
+	}
+	return res
+}
+
+// Fragments returns highlighted text split into matched
+// and not matched fragments
+func (h *SearchHighlight) Fragments() []*SearchHighlightFragment {
+	if h == nil {
+		return nil
+	}
+	return ParseSearchHighlight(h.Text)
+}
+
+// PlainText returns highlighted text without highlight markers
+func (h *SearchHighlight) PlainText() string {
+	if h == nil {
+		return ""
+	}
+	s := strings.Replace(h.Text, searchHighlightStart, "", -1)
+	return strings.Replace(s, searchHighlightEnd, "", -1)
+}
+
+func makeSearchRequest(spaceID string, query string, opts *SearchOptions) *searchRequest {
+	if opts == nil {
+		opts = &SearchOptions{}
+	}
+	req := &searchRequest{
+		Type:    "BlocksInSpace",
+		Query:   query,
+		SpaceID: ToDashID(spaceID),
+		Limit:   opts.Limit,
+		Sort:    opts.Sort,
+		Source:  "quick_find",
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultSearchLimit
+	}
+	if req.Sort == "" {
+		req.Sort = SearchSortRelevance
+	}
+	f := &req.Filters
+	f.IsDeletedOnly = opts.InTrash
+	f.IsNavigableOnly = opts.PagesOnly
+	f.ExcludeTemplates = opts.ExcludeTemplates
+	// server expects empty arrays, not null
+	f.Ancestors = toDashIDs(opts.AncestorIDs)
+	f.CreatedBy = toDashIDs(opts.CreatedByIDs)
+	f.EditedBy = toDashIDs(opts.EditedByIDs)
+	f.LastEditedTime = opts.LastEditedTime
+	if f.LastEditedTime == nil {
+		f.LastEditedTime = &SearchTimeRange{}
+	}
+	f.CreatedTime = opts.CreatedTime
+	if f.CreatedTime == nil {
+		f.CreatedTime = &SearchTimeRange{}
+	}
+	return req
+}
+
+func toDashIDs(ids []string) []string {
+	res := []string{}
+	for _, id := range ids {
+		res = append(res, ToDashID(id))
+	}
+	return res
+}
+
+// resolve SearchResult.Block from records returned with the response
+func (r *SearchResponse) resolveBlocks() error {
+	if r.RecordMap == nil {
+		return nil
+	}
+	for _, res := range r.Results {
+		rec := r.RecordMap.Blocks[res.ID]
+		if rec == nil || rec.Block == nil {
+			continue
+		}
+		if err := parseProperties(rec.Block); err != nil {
+			return err
+		}
+		res.Block = rec.Block
+	}
+	return nil
+}
+
+// Search executes a raw API call /api/v3/search and searches for blocks
+// in a space (workspace) with a given id.
+// opts can be nil, in which case we return first 20 results sorted by relevance
+func (c *Client) Search(spaceID string, query string, opts *SearchOptions) (*SearchResponse, error) {
+	req := makeSearchRequest(spaceID, query, opts)
+	var rsp SearchResponse
+	var err error
+	apiURL := "/api/v3/search"
+	if err = c.doNotionAPI(apiURL, req, &rsp, &rsp.RawJSON); err != nil {
+		return nil, err
+	}
+	if rsp.RecordMap != nil {
+		if err = ParseRecordMap(rsp.RecordMap); err != nil {
+			return nil, err
+		}
+	}
+	if err = rsp.resolveBlocks(); err != nil {
+		return nil, err
+	}
+	return &rsp, nil
+}
+
+// SearchAll returns all results for a search, paginating as needed.
+// /api/v3/search doesn't support cursors so we paginate by asking
+// for more results and skipping the ones we've already seen.
+// maxResults limits number of results (0 means no limit)
+func (c *Client) SearchAll(spaceID string, query string, opts *SearchOptions, maxResults int) ([]*SearchResult, error) {
+	var o SearchOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Limit <= 0 {
+		o.Limit = defaultSearchLimit
+	}
+	pageSize := o.Limit
+	seen := map[string]bool{}
+	var res []*SearchResult
+	for {
+		rsp, err := c.Search(spaceID, query, &o)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rsp.Results {
+			if seen[r.ID] {
+				continue
+			}
+			seen[r.ID] = true
+			res = append(res, r)
+			if maxResults > 0 && len(res) >= maxResults {
+				return res, nil
+			}
+		}
+		// no more results
+		if len(rsp.Results) < o.Limit || len(res) >= rsp.Total {
+			return res, nil
+		}
+		o.Limit += pageSize
+	}
+}
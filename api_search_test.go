@@ -0,0 +1,82 @@
+package notionapi
+
+import (
+	"testing"
+
+	"github.com/kjk/common/require"
+)
+
+const (
+	searchJSON1 = `
+{
+  "results": [
+    {
+      "id": "c3039398-9ae5-49c3-a39f-21ca5a681d72",
+      "isNavigable": true,
+      "score": 74.5,
+      "highlight": {
+        "pathText": "Test pages for notionapi",
+        "text": "Comparing <gzkNfoUU>prices</gzkNfoUU> of VPS servers"
+      }
+    }
+  ],
+  "total": 1,
+  "recordMap": {
+    "block": {
+      "c3039398-9ae5-49c3-a39f-21ca5a681d72": {
+        "role": "reader",
+        "value": {
+          "alive": true,
+          "id": "c3039398-9ae5-49c3-a39f-21ca5a681d72",
+          "parent_id": "0367c2db-381a-4f8b-9ce3-60f388a6b2e3",
+          "parent_table": "block",
+          "properties": {
+            "title": [["Comparing prices of VPS servers"]]
+          },
+          "space_id": "bc202e06-6caa-4e3f-81eb-f226ab5deef7",
+          "type": "page",
+          "version": 30
+        }
+      }
+    }
+  }
+}
+`
+)
+
+func TestSearchResponse(t *testing.T) {
+	var rsp SearchResponse
+	err := jsonit.Unmarshal([]byte(searchJSON1), &rsp)
+	require.NoError(t, err)
+	err = ParseRecordMap(rsp.RecordMap)
+	require.NoError(t, err)
+	err = rsp.resolveBlocks()
+	require.NoError(t, err)
+	require.Equal(t, 1, rsp.Total)
+	require.Equal(t, 1, len(rsp.Results))
+
+	r := rsp.Results[0]
+	require.True(t, r.IsNavigable)
+	require.Equal(t, "Comparing prices of VPS servers", r.Block.Title)
+	require.Equal(t, "Comparing prices of VPS servers", r.Highlight.PlainText())
+
+	frags := r.Highlight.Fragments()
+	require.Equal(t, 3, len(frags))
+	require.Equal(t, "prices", frags[1].Text)
+	require.True(t, frags[1].IsMatch)
+	require.False(t, frags[2].IsMatch)
+}
+
+func TestMakeSearchRequest(t *testing.T) {
+	opts := &SearchOptions{
+		AncestorIDs: []string{"0367c2db381a4f8b9ce360f388a6b2e3"},
+		InTrash:     true,
+	}
+	req := makeSearchRequest("bc202e066caa4e3f81ebf226ab5deef7", "foo", opts)
+	require.Equal(t, "bc202e06-6caa-4e3f-81eb-f226ab5deef7", req.SpaceID)
+	require.Equal(t, defaultSearchLimit, req.Limit)
+	require.Equal(t, SearchSortRelevance, req.Sort)
+	require.Equal(t, []string{"0367c2db-381a-4f8b-9ce3-60f388a6b2e3"}, req.Filters.Ancestors)
+	require.Equal(t, 0, len(req.Filters.CreatedBy))
+	require.True(t, req.Filters.IsDeletedOnly)
+}
@@ -75,3 +75,38 @@ func (c *Client) GetBlockRecords(ids []string) ([]*Block, error) {
 	}
 	return res, nil
 }
+
+// GetNotionUserRecords gets notion_user records with given ids.
+// Returns records in the same order as ids. A record is nil if
+// server didn't return a user for a given id
+func (c *Client) GetNotionUserRecords(ids []string) ([]*Record, error) {
+	var req syncRecordRequest
+	for _, id := range ids {
+		id = ToDashID(id)
+		p := Pointer{
+			ID:    id,
+			Table: TableNotionUser,
+		}
+		pver := PointerWithVersion{
+			Pointer: p,
+			Version: -1,
+		}
+		req.Requests = append(req.Requests, pver)
+	}
+
+	rsp, err := c.SyncRecordValues(req)
+	if err != nil {
+		return nil, err
+	}
+	var res []*Record
+	rm := rsp.RecordMap
+	for _, id := range ids {
+		id = ToDashID(id)
+		var r *Record
+		if rec := rm.NotionUsers[id]; rec != nil && rec.NotionUser != nil {
+			r = rec
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
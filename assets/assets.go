@@ -0,0 +1,356 @@
+// Package assets downloads files referenced by Notion pages (images,
+// attachments, pdfs, audio, video, page covers and icons) and stores them
+// locally, so that exported HTML and markdown doesn't depend on Notion's
+// urls, which expire.
+//
+// Use Bundler.RewriteFileURL as RewriteFileURL hook of tohtml.Converter
+// or tomarkdown.Converter.
+package assets
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kjk/notionapi"
+)
+
+// Downloader downloads a file referenced by a block. It's implemented
+// by *notionapi.Client and *notionapi.CachingClient. Both use signed urls
+// (Client.GetSignedURLs) for files stored by Notion, when needed
+type Downloader interface {
+	DownloadFile(uri string, block *notionapi.Block) (*notionapi.DownloadFileResponse, error)
+}
+
+var _ Downloader = &notionapi.Client{}
+var _ Downloader = &notionapi.CachingClient{}
+
+const (
+	// ManifestFileName is the name of the file in Bundler.Dir that
+	// records downloaded files
+	ManifestFileName = "assets.json"
+	manifestVersion  = 1
+)
+
+// Missing describes a file that couldn't be downloaded
+type Missing struct {
+	URL     string
+	BlockID string
+	Err     error
+}
+
+type manifest struct {
+	Version int `json:"version"`
+	// maps normalized url to file name relative to Bundler.Dir
+	Files map[string]string `json:"files"`
+}
+
+// Bundler downloads files to a directory. Downloaded files are recorded in
+// a manifest (ManifestFileName in Dir), so that re-exports use files
+// already downloaded and work offline.
+// Bundler is not safe for concurrent use
+type Bundler struct {
+	Downloader Downloader
+	// directory where files are stored
+	Dir string
+	// prefix of rewritten urls e.g. "files" or "../files", should point
+	// to Dir from where exported files are. If empty, names of files
+	// in Dir are used
+	URLPrefix string
+
+	manifest *manifest
+	missing  []*Missing
+}
+
+// New returns a Bundler that downloads files with d and stores them in dir
+func New(d Downloader, dir string) *Bundler {
+	return &Bundler{
+		Downloader: d,
+		Dir:        dir,
+	}
+}
+
+// NormalizeURL returns a canonical form of a url of a file. Relative
+// urls of Notion's images are made absolute and signatures of files
+// stored in S3 and file.notion.so are removed, as they change every time
+// the page is downloaded. Urls of Notion's proxy for signed files
+// (www.notion.so/signed/) are replaced with the url of the file
+func NormalizeURL(uri string) string {
+	if strings.HasPrefix(uri, "/images/") {
+		return "https://www.notion.so" + uri
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	if strings.HasSuffix(u.Host, ".amazonaws.com") || u.Host == "file.notion.so" {
+		u.RawQuery = ""
+		return u.String()
+	}
+	if (u.Host == "www.notion.so" || u.Host == "notion.so") && strings.HasPrefix(u.EscapedPath(), "/signed/") {
+		signed, err := url.PathUnescape(strings.TrimPrefix(u.EscapedPath(), "/signed/"))
+		if err == nil && IsFileURL(signed) {
+			return NormalizeURL(signed)
+		}
+		u.RawQuery = ""
+		return u.String()
+	}
+	return uri
+}
+
+// IsFileURL returns true if uri is a url of a file that should be
+// downloaded (as opposed to e.g. an emoji icon)
+func IsFileURL(uri string) bool {
+	return strings.HasPrefix(uri, "https://") || strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "/images/")
+}
+
+func isSafeNameChar(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	case r == '-', r == '_', r == '.':
+		return true
+	}
+	return false
+}
+
+// mime.ExtensionsByType returns extensions sorted alphabetically
+// so e.g. for image/jpeg the first one is .jfif
+var contentTypeExts = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/gif":       ".gif",
+	"image/svg+xml":   ".svg",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+func extFromContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	if ext, ok := contentTypeExts[mediaType]; ok {
+		return ext
+	}
+	exts, _ := mime.ExtensionsByType(mediaType)
+	if len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+// FileName returns a deterministic name of a local file for a url. It's
+// a hash of the normalized url followed by a sanitized name of the file
+// e.g. "3b617da409454a52-diagram.png".
+// contentType is used to guess the extension if url doesn't have one
+func FileName(uri string, contentType string) string {
+	norm := NormalizeURL(uri)
+	sum := sha1.Sum([]byte(norm))
+	hash := hex.EncodeToString(sum[:8])
+
+	base := ""
+	if u, err := url.Parse(norm); err == nil {
+		base, _ = url.PathUnescape(path.Base(u.Path))
+	}
+	var sb strings.Builder
+	for _, r := range base {
+		if isSafeNameChar(r) {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteByte('-')
+		}
+	}
+	name := strings.Trim(sb.String(), "-.")
+	ext := path.Ext(name)
+	name = strings.TrimSuffix(name, ext)
+	if len(name) > 48 {
+		name = name[:48]
+	}
+	if ext == "" || len(ext) > 6 {
+		ext = extFromContentType(contentType)
+	}
+	ext = strings.ToLower(ext)
+	if name == "" {
+		return hash + ext
+	}
+	return hash + "-" + name + ext
+}
+
+func (b *Bundler) manifestPath() string {
+	return filepath.Join(b.Dir, ManifestFileName)
+}
+
+func (b *Bundler) loadManifest() {
+	if b.manifest != nil {
+		return
+	}
+	b.manifest = &manifest{
+		Version: manifestVersion,
+		Files:   map[string]string{},
+	}
+	d, err := os.ReadFile(b.manifestPath())
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			notionapi.Logf("assets: failed to read '%s'. Error: %s\n", b.manifestPath(), err)
+		}
+		return
+	}
+	var m manifest
+	err = json.Unmarshal(d, &m)
+	if err != nil || m.Version != manifestVersion {
+		notionapi.Logf("assets: ignoring invalid '%s'\n", b.manifestPath())
+		return
+	}
+	for uri, name := range m.Files {
+		// only remember files that still exist
+		if _, err := os.Stat(filepath.Join(b.Dir, name)); err == nil {
+			b.manifest.Files[uri] = name
+		}
+	}
+}
+
+func (b *Bundler) localURL(name string) string {
+	if b.URLPrefix == "" {
+		return name
+	}
+	return path.Join(b.URLPrefix, name)
+}
+
+// Download downloads a file (if not already downloaded) and returns its
+// local url
+func (b *Bundler) Download(uri string, block *notionapi.Block) (string, error) {
+	b.loadManifest()
+	norm := NormalizeURL(uri)
+	if name, ok := b.manifest.Files[norm]; ok {
+		return b.localURL(name), nil
+	}
+	if b.Downloader == nil {
+		return "", fmt.Errorf("no Downloader to download '%s'", uri)
+	}
+	// norm is only used to identify the file. Files in S3 must be
+	// downloaded with a signature
+	downloadURL := uri
+	if strings.HasPrefix(uri, "/images/") {
+		downloadURL = norm
+	}
+	rsp, err := b.Downloader.DownloadFile(downloadURL, block)
+	if err != nil {
+		return "", err
+	}
+	contentType := ""
+	if rsp.Header != nil {
+		contentType = rsp.Header.Get("Content-Type")
+	}
+	name := FileName(norm, contentType)
+	err = os.MkdirAll(b.Dir, 0755)
+	if err != nil {
+		return "", err
+	}
+	err = os.WriteFile(filepath.Join(b.Dir, name), rsp.Data, 0644)
+	if err != nil {
+		return "", err
+	}
+	b.manifest.Files[norm] = name
+	return b.localURL(name), nil
+}
+
+// RewriteFileURL downloads a file and returns its local url. If download
+// fails, it's recorded as missing (see Missing) and uri is returned.
+// Can be used as RewriteFileURL in tohtml.Converter and tomarkdown.Converter
+func (b *Bundler) RewriteFileURL(uri string, block *notionapi.Block) string {
+	if !IsFileURL(uri) {
+		return uri
+	}
+	for _, m := range b.missing {
+		// don't retry files that failed to download
+		if m.URL == uri {
+			return uri
+		}
+	}
+	res, err := b.Download(uri, block)
+	if err != nil {
+		blockID := ""
+		if block != nil {
+			blockID = block.ID
+		}
+		notionapi.Logf("assets: failed to download '%s'. Error: %s\n", uri, err)
+		b.missing = append(b.missing, &Missing{URL: uri, BlockID: blockID, Err: err})
+		return uri
+	}
+	return res
+}
+
+// BlockFileURLs returns urls of files referenced by a block: its file,
+// image, pdf, audio or video, page cover and icon
+func BlockFileURLs(block *notionapi.Block) []string {
+	var res []string
+	add := func(uri string) {
+		if IsFileURL(uri) {
+			res = append(res, uri)
+		}
+	}
+	switch block.Type {
+	case notionapi.BlockImage, notionapi.BlockFile, notionapi.BlockPDF:
+		add(block.Source)
+	case notionapi.BlockAudio, notionapi.BlockVideo:
+		// those without file ids are embeds e.g. from YouTube
+		if len(block.FileIDs) > 0 {
+			add(block.Source)
+		}
+	}
+	cover, _ := block.PropAsString("format.page_cover")
+	add(cover)
+	icon, _ := block.PropAsString("format.page_icon")
+	add(icon)
+	return res
+}
+
+// BundlePage downloads all files referenced by blocks of a page.
+// Files that fail to download are recorded as missing
+func (b *Bundler) BundlePage(page *notionapi.Page) {
+	page.ForEachBlock(func(block *notionapi.Block) {
+		for _, uri := range BlockFileURLs(block) {
+			b.RewriteFileURL(uri, block)
+		}
+	})
+}
+
+// Missing returns files that failed to download
+func (b *Bundler) Missing() []*Missing {
+	return b.missing
+}
+
+// WriteManifest writes a manifest of downloaded files to Dir
+func (b *Bundler) WriteManifest() error {
+	b.loadManifest()
+	d, err := json.MarshalIndent(b.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(b.Dir, 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(b.manifestPath(), d, 0644)
+}
+
+// Files returns names of downloaded files, relative to Dir, sorted
+func (b *Bundler) Files() []string {
+	b.loadManifest()
+	var res []string
+	for _, name := range b.manifest.Files {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
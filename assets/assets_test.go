@@ -0,0 +1,137 @@
+package assets
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kjk/common/assert"
+	"github.com/kjk/notionapi"
+	"github.com/kjk/notionapi/internal/testutil"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := [][]string{
+		{"/images/page-cover/met_vincent_van_gogh_cradle.jpg", "https://www.notion.so/images/page-cover/met_vincent_van_gogh_cradle.jpg"},
+		{"https://s3-us-west-2.amazonaws.com/secure.notion-static.com/e5470cfd/image.png?X-Amz-Signature=abc", "https://s3-us-west-2.amazonaws.com/secure.notion-static.com/e5470cfd/image.png"},
+		{"https://images.unsplash.com/photo-1?w=800", "https://images.unsplash.com/photo-1?w=800"},
+		{"https://file.notion.so/f/f/2f6a/8c1d/diagram.png?table=block&id=8c1d&expirationTimestamp=1700000000000&signature=abc", "https://file.notion.so/f/f/2f6a/8c1d/diagram.png"},
+		{"https://www.notion.so/signed/https%3A%2F%2Fs3-us-west-2.amazonaws.com%2Fsecure.notion-static.com%2Fe5470cfd%2Fimage.png?table=block&id=8c1d&userId=&cache=v2", "https://s3-us-west-2.amazonaws.com/secure.notion-static.com/e5470cfd/image.png"},
+		{"https://www.notion.so/signed/not-a-url?table=block&cache=v2", "https://www.notion.so/signed/not-a-url"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test[1], NormalizeURL(test[0]))
+	}
+}
+
+func TestFileName(t *testing.T) {
+	uri := "https://s3-us-west-2.amazonaws.com/secure.notion-static.com/e5470cfd/Schermafbeelding 2018.PNG"
+	name := FileName(uri, "")
+	assert.Equal(t, name, FileName(uri+"?X-Amz-Signature=abc", ""))
+	assert.Equal(t, 16+len("-Schermafbeelding-2018.png"), len(name))
+
+	name = FileName("https://www.notion.so/image/no-ext", "image/jpeg")
+	assert.Equal(t, ".jpg", filepath.Ext(name))
+	assert.NotEqual(t, FileName("https://a.com/x.png", ""), FileName("https://b.com/x.png", ""))
+}
+
+func TestBundler(t *testing.T) {
+	dir := t.TempDir()
+	img := "https://s3-us-west-2.amazonaws.com/secure.notion-static.com/e5470cfd/image.png"
+	signed := img + "?X-Amz-Signature=abc"
+	// only the signed url can be downloaded
+	d := &testutil.Downloader{
+		Files: map[string][]byte{signed: []byte("png data")},
+	}
+	b := New(d, dir)
+	b.URLPrefix = "files"
+	block := &notionapi.Block{ID: "b1", Type: notionapi.BlockImage, Source: signed}
+
+	uri := b.RewriteFileURL(block.Source, block)
+	name := FileName(img, "")
+	assert.Equal(t, "files/"+name, uri)
+	d2, err := os.ReadFile(filepath.Join(dir, name))
+	assert.NoError(t, err)
+	assert.Equal(t, "png data", string(d2))
+
+	// already downloaded files are not downloaded again
+	assert.Equal(t, uri, b.RewriteFileURL(img, block))
+	assert.Equal(t, 1, d.Calls)
+
+	// not files are not rewritten
+	assert.Equal(t, "🚀", b.RewriteFileURL("🚀", block))
+
+	missing := "https://example.com/missing.png"
+	assert.Equal(t, missing, b.RewriteFileURL(missing, block))
+	assert.Equal(t, missing, b.RewriteFileURL(missing, block))
+	assert.Equal(t, 1, len(b.Missing()))
+	assert.Equal(t, "b1", b.Missing()[0].BlockID)
+
+	err = b.WriteManifest()
+	assert.NoError(t, err)
+
+	// works offline with files downloaded before
+	b = New(nil, dir)
+	assert.Equal(t, name, b.RewriteFileURL(img, block))
+	assert.Equal(t, []string{name}, b.Files())
+}
+
+func TestBlockFileURLs(t *testing.T) {
+	block := &notionapi.Block{
+		Type:   notionapi.BlockVideo,
+		Source: "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+		RawJSON: map[string]interface{}{
+			"format": map[string]interface{}{
+				"page_cover": "/images/page-cover/woodcuts_1.jpg",
+				"page_icon":  "🚀",
+			},
+		},
+	}
+	assert.Equal(t, []string{"/images/page-cover/woodcuts_1.jpg"}, BlockFileURLs(block))
+	block.FileIDs = []string{"e5470cfd"}
+	assert.Equal(t, 2, len(BlockFileURLs(block)))
+}
+
+func TestResizeImageURL(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 1000, 500))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	assert.NoError(t, err)
+
+	uri := "https://s3-us-west-2.amazonaws.com/secure.notion-static.com/e5470cfd/image.png"
+	d := &testutil.Downloader{
+		Files: map[string][]byte{uri: buf.Bytes()},
+	}
+	dir := t.TempDir()
+	b := New(d, dir)
+	block := &notionapi.Block{ID: "b1", Type: notionapi.BlockImage, Source: uri}
+
+	name := FileName(uri, "")
+	variant := strings.TrimSuffix(name, ".png") + "-400w.png"
+	assert.Equal(t, variant, b.ResizeImageURL(uri, block, 400))
+	f, err := os.Open(filepath.Join(dir, variant))
+	assert.NoError(t, err)
+	cfg, err := png.DecodeConfig(f)
+	f.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, 400, cfg.Width)
+	assert.Equal(t, 200, cfg.Height)
+
+	// images are not upscaled
+	assert.Equal(t, "", b.ResizeImageURL(uri, block, 1000))
+	assert.Equal(t, "", b.ResizeImageURL(uri, block, 1200))
+
+	// not an image
+	pdf := "https://s3-us-west-2.amazonaws.com/secure.notion-static.com/e5470cfd/doc.pdf"
+	d.Files[pdf] = []byte("%PDF-1.4")
+	assert.Equal(t, "", b.ResizeImageURL(pdf, block, 400))
+	assert.Equal(t, "", b.ResizeImageURL("https://example.com/missing.png", block, 400))
+	assert.Equal(t, 3, d.Calls)
+}
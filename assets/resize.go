@@ -0,0 +1,129 @@
+package assets
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kjk/notionapi"
+)
+
+// ResizeImageURL returns a local url of an image downscaled to width,
+// creating it if needed. Returns "" if the image is not wider than width
+// (so that srcset doesn't claim a wrong width of the original) or can't
+// be downscaled (e.g. it failed to download or is not a jpeg or png).
+// Can be used as ResizeImageURL in tohtml.Converter
+func (b *Bundler) ResizeImageURL(uri string, block *notionapi.Block, width int) string {
+	if width <= 0 || b.RewriteFileURL(uri, block) == uri {
+		return ""
+	}
+	norm := NormalizeURL(uri)
+	name := b.manifest.Files[norm]
+	key := norm + "#width=" + strconv.Itoa(width)
+	if variant, ok := b.manifest.Files[key]; ok {
+		return b.localURL(variant)
+	}
+	variant, err := b.resizeImage(name, width)
+	if err != nil {
+		notionapi.Logf("assets: failed to resize '%s'. Error: %s\n", name, err)
+		return ""
+	}
+	if variant == "" {
+		return ""
+	}
+	b.manifest.Files[key] = variant
+	return b.localURL(variant)
+}
+
+// resizeImage creates a version of image name downscaled to width and
+// returns its name. Returns "" if the image is not wider than width or
+// it's not an image we can resize
+func (b *Bundler) resizeImage(name string, width int) (string, error) {
+	d, err := os.ReadFile(filepath.Join(b.Dir, name))
+	if err != nil {
+		return "", err
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(d))
+	if err != nil || (format != "jpeg" && format != "png") {
+		// not an image or a format we can't encode (e.g. animated gif)
+		return "", nil
+	}
+	if cfg.Width <= width {
+		return "", nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(d))
+	if err != nil {
+		return "", err
+	}
+	height := (cfg.Height*width + cfg.Width/2) / cfg.Width
+	if height < 1 {
+		height = 1
+	}
+	dst := downscale(img, width, height)
+
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buf, dst)
+	}
+	if err != nil {
+		return "", err
+	}
+	ext := filepath.Ext(name)
+	variant := strings.TrimSuffix(name, ext) + "-" + strconv.Itoa(width) + "w" + ext
+	err = os.WriteFile(filepath.Join(b.Dir, variant), buf.Bytes(), 0644)
+	if err != nil {
+		return "", err
+	}
+	return variant, nil
+}
+
+// downscale resizes img to width x height by averaging pixels of src
+// covered by each pixel of the result (a box filter). It's only meant
+// for making images smaller
+func downscale(img image.Image, width, height int) *image.RGBA {
+	bounds := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * sh / height
+		y1 := (y + 1) * sh / height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := x * sw / width
+			x1 := (x + 1) * sw / width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				off := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					sum[0] += int(src.Pix[off])
+					sum[1] += int(src.Pix[off+1])
+					sum[2] += int(src.Pix[off+2])
+					sum[3] += int(src.Pix[off+3])
+					off += 4
+				}
+			}
+			n := (x1 - x0) * (y1 - y0)
+			off := dst.PixOffset(x, y)
+			for i := 0; i < 4; i++ {
+				dst.Pix[off+i] = uint8((sum[i] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
@@ -323,8 +323,9 @@ type Block struct {
 	LastEditedByTable string `json:"last_edited_by_table"` // e.g. "notion_user"
 	LastEditedByID    string `json:"last_edited_by_id"`    // e.g. "bb760e2d-d679-4b64-b2a9-03005b21870a"
 
-	// List of block ids with discussion content
-	DiscussionIDs []string `json:"discussion,omitempty"`
+	// List of ids of discussions about this block
+	// Use Discussions() to get them
+	DiscussionIDs []string `json:"discussions,omitempty"`
 	// those ids seem to map to storage in s3
 	// https://s3-us-west-2.amazonaws.com/secure.notion-static.com/${id}/${name}
 	FileIDs []string `json:"file_ids,omitempty"`
@@ -457,6 +458,62 @@ func (b *Block) LastEditedOn() time.Time {
 	return time.Unix(b.LastEditedTime/1000, 0)
 }
 
+// CreatedByUserID returns id of the user who created the block.
+// Older records use CreatedBy, newer use CreatedByID
+func (b *Block) CreatedByUserID() string {
+	if b.CreatedBy != "" {
+		return b.CreatedBy
+	}
+	return b.CreatedByID
+}
+
+// LastEditedByUserID returns id of the user who last edited the block
+func (b *Block) LastEditedByUserID() string {
+	if b.LastEditedBy != "" {
+		return b.LastEditedBy
+	}
+	return b.LastEditedByID
+}
+
+// Discussions returns discussions about this block. Those are
+// discussions about the whole block (DiscussionIDs) and inline
+// discussions about parts of text (AttrComment in properties)
+func (b *Block) Discussions() []*Discussion {
+	if b.Page == nil {
+		return nil
+	}
+	var res []*Discussion
+	seen := map[string]bool{}
+	add := func(id string) {
+		nid := NewNotionID(id)
+		if nid == nil || seen[nid.DashID] {
+			return
+		}
+		seen[nid.DashID] = true
+		if d := b.Page.DiscussionByID(nid); d != nil {
+			res = append(res, d)
+		}
+	}
+	for _, id := range b.DiscussionIDs {
+		add(id)
+	}
+	var names []string
+	for name := range b.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, span := range b.GetProperty(name) {
+			for _, attr := range span.Attrs {
+				if AttrGetType(attr) == AttrComment && len(attr) > 1 {
+					add(AttrGetComment(attr))
+				}
+			}
+		}
+	}
+	return res
+}
+
 // IsLinkToPage returns true if block element is a link to a page
 // (as opposed to embedded page)
 func (b *Block) IsLinkToPage() bool {
@@ -412,14 +412,6 @@ func (c *Client) DownloadPage(pageID string) (*Page, error) {
 		return nil, fmt.Errorf("failed to resolve blocks on page '%s': %s", p.ID, err)
 	}
 
-	/*
-		TODO: use loadUserContent to get info about users
-			for id, r := range recordMap.Users {
-				p.UserRecords = append(p.UserRecords, r)
-				p.idToUser[id] = r.User
-			}
-	*/
-
 	blockIDs := getBlockIDsSorted(p.idToBlock)
 	for _, id := range blockIDs {
 		block := p.idToBlock[id]
@@ -430,13 +422,6 @@ func (c *Client) DownloadPage(pageID string) (*Page, error) {
 			return nil, fmt.Errorf("collection_view has no ViewIDs")
 		}
 
-		// TODO: should fish out the user based on block.CreatedBy
-		// TODO: notion changed the api and User is no long returned in loadPageChunk
-		// need to use syncRecordValues
-		if false && len(p.UserRecords) == 0 {
-			return nil, fmt.Errorf("no users when trying to resolve collection_view")
-		}
-
 		collectionID := block.FixCollectionID()
 		for _, collectionViewID := range block.ViewIDs {
 			collectionView, ok := p.idToCollectionView[collectionViewID]
@@ -472,39 +457,33 @@ func (c *Client) DownloadPage(pageID string) (*Page, error) {
 		}
 	}
 
-	for _, b := range p.idToBlock {
-		err := parseProperties(b)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse properties of block '%s', err: '%s'", b.ID, err)
-		}
-		b.Page = p
-
-		switch b.ParentTable {
-		case TableSpace:
-			// TODO: Support parent table space
-			continue
-		case TableCollection:
-			// TODO: Support parent table collection
-			continue
-		case TableBlock:
-			// Page's parent is outside of this page
-			if isPageBlock(b) && !p.IsSubPage(b) {
-				continue
-			}
+	if err := p.resolveParents(c.vlogf); err != nil {
+		return nil, err
+	}
 
-			// Page's parent is inside the blocks to skip
-			if _, ok := p.blocksToSkip[b.GetParentNotionID().DashID]; ok {
-				continue
-			}
+	p.resolveDiscussions(c.logf)
+	c.downloadUsers(p)
+	return p, nil
+}
 
-			b.Parent = p.BlockByID(b.GetParentNotionID())
-			if b.Parent == nil {
-				return nil, fmt.Errorf("could not find parent '%s' of id '%s' of block '%s'", b.ParentTable, b.ParentID, b.ID)
-			}
-		default:
-			c.vlogf("unsupported parent table type %s of block %s", b.ParentTable, b.ID)
+// loadPageChunk no longer returns users so we get users referenced
+// by the page with syncRecordValues
+// Failure is not fatal: we only use users for showing names
+func (c *Client) downloadUsers(p *Page) {
+	ids := p.findUserIDs()
+	if len(ids) == 0 {
+		return
+	}
+	records, err := c.GetNotionUserRecords(ids)
+	if err != nil {
+		c.vlogf("DownloadPage: failed to get %d users of page '%s', err: '%s'\n", len(ids), p.ID, err)
+		return
+	}
+	for _, r := range records {
+		if r == nil {
+			continue
 		}
+		p.UserRecords = append(p.UserRecords, r)
+		p.idToNotionUser[r.NotionUser.ID] = r.NotionUser
 	}
-
-	return p, nil
 }
@@ -1,6 +1,7 @@
 package notionapi
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/kjk/common/assert"
@@ -39,3 +40,17 @@ func TestExtractNoDashIDFromNotionURL(t *testing.T) {
 		assert.Equal(t, exp, got)
 	}
 }
+
+func TestResizedImageURL(t *testing.T) {
+	block := &Block{ID: "8511412c-fc1a-4d2a-b3ab-b3ab3a4d2a64", ParentTable: "block"}
+	uri := "https://s3-us-west-2.amazonaws.com/secure.notion-static.com/e5470cfd/image.png"
+	exp := "https://www.notion.so/image/https:%2F%2Fs3-us-west-2.amazonaws.com%2Fsecure.notion-static.com%2Fe5470cfd%2Fimage.png?table=block&id=8511412c-fc1a-4d2a-b3ab-b3ab3a4d2a64&width=640"
+	assert.Equal(t, exp, ResizedImageURL(uri, block, 640))
+
+	got := ResizedImageURL("/images/page-cover/woodcuts_1.jpg", block, 1280)
+	assert.True(t, strings.HasPrefix(got, notionImageProxy+"https:%2F%2Fwww.notion.so%2Fimages%2F"))
+	assert.True(t, strings.HasSuffix(got, "&width=1280"))
+
+	assert.Equal(t, "", ResizedImageURL("https://images.unsplash.com/photo-1", block, 640))
+	assert.Equal(t, "", ResizedImageURL(uri, nil, 640))
+}
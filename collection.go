@@ -2,6 +2,7 @@ package notionapi
 
 import (
 	"fmt"
+	"strings"
 )
 
 const (
@@ -10,6 +11,23 @@ const (
 	CollectionViewTypeTable = "table"
 	// CollectionViewTypeTable is a lists block
 	CollectionViewTypeList = "list"
+	// CollectionViewTypeBoard is a board (kanban) block
+	CollectionViewTypeBoard = "board"
+	// CollectionViewTypeGallery is a gallery block
+	CollectionViewTypeGallery = "gallery"
+	// CollectionViewTypeCalendar is a calendar block
+	CollectionViewTypeCalendar = "calendar"
+	// CollectionViewTypeTimeline is a timeline block
+	CollectionViewTypeTimeline = "timeline"
+)
+
+const (
+	// GalleryCoverPageCover shows page cover as a cover of a gallery card
+	GalleryCoverPageCover = "page_cover"
+	// GalleryCoverPageContent shows first image in the page as a cover
+	GalleryCoverPageContent = "page_content"
+	// GalleryCoverProperty shows a file from a property as a cover
+	GalleryCoverProperty = "property"
 )
 
 // CollectionColumnOption describes options for ColumnTypeMultiSelect
@@ -112,6 +130,20 @@ func (c *Collection) GetName() string {
 	return TextSpansToString(c.name)
 }
 
+// CollectionName returns the name of a collection shown by a block
+// like BlockCollectionView or BlockCollectionViewPage. Page can be nil
+func (p *Page) CollectionName(block *Block) string {
+	if len(block.TableViews) > 0 && block.TableViews[0].Collection != nil {
+		return block.TableViews[0].Collection.GetName()
+	}
+	if colID := block.FixCollectionID(); colID != "" && p != nil {
+		if col := p.CollectionByID(NewNotionID(colID)); col != nil {
+			return col.GetName()
+		}
+	}
+	return ""
+}
+
 // TableProperty describes property of a table
 type TableProperty struct {
 	Width    int    `json:"width"`
@@ -146,11 +178,89 @@ type Query struct {
 	Filter       map[string]interface{} `json:"filter"`
 }
 
+// BoardColumnsBy describes which property groups cards of a board
+type BoardColumnsBy struct {
+	// ColumnTypeSelect etc.
+	Type     string `json:"type"`
+	Property string `json:"property"`
+}
+
+// BoardColumnValue is a value of property for cards in a board column
+type BoardColumnValue struct {
+	Type string `json:"type"`
+	// for select it's the name of the option
+	// missing for a column of cards with no value
+	Value interface{} `json:"value,omitempty"`
+}
+
+// BoardColumn describes a column in a board view
+type BoardColumn struct {
+	Property string            `json:"property"`
+	Hidden   bool              `json:"hidden"`
+	Value    *BoardColumnValue `json:"value"`
+}
+
+// GalleryCover describes what is shown as a cover of a gallery card
+type GalleryCover struct {
+	// GalleryCoverPageCover etc.
+	Type string `json:"type"`
+	// for GalleryCoverProperty, id of a file property
+	Property string `json:"property,omitempty"`
+}
+
 // FormatTable describes format for BlockTable
+// It's also used for other collection views (board, gallery etc.)
 type FormatTable struct {
 	PageSort        []string         `json:"page_sort"`
 	TableWrap       bool             `json:"table_wrap"`
 	TableProperties []*TableProperty `json:"table_properties"`
+
+	// for CollectionViewTypeList
+	ListProperties []*TableProperty `json:"list_properties"`
+
+	// for CollectionViewTypeBoard
+	BoardProperties []*TableProperty `json:"board_properties"`
+	BoardColumnsBy  *BoardColumnsBy  `json:"board_columns_by"`
+	BoardColumns    []*BoardColumn   `json:"board_columns"`
+	BoardCover      *GalleryCover    `json:"board_cover"`
+
+	// for CollectionViewTypeGallery
+	GalleryProperties []*TableProperty `json:"gallery_properties"`
+	GalleryCover      *GalleryCover    `json:"gallery_cover"`
+	// "cover" or "contain"
+	GalleryCoverAspect string `json:"gallery_cover_aspect"`
+	// "small", "medium", "large"
+	GalleryCoverSize string `json:"gallery_cover_size"`
+
+	// for CollectionViewTypeCalendar
+	CalendarProperties []*TableProperty `json:"calendar_properties"`
+	// id of a date property that decides where the page is shown
+	CalendarBy string `json:"calendar_by"`
+
+	// for CollectionViewTypeTimeline
+	TimelineProperties []*TableProperty `json:"timeline_properties"`
+}
+
+// Properties returns properties for a given type of a collection view.
+// Falls back to TableProperties for unknown types
+func (f *FormatTable) Properties(viewType string) []*TableProperty {
+	var res []*TableProperty
+	switch viewType {
+	case CollectionViewTypeList:
+		res = f.ListProperties
+	case CollectionViewTypeBoard:
+		res = f.BoardProperties
+	case CollectionViewTypeGallery:
+		res = f.GalleryProperties
+	case CollectionViewTypeCalendar:
+		res = f.CalendarProperties
+	case CollectionViewTypeTimeline:
+		res = f.TimelineProperties
+	}
+	if len(res) == 0 {
+		res = f.TableProperties
+	}
+	return res
 }
 
 // CollectionView represents a collection view
@@ -198,9 +308,25 @@ func (c *ColumnInfo) ID() string {
 }
 
 func (c *ColumnInfo) Type() string {
+	if c.Schema == nil {
+		return ""
+	}
 	return c.Schema.Type
 }
 
+// IsRichText returns true if cells in this column have text with
+// formatting and mentions, which should be rendered from
+// TableView.CellContent. Other cells are rendered from TableView.CellText
+func (c *ColumnInfo) IsRichText() bool {
+	switch c.Type() {
+	case ColumnTypeNumber, ColumnTypeCheckbox, ColumnTypeMultiSelect,
+		ColumnTypeCreatedTime, ColumnTypeLastEditedTime, ColumnTypeCreatedBy,
+		ColumnTypeLastEditedBy, ColumnTypeURL, ColumnTypeEmail, ColumnTypeRelation:
+		return false
+	}
+	return true
+}
+
 func (c *ColumnInfo) Name() string {
 	if c.Schema == nil {
 		return ""
@@ -233,6 +359,63 @@ func (t *TableView) CellContent(row, col int) []*TextSpan {
 	return t.Rows[row].Columns[col]
 }
 
+// CellText returns content of a cell as plain text, the way Notion shows
+// it: numbers are formatted, names of users are resolved and multiple
+// values are separated with ", ". Checked checkbox is "Yes". Relations
+// are not supported and are "". Formatting and mentions in rich text are
+// lost, see ColumnInfo.IsRichText
+func (t *TableView) CellText(row, col int) string {
+	rowPage := t.Rows[row].Page
+	text := TextSpansToString(t.CellContent(row, col))
+	switch t.Columns[col].Type() {
+	case ColumnTypeNumber:
+		return FormatNumber(text, t.Columns[col].Schema.NumberFormat)
+	case ColumnTypeMultiSelect:
+		var vals []string
+		for _, v := range strings.Split(text, ",") {
+			if v != "" {
+				vals = append(vals, v)
+			}
+		}
+		return strings.Join(vals, ", ")
+	case ColumnTypeCreatedTime:
+		// TODO: Notion uses relative formatting like "Today 3:03pm"
+		return rowPage.CreatedOn().Format("2006-01-02")
+	case ColumnTypeLastEditedTime:
+		return rowPage.LastEditedOn().Format("2006-01-02")
+	case ColumnTypeCreatedBy:
+		return GetUserNameByID(t.Page, rowPage.CreatedByUserID())
+	case ColumnTypeLastEditedBy:
+		return GetUserNameByID(t.Page, rowPage.LastEditedByUserID())
+	case ColumnTypeRelation:
+		// TODO: not sure how to format relations
+		return ""
+	}
+	return text
+}
+
+// ensureTitleProperty makes sure title property is the first
+// visible property
+func ensureTitleProperty(props []*TableProperty, schema map[string]*ColumnSchema) []*TableProperty {
+	titleID := ""
+	for id, col := range schema {
+		if col != nil && col.Type == ColumnTypeTitle {
+			titleID = id
+			break
+		}
+	}
+	if titleID == "" {
+		return props
+	}
+	res := []*TableProperty{{Property: titleID, Visible: true}}
+	for _, prop := range props {
+		if prop.Property != titleID {
+			res = append(res, prop)
+		}
+	}
+	return res
+}
+
 // TODO: some tables miss title column in TableProperties
 // maybe synthesize it if doesn't exist as a first column
 func (c *Client) buildTableView(tv *TableView, res *QueryCollectionResponse) error {
@@ -261,7 +444,12 @@ func (c *Client) buildTableView(tv *TableView, res *QueryCollectionResponse) err
 	}
 
 	idx := 0
-	for _, prop := range cv.Format.TableProperties {
+	props := cv.Format.Properties(cv.Type)
+	if cv.Type != CollectionViewTypeTable {
+		// other views always show title even if not in the properties
+		props = ensureTitleProperty(props, collection.Schema)
+	}
+	for _, prop := range props {
 		if !prop.Visible {
 			continue
 		}
@@ -1,17 +1,82 @@
 package notionapi
 
+import "time"
+
+// CommentTimeFormat is a format of dates of comments in pages
+// generated by converters
+const CommentTimeFormat = "Jan 2, 2006"
+
 // Comment describes a single comment in a discussion
 type Comment struct {
-	ID             string      `json:"id"`
-	Version        int64       `json:"version"`
-	Alive          bool        `json:"alive"`
-	ParentID       string      `json:"parent_id"`
-	ParentTable    string      `json:"parent_table"`
-	CreatedBy      string      `json:"created_by"`
+	ID          string `json:"id"`
+	Version     int64  `json:"version"`
+	Alive       bool   `json:"alive"`
+	ParentID    string `json:"parent_id"`
+	ParentTable string `json:"parent_table"`
+	SpaceID     string `json:"space_id"`
+	// older records use CreatedBy, newer use CreatedByID
+	CreatedBy      string      `json:"created_by,omitempty"`
+	CreatedByID    string      `json:"created_by_id,omitempty"`
+	CreatedByTable string      `json:"created_by_table,omitempty"` // e.g. "notion_user"
 	CreatedTime    int64       `json:"created_time"`
 	Text           interface{} `json:"text"`
 	LastEditedTime int64       `json:"last_edited_time"`
 
 	// set by us
+	// parsed Text
+	InlineContent []*TextSpan `json:"-"`
+	// page this comment belongs to
+	Page *Page `json:"-"`
+
 	RawJSON map[string]interface{} `json:"-"`
 }
+
+// AuthorID returns id of the user who created this comment
+func (c *Comment) AuthorID() string {
+	if c.CreatedByID != "" {
+		return c.CreatedByID
+	}
+	return c.CreatedBy
+}
+
+// Author returns the user who created this comment.
+// Returns nil if not known
+func (c *Comment) Author() *NotionUser {
+	if c.Page == nil {
+		return nil
+	}
+	nid := NewNotionID(c.AuthorID())
+	if nid == nil {
+		return nil
+	}
+	return c.Page.NotionUserByID(nid)
+}
+
+// AuthorName returns a name of the user who created this comment
+func (c *Comment) AuthorName() string {
+	if c.Page == nil {
+		return c.AuthorID()
+	}
+	return GetUserNameByID(c.Page, c.AuthorID())
+}
+
+// CreatedOn returns the time the comment was created
+func (c *Comment) CreatedOn() time.Time {
+	return time.Unix(c.CreatedTime/1000, 0)
+}
+
+// LastEditedOn returns the time the comment was last edited
+func (c *Comment) LastEditedOn() time.Time {
+	return time.Unix(c.LastEditedTime/1000, 0)
+}
+
+// buildOp creates an Operation for this comment
+func (c *Comment) buildOp(command string, path []string, args interface{}) *Operation {
+	return &Operation{
+		ID:      c.ID,
+		Table:   TableComment,
+		Path:    path,
+		Command: command,
+		Args:    args,
+	}
+}
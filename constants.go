@@ -33,6 +33,7 @@ const (
 const (
 	// those are Record.Type and determine the type of Record.Value
 	TableSpace          = "space"
+	TableSpaceView      = "space_view"
 	TableActivity       = "activity"
 	TableBlock          = "block"
 	TableNotionUser     = "notion_user"
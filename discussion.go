@@ -1,13 +1,221 @@
 package notionapi
 
-// Discussion represents a discussion
+import "github.com/google/uuid"
+
+// Discussion represents a discussion (a thread of comments)
 type Discussion struct {
-	ID          string   `json:"id"`
-	Version     int64    `json:"version"`
-	ParentID    string   `json:"parent_id"`
-	ParentTable string   `json:"parent_table"`
-	Resolved    bool     `json:"resolved"`
-	Comments    []string `json:"comments"`
+	ID          string `json:"id"`
+	Version     int64  `json:"version"`
+	Alive       bool   `json:"alive"`
+	ParentID    string `json:"parent_id"`
+	ParentTable string `json:"parent_table"`
+	SpaceID     string `json:"space_id"`
+	// for inline discussions, the text that is being commented on
+	Context  interface{} `json:"context,omitempty"`
+	Type     string      `json:"type,omitempty"`
+	Resolved bool        `json:"resolved"`
+	// ids of comments in this discussion
+	// Use Content to get corresponding comments (they are in the same order)
+	Comments []string `json:"comments"`
+
 	// set by us
+	// comments for Comments ids
+	Content []*Comment `json:"-"`
+	// parsed Context
+	InlineContext []*TextSpan `json:"-"`
+	// page this discussion belongs to
+	Page *Page `json:"-"`
+
 	RawJSON map[string]interface{} `json:"-"`
 }
+
+// IsVisible returns true if a discussion should be shown when rendering
+// a page. Discussions without comments are never shown and resolved
+// discussions only if showResolved is true
+func (d *Discussion) IsVisible(showResolved bool) bool {
+	if d == nil || len(d.Content) == 0 {
+		return false
+	}
+	return showResolved || !d.Resolved
+}
+
+// DiscussionNumbers assigns numbers of footnotes to discussions in the
+// order they are referenced when rendering a page
+type DiscussionNumbers struct {
+	Page *Page
+	// if true, resolved discussions are also numbered
+	ShowResolved bool
+	// discussions numbered so far, Discussions[n-1] has number n
+	Discussions []*Discussion
+
+	idToNo map[string]int
+}
+
+// No returns a number of a discussion with a given id, assigning the next
+// number if it wasn't referenced before. Returns 0 if the discussion
+// shouldn't be shown
+func (n *DiscussionNumbers) No(id string) int {
+	nid := NewNotionID(id)
+	if nid == nil {
+		return 0
+	}
+	if no, ok := n.idToNo[nid.DashID]; ok {
+		return no
+	}
+	d := n.Page.DiscussionByID(nid)
+	if !d.IsVisible(n.ShowResolved) {
+		return 0
+	}
+	if n.idToNo == nil {
+		n.idToNo = map[string]int{}
+	}
+	n.Discussions = append(n.Discussions, d)
+	no := len(n.Discussions)
+	n.idToNo[nid.DashID] = no
+	return no
+}
+
+// FirstComment returns the comment that started a discussion
+// Returns nil if there are no comments
+func (d *Discussion) FirstComment() *Comment {
+	if len(d.Content) == 0 {
+		return nil
+	}
+	return d.Content[0]
+}
+
+// buildOp creates an Operation for this discussion
+func (d *Discussion) buildOp(command string, path []string, args interface{}) *Operation {
+	return &Operation{
+		ID:      d.ID,
+		Table:   TableDiscussion,
+		Path:    path,
+		Command: command,
+		Args:    args,
+	}
+}
+
+// ResolveOp creates an operation to resolve (or re-open) a discussion
+func (d *Discussion) ResolveOp(resolved bool) *Operation {
+	return d.buildOp(CommandUpdate, []string{}, map[string]interface{}{
+		"resolved": resolved,
+	})
+}
+
+// newCommentOps creates a comment in this discussion
+func (d *Discussion) newCommentOps(userID string, text string) (*Comment, []*Operation) {
+	now := Now()
+	comment := &Comment{
+		ID:             uuid.New().String(),
+		Version:        1,
+		Alive:          true,
+		ParentID:       d.ID,
+		ParentTable:    TableDiscussion,
+		SpaceID:        d.SpaceID,
+		CreatedByID:    userID,
+		CreatedByTable: TableNotionUser,
+		CreatedTime:    now,
+		LastEditedTime: now,
+		Text:           [][]string{{text}},
+		InlineContent:  []*TextSpan{{Text: text}},
+		Page:           d.Page,
+	}
+	ops := []*Operation{
+		comment.buildOp(CommandSet, []string{}, map[string]interface{}{
+			"id":               comment.ID,
+			"version":          comment.Version,
+			"alive":            comment.Alive,
+			"parent_id":        comment.ParentID,
+			"parent_table":     comment.ParentTable,
+			"space_id":         comment.SpaceID,
+			"created_by_id":    comment.CreatedByID,
+			"created_by_table": comment.CreatedByTable,
+			"created_time":     comment.CreatedTime,
+			"last_edited_time": comment.LastEditedTime,
+			"text":             comment.Text,
+		}),
+		d.buildOp(CommandListAfter, []string{"comments"}, map[string]string{
+			"id": comment.ID,
+		}),
+	}
+	return comment, ops
+}
+
+// addComment adds a comment to Comments and Content
+func (d *Discussion) addComment(comment *Comment) {
+	d.Comments = append(d.Comments, comment.ID)
+	d.Content = append(d.Content, comment)
+}
+
+// ReplyOps creates operations to add a comment with a given text
+// to this discussion. d is not changed, ReplyToDiscussion updates it
+// after the operations are submitted
+func (d *Discussion) ReplyOps(userID string, text string) (*Comment, []*Operation) {
+	return d.newCommentOps(userID, text)
+}
+
+// AddCommentOps creates operations to start a new discussion about a block
+// with a comment with a given text. b is not changed, AddComment updates
+// it after the operations are submitted
+func (b *Block) AddCommentOps(userID string, text string) (*Discussion, []*Operation) {
+	d := &Discussion{
+		ID:          uuid.New().String(),
+		Version:     1,
+		Alive:       true,
+		ParentID:    b.ID,
+		ParentTable: TableBlock,
+		SpaceID:     b.SpaceID,
+		Type:        "default",
+		Page:        b.Page,
+	}
+	ops := []*Operation{
+		d.buildOp(CommandSet, []string{}, map[string]interface{}{
+			"id":           d.ID,
+			"version":      d.Version,
+			"alive":        d.Alive,
+			"parent_id":    d.ParentID,
+			"parent_table": d.ParentTable,
+			"space_id":     d.SpaceID,
+			"type":         d.Type,
+			"resolved":     false,
+			"comments":     []string{},
+		}),
+		b.buildOp(CommandListAfter, []string{"discussions"}, map[string]string{
+			"id": d.ID,
+		}),
+	}
+	comment, commentOps := d.ReplyOps(userID, text)
+	ops = append(ops, commentOps...)
+	d.addComment(comment)
+	return d, ops
+}
+
+// AddComment starts a new discussion about a block
+func (c *Client) AddComment(userID string, block *Block, text string) (*Discussion, error) {
+	d, ops := block.AddCommentOps(userID, text)
+	if err := c.SubmitTransaction(ops); err != nil {
+		return nil, err
+	}
+	block.DiscussionIDs = append(block.DiscussionIDs, d.ID)
+	return d, nil
+}
+
+// ReplyToDiscussion adds a comment to an existing discussion
+func (c *Client) ReplyToDiscussion(userID string, d *Discussion, text string) (*Comment, error) {
+	comment, ops := d.ReplyOps(userID, text)
+	if err := c.SubmitTransaction(ops); err != nil {
+		return nil, err
+	}
+	d.addComment(comment)
+	return comment, nil
+}
+
+// ResolveDiscussion marks a discussion as resolved
+func (c *Client) ResolveDiscussion(d *Discussion) error {
+	ops := []*Operation{d.ResolveOp(true)}
+	if err := c.SubmitTransaction(ops); err != nil {
+		return err
+	}
+	d.Resolved = true
+	return nil
+}
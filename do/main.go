@@ -145,6 +145,10 @@ func main() {
 		flgTestToHTML        string
 		flgTestDownloadCache string
 		flgBench             bool
+
+		flgSearch         string
+		flgSpaceID        string
+		flgSearchAncestor string
 	)
 
 	{
@@ -173,6 +177,9 @@ func main() {
 		flag.BoolVar(&flgNoOpen, "no-open", false, "if true, will not automatically open the browser with html file generated with -tohtml")
 		flag.BoolVar(&flgWc, "wc", false, "wc -l on source files")
 		flag.BoolVar(&flgBench, "bench", false, "run benchmark")
+		flag.StringVar(&flgSearch, "search", "", "text to search for in space given with -space-id")
+		flag.StringVar(&flgSpaceID, "space-id", "", "id of a space (workspace)")
+		flag.StringVar(&flgSearchAncestor, "search-ancestor", "", "if given, -search only searches in sub-pages of this page")
 		flag.Parse()
 	}
 
@@ -322,6 +329,11 @@ func main() {
 		return
 	}
 
+	if flgSearch != "" {
+		searchSpace(flgSpaceID, flgSearch, flgSearchAncestor)
+		return
+	}
+
 	if flgDownloadPage != "" {
 		client := makeNotionClient()
 		downloadPage(client, flgDownloadPage)
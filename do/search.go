@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/kjk/notionapi"
+)
+
+// highlights matches in search results as *match*
+func searchHighlightToString(h *notionapi.SearchHighlight) string {
+	var s string
+	for _, frag := range h.Fragments() {
+		if frag.IsMatch {
+			s += "*" + frag.Text + "*"
+		} else {
+			s += frag.Text
+		}
+	}
+	return strings.Replace(s, "\n", " ", -1)
+}
+
+func searchSpace(spaceID string, query string, ancestorID string) {
+	if spaceID == "" {
+		logf("-search requires -space-id\n")
+		return
+	}
+	client := makeNotionClient()
+	opts := &notionapi.SearchOptions{}
+	if ancestorID != "" {
+		opts.AncestorIDs = []string{ancestorID}
+	}
+	results, err := client.SearchAll(spaceID, query, opts, 100)
+	if err != nil {
+		logf("client.SearchAll() failed with '%s'\n", err)
+		return
+	}
+	logf("Found %d results for '%s'\n", len(results), query)
+	for _, r := range results {
+		typ := ""
+		if r.Block != nil {
+			typ = r.Block.Type
+		}
+		logf("%s %s\n", notionapi.ToNoDashID(r.ID), typ)
+		if r.Highlight == nil {
+			continue
+		}
+		if r.Highlight.PathText != "" {
+			logf("  in: %s\n", r.Highlight.PathText)
+		}
+		logf("  %s\n", searchHighlightToString(r.Highlight))
+	}
+}
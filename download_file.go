@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 )
 
@@ -100,6 +101,27 @@ func maybeProxyImageURL(uri string, block *Block) string {
 	return uri
 }
 
+// ResizedImageURL returns a url of an image resized to a given width by
+// Notion's image proxy. Returns "" if the image is not served by the proxy
+// (e.g. it's hosted elsewhere)
+func ResizedImageURL(uri string, block *Block, width int) string {
+	if block == nil || width <= 0 {
+		return ""
+	}
+	if strings.HasPrefix(uri, "/images/") {
+		uri = "https://www.notion.so" + uri
+	}
+	if strings.HasPrefix(uri, "https://www.notion.so/images/") {
+		uri = notionImageProxy + url.PathEscape(uri) + "?table=" + block.ParentTable + "&id=" + block.ID
+	} else {
+		uri = maybeProxyImageURL(uri, block)
+	}
+	if !strings.HasPrefix(uri, notionImageProxy) {
+		return ""
+	}
+	return uri + "&width=" + strconv.Itoa(width)
+}
+
 // DownloadFile downloads a file stored in Notion referenced
 // by a block with a given id and of a given block with a given
 // parent table (data present in Block)
@@ -111,7 +133,8 @@ func (c *Client) DownloadFile(uri string, block *Block) (*DownloadFileResponse,
 		// otherwise just try your luck with original URL
 		res, err = c.DownloadURL(uri)
 	}
-	if err != nil {
+	if err != nil && block != nil {
+		// signing needs a block that references the file
 		rsp, err2 := c.GetSignedURLs([]string{uri}, block)
 		if err2 != nil {
 			return nil, err
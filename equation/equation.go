@@ -0,0 +1,104 @@
+// Package equation renders TeX equations (Notion's BlockEquation and
+// inline equations) to HTML.
+//
+// There are several implementations of Renderer:
+//   - MathML generates MathML in pure Go
+//   - SVG generates self-contained SVG images in pure Go
+//   - Katex uses katex CLI (https://katex.org/docs/cli.html)
+package equation
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Renderer converts TeX equations to HTML
+type Renderer interface {
+	// RenderEquation returns HTML for TeX equation. If display is true
+	// it's a block equation, otherwise it's inline with text
+	RenderEquation(tex string, display bool) (string, error)
+}
+
+// StyleSheeter is implemented by renderers whose output needs CSS
+type StyleSheeter interface {
+	// StyleSheet returns CSS that must be included once in the page
+	StyleSheet() string
+}
+
+// Katex renders equations using katex CLI. It requires node and katex
+// to be installed: npm install -g katex
+// Tested with katex 0.10.2
+type Katex struct {
+	// path to katex binary. If empty we look for katex in $PATH
+	Path string
+}
+
+var _ Renderer = &Katex{}
+var _ StyleSheeter = &Katex{}
+
+// DetectKatex returns path of katex binary. If path is not empty, we
+// check if it exists. Otherwise we look for katex in $PATH
+func DetectKatex(path string) (string, error) {
+	if path != "" {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	katexPath, err := exec.LookPath("katex")
+	if err != nil {
+		if path != "" {
+			return "", fmt.Errorf("katex binary ('%s') doesn't exist", path)
+		}
+		return "", fmt.Errorf("couldn't locate katex binary (see https://katex.org/). You can install Katex with `npm install -g katex`")
+	}
+	return katexPath, nil
+}
+
+// RenderEquation converts TeX equation to HTML by running katex
+func (k *Katex) RenderEquation(tex string, display bool) (string, error) {
+	path := k.Path
+	if path == "" {
+		var err error
+		path, err = DetectKatex("")
+		if err != nil {
+			return "", err
+		}
+		k.Path = path
+	}
+	var cmd *exec.Cmd
+	if display {
+		cmd = exec.Command(path, "-d")
+	} else {
+		cmd = exec.Command(path)
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err = cmd.Start()
+	if err != nil {
+		return "", err
+	}
+	_, err = stdin.Write([]byte(tex))
+	if err != nil {
+		_ = cmd.Process.Kill()
+		return "", err
+	}
+	err = stdin.Close()
+	if err != nil {
+		return "", err
+	}
+	if err = cmd.Wait(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// StyleSheet returns CSS needed by katex HTML
+func (k *Katex) StyleSheet() string {
+	return `@import url('https://cdnjs.cloudflare.com/ajax/libs/KaTeX/0.10.0/katex.min.css')`
+}
@@ -0,0 +1,79 @@
+package equation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kjk/common/assert"
+)
+
+func TestMathML(t *testing.T) {
+	tests := [][]string{
+		{`x`, `<mi>x</mi>`},
+		{`\frac{a}{b}`, `<mfrac><mi>a</mi><mi>b</mi></mfrac>`},
+		{`x^2_i`, `<msubsup><mi>x</mi><mi>i</mi><mn>2</mn></msubsup>`},
+		{`\sqrt{x}`, `<msqrt><mi>x</mi></msqrt>`},
+		{`\sqrt[3]{8}`, `<mroot><mn>8</mn><mn>3</mn></mroot>`},
+		{`\mathbb{R}`, `<mi mathvariant="normal">ℝ</mi>`},
+		{`a \leq b`, `<mrow><mi>a</mi><mo>≤</mo><mi>b</mi></mrow>`},
+		{`\text{if } x`, `<mrow><mtext>if </mtext><mi>x</mi></mrow>`},
+		{`\sin x`, `<mrow><mi>sin</mi><mi>x</mi></mrow>`},
+		{`\foo`, `<merror><mtext>\foo</mtext></merror>`},
+		{`\sum_{i=1}^n i`, `<mrow><munderover><mo largeop="true" movablelimits="true">∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi></munderover><mi>i</mi></mrow>`},
+		{`\begin{pmatrix}1&2\\3&4\end{pmatrix}`, `<mrow><mo fence="true" stretchy="true">(</mo><mtable><mtr><mtd><mn>1</mn></mtd><mtd><mn>2</mn></mtd></mtr><mtr><mtd><mn>3</mn></mtd><mtd><mn>4</mn></mtd></mtr></mtable><mo fence="true" stretchy="true">)</mo></mrow>`},
+	}
+	r := &MathML{NoAnnotation: true}
+	for _, test := range tests {
+		got, err := r.RenderEquation(test[0], true)
+		assert.NoError(t, err)
+		exp := `<math xmlns="http://www.w3.org/1998/Math/MathML" display="block">` + test[1] + `</math>`
+		assert.Equal(t, exp, got, "tex: '%s'", test[0])
+	}
+}
+
+func TestMathMLInline(t *testing.T) {
+	r := &MathML{}
+	got, err := r.RenderEquation(`a<b`, false)
+	assert.NoError(t, err)
+	exp := `<math xmlns="http://www.w3.org/1998/Math/MathML"><semantics><mrow><mi>a</mi><mo>&lt;</mo><mi>b</mi></mrow><annotation encoding="application/x-tex">a&lt;b</annotation></semantics></math>`
+	assert.Equal(t, exp, got)
+
+	// in inline mode limits are scripts
+	got, err = (&MathML{NoAnnotation: true}).RenderEquation(`\sum_i`, false)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(got, `<msub>`))
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{`{a`, `a}`, `\frac{a}`, `\left( x`, `\begin{matrix} a`}
+	for _, tex := range tests {
+		_, err := parse(tex)
+		assert.Error(t, err, "tex: '%s'", tex)
+	}
+}
+
+func TestSVG(t *testing.T) {
+	r := &SVG{}
+	got, err := r.RenderEquation(`\frac{a}{b} + \sqrt{x}`, false)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(got, `<svg xmlns="http://www.w3.org/2000/svg"`))
+	assert.True(t, strings.HasSuffix(got, `</svg>`))
+	assert.True(t, strings.Contains(got, `vertical-align:`))
+	assert.True(t, strings.Contains(got, `<line `))
+	assert.True(t, strings.Contains(got, `<path `))
+
+	got, err = r.RenderEquation(`a<b`, true)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(got, `display:block`))
+	assert.True(t, strings.Contains(got, `&lt;`))
+
+	_, err = r.RenderEquation(`{a`, true)
+	assert.Error(t, err)
+}
+
+func TestMapVariant(t *testing.T) {
+	assert.Equal(t, 'ℝ', mapVariant('R', "double-struck"))
+	assert.Equal(t, '𝐚', mapVariant('a', "bold"))
+	assert.Equal(t, '𝟙', mapVariant('1', "double-struck"))
+	assert.Equal(t, 'x', mapVariant('x', "normal"))
+}
@@ -0,0 +1,188 @@
+package equation
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// MathML renders equations as MathML, which is supported natively
+// by modern browsers. It doesn't need any external tools or CSS
+type MathML struct {
+	// if true, doesn't include TeX source as an annotation
+	NoAnnotation bool
+}
+
+var _ Renderer = &MathML{}
+
+// RenderEquation converts TeX equation to MathML
+func (r *MathML) RenderEquation(tex string, display bool) (string, error) {
+	root, err := parse(tex)
+	if err != nil {
+		return "", err
+	}
+	w := &mathMLWriter{display: display}
+	if display {
+		w.WriteString(`<math xmlns="http://www.w3.org/1998/Math/MathML" display="block">`)
+	} else {
+		w.WriteString(`<math xmlns="http://www.w3.org/1998/Math/MathML">`)
+	}
+	if r.NoAnnotation {
+		w.write(root)
+	} else {
+		w.WriteString(`<semantics>`)
+		w.write(root)
+		w.printf(`<annotation encoding="application/x-tex">%s</annotation>`, html.EscapeString(tex))
+		w.WriteString(`</semantics>`)
+	}
+	w.WriteString(`</math>`)
+	return w.String(), nil
+}
+
+type mathMLWriter struct {
+	strings.Builder
+	display bool
+}
+
+func (w *mathMLWriter) printf(format string, args ...interface{}) {
+	fmt.Fprintf(w, format, args...)
+}
+
+func (w *mathMLWriter) writeMo(s string, attrs string) {
+	if s == "" {
+		return
+	}
+	w.printf(`<mo%s>%s</mo>`, attrs, html.EscapeString(s))
+}
+
+func (w *mathMLWriter) writeOrEmpty(n *node) {
+	if n == nil {
+		w.WriteString(`<mrow></mrow>`)
+		return
+	}
+	w.write(n)
+}
+
+func (w *mathMLWriter) write(n *node) {
+	switch n.kind {
+	case kindRow:
+		if len(n.children) == 1 {
+			w.write(n.children[0])
+			return
+		}
+		w.WriteString(`<mrow>`)
+		for _, c := range n.children {
+			w.write(c)
+		}
+		w.WriteString(`</mrow>`)
+	case kindIdent:
+		attrs := ""
+		if n.normal && len([]rune(n.text)) == 1 {
+			attrs = ` mathvariant="normal"`
+		}
+		w.printf(`<mi%s>%s</mi>`, attrs, html.EscapeString(n.text))
+	case kindNumber:
+		w.printf(`<mn>%s</mn>`, html.EscapeString(n.text))
+	case kindOperator:
+		attrs := ""
+		if n.largeOp {
+			attrs = ` largeop="true"`
+			if n.limits {
+				attrs += ` movablelimits="true"`
+			}
+		}
+		w.writeMo(n.text, attrs)
+	case kindText:
+		w.printf(`<mtext>%s</mtext>`, html.EscapeString(n.text))
+	case kindSpace:
+		w.printf(`<mspace width="%gem"/>`, n.width)
+	case kindError:
+		w.printf(`<merror><mtext>%s</mtext></merror>`, html.EscapeString(n.text))
+	case kindFrac:
+		if n.noBar {
+			w.WriteString(`<mfrac linethickness="0">`)
+		} else {
+			w.WriteString(`<mfrac>`)
+		}
+		w.write(n.children[0])
+		w.write(n.children[1])
+		w.WriteString(`</mfrac>`)
+	case kindSqrt:
+		if index := n.children[1]; index != nil {
+			w.WriteString(`<mroot>`)
+			w.write(n.children[0])
+			w.write(index)
+			w.WriteString(`</mroot>`)
+			return
+		}
+		w.WriteString(`<msqrt>`)
+		w.write(n.children[0])
+		w.WriteString(`</msqrt>`)
+	case kindScripts:
+		w.writeScripts(n)
+	case kindAccent:
+		base := n.children[0]
+		if n.under {
+			w.WriteString(`<munder accentunder="true">`)
+			w.writeOrEmpty(base)
+			w.writeMo(n.text, ` stretchy="true"`)
+			w.WriteString(`</munder>`)
+			return
+		}
+		w.WriteString(`<mover accent="true">`)
+		w.writeOrEmpty(base)
+		w.writeMo(n.text, ` stretchy="true"`)
+		w.WriteString(`</mover>`)
+	case kindFenced:
+		w.WriteString(`<mrow>`)
+		w.writeMo(n.open, ` fence="true" stretchy="true"`)
+		w.write(n.children[0])
+		w.writeMo(n.close, ` fence="true" stretchy="true"`)
+		w.WriteString(`</mrow>`)
+	case kindTable:
+		w.WriteString(`<mtable>`)
+		for _, row := range n.rows {
+			w.WriteString(`<mtr>`)
+			for _, cell := range row {
+				w.WriteString(`<mtd>`)
+				w.write(cell)
+				w.WriteString(`</mtd>`)
+			}
+			w.WriteString(`</mtr>`)
+		}
+		w.WriteString(`</mtable>`)
+	}
+}
+
+func (w *mathMLWriter) writeScripts(n *node) {
+	base, sub, sup := n.children[0], n.children[1], n.children[2]
+	// operators like \sum have limits under and over them in display mode
+	underOver := w.display && base.limits
+	tag := ""
+	switch {
+	case sub != nil && sup != nil:
+		tag = "msubsup"
+		if underOver {
+			tag = "munderover"
+		}
+	case sub != nil:
+		tag = "msub"
+		if underOver {
+			tag = "munder"
+		}
+	default:
+		tag = "msup"
+		if underOver {
+			tag = "mover"
+		}
+	}
+	w.printf(`<%s>`, tag)
+	w.writeOrEmpty(base)
+	if sub != nil {
+		w.write(sub)
+	}
+	if sup != nil {
+		w.write(sup)
+	}
+	w.printf(`</%s>`, tag)
+}
@@ -0,0 +1,579 @@
+package equation
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type nodeKind int
+
+const (
+	kindRow nodeKind = iota
+	kindIdent
+	kindNumber
+	kindOperator
+	kindText
+	kindFrac
+	kindSqrt
+	kindScripts
+	kindAccent
+	kindFenced
+	kindTable
+	kindSpace
+	kindError
+)
+
+// node is a node in a parsed TeX equation
+type node struct {
+	kind nodeKind
+	text string
+	// kindRow: items in the row
+	// kindScripts: base, sub, sup (sub and sup can be nil)
+	// kindFrac: numerator, denominator
+	// kindSqrt: radicand, index (index can be nil)
+	// kindAccent: base, text is the accent
+	// kindFenced: content, delimiters are in open and close
+	children []*node
+	// kindTable: cells of the table
+	rows        [][]*node
+	open, close string
+	// kindSpace: width in em
+	width float64
+	// kindIdent: render upright (not italic)
+	normal bool
+	// kindOperator: large operator like sum or integral
+	largeOp bool
+	// kindOperator and kindIdent: in display mode scripts are placed
+	// under and over the operator
+	limits bool
+	// kindFrac: no fraction line (binomial coefficient)
+	noBar bool
+	// kindAccent: accent is placed under the base
+	under bool
+}
+
+func newRow(children []*node) *node {
+	return &node{kind: kindRow, children: children}
+}
+
+const (
+	tokEOF = iota
+	// a single character
+	tokChar
+	// \name, text is name without backslash
+	tokCommand
+)
+
+type token struct {
+	kind int
+	text string
+}
+
+func (t token) isChar(s string) bool {
+	return t.kind == tokChar && t.text == s
+}
+
+func (t token) isCommand(s string) bool {
+	return t.kind == tokCommand && t.text == s
+}
+
+type parser struct {
+	s   []rune
+	pos int
+}
+
+func isLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.s) && unicode.IsSpace(p.s[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *parser) next() token {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return token{kind: tokEOF}
+	}
+	r := p.s[p.pos]
+	p.pos++
+	if r != '\\' {
+		return token{kind: tokChar, text: string(r)}
+	}
+	if p.pos >= len(p.s) {
+		return token{kind: tokChar, text: `\`}
+	}
+	r = p.s[p.pos]
+	if !isLetter(r) {
+		p.pos++
+		return token{kind: tokCommand, text: string(r)}
+	}
+	start := p.pos
+	for p.pos < len(p.s) && isLetter(p.s[p.pos]) {
+		p.pos++
+	}
+	return token{kind: tokCommand, text: string(p.s[start:p.pos])}
+}
+
+func (p *parser) peek() token {
+	pos := p.pos
+	t := p.next()
+	p.pos = pos
+	return t
+}
+
+// readRawGroup reads {...} without parsing it
+func (p *parser) readRawGroup() (string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.s) || p.s[p.pos] != '{' {
+		return "", fmt.Errorf("expected '{' at position %d", p.pos)
+	}
+	p.pos++
+	start := p.pos
+	nesting := 1
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case '\\':
+			p.pos++
+		case '{':
+			nesting++
+		case '}':
+			nesting--
+			if nesting == 0 {
+				s := string(p.s[start:p.pos])
+				p.pos++
+				return s, nil
+			}
+		}
+		p.pos++
+	}
+	return "", fmt.Errorf("missing '}'")
+}
+
+func isRowEnd(t token) bool {
+	switch {
+	case t.kind == tokEOF:
+		return true
+	case t.isChar("}"), t.isChar("&"):
+		return true
+	case t.isCommand(`\`), t.isCommand("right"), t.isCommand("end"):
+		return true
+	}
+	return false
+}
+
+// parseRow parses until end of input or a token that ends a row
+// (which is not consumed)
+func (p *parser) parseRow(stop func(token) bool) ([]*node, error) {
+	var res []*node
+	for {
+		t := p.peek()
+		if stop(t) {
+			return res, nil
+		}
+		n, err := p.parseAtom()
+		if err != nil {
+			return nil, err
+		}
+		if n == nil {
+			continue
+		}
+		n, err = p.parseScripts(n)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, n)
+	}
+}
+
+func (p *parser) parseScripts(base *node) (*node, error) {
+	var sub, sup *node
+	for {
+		t := p.peek()
+		if !t.isChar("^") && !t.isChar("_") && !t.isChar("'") {
+			break
+		}
+		p.next()
+		if t.isChar("'") {
+			// x' is x^{\prime}
+			prime := &node{kind: kindOperator, text: "′"}
+			if sup == nil {
+				sup = prime
+			} else {
+				sup = newRow([]*node{sup, prime})
+			}
+			continue
+		}
+		arg, err := p.parseArg()
+		if err != nil {
+			return nil, err
+		}
+		if arg == nil {
+			return nil, fmt.Errorf("missing argument of '%s'", t.text)
+		}
+		if t.isChar("^") {
+			sup = arg
+		} else {
+			sub = arg
+		}
+	}
+	if sub == nil && sup == nil {
+		return base, nil
+	}
+	return &node{kind: kindScripts, children: []*node{base, sub, sup}}, nil
+}
+
+// parseGroup parses the rest of {...} after '{'
+func (p *parser) parseGroup() (*node, error) {
+	children, err := p.parseRow(isRowEnd)
+	if err != nil {
+		return nil, err
+	}
+	t := p.next()
+	if !t.isChar("}") {
+		return nil, fmt.Errorf("missing '}'")
+	}
+	return newRow(children), nil
+}
+
+// parseArg parses an argument of a command or a script: a group or
+// a single token
+func (p *parser) parseArg() (*node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokEOF:
+		return nil, nil
+	case tokCommand:
+		return p.parseCommand(t.text)
+	}
+	if t.text == "{" {
+		return p.parseGroup()
+	}
+	return charNode(t.text), nil
+}
+
+func charNode(s string) *node {
+	r := []rune(s)[0]
+	if isDigit(r) {
+		return &node{kind: kindNumber, text: s}
+	}
+	if isLetter(r) || unicode.IsLetter(r) {
+		return &node{kind: kindIdent, text: s}
+	}
+	if s == "~" {
+		return &node{kind: kindSpace, width: 0.25}
+	}
+	return &node{kind: kindOperator, text: s}
+}
+
+func (p *parser) parseAtom() (*node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokEOF:
+		return nil, nil
+	case tokCommand:
+		return p.parseCommand(t.text)
+	}
+	switch t.text {
+	case "{":
+		return p.parseGroup()
+	case "}":
+		return nil, fmt.Errorf("unexpected '}'")
+	case "^", "_":
+		// script without a base
+		p.pos--
+		return newRow(nil), nil
+	}
+	r := []rune(t.text)[0]
+	if isDigit(r) || (r == '.' && p.pos < len(p.s) && isDigit(p.s[p.pos])) {
+		s := t.text
+		for p.pos < len(p.s) && (isDigit(p.s[p.pos]) || p.s[p.pos] == '.') {
+			s += string(p.s[p.pos])
+			p.pos++
+		}
+		return &node{kind: kindNumber, text: s}, nil
+	}
+	return charNode(t.text), nil
+}
+
+func (p *parser) readDelimiter() (string, error) {
+	t := p.next()
+	switch t.kind {
+	case tokEOF:
+		return "", fmt.Errorf("missing delimiter")
+	case tokChar:
+		if t.text == "." {
+			return "", nil
+		}
+		return t.text, nil
+	}
+	if s, ok := delimiters[t.text]; ok {
+		return s, nil
+	}
+	if s, ok := operators[t.text]; ok {
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown delimiter '\\%s'", t.text)
+}
+
+// applies a font variant (like \mathbb) to identifiers and numbers
+func applyVariant(n *node, variant string) {
+	if n == nil {
+		return
+	}
+	switch n.kind {
+	case kindIdent, kindNumber:
+		if variant == "normal" {
+			n.normal = true
+			return
+		}
+		var sb strings.Builder
+		for _, r := range n.text {
+			sb.WriteRune(mapVariant(r, variant))
+		}
+		n.text = sb.String()
+		n.normal = true
+	}
+	for _, c := range n.children {
+		applyVariant(c, variant)
+	}
+}
+
+func (p *parser) parseTable(env string) (*node, error) {
+	if env == "array" || env == "alignedat" {
+		// skip column spec
+		if _, err := p.readRawGroup(); err != nil {
+			return nil, err
+		}
+	}
+	var rows [][]*node
+	var row []*node
+	for {
+		cell, err := p.parseRow(isRowEnd)
+		if err != nil {
+			return nil, err
+		}
+		row = append(row, newRow(cell))
+		t := p.next()
+		switch {
+		case t.isChar("&"):
+			continue
+		case t.isCommand(`\`):
+			rows = append(rows, row)
+			row = nil
+			continue
+		case t.isCommand("end"):
+			name, err := p.readRawGroup()
+			if err != nil {
+				return nil, err
+			}
+			if name != env {
+				return nil, fmt.Errorf("\\begin{%s} ended by \\end{%s}", env, name)
+			}
+		default:
+			return nil, fmt.Errorf("missing \\end{%s}", env)
+		}
+		break
+	}
+	// ignore empty last row, from trailing \\
+	if len(row) > 1 || (len(row) == 1 && len(row[0].children) > 0) {
+		rows = append(rows, row)
+	}
+	table := &node{kind: kindTable, rows: rows}
+	open, close := "", ""
+	switch env {
+	case "pmatrix":
+		open, close = "(", ")"
+	case "bmatrix":
+		open, close = "[", "]"
+	case "Bmatrix", "cases":
+		open = "{"
+		if env == "Bmatrix" {
+			close = "}"
+		}
+	case "vmatrix":
+		open, close = "|", "|"
+	case "Vmatrix":
+		open, close = "‖", "‖"
+	}
+	if open == "" && close == "" {
+		return table, nil
+	}
+	return &node{kind: kindFenced, open: open, close: close, children: []*node{table}}, nil
+}
+
+func (p *parser) parseCommand(name string) (*node, error) {
+	if s, ok := greek[name]; ok {
+		n := &node{kind: kindIdent, text: s}
+		// upper-case greek letters are upright
+		n.normal = unicode.IsUpper([]rune(s)[0])
+		return n, nil
+	}
+	if s, ok := symbols[name]; ok {
+		return &node{kind: kindIdent, text: s, normal: true}, nil
+	}
+	if s, ok := operators[name]; ok {
+		return &node{kind: kindOperator, text: s}, nil
+	}
+	if s, ok := largeOperators[name]; ok {
+		isIntegral := strings.Contains(name, "int")
+		return &node{kind: kindOperator, text: s, largeOp: true, limits: !isIntegral}, nil
+	}
+	if functions[name] {
+		return &node{kind: kindIdent, text: name, normal: true}, nil
+	}
+	if limitFunctions[name] {
+		text := name
+		switch name {
+		case "liminf":
+			text = "lim inf"
+		case "limsup":
+			text = "lim sup"
+		}
+		return &node{kind: kindIdent, text: text, normal: true, limits: true}, nil
+	}
+	if w, ok := spaces[name]; ok {
+		return &node{kind: kindSpace, width: w}, nil
+	}
+	if accent, ok := accents[name]; ok {
+		base, err := p.parseArg()
+		if err != nil {
+			return nil, err
+		}
+		under := name == "underline" || name == "underbrace"
+		return &node{kind: kindAccent, text: accent, children: []*node{base}, under: under}, nil
+	}
+	if variant, ok := variants[name]; ok {
+		arg, err := p.parseArg()
+		if err != nil {
+			return nil, err
+		}
+		applyVariant(arg, variant)
+		return arg, nil
+	}
+
+	switch name {
+	case "frac", "dfrac", "tfrac", "cfrac", "binom", "dbinom", "tbinom":
+		num, err := p.parseArg()
+		if err != nil {
+			return nil, err
+		}
+		den, err := p.parseArg()
+		if err != nil {
+			return nil, err
+		}
+		if num == nil || den == nil {
+			return nil, fmt.Errorf("missing argument of '\\%s'", name)
+		}
+		frac := &node{kind: kindFrac, children: []*node{num, den}}
+		if !strings.HasSuffix(name, "binom") {
+			return frac, nil
+		}
+		frac.noBar = true
+		return &node{kind: kindFenced, open: "(", close: ")", children: []*node{frac}}, nil
+	case "sqrt":
+		var index *node
+		if p.peek().isChar("[") {
+			p.next()
+			children, err := p.parseRow(func(t token) bool {
+				return t.isChar("]") || t.kind == tokEOF
+			})
+			if err != nil {
+				return nil, err
+			}
+			if !p.next().isChar("]") {
+				return nil, fmt.Errorf("missing ']'")
+			}
+			index = newRow(children)
+		}
+		arg, err := p.parseArg()
+		if err != nil {
+			return nil, err
+		}
+		if arg == nil {
+			return nil, fmt.Errorf("missing argument of '\\sqrt'")
+		}
+		return &node{kind: kindSqrt, children: []*node{arg, index}}, nil
+	case "text", "textrm", "textit", "textbf", "textsf", "texttt", "mbox", "hbox":
+		s, err := p.readRawGroup()
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: kindText, text: s}, nil
+	case "operatorname":
+		limits := false
+		if p.peek().isChar("*") {
+			p.next()
+			limits = true
+		}
+		s, err := p.readRawGroup()
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: kindIdent, text: s, normal: true, limits: limits}, nil
+	case "left":
+		open, err := p.readDelimiter()
+		if err != nil {
+			return nil, err
+		}
+		children, err := p.parseRow(func(t token) bool {
+			return t.isCommand("right") || t.kind == tokEOF
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !p.next().isCommand("right") {
+			return nil, fmt.Errorf("missing '\\right'")
+		}
+		close, err := p.readDelimiter()
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: kindFenced, open: open, close: close, children: []*node{newRow(children)}}, nil
+	case "right":
+		return nil, fmt.Errorf("'\\right' without '\\left'")
+	case "big", "Big", "bigg", "Bigg", "bigl", "bigr", "Bigl", "Bigr", "biggl", "biggr", "Biggl", "Biggr", "middle":
+		d, err := p.readDelimiter()
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: kindOperator, text: d}, nil
+	case "begin":
+		env, err := p.readRawGroup()
+		if err != nil {
+			return nil, err
+		}
+		return p.parseTable(env)
+	case "end":
+		return nil, fmt.Errorf("'\\end' without '\\begin'")
+	case `\`:
+		// line break outside of environment, we ignore it
+		return nil, nil
+	case "displaystyle", "textstyle", "scriptstyle", "scriptscriptstyle", "limits", "nolimits", "nonumber", "notag", "label":
+		if name == "label" {
+			_, err := p.readRawGroup()
+			return nil, err
+		}
+		return nil, nil
+	}
+	return &node{kind: kindError, text: `\` + name}, nil
+}
+
+// parse parses TeX equation
+func parse(tex string) (*node, error) {
+	p := &parser{s: []rune(tex)}
+	children, err := p.parseRow(func(t token) bool {
+		return t.kind == tokEOF
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newRow(children), nil
+}
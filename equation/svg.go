@@ -0,0 +1,540 @@
+package equation
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+)
+
+// SVG renders equations as self-contained SVG images, in pure Go.
+// Layout approximates TeX using metrics of a typical serif font. It
+// doesn't look as good as MathML or katex but doesn't depend on browser
+// support for MathML or on external CSS and fonts
+type SVG struct {
+	// font used for text, "Times New Roman, serif" if empty
+	FontFamily string
+	// color of text and lines, "currentColor" if empty
+	Color string
+}
+
+var _ Renderer = &SVG{}
+
+// svg coordinates are em * svgScale
+const svgScale = 100
+
+// box is a laid out part of an equation. Dimensions are in em
+type box struct {
+	// width, height above baseline and depth below baseline
+	w, h, d float64
+	// draws the box at x with baseline at y
+	draw func(w *svgWriter, x, y float64)
+}
+
+type svgWriter struct {
+	strings.Builder
+	color string
+}
+
+func fmtCoord(v float64) string {
+	s := fmt.Sprintf("%.1f", v*svgScale)
+	s = strings.TrimSuffix(s, ".0")
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
+
+func (w *svgWriter) text(s string, x, y, size float64, italic bool, extra string) {
+	style := ""
+	if italic {
+		style = ` font-style="italic"`
+	}
+	fmt.Fprintf(w, `<text x="%s" y="%s" font-size="%s"%s%s>%s</text>`, fmtCoord(x), fmtCoord(y), fmtCoord(size), style, extra, html.EscapeString(s))
+}
+
+func (w *svgWriter) line(x1, y1, x2, y2, thickness float64) {
+	fmt.Fprintf(w, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s" stroke-width="%s"/>`, fmtCoord(x1), fmtCoord(y1), fmtCoord(x2), fmtCoord(y2), w.color, fmtCoord(thickness))
+}
+
+// approximate width of a character in em
+func charWidth(r rune) float64 {
+	switch {
+	case strings.ContainsRune("ijlt.,;:!'|`´′", r):
+		return 0.28
+	case strings.ContainsRune("frI()[]{}", r):
+		return 0.35
+	case strings.ContainsRune("mwMW", r):
+		return 0.85
+	case r >= 'A' && r <= 'Z':
+		return 0.68
+	case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		return 0.5
+	case r == ' ':
+		return 0.25
+	case strings.ContainsRune("+−=<>×÷±∓≤≥≠≈≡∼→←↔⇒⇐⇔∈∉⊂⊃⊆⊇∪∩∝≅⟹⟺", r):
+		return 0.78
+	}
+	return 0.6
+}
+
+func textWidth(s string) float64 {
+	res := 0.0
+	for _, r := range s {
+		res += charWidth(r)
+	}
+	return res
+}
+
+const (
+	// height and depth of glyphs, relative to font size
+	glyphHeight = 0.7
+	glyphDepth  = 0.22
+	// position of math axis (e.g. of fraction line) above the baseline
+	axisHeight = 0.25
+	// thickness of fraction lines
+	ruleThickness = 0.05
+)
+
+func glyphBox(s string, size float64, italic bool, extra string) *box {
+	return &box{
+		w: textWidth(s) * size,
+		h: glyphHeight * size,
+		d: glyphDepth * size,
+		draw: func(w *svgWriter, x, y float64) {
+			w.text(s, x, y, size, italic, extra)
+		},
+	}
+}
+
+// spacing around an operator, in em
+func operatorSpacing(s string) (float64, float64) {
+	switch {
+	case strings.ContainsAny(s, "=<>≤≥≠≈≡∼≃≅∝→←↔⇒⇐⇔⟹⟸⟺↦∈∉∋⊂⊃⊆⊇∣∥⊥≪≫≺≻"):
+		return 0.28, 0.28
+	case strings.ContainsAny(s, "+−×÷±∓⋅∪∩∘⊕⊖⊗⊙∧∨∖∗⋆"):
+		return 0.22, 0.22
+	case s == "," || s == ";":
+		return 0, 0.17
+	}
+	return 0, 0
+}
+
+func padBox(b *box, left, right float64) *box {
+	if left == 0 && right == 0 {
+		return b
+	}
+	return &box{
+		w: b.w + left + right,
+		h: b.h,
+		d: b.d,
+		draw: func(w *svgWriter, x, y float64) {
+			b.draw(w, x+left, y)
+		},
+	}
+}
+
+func rowBox(boxes []*box) *box {
+	res := &box{}
+	for _, b := range boxes {
+		res.w += b.w
+		res.h = math.Max(res.h, b.h)
+		res.d = math.Max(res.d, b.d)
+	}
+	res.draw = func(w *svgWriter, x, y float64) {
+		for _, b := range boxes {
+			b.draw(w, x, y)
+			x += b.w
+		}
+	}
+	return res
+}
+
+type svgLayout struct {
+	display bool
+}
+
+func (l *svgLayout) layoutOrEmpty(n *node, size float64, display bool) *box {
+	if n == nil {
+		return &box{draw: func(w *svgWriter, x, y float64) {}}
+	}
+	return l.layout(n, size, display)
+}
+
+func (l *svgLayout) layout(n *node, size float64, display bool) *box {
+	switch n.kind {
+	case kindRow:
+		var boxes []*box
+		for _, c := range n.children {
+			boxes = append(boxes, l.layout(c, size, display))
+		}
+		return rowBox(boxes)
+	case kindIdent:
+		if len([]rune(n.text)) > 1 {
+			// function names like sin are followed by a thin space
+			return padBox(glyphBox(n.text, size, false, ""), 0, 0.17*size)
+		}
+		return glyphBox(n.text, size, !n.normal, "")
+	case kindNumber:
+		return glyphBox(n.text, size, false, "")
+	case kindOperator:
+		s := n.text
+		if s == "-" {
+			s = "−"
+		}
+		if n.largeOp {
+			return l.layoutLargeOp(s, size, display)
+		}
+		b := glyphBox(s, size, false, "")
+		if size < 1 {
+			// no spacing around operators in scripts
+			return b
+		}
+		left, right := operatorSpacing(s)
+		return padBox(b, left, right)
+	case kindText:
+		return glyphBox(n.text, size, false, "")
+	case kindSpace:
+		return &box{w: n.width * size, draw: func(w *svgWriter, x, y float64) {}}
+	case kindError:
+		return glyphBox(n.text, size, false, ` fill="red"`)
+	case kindFrac:
+		return l.layoutFrac(n, size, display)
+	case kindSqrt:
+		return l.layoutSqrt(n, size)
+	case kindScripts:
+		return l.layoutScripts(n, size, display)
+	case kindAccent:
+		return l.layoutAccent(n, size, display)
+	case kindFenced:
+		return l.layoutFenced(n, size, display)
+	case kindTable:
+		return l.layoutTable(n, size)
+	}
+	return &box{draw: func(w *svgWriter, x, y float64) {}}
+}
+
+func (l *svgLayout) layoutLargeOp(s string, size float64, display bool) *box {
+	opSize := size * 1.3
+	if display {
+		opSize = size * 1.8
+	}
+	b := glyphBox(s, opSize, false, "")
+	// center the operator on the math axis
+	shift := (b.h-b.d)/2 - axisHeight*size
+	return padBox(&box{
+		w: b.w,
+		h: b.h - shift,
+		d: b.d + shift,
+		draw: func(w *svgWriter, x, y float64) {
+			b.draw(w, x, y+shift)
+		},
+	}, 0.1*size, 0.1*size)
+}
+
+func scriptSize(size float64) float64 {
+	return math.Max(size*0.7, 0.5)
+}
+
+func (l *svgLayout) layoutFrac(n *node, size float64, display bool) *box {
+	partSize := size
+	if !display {
+		partSize = scriptSize(size)
+	}
+	num := l.layout(n.children[0], partSize, false)
+	den := l.layout(n.children[1], partSize, false)
+	axis := axisHeight * size
+	t := ruleThickness * size
+	gap := 0.1 * size
+	numShift := axis + t/2 + gap + num.d
+	denShift := den.h + gap + t/2 - axis
+	pad := 0.1 * size
+	w := math.Max(num.w, den.w) + 2*pad
+	return &box{
+		w: w,
+		h: numShift + num.h,
+		d: denShift + den.d,
+		draw: func(sw *svgWriter, x, y float64) {
+			num.draw(sw, x+(w-num.w)/2, y-numShift)
+			den.draw(sw, x+(w-den.w)/2, y+denShift)
+			if !n.noBar {
+				sw.line(x+pad/2, y-axis, x+w-pad/2, y-axis, t)
+			}
+		},
+	}
+}
+
+func (l *svgLayout) layoutSqrt(n *node, size float64) *box {
+	c := l.layout(n.children[0], size, false)
+	var index *box
+	if n.children[1] != nil {
+		index = l.layout(n.children[1], 0.5, false)
+	}
+	t := ruleThickness * size
+	gap := 0.1 * size
+	radW := 0.6 * size
+	h := math.Max(c.h, glyphHeight*size) + gap + t
+	d := math.Max(c.d, 0.1*size)
+	offset := 0.0
+	if index != nil && index.w > radW*0.5 {
+		offset = index.w - radW*0.5
+	}
+	return &box{
+		w: offset + radW + c.w + gap,
+		h: h,
+		d: d,
+		draw: func(sw *svgWriter, x, y float64) {
+			x0 := x + offset
+			fmt.Fprintf(sw, `<path d="M%s %s L%s %s L%s %s L%s %s L%s %s" fill="none" stroke="%s" stroke-width="%s"/>`,
+				fmtCoord(x0), fmtCoord(y-h*0.35),
+				fmtCoord(x0+radW*0.25), fmtCoord(y-h*0.45),
+				fmtCoord(x0+radW*0.55), fmtCoord(y+d),
+				fmtCoord(x0+radW), fmtCoord(y-h+t/2),
+				fmtCoord(x0+radW+c.w+gap), fmtCoord(y-h+t/2),
+				sw.color, fmtCoord(t))
+			c.draw(sw, x0+radW, y)
+			if index != nil {
+				index.draw(sw, x, y-h*0.5)
+			}
+		},
+	}
+}
+
+func (l *svgLayout) layoutScripts(n *node, size float64, display bool) *box {
+	base := l.layoutOrEmpty(n.children[0], size, display)
+	var sub, sup *box
+	ss := scriptSize(size)
+	if n.children[1] != nil {
+		sub = l.layout(n.children[1], ss, false)
+	}
+	if n.children[2] != nil {
+		sup = l.layout(n.children[2], ss, false)
+	}
+	gap := 0.1 * size
+	if display && n.children[0].limits {
+		// scripts under and over the base
+		w := base.w
+		if sub != nil {
+			w = math.Max(w, sub.w)
+		}
+		if sup != nil {
+			w = math.Max(w, sup.w)
+		}
+		res := &box{w: w, h: base.h, d: base.d}
+		if sup != nil {
+			res.h += gap + sup.d + sup.h
+		}
+		if sub != nil {
+			res.d += gap + sub.h + sub.d
+		}
+		res.draw = func(sw *svgWriter, x, y float64) {
+			base.draw(sw, x+(w-base.w)/2, y)
+			if sup != nil {
+				sup.draw(sw, x+(w-sup.w)/2, y-base.h-gap-sup.d)
+			}
+			if sub != nil {
+				sub.draw(sw, x+(w-sub.w)/2, y+base.d+gap+sub.h)
+			}
+		}
+		return res
+	}
+
+	supShift := math.Max(base.h-0.25*size, 0.4*size)
+	subShift := 0.2 * size
+	if sup != nil {
+		subShift = math.Max(subShift, base.d)
+		if sub != nil {
+			subShift += 0.1 * size
+		}
+	}
+	scriptsW := 0.0
+	res := &box{h: base.h, d: base.d}
+	if sup != nil {
+		scriptsW = sup.w
+		res.h = math.Max(res.h, supShift+sup.h)
+	}
+	if sub != nil {
+		scriptsW = math.Max(scriptsW, sub.w)
+		res.d = math.Max(res.d, subShift+sub.d)
+	}
+	res.w = base.w + scriptsW + 0.05*size
+	res.draw = func(sw *svgWriter, x, y float64) {
+		base.draw(sw, x, y)
+		if sup != nil {
+			sup.draw(sw, x+base.w, y-supShift)
+		}
+		if sub != nil {
+			sub.draw(sw, x+base.w, y+subShift)
+		}
+	}
+	return res
+}
+
+func (l *svgLayout) layoutAccent(n *node, size float64, display bool) *box {
+	base := l.layoutOrEmpty(n.children[0], size, display)
+	isLine := n.text == "‾" || n.text == "¯" || n.text == "_"
+	t := ruleThickness * size
+	gap := 0.1 * size
+	if isLine {
+		res := &box{w: base.w, h: base.h, d: base.d}
+		if n.under {
+			res.d += gap + t
+		} else {
+			res.h += gap + t
+		}
+		res.draw = func(sw *svgWriter, x, y float64) {
+			base.draw(sw, x, y)
+			if n.under {
+				sw.line(x, y+base.d+gap, x+base.w, y+base.d+gap, t)
+			} else {
+				sw.line(x, y-base.h-gap, x+base.w, y-base.h-gap, t)
+			}
+		}
+		return res
+	}
+	accentSize := size
+	accentW := textWidth(n.text) * accentSize
+	w := math.Max(base.w, accentW)
+	res := &box{w: w, h: base.h, d: base.d}
+	// accent glyphs are drawn high above their baseline so we only need
+	// a bit of extra space
+	lift := 0.3 * size
+	if n.under {
+		res.d += glyphHeight * size
+	} else {
+		res.h += lift
+	}
+	res.draw = func(sw *svgWriter, x, y float64) {
+		base.draw(sw, x+(w-base.w)/2, y)
+		ax := x + (w-accentW)/2
+		if n.under {
+			sw.text(n.text, ax, y+base.d+glyphHeight*size, accentSize, false, "")
+		} else {
+			sw.text(n.text, ax, y-base.h+lift+0.1*size, accentSize, false, "")
+		}
+	}
+	return res
+}
+
+// delimiter scaled vertically to cover h above and d below the baseline
+func delimiterBox(s string, size, h, d float64) *box {
+	if s == "" {
+		return &box{w: 0.1 * size, draw: func(w *svgWriter, x, y float64) {}}
+	}
+	natural := (glyphHeight + glyphDepth) * size
+	sy := math.Max((h+d)/natural, 1)
+	dw := textWidth(s) * size
+	return &box{
+		w: dw,
+		h: h,
+		d: d,
+		draw: func(sw *svgWriter, x, y float64) {
+			ty := y + d - glyphDepth*size*sy
+			if sy == 1 {
+				sw.text(s, x, ty, size, false, "")
+				return
+			}
+			fmt.Fprintf(sw, `<g transform="translate(%s %s) scale(1 %.3f)">`, fmtCoord(x), fmtCoord(ty), sy)
+			sw.text(s, 0, 0, size, false, "")
+			sw.WriteString(`</g>`)
+		},
+	}
+}
+
+func (l *svgLayout) layoutFenced(n *node, size float64, display bool) *box {
+	c := l.layout(n.children[0], size, display)
+	h := math.Max(c.h, glyphHeight*size)
+	d := math.Max(c.d, glyphDepth*size)
+	open := delimiterBox(n.open, size, h, d)
+	close := delimiterBox(n.close, size, h, d)
+	return rowBox([]*box{open, c, close})
+}
+
+func (l *svgLayout) layoutTable(n *node, size float64) *box {
+	var cells [][]*box
+	var colW []float64
+	var rowH, rowD []float64
+	for _, row := range n.rows {
+		var boxes []*box
+		h, d := glyphHeight*size, glyphDepth*size
+		for i, cell := range row {
+			b := l.layout(cell, size, false)
+			boxes = append(boxes, b)
+			if i >= len(colW) {
+				colW = append(colW, 0)
+			}
+			colW[i] = math.Max(colW[i], b.w)
+			h = math.Max(h, b.h)
+			d = math.Max(d, b.d)
+		}
+		cells = append(cells, boxes)
+		rowH = append(rowH, h)
+		rowD = append(rowD, d)
+	}
+	colGap := 1.0 * size
+	rowGap := 0.3 * size
+	w := 0.0
+	for i, cw := range colW {
+		if i > 0 {
+			w += colGap
+		}
+		w += cw
+	}
+	total := 0.0
+	for i := range rowH {
+		if i > 0 {
+			total += rowGap
+		}
+		total += rowH[i] + rowD[i]
+	}
+	axis := axisHeight * size
+	return &box{
+		w: w + 0.2*size,
+		h: total/2 + axis,
+		d: total/2 - axis,
+		draw: func(sw *svgWriter, x, y float64) {
+			top := y - axis - total/2
+			for i, boxes := range cells {
+				baseline := top + rowH[i]
+				cx := x + 0.1*size
+				for j, b := range boxes {
+					b.draw(sw, cx+(colW[j]-b.w)/2, baseline)
+					cx += colW[j] + colGap
+				}
+				top = baseline + rowD[i] + rowGap
+			}
+		},
+	}
+}
+
+// RenderEquation converts TeX equation to SVG image
+func (r *SVG) RenderEquation(tex string, display bool) (string, error) {
+	root, err := parse(tex)
+	if err != nil {
+		return "", err
+	}
+	l := &svgLayout{display: display}
+	b := l.layout(root, 1, display)
+	color := r.Color
+	if color == "" {
+		color = "currentColor"
+	}
+	font := r.FontFamily
+	if font == "" {
+		font = "Times New Roman, serif"
+	}
+	pad := 0.05
+	w := b.w + 2*pad
+	h := b.h + b.d + 2*pad
+	sw := &svgWriter{color: color}
+	style := fmt.Sprintf("vertical-align:-%.3fem", b.d+pad)
+	if display {
+		style = "display:block;margin:0 auto"
+	}
+	fmt.Fprintf(sw, `<svg xmlns="http://www.w3.org/2000/svg" width="%.3fem" height="%.3fem" viewBox="0 0 %s %s" style="%s" role="img" aria-label="%s">`,
+		w, h, fmtCoord(w), fmtCoord(h), style, html.EscapeString(tex))
+	fmt.Fprintf(sw, `<g fill="%s" font-family="%s">`, color, html.EscapeString(font))
+	b.draw(sw, pad, pad+b.h)
+	sw.WriteString(`</g></svg>`)
+	return sw.String(), nil
+}
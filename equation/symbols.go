@@ -0,0 +1,318 @@
+package equation
+
+var greek = map[string]string{
+	"alpha":      "α",
+	"beta":       "β",
+	"gamma":      "γ",
+	"delta":      "δ",
+	"epsilon":    "ϵ",
+	"varepsilon": "ε",
+	"zeta":       "ζ",
+	"eta":        "η",
+	"theta":      "θ",
+	"vartheta":   "ϑ",
+	"iota":       "ι",
+	"kappa":      "κ",
+	"lambda":     "λ",
+	"mu":         "μ",
+	"nu":         "ν",
+	"xi":         "ξ",
+	"omicron":    "ο",
+	"pi":         "π",
+	"varpi":      "ϖ",
+	"rho":        "ρ",
+	"varrho":     "ϱ",
+	"sigma":      "σ",
+	"varsigma":   "ς",
+	"tau":        "τ",
+	"upsilon":    "υ",
+	"phi":        "ϕ",
+	"varphi":     "φ",
+	"chi":        "χ",
+	"psi":        "ψ",
+	"omega":      "ω",
+	"Gamma":      "Γ",
+	"Delta":      "Δ",
+	"Theta":      "Θ",
+	"Lambda":     "Λ",
+	"Xi":         "Ξ",
+	"Pi":         "Π",
+	"Sigma":      "Σ",
+	"Upsilon":    "Υ",
+	"Phi":        "Φ",
+	"Psi":        "Ψ",
+	"Omega":      "Ω",
+}
+
+// symbols that are identifiers
+var symbols = map[string]string{
+	"infty":       "∞",
+	"partial":     "∂",
+	"nabla":       "∇",
+	"ell":         "ℓ",
+	"hbar":        "ℏ",
+	"hslash":      "ℏ",
+	"imath":       "ı",
+	"jmath":       "ȷ",
+	"Re":          "ℜ",
+	"Im":          "ℑ",
+	"aleph":       "ℵ",
+	"wp":          "℘",
+	"emptyset":    "∅",
+	"varnothing":  "∅",
+	"top":         "⊤",
+	"bot":         "⊥",
+	"angle":       "∠",
+	"triangle":    "△",
+	"clubsuit":    "♣",
+	"diamondsuit": "♢",
+	"heartsuit":   "♡",
+	"spadesuit":   "♠",
+	"checkmark":   "✓",
+	"degree":      "°",
+}
+
+var operators = map[string]string{
+	"times":          "×",
+	"cdot":           "⋅",
+	"pm":             "±",
+	"mp":             "∓",
+	"div":            "÷",
+	"ast":            "∗",
+	"star":           "⋆",
+	"circ":           "∘",
+	"bullet":         "∙",
+	"oplus":          "⊕",
+	"ominus":         "⊖",
+	"otimes":         "⊗",
+	"oslash":         "⊘",
+	"odot":           "⊙",
+	"cup":            "∪",
+	"cap":            "∩",
+	"setminus":       "∖",
+	"wedge":          "∧",
+	"land":           "∧",
+	"vee":            "∨",
+	"lor":            "∨",
+	"neg":            "¬",
+	"lnot":           "¬",
+	"leq":            "≤",
+	"le":             "≤",
+	"geq":            "≥",
+	"ge":             "≥",
+	"neq":            "≠",
+	"ne":             "≠",
+	"ll":             "≪",
+	"gg":             "≫",
+	"approx":         "≈",
+	"equiv":          "≡",
+	"sim":            "∼",
+	"simeq":          "≃",
+	"cong":           "≅",
+	"propto":         "∝",
+	"prec":           "≺",
+	"succ":           "≻",
+	"preceq":         "⪯",
+	"succeq":         "⪰",
+	"in":             "∈",
+	"notin":          "∉",
+	"ni":             "∋",
+	"subset":         "⊂",
+	"supset":         "⊃",
+	"subseteq":       "⊆",
+	"supseteq":       "⊇",
+	"perp":           "⊥",
+	"parallel":       "∥",
+	"mid":            "∣",
+	"forall":         "∀",
+	"exists":         "∃",
+	"nexists":        "∄",
+	"to":             "→",
+	"rightarrow":     "→",
+	"leftarrow":      "←",
+	"gets":           "←",
+	"leftrightarrow": "↔",
+	"Rightarrow":     "⇒",
+	"Leftarrow":      "⇐",
+	"Leftrightarrow": "⇔",
+	"implies":        "⟹",
+	"impliedby":      "⟸",
+	"iff":            "⟺",
+	"mapsto":         "↦",
+	"longrightarrow": "⟶",
+	"longleftarrow":  "⟵",
+	"uparrow":        "↑",
+	"downarrow":      "↓",
+	"cdots":          "⋯",
+	"ldots":          "…",
+	"dots":           "…",
+	"vdots":          "⋮",
+	"ddots":          "⋱",
+	"colon":          ":",
+	"prime":          "′",
+	"vert":           "|",
+	"Vert":           "‖",
+	"|":              "‖",
+	"{":              "{",
+	"}":              "}",
+	"#":              "#",
+	"$":              "$",
+	"%":              "%",
+	"&":              "&",
+	"_":              "_",
+	"langle":         "⟨",
+	"rangle":         "⟩",
+	"lfloor":         "⌊",
+	"rfloor":         "⌋",
+	"lceil":          "⌈",
+	"rceil":          "⌉",
+	"lbrace":         "{",
+	"rbrace":         "}",
+	"lbrack":         "[",
+	"rbrack":         "]",
+}
+
+// delimiters for \left, \right and \big
+var delimiters = map[string]string{
+	"{":      "{",
+	"}":      "}",
+	"|":      "‖",
+	"langle": "⟨",
+	"rangle": "⟩",
+	"lbrace": "{",
+	"rbrace": "}",
+	"lfloor": "⌊",
+	"rfloor": "⌋",
+	"lceil":  "⌈",
+	"rceil":  "⌉",
+	"vert":   "|",
+	"Vert":   "‖",
+}
+
+var largeOperators = map[string]string{
+	"sum":       "∑",
+	"prod":      "∏",
+	"coprod":    "∐",
+	"int":       "∫",
+	"iint":      "∬",
+	"iiint":     "∭",
+	"oint":      "∮",
+	"bigcup":    "⋃",
+	"bigcap":    "⋂",
+	"bigvee":    "⋁",
+	"bigwedge":  "⋀",
+	"bigoplus":  "⨁",
+	"bigotimes": "⨂",
+	"bigodot":   "⨀",
+}
+
+// functions are rendered upright
+var functions = map[string]bool{
+	"sin": true, "cos": true, "tan": true, "cot": true, "sec": true, "csc": true,
+	"arcsin": true, "arccos": true, "arctan": true,
+	"sinh": true, "cosh": true, "tanh": true, "coth": true,
+	"log": true, "ln": true, "lg": true, "exp": true,
+	"arg": true, "ker": true, "dim": true, "hom": true, "deg": true,
+}
+
+// functions with scripts under and over them in display mode
+var limitFunctions = map[string]bool{
+	"lim": true, "liminf": true, "limsup": true,
+	"max": true, "min": true, "sup": true, "inf": true,
+	"det": true, "gcd": true, "Pr": true,
+}
+
+// spaces, in em
+var spaces = map[string]float64{
+	",":       0.167,
+	":":       0.222,
+	">":       0.222,
+	";":       0.278,
+	"!":       -0.167,
+	" ":       0.25,
+	"enspace": 0.5,
+	"quad":    1,
+	"qquad":   2,
+}
+
+var accents = map[string]string{
+	"hat":            "^",
+	"widehat":        "^",
+	"check":          "ˇ",
+	"tilde":          "~",
+	"widetilde":      "~",
+	"acute":          "´",
+	"grave":          "`",
+	"dot":            "˙",
+	"ddot":           "¨",
+	"breve":          "˘",
+	"bar":            "¯",
+	"overline":       "‾",
+	"underline":      "_",
+	"vec":            "→",
+	"overrightarrow": "→",
+	"overleftarrow":  "←",
+	"overbrace":      "⏞",
+	"underbrace":     "⏟",
+}
+
+var variants = map[string]string{
+	"mathrm":     "normal",
+	"mathit":     "italic",
+	"mathbf":     "bold",
+	"boldsymbol": "bold",
+	"bm":         "bold",
+	"mathbb":     "double-struck",
+	"mathcal":    "script",
+	"mathscr":    "script",
+	"mathfrak":   "fraktur",
+	"mathsf":     "sans-serif",
+	"mathtt":     "monospace",
+}
+
+// start of A, a and 0 in Mathematical Alphanumeric Symbols
+// unicode block for a given variant
+var variantStarts = map[string][3]rune{
+	"bold":          {0x1D400, 0x1D41A, 0x1D7CE},
+	"italic":        {0x1D434, 0x1D44E, 0},
+	"script":        {0x1D49C, 0x1D4B6, 0},
+	"fraktur":       {0x1D504, 0x1D51E, 0},
+	"double-struck": {0x1D538, 0x1D552, 0x1D7D8},
+	"sans-serif":    {0x1D5A0, 0x1D5BA, 0x1D7E2},
+	"monospace":     {0x1D670, 0x1D68A, 0x1D7F6},
+}
+
+// characters that are not in Mathematical Alphanumeric Symbols because
+// they were already in Letterlike Symbols block
+var variantExceptions = map[string]map[rune]rune{
+	"italic": {'h': 'ℎ'},
+	"script": {
+		'B': 'ℬ', 'E': 'ℰ', 'F': 'ℱ', 'H': 'ℋ', 'I': 'ℐ', 'L': 'ℒ',
+		'M': 'ℳ', 'R': 'ℛ', 'e': 'ℯ', 'g': 'ℊ', 'o': 'ℴ',
+	},
+	"fraktur": {'C': 'ℭ', 'H': 'ℌ', 'I': 'ℑ', 'R': 'ℜ', 'Z': 'ℨ'},
+	"double-struck": {
+		'C': 'ℂ', 'H': 'ℍ', 'N': 'ℕ', 'P': 'ℙ', 'Q': 'ℚ', 'R': 'ℝ', 'Z': 'ℤ',
+	},
+}
+
+// mapVariant maps a letter or a digit to its variant like
+// double-struck (\mathbb)
+func mapVariant(r rune, variant string) rune {
+	if res, ok := variantExceptions[variant][r]; ok {
+		return res
+	}
+	starts, ok := variantStarts[variant]
+	if !ok {
+		return r
+	}
+	switch {
+	case r >= 'A' && r <= 'Z':
+		return starts[0] + (r - 'A')
+	case r >= 'a' && r <= 'z':
+		return starts[1] + (r - 'a')
+	case r >= '0' && r <= '9' && starts[2] != 0:
+		return starts[2] + (r - '0')
+	}
+	return r
+}
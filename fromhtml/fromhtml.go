@@ -0,0 +1,846 @@
+// Package fromhtml imports HTML (e.g. pages of an old wiki or a
+// Confluence export) into Notion.
+//
+// Importer parses HTML and returns operations that create blocks for
+// its content under a parent block. Images are uploaded to Notion.
+// Parts of HTML that can't be represented in Notion are reported by
+// Importer.Unsupported():
+//
+//	im := fromhtml.New(client, userID)
+//	im.Dir = "export" // directory with images referenced by html
+//	ops, err := im.Import(page.Root(), f)
+//	for _, u := range im.Unsupported() {
+//		fmt.Printf("%s\n", u)
+//	}
+//	err = client.SubmitTransaction(ops)
+package fromhtml
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/kjk/notionapi"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Uploader uploads a file to Notion. It's implemented by
+// *notionapi.Client
+type Uploader interface {
+	UploadFile(file *os.File) (fileID, fileURL string, err error)
+}
+
+var _ Uploader = &notionapi.Client{}
+
+// Unsupported describes a part of html that wasn't imported or was only
+// partially imported
+type Unsupported struct {
+	// Tag is the name of html element e.g. "form"
+	Tag string
+	// Reason describes what happened to it
+	Reason string
+	// Text is the beginning of text of the element, to help find it
+	Text string
+}
+
+func (u *Unsupported) String() string {
+	s := fmt.Sprintf("<%s>: %s", u.Tag, u.Reason)
+	if u.Text != "" {
+		s += fmt.Sprintf(" (%q)", u.Text)
+	}
+	return s
+}
+
+type uploadedFile struct {
+	fileID  string
+	fileURL string
+}
+
+// Importer converts html to operations that create Notion blocks.
+// Importer is not safe for concurrent use
+type Importer struct {
+	// Uploader uploads local images, audio and video. If nil, only
+	// files with absolute urls are imported, as external files
+	Uploader Uploader
+	// UserID is recorded as creator of blocks
+	UserID string
+	// Dir is a directory relative to which urls of local files are
+	// resolved. If empty, it's current directory. Files outside of Dir
+	// and file: urls are not imported
+	Dir string
+	// BaseURL, if set, resolves relative urls of links and of files
+	// that don't exist in Dir
+	BaseURL *url.URL
+
+	ops         []*notionapi.Operation
+	unsupported []*Unsupported
+	// so that a file used many times is uploaded once
+	uploaded map[string]*uploadedFile
+}
+
+// New returns an Importer that uploads files with uploader
+func New(uploader Uploader, userID string) *Importer {
+	return &Importer{
+		Uploader: uploader,
+		UserID:   userID,
+	}
+}
+
+// Unsupported returns parts of html that were not imported or were
+// imported only partially by Import
+func (im *Importer) Unsupported() []*Unsupported {
+	return im.unsupported
+}
+
+// Import parses html from r and returns operations that create blocks
+// with its content as children of parent
+func (im *Importer) Import(parent *notionapi.Block, r io.Reader) ([]*notionapi.Operation, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	im.ops = nil
+	im.unsupported = nil
+	im.importNodes(parent, doc.FirstChild)
+	return im.ops, nil
+}
+
+// Import parses html from r and returns operations that create blocks
+// under parent and a list of html that couldn't be imported
+func Import(client *notionapi.Client, userID string, parent *notionapi.Block, r io.Reader) ([]*notionapi.Operation, []*Unsupported, error) {
+	im := New(client, userID)
+	ops, err := im.Import(parent, r)
+	return ops, im.Unsupported(), err
+}
+
+func getAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func hasClass(n *html.Node, classes ...string) bool {
+	for _, cls := range strings.Fields(getAttr(n, "class")) {
+		for _, c := range classes {
+			if cls == c {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// textContent returns text of n and its descendants, <br> is a newline
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(n.Data)
+		case n.Type == html.ElementNode && n.DataAtom == atom.Br:
+			sb.WriteString("\n")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+func isBlank(s string) bool {
+	return strings.TrimSpace(s) == ""
+}
+
+func (im *Importer) report(n *html.Node, reason string) {
+	text := strings.Join(strings.Fields(textContent(n)), " ")
+	if utf8.RuneCountInString(text) > 40 {
+		text = string([]rune(text)[:40]) + "..."
+	}
+	im.unsupported = append(im.unsupported, &Unsupported{
+		Tag:    n.Data,
+		Reason: reason,
+		Text:   text,
+	})
+}
+
+// addBlock adds operations that create a block of a given type as the
+// last child of parent
+func (im *Importer) addBlock(parent *notionapi.Block, blockType string, props map[string]interface{}, format map[string]interface{}) *notionapi.Block {
+	b := &notionapi.Block{
+		ID:          uuid.New().String(),
+		Version:     1,
+		Alive:       true,
+		Type:        blockType,
+		CreatedBy:   im.UserID,
+		CreatedTime: notionapi.Now(),
+		ParentID:    parent.ID,
+		ParentTable: notionapi.TableBlock,
+		SpaceID:     parent.SpaceID,
+	}
+	args := map[string]interface{}{
+		"id":           b.ID,
+		"version":      b.Version,
+		"alive":        b.Alive,
+		"type":         b.Type,
+		"created_by":   b.CreatedBy,
+		"created_time": b.CreatedTime,
+		"parent_id":    b.ParentID,
+		"parent_table": b.ParentTable,
+	}
+	if b.SpaceID != "" {
+		args["space_id"] = b.SpaceID
+	}
+	if len(props) > 0 {
+		args["properties"] = props
+	}
+	if len(format) > 0 {
+		args["format"] = format
+	}
+	op := &notionapi.Operation{
+		ID:      b.ID,
+		Table:   notionapi.TableBlock,
+		Path:    []string{},
+		Command: notionapi.CommandSet,
+		Args:    args,
+	}
+	im.ops = append(im.ops, op, parent.ListAfterContentOp(b.ID, ""))
+	return b
+}
+
+// addTextBlock adds a block with title
+func (im *Importer) addTextBlock(parent *notionapi.Block, blockType string, title []*notionapi.TextSpan, props map[string]interface{}, format map[string]interface{}) *notionapi.Block {
+	if props == nil {
+		props = map[string]interface{}{}
+	}
+	if len(title) > 0 {
+		props["title"] = notionapi.TextSpansToRaw(title)
+	}
+	return im.addBlock(parent, blockType, props, format)
+}
+
+// isInline returns true if n is a part of text of a paragraph
+func isInline(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		// text and comments
+		return true
+	}
+	return inlineElements[n.DataAtom]
+}
+
+// importNodes imports n and its next siblings as children of parent.
+// Inline content between blocks becomes text blocks
+func (im *Importer) importNodes(parent *notionapi.Block, n *html.Node) {
+	p := newInlineParser(im)
+	for ; n != nil; n = n.NextSibling {
+		if isInline(n) {
+			p.parse(n, nil)
+			continue
+		}
+		im.flushInline(parent, p, notionapi.BlockText)
+		p = newInlineParser(im)
+		im.importBlock(parent, n)
+	}
+	im.flushInline(parent, p, notionapi.BlockText)
+}
+
+// flushInline adds a block with text parsed by p, followed by blocks
+// (e.g. images) found in the text
+func (im *Importer) flushInline(parent *notionapi.Block, p *inlineParser, blockType string) {
+	if spans := p.content(); len(spans) > 0 {
+		im.addTextBlock(parent, blockType, spans, nil, nil)
+	}
+	for _, n := range p.blocks {
+		im.importBlock(parent, n)
+	}
+}
+
+// importText imports n as a block whose title is the text of n
+func (im *Importer) importText(parent *notionapi.Block, blockType string, n *html.Node) {
+	p := newInlineParser(im)
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		p.parse(child, nil)
+	}
+	im.flushInline(parent, p, blockType)
+}
+
+// importWithChildren imports n as a block whose title is the inline
+// content at the beginning of n. The rest of n are children of the block
+func (im *Importer) importWithChildren(parent *notionapi.Block, blockType string, n *html.Node, props map[string]interface{}, format map[string]interface{}) *notionapi.Block {
+	p := newInlineParser(im)
+	child := n.FirstChild
+	for ; child != nil && isInline(child); child = child.NextSibling {
+		p.parse(child, nil)
+	}
+	spans := p.content()
+	// e.g. <li><p>text</p><ul>...</ul></li>, text of the first
+	// paragraph is the title
+	if len(spans) == 0 && child != nil && child.DataAtom == atom.P {
+		for c := child.FirstChild; c != nil; c = c.NextSibling {
+			p.parse(c, nil)
+		}
+		spans = p.content()
+		child = child.NextSibling
+	}
+	b := im.addTextBlock(parent, blockType, spans, props, format)
+	for _, n := range p.blocks {
+		im.importBlock(b, n)
+	}
+	im.importNodes(b, child)
+	return b
+}
+
+// importBlock imports an element that is not inline
+func (im *Importer) importBlock(parent *notionapi.Block, n *html.Node) {
+	if n.Type != html.ElementNode {
+		return
+	}
+	switch n.DataAtom {
+	case atom.Head, atom.Title, atom.Meta, atom.Link, atom.Base, atom.Script,
+		atom.Style, atom.Template, atom.Noscript:
+		// not a content
+	case atom.P, atom.Dt, atom.Dd, atom.Address:
+		im.importText(parent, notionapi.BlockText, n)
+	case atom.H1:
+		im.importText(parent, notionapi.BlockHeader, n)
+	case atom.H2:
+		im.importText(parent, notionapi.BlockSubHeader, n)
+	case atom.H3, atom.H4, atom.H5, atom.H6:
+		im.importText(parent, notionapi.BlockSubSubHeader, n)
+	case atom.Ul, atom.Ol, atom.Menu:
+		im.importList(parent, n)
+	case atom.Li:
+		im.importListItem(parent, notionapi.BlockBulletedList, n)
+	case atom.Blockquote:
+		im.importWithChildren(parent, notionapi.BlockQuote, n, nil, nil)
+	case atom.Aside:
+		im.importCallout(parent, n)
+	case atom.Details:
+		im.importDetails(parent, n)
+	case atom.Pre:
+		im.importCode(parent, n)
+	case atom.Hr:
+		im.addBlock(parent, notionapi.BlockDivider, nil, nil)
+	case atom.Img:
+		im.importImage(parent, n, nil)
+	case atom.Figure:
+		im.importFigure(parent, n)
+	case atom.Table:
+		im.importTable(parent, n)
+	case atom.Video:
+		im.importMedia(parent, notionapi.BlockVideo, n)
+	case atom.Audio:
+		im.importMedia(parent, notionapi.BlockAudio, n)
+	case atom.Iframe, atom.Embed:
+		im.importEmbed(parent, n)
+	default:
+		if unsupportedElements[n.DataAtom] {
+			im.report(n, "not supported, skipped")
+			return
+		}
+		if hasClass(n, calloutClasses...) {
+			im.importCallout(parent, n)
+			return
+		}
+		// <div>, <section>, <body> etc. and unknown elements only
+		// group other elements, we import their content
+		im.importNodes(parent, n.FirstChild)
+	}
+}
+
+// importList imports items of <ul> or <ol>
+func (im *Importer) importList(parent *notionapi.Block, n *html.Node) {
+	blockType := notionapi.BlockBulletedList
+	if n.DataAtom == atom.Ol {
+		blockType = notionapi.BlockNumberedList
+	}
+	var last *notionapi.Block
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		switch {
+		case child.DataAtom == atom.Li:
+			last = im.importListItem(parent, blockType, child)
+		case (child.DataAtom == atom.Ul || child.DataAtom == atom.Ol) && last != nil:
+			// <ul><li>a</li><ul>...</ul></ul> is invalid but common,
+			// the nested list belongs to the previous item
+			im.importList(last, child)
+		case child.Type == html.ElementNode:
+			im.importBlock(parent, child)
+		case child.Type == html.TextNode && !isBlank(child.Data):
+			p := newInlineParser(im)
+			p.parse(child, nil)
+			im.flushInline(parent, p, notionapi.BlockText)
+		}
+	}
+}
+
+// findCheckbox returns <input type="checkbox"> at the beginning of
+// an item of a list, which makes it a to-do
+func findCheckbox(li *html.Node) *html.Node {
+	for n := li.FirstChild; n != nil; n = n.NextSibling {
+		switch {
+		case n.Type == html.TextNode && isBlank(n.Data):
+			continue
+		case n.DataAtom == atom.Input:
+			if strings.EqualFold(getAttr(n, "type"), "checkbox") {
+				return n
+			}
+			return nil
+		case n.DataAtom == atom.P || n.DataAtom == atom.Label:
+			return findCheckbox(n)
+		}
+		return nil
+	}
+	return nil
+}
+
+func (im *Importer) importListItem(parent *notionapi.Block, blockType string, n *html.Node) *notionapi.Block {
+	var props map[string]interface{}
+	if checkbox := findCheckbox(n); checkbox != nil {
+		blockType = notionapi.BlockTodo
+		if hasAttr(checkbox, "checked") {
+			props = map[string]interface{}{
+				"checked": [][]string{{"Yes"}},
+			}
+		}
+		checkbox.Parent.RemoveChild(checkbox)
+	}
+	return im.importWithChildren(parent, blockType, n, props, nil)
+}
+
+// classes of elements that are imported as callouts, in addition
+// to <aside>
+var calloutClasses = []string{"callout", "admonition", "confluence-information-macro"}
+
+func (im *Importer) importCallout(parent *notionapi.Block, n *html.Node) {
+	format := map[string]interface{}{
+		"page_icon": "💡",
+	}
+	im.importWithChildren(parent, notionapi.BlockCallout, n, nil, format)
+}
+
+// importDetails imports <details> as a toggle with <summary> as its title
+func (im *Importer) importDetails(parent *notionapi.Block, n *html.Node) {
+	var summary *html.Node
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.DataAtom == atom.Summary {
+			summary = child
+			break
+		}
+	}
+	p := newInlineParser(im)
+	if summary != nil {
+		for c := summary.FirstChild; c != nil; c = c.NextSibling {
+			p.parse(c, nil)
+		}
+		n.RemoveChild(summary)
+	}
+	b := im.addTextBlock(parent, notionapi.BlockToggle, p.content(), nil, nil)
+	for _, n := range p.blocks {
+		im.importBlock(b, n)
+	}
+	im.importNodes(b, n.FirstChild)
+}
+
+// notionLanguages maps names of languages used in classes of <pre> and
+// <code> e.g. "language-js" to names of languages in Notion
+var notionLanguages = map[string]string{
+	"bash":       "Bash",
+	"c":          "C",
+	"clojure":    "Clojure",
+	"cpp":        "C++",
+	"c++":        "C++",
+	"cs":         "C#",
+	"csharp":     "C#",
+	"css":        "CSS",
+	"dart":       "Dart",
+	"diff":       "Diff",
+	"docker":     "Docker",
+	"dockerfile": "Docker",
+	"elixir":     "Elixir",
+	"erlang":     "Erlang",
+	"go":         "Go",
+	"golang":     "Go",
+	"graphql":    "GraphQL",
+	"haskell":    "Haskell",
+	"html":       "HTML",
+	"java":       "Java",
+	"javascript": "JavaScript",
+	"js":         "JavaScript",
+	"json":       "JSON",
+	"kotlin":     "Kotlin",
+	"latex":      "LaTeX",
+	"tex":        "LaTeX",
+	"lua":        "Lua",
+	"makefile":   "Makefile",
+	"markdown":   "Markdown",
+	"md":         "Markdown",
+	"objc":       "Objective-C",
+	"objectivec": "Objective-C",
+	"perl":       "Perl",
+	"php":        "PHP",
+	"powershell": "PowerShell",
+	"ps1":        "PowerShell",
+	"py":         "Python",
+	"python":     "Python",
+	"r":          "R",
+	"rb":         "Ruby",
+	"ruby":       "Ruby",
+	"rs":         "Rust",
+	"rust":       "Rust",
+	"scala":      "Scala",
+	"scss":       "Sass",
+	"sass":       "Sass",
+	"sh":         "Shell",
+	"shell":      "Shell",
+	"sql":        "SQL",
+	"swift":      "Swift",
+	"ts":         "TypeScript",
+	"typescript": "TypeScript",
+	"vb":         "Visual Basic",
+	"xml":        "XML",
+	"yaml":       "YAML",
+	"yml":        "YAML",
+}
+
+// codeLanguage returns language of <pre> from class e.g. "language-go"
+// of <pre> or <code> in it, or from Confluence's "brush: go"
+func codeLanguage(pre *html.Node) string {
+	nodes := []*html.Node{pre}
+	for c := pre.FirstChild; c != nil; c = c.NextSibling {
+		if c.DataAtom == atom.Code {
+			nodes = append(nodes, c)
+		}
+	}
+	for _, n := range nodes {
+		for _, cls := range strings.Fields(getAttr(n, "class")) {
+			for _, prefix := range []string{"language-", "lang-"} {
+				if strings.HasPrefix(cls, prefix) {
+					return cls[len(prefix):]
+				}
+			}
+		}
+		params := getAttr(n, "data-syntaxhighlighter-params")
+		for _, param := range strings.Split(params, ";") {
+			parts := strings.SplitN(param, ":", 2)
+			if len(parts) == 2 && strings.TrimSpace(parts[0]) == "brush" {
+				return strings.TrimSpace(parts[1])
+			}
+		}
+	}
+	return ""
+}
+
+func notionLanguage(lang string) string {
+	if l, ok := notionLanguages[strings.ToLower(lang)]; ok {
+		return l
+	}
+	return "Plain Text"
+}
+
+func (im *Importer) importCode(parent *notionapi.Block, n *html.Node) {
+	code := strings.Replace(textContent(n), "\r\n", "\n", -1)
+	code = strings.TrimRight(code, "\n")
+	props := map[string]interface{}{
+		"title":    [][]string{{code}},
+		"language": [][]string{{notionLanguage(codeLanguage(n))}},
+	}
+	im.addBlock(parent, notionapi.BlockCode, props, nil)
+}
+
+// uploadFile uploads a local file, unless it was already uploaded
+func (im *Importer) uploadFile(path string) (*uploadedFile, error) {
+	if f, ok := im.uploaded[path]; ok {
+		return f, nil
+	}
+	if im.Uploader == nil {
+		return nil, fmt.Errorf("can't upload %s without Uploader", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fileID, fileURL, err := im.Uploader.UploadFile(f)
+	if err != nil {
+		return nil, err
+	}
+	res := &uploadedFile{
+		fileID:  fileID,
+		fileURL: fileURL,
+	}
+	if im.uploaded == nil {
+		im.uploaded = map[string]*uploadedFile{}
+	}
+	im.uploaded[path] = res
+	return res, nil
+}
+
+// uploadDataURL uploads a file embedded in html as data: url
+func (im *Importer) uploadDataURL(uri string) (*uploadedFile, error) {
+	idx := strings.Index(uri, ",")
+	if idx < 0 {
+		return nil, fmt.Errorf("invalid data url")
+	}
+	meta, data := uri[len("data:"):idx], uri[idx+1:]
+	var d []byte
+	var err error
+	if strings.HasSuffix(meta, ";base64") {
+		meta = strings.TrimSuffix(meta, ";base64")
+		d, err = base64.StdEncoding.DecodeString(data)
+	} else {
+		var s string
+		s, err = url.PathUnescape(data)
+		d = []byte(s)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid data url: %s", err)
+	}
+	ext := ".bin"
+	contentType := strings.Split(meta, ";")[0]
+	if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+		ext = exts[0]
+	}
+	f, err := os.CreateTemp("", "fromhtml-*"+ext)
+	if err != nil {
+		return nil, err
+	}
+	path := f.Name()
+	defer os.Remove(path)
+	_, err = f.Write(d)
+	err2 := f.Close()
+	if err == nil {
+		err = err2
+	}
+	if err != nil {
+		return nil, err
+	}
+	return im.uploadFile(path)
+}
+
+// fileSource returns url of a file (image, video etc.) for a block and,
+// if the file was uploaded, its id. Local files are uploaded, files with
+// absolute urls are imported as external files
+func (im *Importer) fileSource(src string) (string, string, error) {
+	if strings.HasPrefix(src, "data:") {
+		f, err := im.uploadDataURL(src)
+		if err != nil {
+			return "", "", err
+		}
+		return f.fileURL, f.fileID, nil
+	}
+	u, err := url.Parse(src)
+	if err != nil {
+		return "", "", err
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return src, "", nil
+	case "":
+	default:
+		return "", "", fmt.Errorf("unsupported url %s", src)
+	}
+	path, err := im.localPath(u.Path)
+	if err == nil {
+		if _, err = os.Stat(path); err == nil {
+			f, err := im.uploadFile(path)
+			if err != nil {
+				return "", "", err
+			}
+			return f.fileURL, f.fileID, nil
+		}
+		err = fmt.Errorf("file %s doesn't exist", path)
+	}
+	if im.BaseURL != nil {
+		return im.BaseURL.ResolveReference(u).String(), "", nil
+	}
+	return "", "", err
+}
+
+// localPath returns path of a file in Dir given its relative url or an
+// error if it's outside of Dir
+func (im *Importer) localPath(uri string) (string, error) {
+	dir := im.Dir
+	if dir == "" {
+		dir = "."
+	}
+	path := filepath.Join(dir, filepath.FromSlash(uri))
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("file %s is outside of %s", uri, dir)
+	}
+	return path, nil
+}
+
+// addFileBlock adds an image, video etc. block with a file
+func (im *Importer) addFileBlock(parent *notionapi.Block, blockType string, n *html.Node, src string, caption []*notionapi.TextSpan) {
+	source, fileID, err := im.fileSource(src)
+	if err != nil {
+		im.report(n, err.Error())
+		return
+	}
+	props := map[string]interface{}{
+		"source": [][]string{{source}},
+	}
+	if len(caption) > 0 {
+		props["caption"] = notionapi.TextSpansToRaw(caption)
+	}
+	format := map[string]interface{}{
+		"display_source": source,
+	}
+	b := im.addBlock(parent, blockType, props, format)
+	if fileID != "" {
+		im.ops = append(im.ops, b.ListAfterFileIDsOp(fileID))
+	}
+}
+
+func (im *Importer) importImage(parent *notionapi.Block, n *html.Node, caption []*notionapi.TextSpan) {
+	src := getAttr(n, "src")
+	if src == "" {
+		im.report(n, "image without src, skipped")
+		return
+	}
+	im.addFileBlock(parent, notionapi.BlockImage, n, src, caption)
+}
+
+// importFigure imports <figure> with an image as image with caption
+// from <figcaption>. Other figures are imported as their content
+func (im *Importer) importFigure(parent *notionapi.Block, n *html.Node) {
+	var img, caption *html.Node
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		switch child.DataAtom {
+		case atom.Img:
+			img = child
+		case atom.Figcaption:
+			caption = child
+		case atom.A, atom.Picture:
+			// linked image or <picture> with <img> as fallback
+			for c := child.FirstChild; c != nil; c = c.NextSibling {
+				if c.DataAtom == atom.Img {
+					img = c
+				}
+			}
+		}
+	}
+	if img == nil {
+		im.importNodes(parent, n.FirstChild)
+		return
+	}
+	p := newInlineParser(im)
+	if caption != nil {
+		for c := caption.FirstChild; c != nil; c = c.NextSibling {
+			p.parse(c, nil)
+		}
+	}
+	im.importImage(parent, img, p.content())
+}
+
+func (im *Importer) importMedia(parent *notionapi.Block, blockType string, n *html.Node) {
+	src := getAttr(n, "src")
+	for c := n.FirstChild; c != nil && src == ""; c = c.NextSibling {
+		if c.DataAtom == atom.Source {
+			src = getAttr(c, "src")
+		}
+	}
+	if src == "" {
+		im.report(n, "no src, skipped")
+		return
+	}
+	im.addFileBlock(parent, blockType, n, src, nil)
+}
+
+func (im *Importer) importEmbed(parent *notionapi.Block, n *html.Node) {
+	src := getAttr(n, "src")
+	u, err := url.Parse(src)
+	if err == nil && im.BaseURL != nil {
+		u = im.BaseURL.ResolveReference(u)
+	}
+	if src == "" || err != nil || !u.IsAbs() {
+		im.report(n, "embed without absolute url, skipped")
+		return
+	}
+	props := map[string]interface{}{
+		"source": [][]string{{u.String()}},
+	}
+	format := map[string]interface{}{
+		"display_source": u.String(),
+	}
+	im.addBlock(parent, notionapi.BlockEmbed, props, format)
+}
+
+// tableRows returns rows of a table and its caption
+func tableRows(table *html.Node) ([]*html.Node, *html.Node) {
+	var rows []*html.Node
+	var caption *html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch c.DataAtom {
+			case atom.Tr:
+				rows = append(rows, c)
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				walk(c)
+			case atom.Caption:
+				caption = c
+			}
+		}
+	}
+	walk(table)
+	return rows, caption
+}
+
+// importTable imports a table as text blocks, one per row, with cells
+// separated by |, because we can't create tables
+func (im *Importer) importTable(parent *notionapi.Block, n *html.Node) {
+	rows, caption := tableRows(n)
+	if caption != nil {
+		im.importText(parent, notionapi.BlockText, caption)
+	}
+	for _, row := range rows {
+		p := newInlineParser(im)
+		nCells := 0
+		for cell := row.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.DataAtom != atom.Td && cell.DataAtom != atom.Th {
+				continue
+			}
+			if nCells > 0 {
+				p.addText(" | ", nil)
+			}
+			var attrs []notionapi.TextAttr
+			if cell.DataAtom == atom.Th {
+				attrs = []notionapi.TextAttr{{notionapi.AttrBold}}
+			}
+			cp := newInlineParser(im)
+			for c := cell.FirstChild; c != nil; c = c.NextSibling {
+				cp.parse(c, attrs)
+			}
+			for _, span := range cp.content() {
+				p.addText(span.Text, span.Attrs)
+			}
+			p.blocks = append(p.blocks, cp.blocks...)
+			nCells++
+		}
+		im.flushInline(parent, p, notionapi.BlockText)
+	}
+	im.report(n, "tables are not supported, imported as text with one row per block")
+}
@@ -0,0 +1,233 @@
+package fromhtml
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kjk/common/assert"
+	"github.com/kjk/notionapi"
+)
+
+type fakeUploader struct {
+	names []string
+}
+
+func (u *fakeUploader) UploadFile(file *os.File) (string, string, error) {
+	name := filepath.Base(file.Name())
+	u.names = append(u.names, name)
+	fileID := fmt.Sprintf("file-%d", len(u.names))
+	return fileID, "https://s3-us-west-2.amazonaws.com/secure.notion-static.com/" + fileID + "/" + name, nil
+}
+
+// dumpOps returns a tree of blocks created by ops, one block per line
+// e.g. "  text: Hello"
+func dumpOps(t *testing.T, parentID string, ops []*notionapi.Operation) string {
+	blocks := map[string]map[string]interface{}{}
+	children := map[string][]string{}
+	fileIDs := map[string]string{}
+	for _, op := range ops {
+		args := op.Args
+		switch op.Command {
+		case notionapi.CommandSet:
+			blocks[op.ID] = args.(map[string]interface{})
+		case notionapi.CommandListAfter:
+			id := args.(map[string]string)["id"]
+			switch op.Path[0] {
+			case "content":
+				children[op.ID] = append(children[op.ID], id)
+			case "file_ids":
+				fileIDs[op.ID] = id
+			}
+		}
+	}
+	var sb strings.Builder
+	var dump func(id string, indent string)
+	dump = func(id string, indent string) {
+		for _, childID := range children[id] {
+			b := blocks[childID]
+			assert.Equal(t, id, b["parent_id"])
+			sb.WriteString(indent + b["type"].(string) + ":")
+			props, _ := b["properties"].(map[string]interface{})
+			if title, ok := props["title"].([]interface{}); ok {
+				spans, err := notionapi.ParseTextSpans(title)
+				assert.NoError(t, err)
+				for _, span := range spans {
+					sb.WriteString(" " + fmt.Sprintf("%q", span.Text))
+					for _, attr := range span.Attrs {
+						sb.WriteString(strings.Join(attr, "="))
+					}
+				}
+			}
+			for _, name := range []string{"title", "language", "source", "checked"} {
+				if v, ok := props[name].([][]string); ok {
+					sb.WriteString(" " + name + "=" + v[0][0])
+				}
+			}
+			if caption, ok := props["caption"].([]interface{}); ok {
+				spans, err := notionapi.ParseTextSpans(caption)
+				assert.NoError(t, err)
+				sb.WriteString(" caption=" + notionapi.TextSpansToString(spans))
+			}
+			if fileID := fileIDs[childID]; fileID != "" {
+				sb.WriteString(" file_id=" + fileID)
+			}
+			sb.WriteString("\n")
+			dump(childID, indent+"  ")
+		}
+	}
+	dump(parentID, "")
+	return sb.String()
+}
+
+const testHTML = `<!DOCTYPE html>
+<html>
+<head><title>Wiki</title><style>p { color: red }</style></head>
+<body>
+<h1>Release   notes</h1>
+<div class="content">
+  <p>Some <b>bold</b> and <em>italic <code>code</code></em>,<br>
+  a <a href="https://example.com/docs">link</a> and <a href="other.html">another</a>.</p>
+  Loose text
+  <ul>
+    <li>first</li>
+    <li><p>second</p>
+      <ol><li>nested</li></ol>
+    </li>
+    <li><input type="checkbox" checked> done</li>
+    <li><input type="checkbox"> not done</li>
+  </ul>
+  <blockquote><p>Quoted</p><p>more</p></blockquote>
+  <pre><code class="language-go">func main() {
+	fmt.Println("hi")
+}
+</code></pre>
+  <hr>
+  <p>Inline <img src="img/chart.png"> image</p>
+  <figure><img src="https://example.com/logo.png"><figcaption>The logo</figcaption></figure>
+  <img src="img/chart.png">
+  <img src="img/missing.png">
+  <details><summary>More</summary><p>Hidden</p></details>
+  <aside>Be careful</aside>
+  <table><tr><th>Name</th><th>Age</th></tr><tr><td>Ann</td><td>30</td></tr></table>
+  <form><input type="text" value="x"><button>Send</button></form>
+</div>
+</body>
+</html>`
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(dir, "img"), 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(dir, "img", "chart.png"), []byte("png"), 0644)
+	assert.NoError(t, err)
+
+	uploader := &fakeUploader{}
+	im := New(uploader, "user-id")
+	im.Dir = dir
+	parent := &notionapi.Block{ID: "parent-id", SpaceID: "space-id"}
+	ops, err := im.Import(parent, strings.NewReader(testHTML))
+	assert.NoError(t, err)
+
+	exp := `header: "Release notes"
+text: "Some " "bold"b " and " "italic "i "code"ic ",\na " "link"a=https://example.com/docs " and " "another"a=other.html "."
+text: "Loose text"
+bulleted_list: "first"
+bulleted_list: "second"
+  numbered_list: "nested"
+to_do: "done" checked=Yes
+to_do: "not done"
+quote: "Quoted"
+  text: "more"
+code: title=func main() {
+	fmt.Println("hi")
+} language=Go
+divider:
+text: "Inline image"
+image: source=https://s3-us-west-2.amazonaws.com/secure.notion-static.com/file-1/chart.png file_id=file-1
+image: source=https://example.com/logo.png caption=The logo
+image: source=https://s3-us-west-2.amazonaws.com/secure.notion-static.com/file-1/chart.png file_id=file-1
+toggle: "More"
+  text: "Hidden"
+callout: "Be careful"
+text: "Name"b " | " "Age"b
+text: "Ann | 30"
+`
+	assert.Equal(t, exp, dumpOps(t, parent.ID, ops))
+	// the same image is uploaded once
+	assert.Equal(t, []string{"chart.png"}, uploader.names)
+
+	for _, op := range ops {
+		if op.Command == notionapi.CommandSet {
+			args := op.Args.(map[string]interface{})
+			assert.Equal(t, "space-id", args["space_id"])
+			assert.Equal(t, "user-id", args["created_by"])
+		}
+	}
+
+	var unsupported []string
+	for _, u := range im.Unsupported() {
+		unsupported = append(unsupported, u.String())
+	}
+	expUnsupported := []string{
+		`<a>: relative link, imported as is ("another")`,
+		`<img>: file ` + filepath.Join(dir, "img", "missing.png") + ` doesn't exist`,
+		`<table>: tables are not supported, imported as text with one row per block ("NameAgeAnn30")`,
+		`<form>: not supported, skipped ("Send")`,
+	}
+	assert.Equal(t, expUnsupported, unsupported)
+}
+
+func TestImportDataURL(t *testing.T) {
+	uploader := &fakeUploader{}
+	im := New(uploader, "")
+	parent := &notionapi.Block{ID: "parent-id"}
+	ops, err := im.Import(parent, strings.NewReader(`<img src="data:image/png;base64,cG5n">`))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(uploader.names))
+	assert.True(t, strings.HasSuffix(uploader.names[0], ".png"))
+	assert.True(t, strings.Contains(dumpOps(t, parent.ID, ops), "image: source=https://s3-us-west-2.amazonaws.com/secure.notion-static.com/file-1/"))
+
+	// without Uploader local images can't be imported
+	im = New(nil, "")
+	_, err = im.Import(parent, strings.NewReader(`<img src="data:image/png;base64,cG5n">`))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(im.Unsupported()))
+}
+
+func TestImportOutsideDir(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "export")
+	err := os.MkdirAll(dir, 0755)
+	assert.NoError(t, err)
+	secret := filepath.Join(root, "secret.png")
+	err = os.WriteFile(secret, []byte("png"), 0644)
+	assert.NoError(t, err)
+
+	uploader := &fakeUploader{}
+	im := New(uploader, "")
+	im.Dir = dir
+	parent := &notionapi.Block{ID: "parent-id"}
+	s := `<img src="file://` + filepath.ToSlash(secret) + `"><img src="../secret.png"><img src="img/../../secret.png">`
+	ops, err := im.Import(parent, strings.NewReader(s))
+	assert.NoError(t, err)
+	assert.Equal(t, "", dumpOps(t, parent.ID, ops))
+	assert.Equal(t, 0, len(uploader.names))
+
+	var unsupported []string
+	for _, u := range im.Unsupported() {
+		unsupported = append(unsupported, u.String())
+	}
+	exp := []string{
+		`<img>: unsupported url file://` + filepath.ToSlash(secret),
+		`<img>: file ../secret.png is outside of ` + dir,
+		`<img>: file img/../../secret.png is outside of ` + dir,
+	}
+	assert.Equal(t, exp, unsupported)
+}
+
+func TestCollapseSpace(t *testing.T) {
+	assert.Equal(t, " a b ", collapseSpace("\n a \t\n b  "))
+}
@@ -0,0 +1,280 @@
+package fromhtml
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/kjk/notionapi"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// inlineElements are elements that are part of text of a paragraph
+var inlineElements = map[atom.Atom]bool{
+	atom.A:      true,
+	atom.Abbr:   true,
+	atom.B:      true,
+	atom.Bdi:    true,
+	atom.Bdo:    true,
+	atom.Big:    true,
+	atom.Br:     true,
+	atom.Cite:   true,
+	atom.Code:   true,
+	atom.Data:   true,
+	atom.Del:    true,
+	atom.Dfn:    true,
+	atom.Em:     true,
+	atom.Font:   true,
+	atom.I:      true,
+	atom.Img:    true,
+	atom.Input:  true,
+	atom.Ins:    true,
+	atom.Kbd:    true,
+	atom.Label:  true,
+	atom.Mark:   true,
+	atom.Nobr:   true,
+	atom.Q:      true,
+	atom.S:      true,
+	atom.Samp:   true,
+	atom.Small:  true,
+	atom.Span:   true,
+	atom.Strike: true,
+	atom.Strong: true,
+	atom.Sub:    true,
+	atom.Sup:    true,
+	atom.Time:   true,
+	atom.Tt:     true,
+	atom.U:      true,
+	atom.Var:    true,
+	atom.Wbr:    true,
+}
+
+// unsupportedElements are elements that we can't import
+var unsupportedElements = map[atom.Atom]bool{
+	atom.Applet:   true,
+	atom.Button:   true,
+	atom.Canvas:   true,
+	atom.Form:     true,
+	atom.Frameset: true,
+	atom.Input:    true,
+	atom.Map:      true,
+	atom.Math:     true,
+	atom.Object:   true,
+	atom.Select:   true,
+	atom.Svg:      true,
+	atom.Textarea: true,
+}
+
+// attrsForElement maps inline elements to attributes of text
+var attrsForElement = map[atom.Atom]string{
+	atom.B:      notionapi.AttrBold,
+	atom.Strong: notionapi.AttrBold,
+	atom.I:      notionapi.AttrItalic,
+	atom.Em:     notionapi.AttrItalic,
+	atom.Cite:   notionapi.AttrItalic,
+	atom.Dfn:    notionapi.AttrItalic,
+	atom.Var:    notionapi.AttrItalic,
+	atom.S:      notionapi.AttrStrikeThrought,
+	atom.Strike: notionapi.AttrStrikeThrought,
+	atom.Del:    notionapi.AttrStrikeThrought,
+	atom.U:      notionapi.AttrUnderline,
+	atom.Ins:    notionapi.AttrUnderline,
+	atom.Code:   notionapi.AttrCode,
+	atom.Kbd:    notionapi.AttrCode,
+	atom.Samp:   notionapi.AttrCode,
+	atom.Tt:     notionapi.AttrCode,
+}
+
+// inlineParser converts inline html to text spans
+type inlineParser struct {
+	im    *Importer
+	spans []*notionapi.TextSpan
+	// elements found in text that must be imported as blocks, after
+	// the text e.g. images
+	blocks []*html.Node
+}
+
+func newInlineParser(im *Importer) *inlineParser {
+	return &inlineParser{
+		im: im,
+	}
+}
+
+func isSpace(c rune) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// collapseSpace replaces runs of whitespace with a single space, which
+// is how browsers show text
+func collapseSpace(s string) string {
+	var sb strings.Builder
+	inSpace := false
+	for _, c := range s {
+		if isSpace(c) {
+			if !inSpace {
+				sb.WriteByte(' ')
+			}
+			inSpace = true
+			continue
+		}
+		inSpace = false
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+func sameAttrs(a1, a2 []notionapi.TextAttr) bool {
+	if len(a1) != len(a2) {
+		return false
+	}
+	for i, attr := range a1 {
+		if strings.Join(attr, "\x00") != strings.Join(a2[i], "\x00") {
+			return false
+		}
+	}
+	return true
+}
+
+// withAttr returns attrs with attr added, unless there already is an
+// attribute of this type
+func withAttr(attrs []notionapi.TextAttr, attr notionapi.TextAttr) []notionapi.TextAttr {
+	for _, a := range attrs {
+		if notionapi.AttrGetType(a) == notionapi.AttrGetType(attr) {
+			return attrs
+		}
+	}
+	res := append([]notionapi.TextAttr{}, attrs...)
+	return append(res, attr)
+}
+
+// addText adds s, merging it with the previous span if it has the same
+// attributes
+func (p *inlineParser) addText(s string, attrs []notionapi.TextAttr) {
+	if s == "" {
+		return
+	}
+	if n := len(p.spans); n > 0 && sameAttrs(p.spans[n-1].Attrs, attrs) {
+		p.spans[n-1].Text += s
+		return
+	}
+	p.spans = append(p.spans, &notionapi.TextSpan{Text: s, Attrs: attrs})
+}
+
+func (p *inlineParser) lastChar() byte {
+	n := len(p.spans)
+	if n == 0 {
+		return 0
+	}
+	s := p.spans[n-1].Text
+	return s[len(s)-1]
+}
+
+// addWords adds text of html, where whitespace is not significant
+func (p *inlineParser) addWords(s string, attrs []notionapi.TextAttr) {
+	s = collapseSpace(s)
+	if c := p.lastChar(); c == 0 || c == ' ' || c == '\n' {
+		s = strings.TrimLeft(s, " ")
+	}
+	p.addText(s, attrs)
+}
+
+// trimRight removes characters in cutset from the end of text
+func (p *inlineParser) trimRight(cutset string) {
+	for n := len(p.spans); n > 0; n = len(p.spans) {
+		span := p.spans[n-1]
+		span.Text = strings.TrimRight(span.Text, cutset)
+		if span.Text != "" {
+			return
+		}
+		p.spans = p.spans[:n-1]
+	}
+}
+
+// content returns parsed text, without whitespace at the beginning
+// and at the end
+func (p *inlineParser) content() []*notionapi.TextSpan {
+	p.trimRight(" \n")
+	for len(p.spans) > 0 {
+		span := p.spans[0]
+		span.Text = strings.TrimLeft(span.Text, " \n")
+		if span.Text != "" {
+			break
+		}
+		p.spans = p.spans[1:]
+	}
+	return p.spans
+}
+
+// linkURL returns url of a link or "" if it can't be linked to
+func (p *inlineParser) linkURL(n *html.Node) string {
+	href := strings.TrimSpace(getAttr(n, "href"))
+	if href == "" {
+		return ""
+	}
+	if strings.HasPrefix(href, "#") {
+		p.im.report(n, "link to an anchor, imported as text")
+		return ""
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		p.im.report(n, "invalid url, imported as text")
+		return ""
+	}
+	if u.Scheme == "javascript" {
+		p.im.report(n, "javascript link, imported as text")
+		return ""
+	}
+	if p.im.BaseURL != nil {
+		u = p.im.BaseURL.ResolveReference(u)
+	}
+	if !u.IsAbs() {
+		p.im.report(n, "relative link, imported as is")
+	}
+	return u.String()
+}
+
+// parse parses n and its children, attrs are attributes of the parent
+func (p *inlineParser) parse(n *html.Node, attrs []notionapi.TextAttr) {
+	switch n.Type {
+	case html.TextNode:
+		p.addWords(n.Data, attrs)
+		return
+	case html.ElementNode:
+	default:
+		// comments
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		p.trimRight(" ")
+		p.addText("\n", attrs)
+		return
+	case atom.Wbr, atom.Script, atom.Style, atom.Template:
+		return
+	case atom.Img, atom.Video, atom.Audio, atom.Iframe, atom.Embed:
+		p.blocks = append(p.blocks, n)
+		return
+	case atom.A:
+		if uri := p.linkURL(n); uri != "" {
+			attrs = withAttr(attrs, notionapi.TextAttr{notionapi.AttrLink, uri})
+		}
+	case atom.Mark:
+		attrs = withAttr(attrs, notionapi.TextAttr{notionapi.AttrHighlight, "yellow_background"})
+	case atom.Q:
+		p.addWords("“", attrs)
+		defer p.addWords("”", attrs)
+	default:
+		if attr, ok := attrsForElement[n.DataAtom]; ok {
+			attrs = withAttr(attrs, notionapi.TextAttr{attr})
+			break
+		}
+		if unsupportedElements[n.DataAtom] {
+			p.im.report(n, "not supported, skipped")
+			return
+		}
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		p.parse(child, attrs)
+	}
+}
@@ -1,12 +1,16 @@
 module github.com/kjk/notionapi
 
 require (
+	github.com/alecthomas/chroma/v2 v2.14.0
 	github.com/google/uuid v1.6.0
 	github.com/json-iterator/go v1.1.12
 	github.com/kjk/common v0.0.0-20211010101831-6203abf05163
 	github.com/kjk/siser v0.0.0-20220410204903-1b1e84ea1397
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/tidwall/pretty v1.2.1
+	golang.org/x/net v0.24.0
 )
 
+require github.com/dlclark/regexp2 v1.11.0 // indirect
+
 go 1.21
@@ -2,11 +2,14 @@ package notionapi
 
 import (
 	"fmt"
+	"strings"
 )
 
 const (
 	// TextSpanSpecial is what Notion uses for text to represent @user and @date blocks
 	TextSpanSpecial = "‣"
+	// TextSpanEquation is what Notion uses for text of inline equations
+	TextSpanEquation = "⁍"
 )
 
 const (
@@ -30,8 +33,28 @@ const (
 	AttrDate = "d"
 	// AtttrPage represents a link to a Notion page
 	AttrPage = "p"
+	// AttrUnderline represents underlined text
+	AttrUnderline = "_"
+	// AttrEquation represents an inline equation (TeX)
+	AttrEquation = "e"
+	// AttrLinkMention represents a mention of a web page (a link preview)
+	AttrLinkMention = "lm"
+	// AttrExternalObject represents a mention of an external object
+	// e.g. a GitHub issue or a Google Drive file
+	AttrExternalObject = "eoi"
 )
 
+// LinkMention describes a web page mentioned with AttrLinkMention
+type LinkMention struct {
+	Href         string `json:"href"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	IconURL      string `json:"icon_url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	LinkAuthor   string `json:"link_author"`
+	LinkProvider string `json:"link_provider"`
+}
+
 // TextAttr describes attributes of a span of text
 // First element is name of the attribute (e.g. AttrLink)
 // The rest are optional information about attribute (e.g.
@@ -49,6 +72,38 @@ func (t *TextSpan) IsPlain() bool {
 	return len(t.Attrs) == 0
 }
 
+// DiscussionIDs returns ids of discussions about this text
+func (t *TextSpan) DiscussionIDs() []string {
+	if t == nil {
+		return nil
+	}
+	var res []string
+	for _, attr := range t.Attrs {
+		if AttrGetType(attr) == AttrComment && len(attr) > 1 {
+			res = append(res, AttrGetComment(attr))
+		}
+	}
+	return res
+}
+
+// DiscussionIDsEndingAt returns ids of discussions about spans[i] that
+// don't continue in spans[i+1]. Text commented on can span multiple
+// TextSpans and converters show a reference to a discussion once, at
+// the end of the text
+func DiscussionIDsEndingAt(spans []*TextSpan, i int) []string {
+	var next []string
+	if i+1 < len(spans) {
+		next = spans[i+1].DiscussionIDs()
+	}
+	var res []string
+	for _, id := range spans[i].DiscussionIDs() {
+		if !hasString(next, id) {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
 func AttrGetType(attr TextAttr) string {
 	return attr[0]
 }
@@ -59,38 +114,119 @@ func panicIfAttrNot(attr TextAttr, fnName string, expectedType string) {
 	}
 }
 
-func AttrGetLink(attr TextAttr) string {
-	panicIfAttrNot(attr, "AttrGetLink", AttrLink)
-	// there are links with
-	if len(attr) == 1 {
+// attrValue returns first value of the attribute or "" if it doesn't have one
+func attrValue(attr TextAttr) string {
+	if len(attr) < 2 {
 		return ""
 	}
 	return attr[1]
 }
 
+func AttrGetLink(attr TextAttr) string {
+	panicIfAttrNot(attr, "AttrGetLink", AttrLink)
+	// there are links without url
+	return attrValue(attr)
+}
+
 func AttrGetUserID(attr TextAttr) string {
 	panicIfAttrNot(attr, "AttrGetUserID", AttrUser)
-	return attr[1]
+	return attrValue(attr)
 }
 
 func AttrGetPageID(attr TextAttr) string {
 	panicIfAttrNot(attr, "AttrGetPageID", AttrPage)
-	return attr[1]
+	return attrValue(attr)
 }
 
 func AttrGetComment(attr TextAttr) string {
 	panicIfAttrNot(attr, "AttrGetComment", AttrComment)
-	return attr[1]
+	return attrValue(attr)
 }
 
 func AttrGetHighlight(attr TextAttr) string {
 	panicIfAttrNot(attr, "AttrGetHighlight", AttrHighlight)
-	return attr[1]
+	return attrValue(attr)
+}
+
+// ParseHighlight splits highlight (from AttrGetHighlight) like "red"
+// or "red_background" into a color and whether it's a background color
+func ParseHighlight(hl string) (string, bool) {
+	if color := strings.TrimSuffix(hl, "_background"); color != hl {
+		return color, true
+	}
+	return hl, false
+}
+
+// colors of text and backgrounds, as used by Notion
+var (
+	textColors = map[string]string{
+		"gray":   "rgb(155,154,151)",
+		"brown":  "rgb(100,71,58)",
+		"orange": "rgb(217,115,13)",
+		"yellow": "rgb(223,171,1)",
+		"teal":   "rgb(15,123,108)",
+		"blue":   "rgb(11,110,153)",
+		"purple": "rgb(105,64,165)",
+		"pink":   "rgb(173,26,114)",
+		"red":    "rgb(224,62,62)",
+	}
+	backgroundColors = map[string]string{
+		"gray":   "rgb(235,236,237)",
+		"brown":  "rgb(233,229,227)",
+		"orange": "rgb(250,235,221)",
+		"yellow": "rgb(251,243,219)",
+		"teal":   "rgb(221,237,234)",
+		"blue":   "rgb(221,235,241)",
+		"purple": "rgb(234,228,242)",
+		"pink":   "rgb(244,223,235)",
+		"red":    "rgb(251,228,228)",
+	}
+)
+
+// HighlightCSS returns inline CSS style (e.g. "color:rgb(224,62,62)")
+// for a highlight from AttrGetHighlight. Returns "" for default or
+// unknown colors
+func HighlightCSS(hl string) string {
+	color, isBackground := ParseHighlight(hl)
+	if isBackground {
+		if rgb, ok := backgroundColors[color]; ok {
+			return "background:" + rgb
+		}
+		return ""
+	}
+	if rgb, ok := textColors[color]; ok {
+		return "color:" + rgb
+	}
+	return ""
+}
+
+// AttrGetEquation returns TeX source of inline equation
+func AttrGetEquation(attr TextAttr) string {
+	panicIfAttrNot(attr, "AttrGetEquation", AttrEquation)
+	return attrValue(attr)
+}
+
+// AttrGetExternalObjectID returns id of external object instance block
+func AttrGetExternalObjectID(attr TextAttr) string {
+	panicIfAttrNot(attr, "AttrGetExternalObjectID", AttrExternalObject)
+	return attrValue(attr)
+}
+
+// AttrGetLinkMention returns info about mentioned web page. Returns nil
+// if the attribute is malformed
+func AttrGetLinkMention(attr TextAttr) *LinkMention {
+	panicIfAttrNot(attr, "AttrGetLinkMention", AttrLinkMention)
+	var res *LinkMention
+	err := jsonit.Unmarshal([]byte(attrValue(attr)), &res)
+	if err != nil {
+		return nil
+	}
+	return res
 }
 
 func AttrGetDate(attr TextAttr) *Date {
 	panicIfAttrNot(attr, "AttrGetDate", AttrDate)
-	js := []byte(attr[1])
+	js := []byte(attrValue(attr))
 	var d *Date
 	err := jsonit.Unmarshal(js, &d)
 	if err != nil {
@@ -128,7 +264,13 @@ func parseTextSpanAttribute(b *TextSpan, a []interface{}) error {
 	for _, v := range a[1:] {
 		s, ok := v.(string)
 		if !ok {
-			return fmt.Errorf("got unexpected type %T (expected string)", v)
+			// values of some attributes (e.g. AttrLinkMention) are objects.
+			// we don't know all of them so we preserve them as JSON
+			js, err := jsonit.Marshal(v)
+			if err != nil {
+				return err
+			}
+			s = string(js)
 		}
 		attr = append(attr, s)
 	}
@@ -136,6 +278,16 @@ func parseTextSpanAttribute(b *TextSpan, a []interface{}) error {
 	return nil
 }
 
+// getTextSpanAttr returns first attribute of a given type or nil
+func getTextSpanAttr(ts *TextSpan, attrType string) TextAttr {
+	for _, attr := range ts.Attrs {
+		if AttrGetType(attr) == attrType {
+			return attr
+		}
+	}
+	return nil
+}
+
 func parseTextSpanAttributes(b *TextSpan, a []interface{}) error {
 	for _, rawAttr := range a {
 		attrList, ok := rawAttr.([]interface{})
@@ -221,11 +373,50 @@ func TextSpansToString(blocks []*TextSpan) string {
 			// TODO: how to handle dates, users etc.?
 			continue
 		}
+		if attr := getTextSpanAttr(block, AttrEquation); attr != nil {
+			s += AttrGetEquation(attr)
+			continue
+		}
 		s += block.Text
 	}
 	return s
 }
 
+// attrValueToRaw reverses parseTextSpanAttribute, which stores values
+// that are objects (e.g. of AttrDate) as JSON
+func attrValueToRaw(s string) interface{} {
+	if strings.HasPrefix(s, "{") {
+		var v map[string]interface{}
+		if err := jsonit.Unmarshal([]byte(s), &v); err == nil {
+			return v
+		}
+	}
+	return s
+}
+
+// TextSpansToRaw converts spans to the form used by Notion in text
+// properties (e.g. "title"), which can be sent in an Operation.
+// It's the reverse of ParseTextSpans
+func TextSpansToRaw(spans []*TextSpan) []interface{} {
+	res := []interface{}{}
+	for _, span := range spans {
+		if len(span.Attrs) == 0 {
+			res = append(res, []interface{}{span.Text})
+			continue
+		}
+		var attrs []interface{}
+		for _, attr := range span.Attrs {
+			rawAttr := []interface{}{attr[0]}
+			for _, v := range attr[1:] {
+				rawAttr = append(rawAttr, attrValueToRaw(v))
+			}
+			attrs = append(attrs, rawAttr)
+		}
+		res = append(res, []interface{}{span.Text, attrs})
+	}
+	return res
+}
+
 func getFirstInline(inline []*TextSpan) string {
 	if len(inline) == 0 {
 		return ""
@@ -237,6 +237,17 @@ const title7 = `{
 	]
 }`
 
+const title8 = `{
+	"title": [
+		["Energy: "],
+		["⁍", [["e", "E = mc^2"]]],
+		[" see "],
+		["‣", [["lm", {"href": "https://github.com", "title": "GitHub", "icon_url": "https://github.com/favicon.ico"}]]],
+		["‣", [["eoi", "e4c3ad8e-5b41-4f0e-8f0c-3b1f0a6b1f2c"]]],
+		["underlined", [["_"], ["xyz", {"foo": 1}]]]
+	]
+}`
+
 func parseTextSpans(t *testing.T, s string) []*TextSpan {
 	var m map[string]interface{}
 	err := jsonit.Unmarshal([]byte(s), &m)
@@ -360,3 +371,72 @@ func TestParseTextSpan7(t *testing.T) {
 	blocks := parseTextSpans(t, title7)
 	assert.Equal(t, 4, len(blocks))
 }
+
+func TestParseTextSpans8(t *testing.T) {
+	blocks := parseTextSpans(t, title8)
+	assert.Equal(t, 6, len(blocks))
+	{
+		b := blocks[1]
+		assert.Equal(t, TextSpanEquation, b.Text)
+		attr := b.Attrs[0]
+		assert.Equal(t, AttrEquation, AttrGetType(attr))
+		assert.Equal(t, "E = mc^2", AttrGetEquation(attr))
+	}
+	{
+		attr := blocks[3].Attrs[0]
+		assert.Equal(t, AttrLinkMention, AttrGetType(attr))
+		lm := AttrGetLinkMention(attr)
+		assert.Equal(t, "https://github.com", lm.Href)
+		assert.Equal(t, "GitHub", lm.Title)
+		assert.Equal(t, "https://github.com/favicon.ico", lm.IconURL)
+	}
+	{
+		attr := blocks[4].Attrs[0]
+		assert.Equal(t, AttrExternalObject, AttrGetType(attr))
+		assert.Equal(t, "e4c3ad8e-5b41-4f0e-8f0c-3b1f0a6b1f2c", AttrGetExternalObjectID(attr))
+	}
+	{
+		// unknown attributes with non-string values are preserved as JSON
+		b := blocks[5]
+		assert.Equal(t, AttrUnderline, AttrGetType(b.Attrs[0]))
+		assert.Equal(t, TextAttr{"xyz", `{"foo":1}`}, b.Attrs[1])
+	}
+	assert.Equal(t, "Energy: E = mc^2 see underlined", TextSpansToString(blocks))
+}
+
+func TestHighlightCSS(t *testing.T) {
+	tests := [][]string{
+		{"red", "color:rgb(224,62,62)"},
+		{"teal_background", "background:rgb(221,237,234)"},
+		{"default", ""},
+		{"no_such_color_background", ""},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test[1], HighlightCSS(test[0]))
+	}
+	color, isBackground := ParseHighlight("blue_background")
+	assert.Equal(t, "blue", color)
+	assert.True(t, isBackground)
+}
+
+func TestTextSpansToRaw(t *testing.T) {
+	for _, s := range []string{title1, title2, title4, title5, titleBig, titleWithComment, title6, title8} {
+		spans := parseTextSpans(t, s)
+		spans2, err := ParseTextSpans(TextSpansToRaw(spans))
+		assert.NoError(t, err)
+		assert.Equal(t, spans, spans2)
+	}
+}
+
+func TestDiscussionIDsEndingAt(t *testing.T) {
+	spans := []*TextSpan{
+		{Text: "a", Attrs: []TextAttr{{AttrComment, "d1"}}},
+		{Text: "b", Attrs: []TextAttr{{AttrBold}, {AttrComment, "d1"}, {AttrComment, "d2"}}},
+		{Text: "c", Attrs: []TextAttr{{AttrComment, "d2"}}},
+		{Text: "d"},
+	}
+	assert.Equal(t, 0, len(DiscussionIDsEndingAt(spans, 0)))
+	assert.Equal(t, []string{"d1"}, DiscussionIDsEndingAt(spans, 1))
+	assert.Equal(t, []string{"d2"}, DiscussionIDsEndingAt(spans, 2))
+	assert.Equal(t, 0, len(DiscussionIDsEndingAt(spans, 3)))
+}
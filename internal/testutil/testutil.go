@@ -0,0 +1,58 @@
+// Package testutil has helpers shared by tests of converters
+package testutil
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/kjk/notionapi"
+)
+
+// LoadPage loads a page saved with notionapi.MarshalPageJSON from
+// testdata directory at the root of the repository. It must be called
+// from tests of top-level packages e.g. tohtml
+func LoadPage(t *testing.T, name string) *notionapi.Page {
+	d, err := os.ReadFile(filepath.Join("..", "testdata", name))
+	if err != nil {
+		t.Fatalf("os.ReadFile() failed with '%s'", err)
+	}
+	page, err := notionapi.UnmarshalPageJSON(d)
+	if err != nil {
+		t.Fatalf("notionapi.UnmarshalPageJSON('%s') failed with '%s'", name, err)
+	}
+	return page
+}
+
+// Downloader serves Files from memory. Files are keyed by url and
+// Content-Type is based on file extension
+type Downloader struct {
+	Files map[string][]byte
+	// Calls is the number of DownloadFile calls
+	Calls int
+}
+
+// DownloadFile returns a file or an error if it's not in Files
+func (d *Downloader) DownloadFile(uri string, block *notionapi.Block) (*notionapi.DownloadFileResponse, error) {
+	d.Calls++
+	data, ok := d.Files[uri]
+	if !ok {
+		return nil, fmt.Errorf("http GET '%s' failed with status 404 Not Found", uri)
+	}
+	header := http.Header{}
+	if u, err := url.Parse(uri); err == nil {
+		if mimeType := mime.TypeByExtension(path.Ext(u.Path)); mimeType != "" {
+			header.Set("Content-Type", mimeType)
+		}
+	}
+	return &notionapi.DownloadFileResponse{
+		URL:    uri,
+		Data:   data,
+		Header: header,
+	}, nil
+}
@@ -60,3 +60,17 @@ func jsonGetMap(m map[string]interface{}, key string) map[string]interface{} {
 	}
 	return nil
 }
+
+func jsonGetStrings(m map[string]interface{}, key string) []string {
+	a, ok := m[key].([]interface{})
+	if !ok {
+		return nil
+	}
+	var res []string
+	for _, v := range a {
+		if s, ok := v.(string); ok {
+			res = append(res, s)
+		}
+	}
+	return res
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kjk/notionapi"
+	"github.com/kjk/notionapi/tohtml"
+)
+
+// max number of items in rss feed
+const rssMaxItems = 50
+
+// max length of item description in rss feed
+const rssMaxDescriptionLen = 300
+
+func (s *Site) absURL(uri string) string {
+	return strings.TrimSuffix(s.BaseURL, "/") + "/" + uri
+}
+
+func (s *Site) writeIndexPages(w *bytes.Buffer, page *notionapi.Page, seen map[string]bool) {
+	id := notionapi.ToNoDashID(page.ID)
+	if seen[id] {
+		return
+	}
+	seen[id] = true
+	root := page.Root()
+	title := root.Title
+	if title == "" {
+		title = "Untitled"
+	}
+	uri := s.URLForPage(id)
+	fmt.Fprintf(w, `<li><a href="%s">%s</a>`, tohtml.EscapeHTML(uri), tohtml.EscapeHTML(title))
+	fmt.Fprintf(w, ` <time>%s</time>`, root.LastEditedOn().Format("Jan 2, 2006"))
+	var children []*notionapi.Page
+	for _, nid := range page.GetSubPages() {
+		if child := s.idToPage[nid.NoDashID]; child != nil && !seen[nid.NoDashID] {
+			children = append(children, child)
+		}
+	}
+	if len(children) > 0 {
+		w.WriteString("\n<ul>\n")
+		for _, child := range children {
+			s.writeIndexPages(w, child, seen)
+		}
+		w.WriteString("</ul>\n")
+	}
+	w.WriteString("</li>\n")
+}
+
+// writeIndex writes index.html with a tree of all pages
+func (s *Site) writeIndex() error {
+	title := s.RootPage.Root().Title
+	var w bytes.Buffer
+	w.WriteString("<html>\n<head>\n")
+	w.WriteString(`<meta http-equiv="Content-Type" content="text/html; charset=utf-8"/>` + "\n")
+	fmt.Fprintf(&w, "<title>%s</title>\n", tohtml.EscapeHTML(title))
+	fmt.Fprintf(&w, "<style>%s\t\n</style>\n", tohtml.CSS+tohtml.CSSPlus)
+	if s.BaseURL != "" {
+		fmt.Fprintf(&w, `<link rel="alternate" type="application/rss+xml" title="%s" href="rss.xml"/>`+"\n", tohtml.EscapeHTML(title))
+	}
+	w.WriteString("</head>\n<body>\n")
+	w.WriteString(`<article class="page sans">` + "\n")
+	fmt.Fprintf(&w, `<header><h1 class="page-title">%s</h1></header>`+"\n", tohtml.EscapeHTML(title))
+	w.WriteString(`<div class="page-body">` + "\n<ul>\n")
+	seen := map[string]bool{}
+	s.writeIndexPages(&w, s.RootPage, seen)
+	// pages that we've downloaded but are not reachable via sub-pages
+	for _, page := range s.Pages {
+		if !seen[notionapi.ToNoDashID(page.ID)] {
+			s.writeIndexPages(&w, page, seen)
+		}
+	}
+	w.WriteString("</ul>\n</div>\n</article>\n</body>\n</html>\n")
+	return writeFile(filepath.Join(s.OutDir, "index.html"), w.Bytes())
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name      `xml:"urlset"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	URLs    []*sitemapURL `xml:"url"`
+}
+
+func writeXML(path string, v interface{}) error {
+	d, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	d = append([]byte(xml.Header), d...)
+	return writeFile(path, d)
+}
+
+// writeSitemap writes sitemap.xml (https://www.sitemaps.org/protocol.html)
+func (s *Site) writeSitemap() error {
+	urlset := &sitemapURLSet{
+		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
+	}
+	urlset.URLs = append(urlset.URLs, &sitemapURL{
+		Loc: s.absURL("index.html"),
+	})
+	for _, page := range s.Pages {
+		u := &sitemapURL{
+			Loc: s.absURL(s.URLForPage(page.ID)),
+		}
+		if t := page.Root().LastEditedOn(); !t.IsZero() {
+			u.LastMod = t.UTC().Format("2006-01-02")
+		}
+		urlset.URLs = append(urlset.URLs, u)
+	}
+	return writeXML(filepath.Join(s.OutDir, "sitemap.xml"), urlset)
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate,omitempty"`
+	Description string `xml:"description,omitempty"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate"`
+	Items         []*rssItem `xml:"item"`
+}
+
+type rss struct {
+	XMLName xml.Name    `xml:"rss"`
+	Version string      `xml:"version,attr"`
+	Channel *rssChannel `xml:"channel"`
+}
+
+// pageSummary returns beginning of the text of the page
+func pageSummary(page *notionapi.Page) string {
+	var parts []string
+	n := 0
+	for _, block := range page.Root().Content {
+		if n >= rssMaxDescriptionLen {
+			break
+		}
+		if block.Type != notionapi.BlockText {
+			continue
+		}
+		s := strings.TrimSpace(notionapi.TextSpansToString(block.InlineContent))
+		if s == "" {
+			continue
+		}
+		parts = append(parts, s)
+		n += len(s)
+	}
+	res := []rune(strings.Join(parts, " "))
+	if len(res) > rssMaxDescriptionLen {
+		res = append(res[:rssMaxDescriptionLen], '…')
+	}
+	return string(res)
+}
+
+// writeRSS writes rss.xml with most recently created pages
+func (s *Site) writeRSS() error {
+	pages := append([]*notionapi.Page{}, s.Pages[1:]...)
+	sort.SliceStable(pages, func(i, j int) bool {
+		return pages[i].Root().CreatedOn().After(pages[j].Root().CreatedOn())
+	})
+	if len(pages) > rssMaxItems {
+		pages = pages[:rssMaxItems]
+	}
+	channel := &rssChannel{
+		Title:         s.RootPage.Root().Title,
+		Link:          s.absURL("index.html"),
+		Description:   pageSummary(s.RootPage),
+		LastBuildDate: time.Now().UTC().Format(time.RFC1123Z),
+	}
+	for _, page := range pages {
+		root := page.Root()
+		uri := s.absURL(s.URLForPage(page.ID))
+		item := &rssItem{
+			Title:       root.Title,
+			Link:        uri,
+			GUID:        uri,
+			Description: pageSummary(page),
+		}
+		if t := root.CreatedOn(); !t.IsZero() {
+			item.PubDate = t.UTC().Format(time.RFC1123Z)
+		}
+		channel.Items = append(channel.Items, item)
+	}
+	feed := &rss{
+		Version: "2.0",
+		Channel: channel,
+	}
+	return writeXML(filepath.Join(s.OutDir, "rss.xml"), feed)
+}
@@ -0,0 +1,111 @@
+// notion2site generates a static website from a Notion page and all
+// its sub-pages.
+//
+// Usage:
+//
+//	go run ./notion2site -out www -base-url https://example.com/ ${pageID}
+//
+// Pages are downloaded with notionapi.CachingClient so re-running the
+// command only downloads pages that changed since the last run. Only
+// pages that changed are re-generated.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/kjk/notionapi"
+	"github.com/kjk/notionapi/tohtml"
+)
+
+var (
+	flgToken     string
+	flgOutDir    string
+	flgCacheDir  string
+	flgBaseURL   string
+	flgTheme     string
+	flgCacheOnly bool
+	flgForce     bool
+	flgStrict    bool
+	flgImages    bool
+	flgVerbose   bool
+)
+
+func logf(format string, args ...interface{}) {
+	fmt.Printf(format, args...)
+}
+
+func logvf(format string, args ...interface{}) {
+	if flgVerbose {
+		fmt.Printf(format, args...)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: notion2site [flags] ${pageID}\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.StringVar(&flgToken, "token", "", "auth token (default: $NOTION_TOKEN)")
+	flag.StringVar(&flgOutDir, "out", "www", "directory where the site is generated")
+	flag.StringVar(&flgCacheDir, "cache-dir", "notion2site_cache", "directory for caching Notion API requests and files")
+	flag.StringVar(&flgBaseURL, "base-url", "", "absolute url of the site, needed for sitemap.xml and rss.xml")
+	flag.StringVar(&flgTheme, "theme", "", "theme: notion, light, dark or auto (default: same as tohtml)")
+	flag.BoolVar(&flgCacheOnly, "cache-only", false, "don't talk to Notion, only use cached data")
+	flag.BoolVar(&flgForce, "force", false, "re-generate all pages, even if they didn't change")
+	flag.BoolVar(&flgStrict, "strict", false, "generate sanitized html, safe for publishing untrusted pages")
+	flag.BoolVar(&flgImages, "responsive-images", false, "render images with srcset of downscaled versions and lazy loading")
+	flag.BoolVar(&flgVerbose, "verbose", false, "if true, verbose logging")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		usage()
+		os.Exit(1)
+	}
+	rootID := notionapi.ExtractNoDashIDFromNotionURL(flag.Arg(0))
+	if rootID == "" {
+		logf("'%s' is not a valid notion id or url\n", flag.Arg(0))
+		os.Exit(1)
+	}
+
+	token := flgToken
+	if token == "" {
+		token = os.Getenv("NOTION_TOKEN")
+	}
+	client := &notionapi.Client{
+		AuthToken: token,
+	}
+	if flgVerbose {
+		client.DebugLog = true
+		client.Logger = os.Stdout
+	}
+	cc, err := notionapi.NewCachingClient(flgCacheDir, client)
+	if err != nil {
+		logf("notionapi.NewCachingClient() failed with '%s'\n", err)
+		os.Exit(1)
+	}
+	if flgCacheOnly {
+		cc.Policy = notionapi.PolicyCacheOnly
+	}
+
+	site := NewSite(cc, flgOutDir)
+	if flgTheme != "" {
+		site.Theme = tohtml.ThemeByName(flgTheme)
+		if site.Theme == nil {
+			logf("unknown theme '%s'\n", flgTheme)
+			os.Exit(1)
+		}
+	}
+	site.BaseURL = flgBaseURL
+	site.Force = flgForce
+	site.Strict = flgStrict
+	site.ResponsiveImages = flgImages
+	err = site.Build(rootID)
+	if err != nil {
+		logf("failed to generate the site: '%s'\n", err)
+		os.Exit(1)
+	}
+}
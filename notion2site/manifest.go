@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// bump when the way we generate pages changes, to force re-generating
+// all pages
+const manifestVersion = 3
+
+const manifestFileName = ".notion2site.json"
+
+// ManifestPage records what we generated for a page
+type ManifestPage struct {
+	Slug string `json:"slug"`
+	// hash of data the page is generated from, see hashPage
+	Hash string `json:"hash"`
+}
+
+// Manifest records the state of the generated site. It allows incremental
+// re-builds where we only re-generate pages that changed
+type Manifest struct {
+	Version int    `json:"version"`
+	Theme   string `json:"theme"`
+	Strict  bool   `json:"strict"`
+	// if true, pages were generated with downscaled versions of images
+	ResponsiveImages bool `json:"responsive_images"`
+	// hash of id => slug mapping. If it changes, links in all
+	// pages might have changed
+	SlugsHash string `json:"slugs_hash"`
+	// maps no-dash page id to info about generated page
+	Pages map[string]*ManifestPage `json:"pages"`
+}
+
+func newManifest() *Manifest {
+	return &Manifest{
+		Version: manifestVersion,
+		Pages:   map[string]*ManifestPage{},
+	}
+}
+
+func (p *ManifestPage) isSame(other *ManifestPage) bool {
+	if p == nil || other == nil {
+		return false
+	}
+	return p.Slug == other.Slug && p.Hash == other.Hash
+}
+
+func (s *Site) manifestPath() string {
+	return filepath.Join(s.OutDir, manifestFileName)
+}
+
+// readManifest returns nil if manifest doesn't exist
+func readManifest(path string) (*Manifest, error) {
+	d, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	res := newManifest()
+	err = json.Unmarshal(d, res)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (m *Manifest) write(path string) error {
+	d, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(path, d)
+}
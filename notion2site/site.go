@@ -0,0 +1,340 @@
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kjk/notionapi"
+	"github.com/kjk/notionapi/assets"
+	"github.com/kjk/notionapi/tohtml"
+)
+
+// Site generates a static website from Notion pages
+type Site struct {
+	Client *notionapi.CachingClient
+	// directory where we write generated files
+	OutDir string
+	// absolute URL of the site e.g. https://blog.example.com/
+	// sitemap.xml and rss.xml are only generated if it's set
+	BaseURL string
+	// if true, re-generates all pages even if they didn't change
+	Force bool
+	// optional theme for generated pages
+	Theme *tohtml.Theme
+	// if true, generates sanitized html, safe for publishing untrusted
+	// pages. Only embeds from tohtml.DefaultEmbedHosts are rendered
+	Strict bool
+	// if true, images have srcset with downscaled versions, which are
+	// generated in filesDir
+	ResponsiveImages bool
+
+	RootPage *notionapi.Page
+	Pages    []*notionapi.Page
+
+	// maps no-dash page id to its slug
+	idToSlug map[string]string
+	idToPage map[string]*notionapi.Page
+
+	prevManifest *Manifest
+	manifest     *Manifest
+	// downloads files (images, attachments etc.) to filesDir
+	assets *assets.Bundler
+}
+
+// NewSite creates a Site
+func NewSite(client *notionapi.CachingClient, outDir string) *Site {
+	return &Site{
+		Client: client,
+		OutDir: outDir,
+	}
+}
+
+func slugify(title string) string {
+	s := strings.ToLower(notionapi.SafeName(title))
+	if s == "" {
+		s = "untitled"
+	}
+	return s
+}
+
+// assignSlugs returns a unique slug for each page, derived from page title.
+// Root page is first so that it gets the shortest slug
+func assignSlugs(pages []*notionapi.Page) map[string]string {
+	res := map[string]string{}
+	taken := map[string]bool{
+		// reserved for the index
+		"index": true,
+	}
+	for _, page := range pages {
+		id := notionapi.ToNoDashID(page.ID)
+		slug := slugify(page.Root().Title)
+		if taken[slug] {
+			slug = slug + "-" + id[:8]
+		}
+		if taken[slug] {
+			slug = slugify(page.Root().Title) + "-" + id
+		}
+		taken[slug] = true
+		res[id] = slug
+	}
+	return res
+}
+
+// hashSlugs returns a hash of page id => slug mapping. When it changes,
+// links in all pages might need updating
+func hashSlugs(idToSlug map[string]string) string {
+	var ids []string
+	for id := range idToSlug {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	h := sha1.New()
+	for _, id := range ids {
+		fmt.Fprintf(h, "%s:%s\n", id, idToSlug[id])
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// hashPage returns a hash of data that page is generated from. Editing a
+// sub-page or a row of a collection doesn't change version of the root
+// block so we also hash collection rows and titles of sub-pages, which
+// are taken from idToPage if sub-page was downloaded
+func hashPage(page *notionapi.Page, idToPage map[string]*notionapi.Page) string {
+	h := sha1.New()
+	root := page.Root()
+	fmt.Fprintf(h, "%s:%d:%d\n", root.ID, root.Version, root.LastEditedTime)
+	page.ForEachBlock(func(block *notionapi.Block) {
+		// editing a block doesn't always change the root block
+		fmt.Fprintf(h, "block %s:%d\n", block.ID, block.Version)
+		for _, child := range block.Content {
+			if child.Type != notionapi.BlockPage && child.Type != notionapi.BlockCollectionViewPage {
+				continue
+			}
+			title := child.Title
+			if sub := idToPage[notionapi.ToNoDashID(child.ID)]; sub != nil {
+				title = sub.Root().Title
+			}
+			fmt.Fprintf(h, "page %s:%s\n", child.ID, title)
+		}
+	})
+	for _, tv := range page.TableViews {
+		fmt.Fprintf(h, "view %s:%d\n", tv.CollectionView.ID, tv.CollectionView.Version)
+		if tv.Collection != nil {
+			fmt.Fprintf(h, "collection %s:%d\n", tv.Collection.ID, tv.Collection.Version)
+		}
+		for _, row := range tv.Rows {
+			fmt.Fprintf(h, "row %s:%d:%d\n", row.Page.ID, row.Page.Version, row.Page.LastEditedTime)
+		}
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// directory, relative to OutDir, where we store files
+const filesDir = "files"
+
+func pageFileName(slug string) string {
+	return slug + ".html"
+}
+
+func isNotionURL(uri string) bool {
+	if strings.HasPrefix(uri, "/") {
+		return true
+	}
+	return strings.Contains(uri, "notion.so/") || strings.Contains(uri, "notion.site/")
+}
+
+func writeFile(path string, d []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, d, 0644)
+}
+
+func fileExists(path string) bool {
+	st, err := os.Stat(path)
+	return err == nil && st.Mode().IsRegular()
+}
+
+// URLForPage returns a url of a generated page, relative to the site root
+func (s *Site) URLForPage(pageID string) string {
+	slug, ok := s.idToSlug[notionapi.ToNoDashID(pageID)]
+	if !ok {
+		return ""
+	}
+	return pageFileName(slug)
+}
+
+// rewriteURL converts links to Notion pages that are part of the site
+// to links to generated pages
+func (s *Site) rewriteURL(uri string) string {
+	if !isNotionURL(uri) {
+		return uri
+	}
+	id := notionapi.ExtractNoDashIDFromNotionURL(uri)
+	res := s.URLForPage(id)
+	if res == "" {
+		return uri
+	}
+	if idx := strings.Index(uri, "#"); idx != -1 {
+		res += uri[idx:]
+	}
+	return res
+}
+
+func (s *Site) tableTitleCellURL(tv *notionapi.TableView, row, col int) string {
+	id := tv.Rows[row].Page.ID
+	if uri := s.URLForPage(id); uri != "" {
+		return uri
+	}
+	return "https://www.notion.so/" + notionapi.ToNoDashID(id)
+}
+
+func (s *Site) renderPage(page *notionapi.Page) ([]byte, error) {
+	c := tohtml.NewConverter(page)
+	c.FullHTML = true
+	c.Theme = s.Theme
+	c.Strict = s.Strict
+	if s.Strict {
+		c.EmbedHosts = tohtml.DefaultEmbedHosts
+	}
+	c.RewriteURL = s.rewriteURL
+	c.TableTitleCellURLOverride = s.tableTitleCellURL
+	c.PageByIDProvider = tohtml.NewPageByIDFromPages(s.Pages)
+	c.RewriteFileURL = s.assets.RewriteFileURL
+	if s.ResponsiveImages {
+		c.ResponsiveImages = true
+		c.ResizeImageURL = s.assets.ResizeImageURL
+	}
+	return c.ToHTML()
+}
+
+// Build downloads the page with rootPageID and all its sub-pages and
+// generates the site
+func (s *Site) Build(rootPageID string) error {
+	afterDownload := func(di *notionapi.DownloadInfo) error {
+		logvf("downloaded %s '%s' in %s\n", di.Page.ID, di.Page.Root().Title, di.Duration)
+		return nil
+	}
+	pages, err := s.Client.DownloadPagesRecursively(rootPageID, afterDownload)
+	if err != nil {
+		return err
+	}
+	if len(pages) == 0 {
+		return fmt.Errorf("didn't download any pages")
+	}
+	s.Pages = nil
+	s.idToPage = map[string]*notionapi.Page{}
+	for _, page := range pages {
+		id := notionapi.ToNoDashID(page.ID)
+		if id == notionapi.ToNoDashID(rootPageID) {
+			s.RootPage = page
+		} else {
+			s.Pages = append(s.Pages, page)
+		}
+		s.idToPage[id] = page
+	}
+	if s.RootPage == nil {
+		return fmt.Errorf("didn't download root page '%s'", rootPageID)
+	}
+	s.Pages = append([]*notionapi.Page{s.RootPage}, s.Pages...)
+	s.idToSlug = assignSlugs(s.Pages)
+
+	s.prevManifest, err = readManifest(s.manifestPath())
+	if err != nil {
+		logf("failed to read manifest, re-generating all pages. error: '%s'\n", err)
+	}
+	s.manifest = newManifest()
+	s.manifest.SlugsHash = hashSlugs(s.idToSlug)
+	if s.Theme != nil {
+		s.manifest.Theme = s.Theme.Name
+	}
+	prev := s.prevManifest
+	s.manifest.Strict = s.Strict
+	s.manifest.ResponsiveImages = s.ResponsiveImages
+	rebuildAll := s.Force || prev == nil || prev.Version != manifestVersion || prev.Theme != s.manifest.Theme || prev.Strict != s.manifest.Strict || prev.ResponsiveImages != s.manifest.ResponsiveImages || prev.SlugsHash != s.manifest.SlugsHash
+
+	s.assets = assets.New(s.Client, filepath.Join(s.OutDir, filesDir))
+	s.assets.URLPrefix = filesDir
+
+	nGenerated := 0
+	for _, page := range s.Pages {
+		id := notionapi.ToNoDashID(page.ID)
+		mp := &ManifestPage{
+			Slug: s.idToSlug[id],
+			Hash: hashPage(page, s.idToPage),
+		}
+		s.manifest.Pages[id] = mp
+		path := filepath.Join(s.OutDir, pageFileName(mp.Slug))
+		if !rebuildAll && prev.Pages[id].isSame(mp) && fileExists(path) {
+			logvf("skipping '%s', didn't change\n", path)
+			continue
+		}
+		html, err := s.renderPage(page)
+		if err != nil {
+			return err
+		}
+		err = writeFile(path, html)
+		if err != nil {
+			return err
+		}
+		nGenerated++
+		logvf("wrote '%s'\n", path)
+	}
+	s.removeStalePages()
+
+	err = s.writeIndex()
+	if err != nil {
+		return err
+	}
+	if s.BaseURL == "" {
+		logf("-base-url not provided, not generating sitemap.xml and rss.xml\n")
+	} else {
+		err = s.writeSitemap()
+		if err != nil {
+			return err
+		}
+		err = s.writeRSS()
+		if err != nil {
+			return err
+		}
+	}
+	err = s.assets.WriteManifest()
+	if err != nil {
+		return err
+	}
+	for _, m := range s.assets.Missing() {
+		logf("missing file '%s' in block %s, error: '%s'\n", m.URL, m.BlockID, m.Err)
+	}
+	err = s.manifest.write(s.manifestPath())
+	if err != nil {
+		return err
+	}
+	logf("generated %d out of %d pages in '%s'\n", nGenerated, len(s.Pages), s.OutDir)
+	return nil
+}
+
+// removeStalePages deletes html files of pages that are no longer part
+// of the site or were renamed
+func (s *Site) removeStalePages() {
+	if s.prevManifest == nil {
+		return
+	}
+	currSlugs := map[string]bool{}
+	for _, mp := range s.manifest.Pages {
+		currSlugs[mp.Slug] = true
+	}
+	for _, mp := range s.prevManifest.Pages {
+		if currSlugs[mp.Slug] {
+			continue
+		}
+		path := filepath.Join(s.OutDir, pageFileName(mp.Slug))
+		if err := os.Remove(path); err == nil {
+			logvf("removed '%s'\n", path)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kjk/common/assert"
+	"github.com/kjk/notionapi"
+	"github.com/kjk/notionapi/internal/testutil"
+)
+
+func newPage(t *testing.T, id string, title string) *notionapi.Page {
+	s := fmt.Sprintf(`{"version": 1, "id": %q, "blocks": [{"id": %q, "type": "page", "alive": true, "properties": {"title": [[%q]]}}]}`, id, id, title)
+	page, err := notionapi.UnmarshalPageJSON([]byte(s))
+	assert.NoError(t, err)
+	return page
+}
+
+func TestSlugify(t *testing.T) {
+	tests := [][]string{
+		{"Hello World", "hello-world"},
+		{"Report <Q1> & Notes", "report-q1-notes"},
+		{"", "untitled"},
+		{"???", "untitled"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test[1], slugify(test[0]))
+	}
+}
+
+func TestAssignSlugs(t *testing.T) {
+	pages := []*notionapi.Page{
+		newPage(t, "11111111-1111-4111-8111-111111111111", "Index"),
+		newPage(t, "22222222-2222-4222-8222-222222222222", "Notes"),
+		newPage(t, "33333333-3333-4333-8333-333333333333", "Notes"),
+		newPage(t, "44444444-4444-4444-8444-444444444444", "notes 33333333"),
+		newPage(t, "33333333-5555-4555-8555-555555555555", "Notes"),
+	}
+	exp := map[string]string{
+		"11111111111141118111111111111111": "index-11111111",
+		"22222222222242228222222222222222": "notes",
+		"33333333333343338333333333333333": "notes-33333333",
+		"44444444444444448444444444444444": "notes-33333333-44444444",
+		"33333333555545558555555555555555": "notes-33333333555545558555555555555555",
+	}
+	assert.Equal(t, exp, assignSlugs(pages))
+}
+
+func TestHashSlugs(t *testing.T) {
+	m := map[string]string{"a": "x", "b": "y"}
+	h := hashSlugs(m)
+	assert.Equal(t, h, hashSlugs(map[string]string{"b": "y", "a": "x"}))
+	tests := []map[string]string{
+		{"a": "x"},
+		{"a": "x", "b": "z"},
+		{"a": "y", "b": "x"},
+	}
+	for _, test := range tests {
+		assert.NotEqual(t, h, hashSlugs(test))
+	}
+}
+
+func TestRewriteURL(t *testing.T) {
+	s := &Site{
+		idToSlug: map[string]string{"1b0a6f4c6c1e4a3b9a7e3c1f0e2d4b5a": "report"},
+	}
+	tests := [][]string{
+		{"https://www.notion.so/1b0a6f4c6c1e4a3b9a7e3c1f0e2d4b5a", "report.html"},
+		{"https://www.notion.so/Report-1b0a6f4c6c1e4a3b9a7e3c1f0e2d4b5a#10000000000040008000000000000001", "report.html#10000000000040008000000000000001"},
+		{"/1b0a6f4c6c1e4a3b9a7e3c1f0e2d4b5a", "report.html"},
+		{"https://team.notion.site/1b0a6f4c6c1e4a3b9a7e3c1f0e2d4b5a", "report.html"},
+		// not part of the site
+		{"https://www.notion.so/6a5f1c9b1b6d4f808fcd8b6e5d7c9a0f", "https://www.notion.so/6a5f1c9b1b6d4f808fcd8b6e5d7c9a0f"},
+		{"https://example.com/1b0a6f4c6c1e4a3b9a7e3c1f0e2d4b5a", "https://example.com/1b0a6f4c6c1e4a3b9a7e3c1f0e2d4b5a"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test[1], s.rewriteURL(test[0]))
+	}
+}
+
+func TestManifestPageIsSame(t *testing.T) {
+	mp := &ManifestPage{Slug: "a", Hash: "h1"}
+	var null *ManifestPage
+	tests := []struct {
+		other *ManifestPage
+		exp   bool
+	}{
+		{&ManifestPage{Slug: "a", Hash: "h1"}, true},
+		{&ManifestPage{Slug: "b", Hash: "h1"}, false},
+		{&ManifestPage{Slug: "a", Hash: "h2"}, false},
+		{nil, false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.exp, mp.isSame(test.other))
+	}
+	assert.False(t, null.isSame(mp))
+}
+
+func TestHashPage(t *testing.T) {
+	page := testutil.LoadPage(t, "page.json")
+	sub := testutil.LoadPage(t, "subpage.json")
+	idToPage := map[string]*notionapi.Page{
+		notionapi.ToNoDashID(sub.ID): sub,
+	}
+	h := hashPage(page, idToPage)
+	assert.Equal(t, h, hashPage(page, idToPage))
+
+	// editing a collection row doesn't change the root block
+	row := page.TableViews[0].Rows[1].Page
+	row.LastEditedTime = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	h2 := hashPage(page, idToPage)
+	assert.NotEqual(t, h, h2)
+
+	// neither does renaming a sub-page
+	sub.Root().Title = "Appendix A"
+	h3 := hashPage(page, idToPage)
+	assert.NotEqual(t, h2, h3)
+
+	// nor editing a block
+	block := page.Root().Content[0]
+	for len(block.Content) > 0 {
+		block = block.Content[0]
+	}
+	block.Version++
+	assert.NotEqual(t, h3, hashPage(page, idToPage))
+}
@@ -0,0 +1,62 @@
+package notionapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FormatNumberWithCommas is a very crude way of formatting "1234.33" => "1,234.33"
+func FormatNumberWithCommas(v string) string {
+	if v == "" {
+		return v
+	}
+	parts := strings.Split(v, ".")
+	s := parts[0]
+	var a []string
+	for len(s) > 3 {
+		n := len(s)
+		subs := s[n-3 : n]
+		a = append(a, subs)
+		s = s[0 : n-3]
+	}
+	if len(s) > 0 {
+		a = append(a, s)
+	}
+	// reverse an array
+	al := len(a)
+	n := al / 2
+	for i := 0; i < n; i++ {
+		a[i], a[al-i-1] = a[al-i-1], a[i]
+	}
+	res := strings.Join(a, ",")
+	if len(parts) == 2 {
+		return res + "." + parts[1]
+	}
+	return res
+}
+
+// FormatNumber formats a value of ColumnTypeNumber property according
+// to ColumnSchema.NumberFormat
+// TODO: mmore formats
+func FormatNumber(v string, numFmt string) string {
+	if numFmt == "dollar" {
+		v = strings.TrimPrefix(v, "$")
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return v
+		}
+		return fmt.Sprintf("$%.02f", f)
+	}
+	if numFmt == "percent" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return v
+		}
+		return fmt.Sprintf("%.02f%%", f*100)
+	}
+	if numFmt == "number_with_commas" {
+		return FormatNumberWithCommas(v)
+	}
+	return v
+}
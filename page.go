@@ -281,6 +281,11 @@ func makeUserName(user *NotionUser) string {
 // GetUserNameByID returns a full user name given user id
 // it's a helper function
 func GetUserNameByID(page *Page, userID string) string {
+	if nid := NewNotionID(userID); nid != nil {
+		if user := page.NotionUserByID(nid); user != nil {
+			return makeUserName(user)
+		}
+	}
 	for _, r := range page.UserRecords {
 		user := r.NotionUser
 		if user.ID == userID {
@@ -290,6 +295,93 @@ func GetUserNameByID(page *Page, userID string) string {
 	return userID
 }
 
+// ParseTextSpans fails on empty values but it's ok for e.g. a comment
+// to have no text
+func isEmptyTextValue(v interface{}) bool {
+	a, ok := v.([]interface{})
+	return v == nil || (ok && len(a) == 0)
+}
+
+// resolveDiscussions links comments to discussions and parses
+// text of comments. Comments and contexts that fail to parse are logged
+// and skipped so that they don't fail the whole page
+func (p *Page) resolveDiscussions(logf func(format string, args ...interface{})) {
+	for _, c := range p.idToComment {
+		if c == nil {
+			continue
+		}
+		c.Page = p
+		if isEmptyTextValue(c.Text) {
+			continue
+		}
+		spans, err := ParseTextSpans(c.Text)
+		if err != nil {
+			logf("failed to parse text of comment '%s', err: '%s'\n", c.ID, err)
+			continue
+		}
+		c.InlineContent = spans
+	}
+	for _, d := range p.idToDiscussion {
+		if d == nil {
+			continue
+		}
+		d.Page = p
+		d.Content = nil
+		for _, id := range d.Comments {
+			if c := p.idToComment[id]; c != nil {
+				d.Content = append(d.Content, c)
+			}
+		}
+		if isEmptyTextValue(d.Context) {
+			continue
+		}
+		spans, err := ParseTextSpans(d.Context)
+		if err != nil {
+			logf("failed to parse context of discussion '%s', err: '%s'\n", d.ID, err)
+			continue
+		}
+		d.InlineContext = spans
+	}
+}
+
+// resolveParents sets Page and Parent of blocks
+func (p *Page) resolveParents(vlogf func(format string, args ...interface{})) error {
+	for _, b := range p.idToBlock {
+		err := parseProperties(b)
+		if err != nil {
+			return fmt.Errorf("failed to parse properties of block '%s', err: '%s'", b.ID, err)
+		}
+		b.Page = p
+
+		switch b.ParentTable {
+		case TableSpace:
+			// TODO: Support parent table space
+			continue
+		case TableCollection:
+			// TODO: Support parent table collection
+			continue
+		case TableBlock:
+			// Page's parent is outside of this page
+			if isPageBlock(b) && !p.IsSubPage(b) {
+				continue
+			}
+
+			// Page's parent is inside the blocks to skip
+			if _, ok := p.blocksToSkip[b.GetParentNotionID().DashID]; ok {
+				continue
+			}
+
+			b.Parent = p.BlockByID(b.GetParentNotionID())
+			if b.Parent == nil {
+				return fmt.Errorf("could not find parent '%s' of id '%s' of block '%s'", b.ParentTable, b.ParentID, b.ID)
+			}
+		default:
+			vlogf("unsupported parent table type %s of block %s", b.ParentTable, b.ID)
+		}
+	}
+	return nil
+}
+
 func (p *Page) resolveBlocks() error {
 	for _, block := range p.idToBlock {
 		err := resolveBlock(p, block)
@@ -324,3 +416,65 @@ func resolveBlock(p *Page, block *Block) error {
 	block.Content = content
 	return nil
 }
+
+func addUserIDsFromSpans(seen map[string]bool, spans []*TextSpan) {
+	for _, span := range spans {
+		for _, attr := range span.Attrs {
+			if AttrGetType(attr) == AttrUser && len(attr) > 1 {
+				seen[ToDashID(AttrGetUserID(attr))] = true
+			}
+		}
+	}
+}
+
+func addUserIDsFromBlock(seen map[string]bool, b *Block) {
+	if b.CreatedBy != "" {
+		seen[b.CreatedBy] = true
+	}
+	if b.CreatedByID != "" && b.CreatedByTable == TableNotionUser {
+		seen[b.CreatedByID] = true
+	}
+	if b.LastEditedBy != "" {
+		seen[b.LastEditedBy] = true
+	}
+	if b.LastEditedByID != "" && b.LastEditedByTable == TableNotionUser {
+		seen[b.LastEditedByID] = true
+	}
+	// mentions in text and values of person properties
+	for name := range b.Properties {
+		addUserIDsFromSpans(seen, b.GetProperty(name))
+	}
+}
+
+// findUserIDs returns sorted ids of all users referenced by the page:
+// authors of blocks and comments, @-mentions and person properties
+// in collection rows
+func (p *Page) findUserIDs() []string {
+	seen := map[string]bool{}
+	for _, b := range p.idToBlock {
+		addUserIDsFromBlock(seen, b)
+	}
+	for _, tv := range p.TableViews {
+		for _, row := range tv.Rows {
+			addUserIDsFromBlock(seen, row.Page)
+		}
+	}
+	for _, c := range p.idToComment {
+		if c == nil {
+			continue
+		}
+		if id := c.AuthorID(); id != "" {
+			seen[id] = true
+		}
+		addUserIDsFromSpans(seen, c.InlineContent)
+	}
+	var res []string
+	for id := range seen {
+		if NewNotionID(id) == nil {
+			continue
+		}
+		res = append(res, ToDashID(id))
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,215 @@
+package notionapi
+
+// PageInfo is information about a page needed to render a link to it
+// e.g. a mention of a page
+type PageInfo struct {
+	ID    string
+	Title string
+	// emoji or url of an icon, if page has one
+	Icon string
+}
+
+// PageInfoProvider returns information about a page with a given id
+// or nil if it's not known. It's used by converters (tohtml, tomarkdown)
+// to render titles of mentioned pages
+type PageInfoProvider interface {
+	PageInfo(id string) *PageInfo
+}
+
+// BlockRecordsGetter returns blocks with given ids. It's implemented
+// by *Client
+type BlockRecordsGetter interface {
+	GetBlockRecords(ids []string) ([]*Block, error)
+}
+
+var _ BlockRecordsGetter = &Client{}
+
+// PageInfoFromBlock returns PageInfo for a block of a page or nil if
+// block is not a page
+func PageInfoFromBlock(block *Block) *PageInfo {
+	if block == nil || !block.IsPage() {
+		return nil
+	}
+	title := block.Title
+	if title == "" {
+		title = TextSpansToString(block.GetTitle())
+	}
+	icon, _ := block.PropAsString("format.page_icon")
+	return &PageInfo{
+		ID:    ToDashID(block.ID),
+		Title: title,
+		Icon:  icon,
+	}
+}
+
+// FindPageInfo returns info about a page with a given id from records
+// of page or, if it's not there, from provider, which can be nil.
+// Returns nil if the page is not known
+func FindPageInfo(page *Page, provider PageInfoProvider, pageID string) *PageInfo {
+	if nid := NewNotionID(pageID); nid != nil && page != nil {
+		if info := PageInfoFromBlock(page.BlockByID(nid)); info != nil {
+			return info
+		}
+	}
+	if provider != nil {
+		return provider.PageInfo(pageID)
+	}
+	return nil
+}
+
+// PageTitle returns a title of a page with a given id, see FindPageInfo.
+// Returns "" if the page is not known
+func PageTitle(page *Page, provider PageInfoProvider, pageID string) string {
+	if info := FindPageInfo(page, provider, pageID); info != nil {
+		return info.Title
+	}
+	return ""
+}
+
+// PageInfoFromPages is PageInfoProvider backed by records of pages e.g.
+// the page being converted or all pages of a website. Pages are known
+// if they were downloaded or are referenced by blocks of the pages
+type PageInfoFromPages struct {
+	pages []*Page
+}
+
+var _ PageInfoProvider = &PageInfoFromPages{}
+
+// NewPageInfoFromPages returns PageInfoProvider backed by pages
+func NewPageInfoFromPages(pages ...*Page) *PageInfoFromPages {
+	return &PageInfoFromPages{
+		pages: pages,
+	}
+}
+
+// PageInfo returns info about a page with a given id
+func (p *PageInfoFromPages) PageInfo(id string) *PageInfo {
+	nid := NewNotionID(id)
+	if nid == nil {
+		return nil
+	}
+	for _, page := range p.pages {
+		if page == nil {
+			continue
+		}
+		if info := PageInfoFromBlock(page.BlockByID(nid)); info != nil {
+			return info
+		}
+	}
+	return nil
+}
+
+// PageInfoFetcher is PageInfoProvider that downloads pages it doesn't
+// know yet with GetBlockRecords. Results (including failures) are cached
+// so each page is only requested once. It's not safe for concurrent use
+type PageInfoFetcher struct {
+	Getter BlockRecordsGetter
+
+	idToInfo map[string]*PageInfo
+}
+
+var _ PageInfoProvider = &PageInfoFetcher{}
+
+// NewPageInfoFetcher returns PageInfoFetcher that uses getter (e.g.
+// *Client) to download pages
+func NewPageInfoFetcher(getter BlockRecordsGetter) *PageInfoFetcher {
+	return &PageInfoFetcher{
+		Getter:   getter,
+		idToInfo: map[string]*PageInfo{},
+	}
+}
+
+// Prefetch downloads info about pages in one request. Use it with e.g.
+// Page.MentionedPageIDs() to avoid requesting pages one by one
+func (p *PageInfoFetcher) Prefetch(ids []string) error {
+	if p.idToInfo == nil {
+		p.idToInfo = map[string]*PageInfo{}
+	}
+	var toGet []string
+	for _, id := range ids {
+		id = ToDashID(id)
+		if _, ok := p.idToInfo[id]; !ok {
+			toGet = append(toGet, id)
+		}
+	}
+	if len(toGet) == 0 {
+		return nil
+	}
+	blocks, err := p.Getter.GetBlockRecords(toGet)
+	if err != nil {
+		return err
+	}
+	for i, id := range toGet {
+		var info *PageInfo
+		if i < len(blocks) {
+			info = PageInfoFromBlock(blocks[i])
+		}
+		// nil means we tried and don't have access to the page
+		p.idToInfo[id] = info
+	}
+	return nil
+}
+
+// PageInfo returns info about a page with a given id, downloading it
+// if needed
+func (p *PageInfoFetcher) PageInfo(id string) *PageInfo {
+	id = ToDashID(id)
+	if p.idToInfo == nil {
+		p.idToInfo = map[string]*PageInfo{}
+	}
+	if info, ok := p.idToInfo[id]; ok {
+		return info
+	}
+	err := p.Prefetch([]string{id})
+	if err != nil {
+		Logf("PageInfoFetcher: failed to get page '%s'. Error: %s\n", id, err)
+		p.idToInfo[id] = nil
+	}
+	return p.idToInfo[id]
+}
+
+// PageInfoProviders tries providers in order and returns the first info
+// found. Use it to e.g. first look in downloaded pages and only then
+// fetch the rest with PageInfoFetcher
+type PageInfoProviders []PageInfoProvider
+
+var _ PageInfoProvider = PageInfoProviders{}
+
+// PageInfo returns info from the first provider that knows the page
+func (a PageInfoProviders) PageInfo(id string) *PageInfo {
+	for _, p := range a {
+		if p == nil {
+			continue
+		}
+		if info := p.PageInfo(id); info != nil {
+			return info
+		}
+	}
+	return nil
+}
+
+// MentionedPageIDs returns ids of pages mentioned in text of blocks
+// of the page, in order of appearance and without duplicates
+func (p *Page) MentionedPageIDs() []string {
+	var res []string
+	seen := map[string]bool{}
+	addFromSpans := func(spans []*TextSpan) {
+		for _, ts := range spans {
+			for _, attr := range ts.Attrs {
+				if AttrGetType(attr) != AttrPage {
+					continue
+				}
+				id := ToDashID(AttrGetPageID(attr))
+				if id != "" && !seen[id] {
+					seen[id] = true
+					res = append(res, id)
+				}
+			}
+		}
+	}
+	p.ForEachBlock(func(block *Block) {
+		addFromSpans(block.InlineContent)
+		addFromSpans(block.GetCaption())
+	})
+	return res
+}
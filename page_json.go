@@ -0,0 +1,311 @@
+package notionapi
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PageJSONVersion is the version of format written by MarshalPageJSON.
+// It changes when the format changes in incompatible ways
+const PageJSONVersion = 1
+
+// pageJSON is a format for archiving a Page. Records are stored as
+// returned by Notion API so that the page can be reconstructed the same
+// way DownloadPage does it. Records are sorted by id so that the same
+// page always serializes to the same JSON:
+//
+//	{
+//	  "version": 1,
+//	  "id": "<page id>",
+//	  "blocks": [<block>, ...],
+//	  "skipped_block_ids": ["<id>", ...],
+//	  "collections": [<collection>, ...],
+//	  "collection_views": [<collection_view>, ...],
+//	  "users": [<notion_user>, ...],
+//	  "discussions": [<discussion>, ...],
+//	  "comments": [<comment>, ...],
+//	  "spaces": [<space>, ...],
+//	  "table_views": [{
+//	    "block_id": "<id of collection_view block>",
+//	    "collection_view_id": "<id>",
+//	    "collection_id": "<id>",
+//	    "rows": [<block>, ...]
+//	  }, ...]
+//	}
+type pageJSON struct {
+	Version         int                      `json:"version"`
+	ID              string                   `json:"id"`
+	Blocks          []map[string]interface{} `json:"blocks"`
+	SkippedBlockIDs []string                 `json:"skipped_block_ids,omitempty"`
+	Collections     []map[string]interface{} `json:"collections,omitempty"`
+	CollectionViews []map[string]interface{} `json:"collection_views,omitempty"`
+	Users           []map[string]interface{} `json:"users,omitempty"`
+	Discussions     []map[string]interface{} `json:"discussions,omitempty"`
+	Comments        []map[string]interface{} `json:"comments,omitempty"`
+	Spaces          []map[string]interface{} `json:"spaces,omitempty"`
+	TableViews      []*tableViewJSON         `json:"table_views,omitempty"`
+}
+
+type tableViewJSON struct {
+	BlockID          string                   `json:"block_id"`
+	CollectionViewID string                   `json:"collection_view_id"`
+	CollectionID     string                   `json:"collection_id"`
+	Rows             []map[string]interface{} `json:"rows"`
+}
+
+// sortedRaw returns raw JSON of records sorted by id
+func sortedRaw(idToRaw map[string]map[string]interface{}) []map[string]interface{} {
+	var ids []string
+	for id, raw := range idToRaw {
+		if raw != nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	var res []map[string]interface{}
+	for _, id := range ids {
+		res = append(res, idToRaw[id])
+	}
+	return res
+}
+
+// MarshalPageJSON serializes a page, including its collections, rows
+// of collection views, users and discussions, to JSON. Use
+// UnmarshalPageJSON to load it
+func MarshalPageJSON(p *Page) ([]byte, error) {
+	pj := &pageJSON{
+		Version: PageJSONVersion,
+		ID:      ToDashID(p.ID),
+	}
+	m := map[string]map[string]interface{}{}
+	tvToBlockID := map[*TableView]string{}
+	for id, b := range p.idToBlock {
+		m[id] = b.RawJSON
+		for _, tv := range b.TableViews {
+			tvToBlockID[tv] = id
+		}
+	}
+	pj.Blocks = sortedRaw(m)
+	for id := range p.blocksToSkip {
+		pj.SkippedBlockIDs = append(pj.SkippedBlockIDs, id)
+	}
+	sort.Strings(pj.SkippedBlockIDs)
+
+	m = map[string]map[string]interface{}{}
+	for id, v := range p.idToCollection {
+		if v != nil {
+			m[id] = v.RawJSON
+		}
+	}
+	pj.Collections = sortedRaw(m)
+	m = map[string]map[string]interface{}{}
+	for id, v := range p.idToCollectionView {
+		if v != nil {
+			m[id] = v.RawJSON
+		}
+	}
+	pj.CollectionViews = sortedRaw(m)
+	m = map[string]map[string]interface{}{}
+	for id, v := range p.idToNotionUser {
+		if v != nil {
+			m[id] = v.RawJSON
+		}
+	}
+	pj.Users = sortedRaw(m)
+	m = map[string]map[string]interface{}{}
+	for id, v := range p.idToDiscussion {
+		if v != nil {
+			m[id] = v.RawJSON
+		}
+	}
+	pj.Discussions = sortedRaw(m)
+	m = map[string]map[string]interface{}{}
+	for id, v := range p.idToComment {
+		if v != nil {
+			m[id] = v.RawJSON
+		}
+	}
+	pj.Comments = sortedRaw(m)
+	m = map[string]map[string]interface{}{}
+	for id, v := range p.idToSpace {
+		if v != nil {
+			m[id] = v.RawJSON
+		}
+	}
+	pj.Spaces = sortedRaw(m)
+
+	for _, tv := range p.TableViews {
+		blockID := tvToBlockID[tv]
+		if blockID == "" || tv.CollectionView == nil || tv.Collection == nil {
+			continue
+		}
+		tvj := &tableViewJSON{
+			BlockID:          blockID,
+			CollectionViewID: tv.CollectionView.ID,
+			CollectionID:     tv.Collection.ID,
+			Rows:             []map[string]interface{}{},
+		}
+		for _, row := range tv.Rows {
+			tvj.Rows = append(tvj.Rows, row.Page.RawJSON)
+		}
+		pj.TableViews = append(pj.TableViews, tvj)
+	}
+	return jsonit.MarshalIndent(pj, "", "  ")
+}
+
+// parseRawRecords parses records of a given table serialized by
+// MarshalPageJSON
+func parseRawRecords(table string, raws []map[string]interface{}) ([]*Record, error) {
+	var res []*Record
+	for _, raw := range raws {
+		d, err := jsonit.Marshal(raw)
+		if err != nil {
+			return nil, err
+		}
+		r := &Record{
+			Value: d,
+		}
+		if err = parseRecord(table, r); err != nil {
+			return nil, fmt.Errorf("failed to parse %s record, err: '%s'", table, err)
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+// UnmarshalPageJSON loads a page serialized with MarshalPageJSON. The
+// page is resolved the same way as by Client.DownloadPage so it can be
+// rendered and queried with *ByID functions. It can't be used to make
+// changes with SetTitle etc.
+func UnmarshalPageJSON(d []byte) (*Page, error) {
+	var pj pageJSON
+	if err := jsonit.Unmarshal(d, &pj); err != nil {
+		return nil, err
+	}
+	if pj.Version != PageJSONVersion {
+		return nil, fmt.Errorf("unsupported page json version %d, expected %d", pj.Version, PageJSONVersion)
+	}
+	if !IsValidDashID(pj.ID) {
+		return nil, fmt.Errorf("%s is not a valid Notion page id", pj.ID)
+	}
+	p := &Page{
+		ID:                 pj.ID,
+		idToBlock:          map[string]*Block{},
+		idToCollection:     map[string]*Collection{},
+		idToCollectionView: map[string]*CollectionView{},
+		idToComment:        map[string]*Comment{},
+		idToDiscussion:     map[string]*Discussion{},
+		idToNotionUser:     map[string]*NotionUser{},
+		idToUserRoot:       map[string]*UserRoot{},
+		idToUserSettings:   map[string]*UserSettings{},
+		idToSpace:          map[string]*Space{},
+		blocksToSkip:       map[string]struct{}{},
+	}
+	for _, id := range pj.SkippedBlockIDs {
+		p.blocksToSkip[id] = struct{}{}
+	}
+
+	records, err := parseRawRecords(TableBlock, pj.Blocks)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		p.BlockRecords = append(p.BlockRecords, r)
+		p.idToBlock[r.ID] = r.Block
+	}
+	if p.idToBlock[p.ID] == nil {
+		return nil, newErrPageNotFound(p.ID)
+	}
+	if records, err = parseRawRecords(TableCollection, pj.Collections); err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		p.CollectionRecords = append(p.CollectionRecords, r)
+		p.idToCollection[r.ID] = r.Collection
+	}
+	if records, err = parseRawRecords(TableCollectionView, pj.CollectionViews); err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		p.CollectionViewRecords = append(p.CollectionViewRecords, r)
+		p.idToCollectionView[r.ID] = r.CollectionView
+	}
+	if records, err = parseRawRecords(TableNotionUser, pj.Users); err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		p.UserRecords = append(p.UserRecords, r)
+		p.idToNotionUser[r.ID] = r.NotionUser
+	}
+	if records, err = parseRawRecords(TableDiscussion, pj.Discussions); err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		p.DiscussionRecords = append(p.DiscussionRecords, r)
+		p.idToDiscussion[r.ID] = r.Discussion
+	}
+	if records, err = parseRawRecords(TableComment, pj.Comments); err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		p.CommentRecords = append(p.CommentRecords, r)
+		p.idToComment[r.ID] = r.Comment
+	}
+	if records, err = parseRawRecords(TableSpace, pj.Spaces); err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		p.SpaceRecords = append(p.SpaceRecords, r)
+		p.idToSpace[r.ID] = r.Space
+	}
+
+	if err = p.resolveBlocks(); err != nil {
+		return nil, fmt.Errorf("failed to resolve blocks on page '%s': %s", p.ID, err)
+	}
+
+	// buildTableView only uses the client for logging
+	c := &Client{}
+	for _, tvj := range pj.TableViews {
+		block := p.idToBlock[tvj.BlockID]
+		if block == nil {
+			return nil, fmt.Errorf("didn't find collection_view block with id '%s'", tvj.BlockID)
+		}
+		collectionView := p.idToCollectionView[tvj.CollectionViewID]
+		if collectionView == nil {
+			return nil, fmt.Errorf("didn't find collection_view with id '%s'", tvj.CollectionViewID)
+		}
+		rows, err := parseRawRecords(TableBlock, tvj.Rows)
+		if err != nil {
+			return nil, err
+		}
+		res := &QueryCollectionResponse{
+			RecordMap: &RecordMap{
+				Blocks: map[string]*Record{},
+			},
+		}
+		res.Result.ReducerResults = &ReducerResults{
+			CollectionGroupResults: &CollectionGroupResults{},
+		}
+		for _, r := range rows {
+			res.RecordMap.Blocks[r.ID] = r
+			ids := &res.Result.ReducerResults.CollectionGroupResults.BlockIds
+			*ids = append(*ids, r.ID)
+		}
+		tableView := &TableView{
+			Page:           p,
+			CollectionView: collectionView,
+			Collection:     p.idToCollection[tvj.CollectionID],
+		}
+		if err := c.buildTableView(tableView, res); err != nil {
+			return nil, err
+		}
+		block.TableViews = append(block.TableViews, tableView)
+		p.TableViews = append(p.TableViews, tableView)
+	}
+
+	if err = p.resolveParents(c.vlogf); err != nil {
+		return nil, err
+	}
+	p.resolveDiscussions(c.logf)
+	return p, nil
+}
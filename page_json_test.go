@@ -0,0 +1,113 @@
+package notionapi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kjk/common/require"
+)
+
+func TestPageJSONRoundTrip(t *testing.T) {
+	p := testDownloadFromCache(t, "6682351e44bb4f9ca0e149b703265bdb")
+	d, err := MarshalPageJSON(p)
+	require.NoError(t, err)
+
+	p2, err := UnmarshalPageJSON(d)
+	require.NoError(t, err)
+	require.Equal(t, p.ID, p2.ID)
+	require.Equal(t, p.Root().Title, p2.Root().Title)
+
+	var types, types2 []string
+	p.ForEachBlock(func(b *Block) {
+		types = append(types, b.Type)
+	})
+	p2.ForEachBlock(func(b *Block) {
+		types2 = append(types2, b.Type)
+		require.Equal(t, TextSpansToString(p.BlockByID(NewNotionID(b.ID)).InlineContent), TextSpansToString(b.InlineContent))
+		require.True(t, b.Page == p2)
+		if b.ID != p2.ID {
+			require.True(t, b.Parent != nil, "block %s has no parent", b.ID)
+		}
+	})
+	require.Equal(t, types, types2)
+	for _, r := range p.UserRecords {
+		nid := NewNotionID(r.NotionUser.ID)
+		require.Equal(t, p.NotionUserByID(nid).Email, p2.NotionUserByID(nid).Email)
+	}
+
+	// serialization is stable
+	d2, err := MarshalPageJSON(p2)
+	require.NoError(t, err)
+	require.Equal(t, string(d), string(d2))
+}
+
+func TestPageJSONTableViews(t *testing.T) {
+	d, err := os.ReadFile(filepath.Join("testdata", "page.json"))
+	require.NoError(t, err)
+	p, err := UnmarshalPageJSON(d)
+	require.NoError(t, err)
+	require.Equal(t, "Report <Q1> & Notes", p.Root().Title)
+
+	b := p.BlockByID(NewNotionID("2c1b7e5d-7d2f-4b4c-8b8f-4d2a1f3e5c6b"))
+	require.True(t, b.Parent == p.Root())
+	require.Equal(t, 1, len(b.TableViews))
+	tv := b.TableViews[0]
+	require.Equal(t, "Todo", tv.Collection.GetName())
+	require.Equal(t, 6, tv.ColumnCount())
+	require.Equal(t, 2, tv.RowCount())
+	require.Equal(t, "Write tests", TextSpansToString(tv.CellContent(0, 0)))
+	require.Equal(t, "Yes", TextSpansToString(tv.CellContent(0, 1)))
+	require.Equal(t, "<img src=x onerror=alert(1)>", GetUserNameByID(p, tv.Rows[0].Page.CreatedBy))
+
+	d, err = MarshalPageJSON(p)
+	require.NoError(t, err)
+	p2, err := UnmarshalPageJSON(d)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(p2.TableViews))
+	require.Equal(t, "Write tests", TextSpansToString(p2.TableViews[0].CellContent(0, 0)))
+
+	_, err = UnmarshalPageJSON([]byte(`{"version": 2, "id": "1b0a6f4c-6c1e-4a3b-9a7e-3c1f0e2d4b5a"}`))
+	require.True(t, err != nil)
+}
+
+func TestTableViewCellText(t *testing.T) {
+	d, err := os.ReadFile(filepath.Join("testdata", "page.json"))
+	require.NoError(t, err)
+	p, err := UnmarshalPageJSON(d)
+	require.NoError(t, err)
+
+	b := p.BlockByID(NewNotionID("2c1b7e5d-7d2f-4b4c-8b8f-4d2a1f3e5c6b"))
+	require.Equal(t, "Todo", p.CollectionName(b))
+	tv := b.TableViews[0]
+	hostile := "<img src=x onerror=alert(1)>"
+	tests := []struct {
+		col      int
+		text     string
+		richText bool
+	}{
+		{0, "Write tests", true},
+		{1, "Yes", false},
+		{2, "1,234.5", false},
+		{4, "", true},
+		{5, hostile, false},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.text, tv.CellText(0, test.col))
+		require.Equal(t, test.richText, tv.Columns[test.col].IsRichText())
+	}
+	require.Equal(t, "", tv.CellText(1, 1))
+	require.Equal(t, "", tv.CellText(1, 2))
+}
+
+func TestPageTitle(t *testing.T) {
+	d, err := os.ReadFile(filepath.Join("testdata", "page.json"))
+	require.NoError(t, err)
+	p, err := UnmarshalPageJSON(d)
+	require.NoError(t, err)
+
+	require.Equal(t, "Appendix", PageTitle(p, nil, "6a5f1c9b1b6d4f808fcd8b6e5d7c9a0f"))
+	require.Equal(t, "", PageTitle(p, nil, "7b6a2d0c-2c8e-4a91-9bde-9c7f6e8dab1f"))
+	provider := NewPageInfoFromPages(p)
+	require.Equal(t, "Appendix", PageTitle(nil, provider, "6a5f1c9b-1b6d-4f80-8fcd-8b6e5d7c9a0f"))
+}
@@ -0,0 +1,176 @@
+package notionapi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kjk/common/require"
+)
+
+func TestFindUserIDs(t *testing.T) {
+	var rsp SyncRecordValuesResponse
+	err := jsonit.Unmarshal([]byte(syncRecordValuesJSON_1), &rsp)
+	require.NoError(t, err)
+	err = ParseRecordMap(rsp.RecordMap)
+	require.NoError(t, err)
+
+	p := &Page{
+		idToBlock:   map[string]*Block{},
+		idToComment: map[string]*Comment{},
+	}
+	for id, r := range rsp.RecordMap.Blocks {
+		p.idToBlock[id] = r.Block
+	}
+	// a comment by another user that @-mentions a third user
+	p.idToComment["c1"] = &Comment{
+		CreatedBy: "9f4b1e0e-4a6b-4c0d-8a3e-7b9a2f1d0c11",
+		Text: []interface{}{
+			[]interface{}{"see "},
+			[]interface{}{"‣", []interface{}{[]interface{}{"u", "5d3cbd1f0c2b4a7c9e8f1a2b3c4d5e6f"}}},
+		},
+	}
+	p.resolveDiscussions(t.Logf)
+	exp := []string{
+		"5d3cbd1f-0c2b-4a7c-9e8f-1a2b3c4d5e6f",
+		"9f4b1e0e-4a6b-4c0d-8a3e-7b9a2f1d0c11",
+		"bb760e2d-d679-4b64-b2a9-03005b21870a",
+	}
+	require.Equal(t, exp, p.findUserIDs())
+}
+
+func TestBlockDiscussions(t *testing.T) {
+	var rsp LoadCachedPageChunkResponse
+	err := jsonit.Unmarshal([]byte(loadPageJSON1), &rsp)
+	require.NoError(t, err)
+	err = ParseRecordMap(rsp.RecordMap)
+	require.NoError(t, err)
+
+	p := &Page{
+		idToBlock:      map[string]*Block{},
+		idToComment:    map[string]*Comment{},
+		idToDiscussion: map[string]*Discussion{},
+	}
+	for id, r := range rsp.RecordMap.Blocks {
+		r.Block.Page = p
+		p.idToBlock[id] = r.Block
+	}
+	for id, r := range rsp.RecordMap.Comments {
+		p.idToComment[id] = r.Comment
+	}
+	for id, r := range rsp.RecordMap.Discussions {
+		p.idToDiscussion[id] = r.Discussion
+	}
+	p.resolveDiscussions(t.Logf)
+
+	b := p.idToBlock["0367c2db-381a-4f8b-9ce3-60f388a6b2e3"]
+	ds := b.Discussions()
+	require.Equal(t, 1, len(ds))
+	d := ds[0]
+	require.False(t, d.Resolved)
+	require.Equal(t, 1, len(d.Content))
+	c := d.FirstComment()
+	require.Equal(t, "bb760e2d-d679-4b64-b2a9-03005b21870a", c.AuthorID())
+	require.Equal(t, "a discussion for page\nanother comment about the page", TextSpansToString(c.InlineContent))
+	require.Equal(t, int64(1566024240), c.CreatedOn().Unix())
+
+	reply, ops := d.ReplyOps(c.AuthorID(), "a reply")
+	require.Equal(t, 2, len(ops))
+	require.Equal(t, TableComment, ops[0].Table)
+	require.Equal(t, d.ID, reply.ParentID)
+	require.Equal(t, []string{"comments"}, ops[1].Path)
+	require.Equal(t, 1, len(d.Content))
+
+	nDiscussions := len(b.DiscussionIDs)
+	d2, ops := b.AddCommentOps(c.AuthorID(), "new thread")
+	require.Equal(t, 4, len(ops))
+	require.Equal(t, []string{"discussions"}, ops[1].Path)
+	require.Equal(t, b.ID, d2.ParentID)
+	require.Equal(t, "new thread", TextSpansToString(d2.FirstComment().InlineContent))
+	require.Equal(t, nDiscussions, len(b.DiscussionIDs))
+
+	// page is only changed if submitting a transaction succeeds
+	client := &Client{}
+	submitErr := errors.New("submit failed")
+	client.httpPostOverride = func(uri string, body []byte) ([]byte, error) {
+		return nil, submitErr
+	}
+	_, err = client.ReplyToDiscussion(c.AuthorID(), d, "a reply")
+	require.True(t, err != nil)
+	require.Equal(t, 1, len(d.Content))
+	_, err = client.AddComment(c.AuthorID(), b, "new thread")
+	require.True(t, err != nil)
+	require.Equal(t, nDiscussions, len(b.DiscussionIDs))
+
+	client.httpPostOverride = func(uri string, body []byte) ([]byte, error) {
+		return []byte("{}"), nil
+	}
+	reply, err = client.ReplyToDiscussion(c.AuthorID(), d, "a reply")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(d.Content))
+	require.Equal(t, reply, d.Content[1])
+	d2, err = client.AddComment(c.AuthorID(), b, "new thread")
+	require.NoError(t, err)
+	require.Equal(t, nDiscussions+1, len(b.DiscussionIDs))
+	require.Equal(t, d2.ID, b.DiscussionIDs[nDiscussions])
+}
+
+func TestLegacyBlockDiscussionIDs(t *testing.T) {
+	for _, key := range []string{"discussions", "discussion"} {
+		r := &Record{
+			Value: []byte(`{"id": "b1", "type": "text", "` + key + `": ["d1", "d2"]}`),
+		}
+		err := parseRecord(TableBlock, r)
+		require.NoError(t, err)
+		require.Equal(t, []string{"d1", "d2"}, r.Block.DiscussionIDs)
+	}
+}
+
+type fakeBlockRecordsGetter struct {
+	blocks map[string]*Block
+	calls  int
+}
+
+func (g *fakeBlockRecordsGetter) GetBlockRecords(ids []string) ([]*Block, error) {
+	g.calls++
+	var res []*Block
+	for _, id := range ids {
+		res = append(res, g.blocks[id])
+	}
+	return res, nil
+}
+
+func TestPageInfoFetcher(t *testing.T) {
+	id := "3b617da4-0945-4a52-bc3a-920ba8832bf7"
+	missingID := "5fea9664-0720-4d90-80a5-b989360b205f"
+	g := &fakeBlockRecordsGetter{
+		blocks: map[string]*Block{
+			id: {
+				ID:   id,
+				Type: BlockPage,
+				Properties: map[string]interface{}{
+					"title": []interface{}{[]interface{}{"Employee Handbook"}},
+				},
+				RawJSON: map[string]interface{}{
+					"format": map[string]interface{}{
+						"page_icon": "📖",
+					},
+				},
+			},
+		},
+	}
+	p := NewPageInfoFetcher(g)
+	err := p.Prefetch([]string{ToNoDashID(id), missingID})
+	require.NoError(t, err)
+	require.Equal(t, 1, g.calls)
+
+	info := p.PageInfo(id)
+	require.NotNil(t, info)
+	require.Equal(t, "Employee Handbook", info.Title)
+	require.Equal(t, "📖", info.Icon)
+	require.Nil(t, p.PageInfo(missingID))
+	require.Equal(t, 1, g.calls)
+
+	providers := PageInfoProviders{NewPageInfoFromPages(), p}
+	require.Equal(t, "Employee Handbook", providers.PageInfo(ToNoDashID(id)).Title)
+	require.Nil(t, providers.PageInfo("not an id"))
+}
@@ -18,6 +18,7 @@ type Record struct {
 	Activity       *Activity       `json:"-"`
 	Block          *Block          `json:"-"`
 	Space          *Space          `json:"-"`
+	SpaceView      *SpaceView      `json:"-"`
 	NotionUser     *NotionUser     `json:"-"`
 	UserRoot       *UserRoot       `json:"-"`
 	UserSettings   *UserSettings   `json:"-"`
@@ -70,6 +71,10 @@ func parseRecord(table string, r *Record) error {
 		r.Space = &Space{}
 		obj = r.Space
 		pRawJSON = &r.Space.RawJSON
+	case TableSpaceView:
+		r.SpaceView = &SpaceView{}
+		obj = r.SpaceView
+		pRawJSON = &r.SpaceView.RawJSON
 	case TableCollection:
 		r.Collection = &Collection{}
 		obj = r.Collection
@@ -100,5 +105,9 @@ func parseRecord(table string, r *Record) error {
 	if err := jsonit.Unmarshal(r.Value, &obj); err != nil {
 		return err
 	}
+	if r.Block != nil && len(r.Block.DiscussionIDs) == 0 {
+		// older pages store ids of discussions as "discussion"
+		r.Block.DiscussionIDs = jsonGetStrings(r.Block.RawJSON, "discussion")
+	}
 	return nil
 }
@@ -37,3 +37,22 @@ type Space struct {
 
 	RawJSON map[string]interface{} `json:"-"`
 }
+
+// SpaceView describes per-user information about a space, like
+// private and bookmarked pages
+type SpaceView struct {
+	ID                     string   `json:"id"`
+	Version                int      `json:"version"`
+	SpaceID                string   `json:"space_id"`
+	ParentID               string   `json:"parent_id"`
+	ParentTable            string   `json:"parent_table"`
+	Alive                  bool     `json:"alive"`
+	Joined                 bool     `json:"joined"`
+	PrivatePages           []string `json:"private_pages,omitempty"`
+	SharedPages            []string `json:"shared_pages,omitempty"`
+	BookmarkedPages        []string `json:"bookmarked_pages,omitempty"`
+	VisitedTemplates       []string `json:"visited_templates,omitempty"`
+	SidebarHiddenTemplates []string `json:"sidebar_hidden_templates,omitempty"`
+
+	RawJSON map[string]interface{} `json:"-"`
+}
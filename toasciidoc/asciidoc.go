@@ -0,0 +1,900 @@
+// Package toasciidoc converts a Notion page to AsciiDoc.
+//
+// Toggles are collapsible example blocks, callouts are admonitions
+// (NOTE, TIP, WARNING etc.) and equations are rendered with latexmath.
+// Links to sub-pages are cross references to their .adoc files.
+package toasciidoc
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/kjk/notionapi"
+)
+
+func maybePanic(format string, args ...interface{}) {
+	notionapi.MaybePanic(format, args...)
+}
+
+func adocFileName(title, pageID string) string {
+	s := notionapi.SafeName(title)
+	return s + "-" + notionapi.ToDashID(pageID) + ".adoc"
+}
+
+// AdocFileNameForPage returns file name for .adoc file
+func AdocFileNameForPage(page *notionapi.Page) string {
+	rootPage := page.Root()
+	return adocFileName(rootPage.Title, page.ID)
+}
+
+// BlockRenderFunc is a function for rendering a particular block
+type BlockRenderFunc func(block *notionapi.Block) bool
+
+// Converter converts a Page to AsciiDoc
+type Converter struct {
+	Page *notionapi.Page
+
+	// Buf is where AsciiDoc text is being written to
+	Buf *bytes.Buffer
+
+	// allows over-riding rendering of specific blocks
+	// return false for default rendering
+	RenderBlockOverride BlockRenderFunc
+
+	// RewriteURL allows re-writing URLs e.g. to convert inter-notion URLs
+	// to destination URLs
+	RewriteURL func(url string) string
+
+	// RewriteFileURL allows re-writing URLs of files (images, attachments
+	// etc.) e.g. to download them and link to local copies.
+	// See assets.Bundler
+	RewriteFileURL func(uri string, block *notionapi.Block) string
+
+	// data provided by they caller, useful when providing
+	// RenderBlockOverride
+	Data interface{}
+
+	// we need this to properly render ordered and numbered lists
+	CurrBlocks   []*notionapi.Block
+	CurrBlockIdx int
+
+	// PageInfoProvider, if set, is used to get titles of mentioned pages
+	// that are not part of Page
+	PageInfoProvider notionapi.PageInfoProvider
+
+	// nesting level of lists, it's the number of * or . in list markers
+	listDepth int
+	// true when rendering children of a list item, they must be
+	// attached to the item with a + line
+	inListItem bool
+	// nesting level of delimited blocks, nested blocks need longer
+	// delimiters
+	delimDepth int
+
+	// document attributes are only written if needed
+	hasMath bool
+	hasTOC  bool
+
+	bufs []*bytes.Buffer
+}
+
+// NewConverter returns customizable AsciiDoc renderer
+func NewConverter(page *notionapi.Page) *Converter {
+	return &Converter{
+		Page: page,
+	}
+}
+
+// PushNewBuffer creates a new buffer and sets Buf to it
+func (c *Converter) PushNewBuffer() {
+	c.bufs = append(c.bufs, c.Buf)
+	c.Buf = &bytes.Buffer{}
+}
+
+// PopBuffer pops a buffer
+func (c *Converter) PopBuffer() *bytes.Buffer {
+	res := c.Buf
+	n := len(c.bufs)
+	c.Buf = c.bufs[n-1]
+	c.bufs = c.bufs[:n-1]
+	return res
+}
+
+// Eol writes end-of-line to the buffer. Doesn't write multiple.
+func (c *Converter) Eol() {
+	d := c.Buf.Bytes()
+	n := len(d)
+	if n > 0 && d[n-1] != '\n' {
+		c.Buf.WriteByte('\n')
+	}
+}
+
+// Newline writes a newline to the buffer. It'll suppress multiple newlines.
+func (c *Converter) Newline() {
+	d := c.Buf.Bytes()
+	n := 0
+	idx := len(d) - 1
+	for idx >= 0 && d[idx] == '\n' {
+		n++
+		idx--
+	}
+	switch n {
+	case 0:
+		c.Buf.WriteString("\n\n")
+	case 1:
+		c.Buf.WriteByte('\n')
+	}
+}
+
+// WriteString writes a string to the buffer
+func (c *Converter) WriteString(s string) {
+	c.Buf.WriteString(s)
+}
+
+// Printf writes formatted string to the buffer
+func (c *Converter) Printf(format string, args ...interface{}) {
+	s := format
+	if len(args) > 0 {
+		s = fmt.Sprintf(format, args...)
+	}
+	c.Buf.WriteString(s)
+}
+
+// PrevBlock is a block preceding current block
+func (c *Converter) PrevBlock() *notionapi.Block {
+	if c.CurrBlockIdx == 0 {
+		return nil
+	}
+	return c.CurrBlocks[c.CurrBlockIdx-1]
+}
+
+// NextBlock is a block following current block
+func (c *Converter) NextBlock() *notionapi.Block {
+	nextIdx := c.CurrBlockIdx + 1
+	if nextIdx >= len(c.CurrBlocks) {
+		return nil
+	}
+	return c.CurrBlocks[nextIdx]
+}
+
+// IsPrevBlockOfType returns true if previous block is of a given type
+func (c *Converter) IsPrevBlockOfType(t string) bool {
+	b := c.PrevBlock()
+	return b != nil && b.Type == t
+}
+
+// IsNextBlockOfType returns true if next block is of a given type
+func (c *Converter) IsNextBlockOfType(t string) bool {
+	b := c.NextBlock()
+	return b != nil && b.Type == t
+}
+
+// FormatDate formats the date
+func (c *Converter) FormatDate(d *notionapi.Date) string {
+	return notionapi.FormatDate(d)
+}
+
+func isWs(c byte) bool {
+	return c == ' '
+}
+
+// shuffleWhitespace splits text into leading whitespace, text and
+// trailing whitespace, as formatting marks must be next to text
+func shuffleWhitespace(text string) (string, string, string) {
+	n := 0
+	for n < len(text) && isWs(text[n]) {
+		n++
+	}
+	before := text[:n]
+	text = text[n:]
+	n = len(text)
+	for n > 0 && isWs(text[n-1]) {
+		n--
+	}
+	return before, text[:n], text[n:]
+}
+
+var urlEscaper = strings.NewReplacer(
+	" ", "%20",
+	"[", "%5B",
+	"]", "%5D",
+)
+
+// escapeMacroText escapes text of a macro e.g. link:url[text] or stem:[text]
+func escapeMacroText(s string) string {
+	return strings.Replace(s, "]", `\]`, -1)
+}
+
+// adocLink returns a link to uri. Without text, AsciiDoc shows the uri
+func adocLink(uri string, text string) string {
+	return "link:" + urlEscaper.Replace(uri) + "[" + escapeMacroText(text) + "]"
+}
+
+// pageLink returns a cross reference to .adoc file of a page or, if
+// RewriteURL is set, a link to re-written url
+func (c *Converter) pageLink(title string, pageID string, text string) string {
+	if c.RewriteURL != nil {
+		return adocLink(c.RewriteURL("https://notion.so/"+pageID), text)
+	}
+	return "xref:" + adocFileName(title, pageID) + "[" + escapeMacroText(text) + "]"
+}
+
+// InlineToString renders inline block
+func (c *Converter) InlineToString(b *notionapi.TextSpan) string {
+	text := b.Text
+	var start, end string
+	for _, attr := range b.Attrs {
+		switch notionapi.AttrGetType(attr) {
+		case notionapi.AttrBold:
+			start += "**"
+			end = "**" + end
+		case notionapi.AttrItalic:
+			start += "__"
+			end = "__" + end
+		case notionapi.AttrStrikeThrought:
+			start += "[.line-through]##"
+			end = "##" + end
+		case notionapi.AttrUnderline:
+			start += "[.underline]##"
+			end = "##" + end
+		case notionapi.AttrCode:
+			start += "`+"
+			end = "+`" + end
+		case notionapi.AttrPage:
+			pageID := notionapi.AttrGetPageID(attr)
+			title := notionapi.PageTitle(c.Page, c.PageInfoProvider, pageID)
+			if c.RewriteURL != nil {
+				text = adocLink(c.RewriteURL("https://www.notion.so/"+pageID), title)
+			} else {
+				text = c.pageLink(title, pageID, title)
+			}
+		case notionapi.AttrLink:
+			uri := notionapi.AttrGetLink(attr)
+			if c.RewriteURL != nil {
+				uri = c.RewriteURL(uri)
+			}
+			var before, after string
+			before, text, after = shuffleWhitespace(text)
+			text = before + adocLink(uri, text) + after
+		case notionapi.AttrUser:
+			userID := notionapi.AttrGetUserID(attr)
+			text = "@" + notionapi.GetUserNameByID(c.Page, userID)
+		case notionapi.AttrDate:
+			text = c.FormatDate(notionapi.AttrGetDate(attr))
+		case notionapi.AttrEquation:
+			c.hasMath = true
+			text = "stem:[" + escapeMacroText(notionapi.AttrGetEquation(attr)) + "]"
+		case notionapi.AttrLinkMention:
+			lm := notionapi.AttrGetLinkMention(attr)
+			if lm == nil {
+				continue
+			}
+			uri := lm.Href
+			if c.RewriteURL != nil {
+				uri = c.RewriteURL(uri)
+			}
+			text = adocLink(uri, lm.Title)
+		}
+	}
+	if start == "" {
+		return text
+	}
+	before, text, after := shuffleWhitespace(text)
+	if text == "" {
+		return before + after
+	}
+	return before + start + text + end + after
+}
+
+// GetInlineContent returns AsciiDoc text of inline blocks
+func (c *Converter) GetInlineContent(blocks []*notionapi.TextSpan) string {
+	var sb strings.Builder
+	for _, b := range blocks {
+		sb.WriteString(c.InlineToString(b))
+	}
+	return strings.TrimRight(sb.String(), " ")
+}
+
+// RenderInlines renders inline blocks
+func (c *Converter) RenderInlines(blocks []*notionapi.TextSpan) {
+	c.WriteString(c.GetInlineContent(blocks))
+}
+
+// lineStartMarkup are prefixes of lines that AsciiDoc doesn't treat
+// as a text of a paragraph
+var lineStartMarkup = []string{
+	"=", "*", "-", ".", "[", "|", "/", "+", "'", "_", ":", "<",
+	"NOTE:", "TIP:", "IMPORTANT:", "WARNING:", "CAUTION:",
+}
+
+func startsWithMarkup(line string) bool {
+	for _, prefix := range lineStartMarkup {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	// 1. starts an ordered list
+	n := 0
+	for n < len(line) && line[n] >= '0' && line[n] <= '9' {
+		n++
+	}
+	return n > 0 && strings.HasPrefix(line[n:], ". ")
+}
+
+// escapeLineStart prevents lines of a paragraph from being parsed as
+// markup by prefixing them with an empty attribute
+func escapeLineStart(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if startsWithMarkup(line) {
+			lines[i] = "{empty}" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// paragraph returns text as a paragraph, line breaks are preserved
+func paragraph(s string) string {
+	s = strings.Replace(s, "\r\n", "\n", -1)
+	return strings.Replace(escapeLineStart(s), "\n", " +\n", -1)
+}
+
+// blockTitle returns .Title line that precedes a block
+func blockTitle(s string) string {
+	s = strings.TrimSpace(strings.Replace(s, "\n", " ", -1))
+	if s == "" {
+		return ""
+	}
+	return "." + s + "\n"
+}
+
+func (c *Converter) renderCaption(block *notionapi.Block) {
+	c.WriteString(blockTitle(c.GetInlineContent(block.GetCaption())))
+}
+
+// renderDelimitedBlock renders text and children of a block between
+// delimiters made of ch. Lists inside the block start from scratch
+func (c *Converter) renderDelimitedBlock(block *notionapi.Block, ch string, text string) {
+	delim := strings.Repeat(ch, 4+c.delimDepth)
+	listDepth, inListItem := c.listDepth, c.inListItem
+	c.listDepth, c.inListItem = 0, false
+	c.delimDepth++
+
+	c.WriteString(delim + "\n")
+	if text != "" {
+		c.WriteString(paragraph(text) + "\n")
+	}
+	c.RenderChildren(block)
+	c.Eol()
+	c.WriteString(delim + "\n")
+
+	c.delimDepth--
+	c.listDepth, c.inListItem = listDepth, inListItem
+}
+
+func (c *Converter) renderRootPage(block *notionapi.Block) {
+	// we only know which document attributes are needed after rendering
+	// the content
+	c.PushNewBuffer()
+	c.RenderChildren(block)
+	body := c.PopBuffer()
+
+	title := c.GetInlineContent(block.InlineContent)
+	c.Printf("= %s\n", strings.Replace(title, "\n", " ", -1))
+	if c.hasMath {
+		c.WriteString(":stem: latexmath\n")
+	}
+	if c.hasTOC {
+		c.WriteString(":toc: macro\n")
+	}
+	c.Newline()
+	c.Buf.Write(bytes.TrimLeft(body.Bytes(), "\n"))
+}
+
+// RenderPage renders BlockPage
+func (c *Converter) RenderPage(block *notionapi.Block) {
+	if c.Page.IsRoot(block) {
+		c.renderRootPage(block)
+		return
+	}
+	title := c.GetInlineContent(block.InlineContent)
+	c.WriteString(c.pageLink(block.Title, block.ID, title) + "\n")
+}
+
+// RenderText renders BlockText
+func (c *Converter) RenderText(block *notionapi.Block) {
+	if text := c.GetInlineContent(block.InlineContent); text != "" {
+		c.WriteString(paragraph(text) + "\n")
+	}
+	c.RenderChildren(block)
+}
+
+// RenderHeaderLevel renders BlockHeader, SubHeader and SubSubHeader
+// as section titles
+func (c *Converter) RenderHeaderLevel(block *notionapi.Block, level int) {
+	content := c.GetInlineContent(block.InlineContent)
+	c.Printf("%s %s\n", strings.Repeat("=", level+1), strings.Replace(content, "\n", " ", -1))
+}
+
+// RenderHeader renders BlockHeader
+func (c *Converter) RenderHeader(block *notionapi.Block) {
+	c.RenderHeaderLevel(block, 1)
+}
+
+// RenderSubHeader renders BlockSubHeader
+func (c *Converter) RenderSubHeader(block *notionapi.Block) {
+	c.RenderHeaderLevel(block, 2)
+}
+
+// RenderSubSubHeader renders BlockSubSubHeader
+func (c *Converter) RenderSubSubHeader(block *notionapi.Block) {
+	c.RenderHeaderLevel(block, 3)
+}
+
+// renderListItem renders an item of a list. The marker is repeated to
+// show nesting e.g. ** is an item of a list nested in a list
+func (c *Converter) renderListItem(block *notionapi.Block, marker string, prefix string) {
+	text := c.GetInlineContent(block.InlineContent)
+	if text == "" {
+		// an item must have text
+		text = "{empty}"
+	}
+	c.WriteString(strings.Repeat(marker, c.listDepth+1) + " " + prefix + paragraph(text) + "\n")
+
+	inListItem := c.inListItem
+	c.listDepth++
+	c.inListItem = true
+	c.RenderChildren(block)
+	c.listDepth--
+	c.inListItem = inListItem
+}
+
+// RenderBulletedList renders BlockBulletedList
+func (c *Converter) RenderBulletedList(block *notionapi.Block) {
+	c.renderListItem(block, "*", "")
+}
+
+// RenderNumberedList renders BlockNumberedList
+func (c *Converter) RenderNumberedList(block *notionapi.Block) {
+	c.renderListItem(block, ".", "")
+}
+
+// RenderTodo renders BlockTodo as an item of a checklist
+func (c *Converter) RenderTodo(block *notionapi.Block) {
+	if block.IsChecked {
+		c.renderListItem(block, "*", "[x] ")
+		return
+	}
+	c.renderListItem(block, "*", "[ ] ")
+}
+
+// RenderToggle renders BlockToggle as a collapsible block
+func (c *Converter) RenderToggle(block *notionapi.Block) {
+	c.WriteString(blockTitle(c.GetInlineContent(block.InlineContent)))
+	c.WriteString("[%collapsible]\n")
+	c.renderDelimitedBlock(block, "=", "")
+}
+
+// RenderQuote renders BlockQuote
+func (c *Converter) RenderQuote(block *notionapi.Block) {
+	c.WriteString("[quote]\n")
+	c.renderDelimitedBlock(block, "_", c.GetInlineContent(block.InlineContent))
+}
+
+// admonitions maps color of a callout to a type of admonition
+var admonitions = map[string]string{
+	"blue":   "NOTE",
+	"green":  "TIP",
+	"purple": "IMPORTANT",
+	"pink":   "IMPORTANT",
+	"yellow": "WARNING",
+	"orange": "WARNING",
+	"red":    "CAUTION",
+}
+
+// RenderCallout renders BlockCallout as an admonition block e.g. [NOTE]
+func (c *Converter) RenderCallout(block *notionapi.Block) {
+	col, _ := block.PropAsString("format.block_color")
+	col, _ = notionapi.ParseHighlight(col)
+	kind := admonitions[col]
+	if kind == "" {
+		kind = "NOTE"
+	}
+	text := c.GetInlineContent(block.InlineContent)
+	icon, _ := block.PropAsString("format.page_icon")
+	if icon != "" && !strings.HasPrefix(icon, "http") {
+		text = icon + " " + text
+	}
+	c.WriteString("[" + kind + "]\n")
+	c.renderDelimitedBlock(block, "=", text)
+}
+
+// RenderEquation renders BlockEquation as latexmath block
+func (c *Converter) RenderEquation(block *notionapi.Block) {
+	eq := strings.TrimSpace(notionapi.TextSpansToString(block.InlineContent))
+	if eq == "" {
+		return
+	}
+	c.hasMath = true
+	c.WriteString("[stem]\n++++\n" + eq + "\n++++\n")
+}
+
+// adocLanguages maps names of languages in Notion to names of languages
+// understood by highlighters, when lower-cased name isn't the name
+var adocLanguages = map[string]string{
+	"c#":     "csharp",
+	"f#":     "fsharp",
+	"shell":  "sh",
+	"markup": "html",
+}
+
+func codeLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if l, ok := adocLanguages[lang]; ok {
+		return l
+	}
+	if lang == "plain text" || strings.ContainsAny(lang, " \t") {
+		return ""
+	}
+	return lang
+}
+
+// codeDelimiter returns a delimiter of a listing block that doesn't
+// appear in the code
+func codeDelimiter(code string) string {
+	delim := "----"
+	lines := strings.Split(code, "\n")
+	for {
+		found := false
+		for _, line := range lines {
+			if line == delim {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return delim
+		}
+		delim += "-"
+	}
+}
+
+// RenderCode renders BlockCode as source block or, if it doesn't have a
+// language, as listing block
+func (c *Converter) RenderCode(block *notionapi.Block) {
+	c.renderCaption(block)
+	code := strings.TrimRight(strings.Replace(block.Code, "\r\n", "\n", -1), "\n")
+	if lang := codeLanguage(block.CodeLanguage); lang != "" {
+		c.WriteString("[source," + lang + "]\n")
+	}
+	delim := codeDelimiter(code)
+	c.WriteString(delim + "\n" + code + "\n" + delim + "\n")
+}
+
+// RenderTableOfContents renders BlockTableOfContents
+func (c *Converter) RenderTableOfContents(block *notionapi.Block) {
+	c.hasTOC = true
+	c.WriteString("toc::[]\n")
+}
+
+// RenderAlias renders BlockAlias as a link to the page
+func (c *Converter) RenderAlias(block *notionapi.Block) {
+	format := block.FormatAlias()
+	if format == nil || format.Alias == nil {
+		return
+	}
+	id := format.Alias.ID
+	title := notionapi.PageTitle(c.Page, c.PageInfoProvider, id)
+	c.WriteString(c.pageLink(title, id, title) + "\n")
+}
+
+// RenderTransclusionReference renders BlockTransclusionReference by
+// rendering content of the referenced block, if we have it
+func (c *Converter) RenderTransclusionReference(block *notionapi.Block) {
+	id, _ := block.PropAsString("format.transclusion_reference_pointer.id")
+	nid := notionapi.NewNotionID(id)
+	if nid == nil {
+		return
+	}
+	ref := c.Page.BlockByID(nid)
+	if ref == nil {
+		return
+	}
+	c.RenderChildren(ref)
+}
+
+// RenderDivider renders BlockDivider
+func (c *Converter) RenderDivider(block *notionapi.Block) {
+	c.WriteString("'''\n")
+}
+
+// RenderBookmark renders BlockBookmark
+func (c *Converter) RenderBookmark(block *notionapi.Block) {
+	if block.Link == "" {
+		return
+	}
+	c.WriteString(adocLink(block.Link, notionapi.TextSpansToString(block.InlineContent)) + "\n")
+	if caption := c.GetInlineContent(block.GetCaption()); caption != "" {
+		c.WriteString("\n" + paragraph(caption) + "\n")
+	}
+}
+
+// fileURL returns url of a file (image, attachment etc.) of the block
+func (c *Converter) fileURL(block *notionapi.Block) string {
+	if c.RewriteFileURL != nil && block.Source != "" {
+		return c.RewriteFileURL(block.Source, block)
+	}
+	return block.Source
+}
+
+func fileName(uri string) string {
+	if idx := strings.IndexAny(uri, "?#"); idx >= 0 {
+		uri = uri[:idx]
+	}
+	parts := strings.Split(uri, "/")
+	return parts[len(parts)-1]
+}
+
+// RenderImage renders BlockImage
+func (c *Converter) RenderImage(block *notionapi.Block) {
+	uri := c.fileURL(block)
+	if uri == "" {
+		return
+	}
+	c.renderCaption(block)
+	c.WriteString("image::" + urlEscaper.Replace(uri) + "[]\n")
+}
+
+func isLocalFile(uri string) bool {
+	return uri != "" && !strings.Contains(uri, ":")
+}
+
+var mediaExts = []string{".mp4", ".webm", ".ogv", ".mov", ".mp3", ".ogg", ".wav", ".m4a"}
+
+func isMediaFile(uri string) bool {
+	name := strings.ToLower(fileName(uri))
+	for _, ext := range mediaExts {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderMedia renders BlockVideo and BlockAudio with video:: and audio::
+// macros if it's a file that can be played. Other media (e.g. YouTube
+// videos) are rendered as links
+func (c *Converter) RenderMedia(block *notionapi.Block) {
+	uri := c.fileURL(block)
+	if uri == "" {
+		return
+	}
+	if !isLocalFile(uri) && !isMediaFile(uri) {
+		c.RenderEmbed(block)
+		return
+	}
+	c.renderCaption(block)
+	c.WriteString(block.Type + "::" + urlEscaper.Replace(uri) + "[]\n")
+}
+
+// RenderFile renders BlockFile and BlockPDF as a link
+func (c *Converter) RenderFile(block *notionapi.Block) {
+	uri := c.fileURL(block)
+	if uri == "" {
+		return
+	}
+	name := block.Title
+	if name == "" {
+		name = fileName(block.Source)
+	}
+	c.WriteString(adocLink(uri, name) + "\n")
+	if caption := c.GetInlineContent(block.GetCaption()); caption != "" {
+		c.WriteString("\n" + paragraph(caption) + "\n")
+	}
+}
+
+// RenderEmbed renders BlockEmbed and other embeds as a link
+func (c *Converter) RenderEmbed(block *notionapi.Block) {
+	if block.Source == "" {
+		return
+	}
+	c.WriteString(adocLink(block.Source, "") + "\n")
+	if caption := c.GetInlineContent(block.GetCaption()); caption != "" {
+		c.WriteString("\n" + paragraph(caption) + "\n")
+	}
+}
+
+// RenderDrive renders BlockDrive
+func (c *Converter) RenderDrive(block *notionapi.Block) {
+	docURL, _ := block.PropAsString("format.drive_properties.url")
+	title, _ := block.PropAsString("format.drive_properties.title")
+	if docURL == "" {
+		return
+	}
+	c.WriteString(adocLink(docURL, title) + "\n")
+}
+
+// RenderColumnList renders BlockColumnList
+// it's children are BlockColumn
+func (c *Converter) RenderColumnList(block *notionapi.Block) {
+	c.RenderChildren(block)
+}
+
+// RenderColumn renders BlockColumn
+// it's parent is BlockColumnList
+func (c *Converter) RenderColumn(block *notionapi.Block) {
+	c.RenderChildren(block)
+}
+
+// DefaultRenderFunc returns a defult rendering function for a type of
+// a given block
+func (c *Converter) DefaultRenderFunc(blockType string) func(*notionapi.Block) {
+	switch blockType {
+	case notionapi.BlockPage:
+		return c.RenderPage
+	case notionapi.BlockText:
+		return c.RenderText
+	case notionapi.BlockEquation:
+		return c.RenderEquation
+	case notionapi.BlockNumberedList:
+		return c.RenderNumberedList
+	case notionapi.BlockBulletedList:
+		return c.RenderBulletedList
+	case notionapi.BlockHeader:
+		return c.RenderHeader
+	case notionapi.BlockSubHeader:
+		return c.RenderSubHeader
+	case notionapi.BlockSubSubHeader:
+		return c.RenderSubSubHeader
+	case notionapi.BlockTodo:
+		return c.RenderTodo
+	case notionapi.BlockToggle:
+		return c.RenderToggle
+	case notionapi.BlockQuote:
+		return c.RenderQuote
+	case notionapi.BlockDivider:
+		return c.RenderDivider
+	case notionapi.BlockCode:
+		return c.RenderCode
+	case notionapi.BlockBookmark:
+		return c.RenderBookmark
+	case notionapi.BlockImage:
+		return c.RenderImage
+	case notionapi.BlockColumnList:
+		return c.RenderColumnList
+	case notionapi.BlockColumn:
+		return c.RenderColumn
+	case notionapi.BlockCollectionView:
+		return c.RenderCollectionView
+	case notionapi.BlockCollectionViewPage:
+		return c.RenderCollectionViewPage
+	case notionapi.BlockLinkToCollection:
+		return c.RenderLinkToCollection
+	case notionapi.BlockEmbed, notionapi.BlockGist, notionapi.BlockMaps,
+		notionapi.BlockCodepen, notionapi.BlockTweet, notionapi.BlockFigma,
+		notionapi.BlockMiro:
+		return c.RenderEmbed
+	case notionapi.BlockVideo, notionapi.BlockAudio:
+		return c.RenderMedia
+	case notionapi.BlockFile, notionapi.BlockPDF:
+		return c.RenderFile
+	case notionapi.BlockDrive:
+		return c.RenderDrive
+	case notionapi.BlockCallout:
+		return c.RenderCallout
+	case notionapi.BlockTableOfContents:
+		return c.RenderTableOfContents
+	case notionapi.BlockAlias:
+		return c.RenderAlias
+	case notionapi.BlockTransclusionReference:
+		return c.RenderTransclusionReference
+	case notionapi.BlockBreadcrumb, notionapi.BlockFactory, notionapi.BlockComment,
+		notionapi.BlockCopyIndicator:
+		return nil
+	case notionapi.BlockLinkToPage:
+		// TODO: not sure how to render it
+		return nil
+	default:
+		maybePanic("DefaultRenderFunc: unsupported block type '%s' in %s\n", blockType, c.Page.NotionURL())
+	}
+	return nil
+}
+
+// listMarker returns a character that starts an item of a list or ""
+// if block is not a list item
+func listMarker(block *notionapi.Block) string {
+	switch block.Type {
+	case notionapi.BlockBulletedList, notionapi.BlockTodo:
+		return "*"
+	case notionapi.BlockNumberedList:
+		return "."
+	}
+	return ""
+}
+
+func (c *Converter) skipChildren(block *notionapi.Block) bool {
+	if len(block.Content) == 0 {
+		return true
+	}
+	if block.Type == notionapi.BlockPage {
+		// we don't want to render content of links to pages
+		return !c.Page.IsRoot(block)
+	}
+	return false
+}
+
+// RenderChildren renders children of the block
+func (c *Converter) RenderChildren(block *notionapi.Block) {
+	if c.skipChildren(block) {
+		return
+	}
+	currIdx := c.CurrBlockIdx
+	currBlocks := c.CurrBlocks
+	c.CurrBlocks = block.Content
+	for i, child := range block.Content {
+		c.CurrBlockIdx = i
+		c.RenderBlock(child)
+	}
+	c.CurrBlockIdx = currIdx
+	c.CurrBlocks = currBlocks
+}
+
+// separateBlock writes what must separate block from the previous one
+func (c *Converter) separateBlock(block *notionapi.Block) {
+	marker := listMarker(block)
+	var prevMarker string
+	if prev := c.PrevBlock(); prev != nil {
+		prevMarker = listMarker(prev)
+	}
+	switch {
+	case marker != "" && marker == prevMarker:
+		// items of a list are not separated by empty lines
+		c.Eol()
+	case marker != "" && prevMarker != "" && !c.inListItem:
+		// without a comment a list would be nested in the previous list
+		c.Newline()
+		c.WriteString("//-\n")
+		c.Newline()
+	case marker != "" && c.inListItem:
+		// nested list
+		c.Eol()
+	case c.inListItem:
+		// other blocks are attached to a list item with a + line
+		c.Eol()
+		c.WriteString("+\n")
+	default:
+		c.Newline()
+	}
+}
+
+// RenderBlock renders a block to AsciiDoc
+func (c *Converter) RenderBlock(block *notionapi.Block) {
+	if block == nil {
+		// a missing block, can happen if we don't have access to a referenced block
+		return
+	}
+	if c.RenderBlockOverride != nil && c.RenderBlockOverride(block) {
+		return
+	}
+	def := c.DefaultRenderFunc(block.Type)
+	if def == nil {
+		return
+	}
+	c.separateBlock(block)
+	def(block)
+}
+
+// ToAsciiDoc returns AsciiDoc text of the page
+func (c *Converter) ToAsciiDoc() []byte {
+	c.PushNewBuffer()
+	c.RenderBlock(c.Page.Root())
+	buf := c.PopBuffer()
+	return bytes.TrimSpace(buf.Bytes())
+}
+
+// ToAsciiDoc converts a page to AsciiDoc
+func ToAsciiDoc(page *notionapi.Page) []byte {
+	r := NewConverter(page)
+	return r.ToAsciiDoc()
+}
@@ -0,0 +1,50 @@
+package toasciidoc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kjk/common/assert"
+	"github.com/kjk/notionapi/internal/testutil"
+)
+
+func TestToAsciiDoc(t *testing.T) {
+	page := testutil.LoadPage(t, "page.json")
+	s := string(ToAsciiDoc(page))
+	exp := []string{
+		// stem attribute is only set because the page has an equation
+		"= Report <Q1> & Notes\n:stem: latexmath\n\n== Plan\n",
+		"Costs 50% of **x_1** and [.line-through]##marked## see link:https://example.com/?a=1&b=2#top[docs] and run `+make+`, ask @<img src=x onerror=alert(1)>\n",
+		"* [x] Write spec\n* [ ] Ship it\n* Steps\n.. build\n.. test\n",
+		".Details\n[%collapsible]\n====\n\nHidden text\n====\n",
+		"[WARNING]\n====\n⚠️ Mind the gap\n====\n",
+		". first\n. second\n** nested\n\nbetween lists\n\n. restarted\n",
+		"[source,python]\n----\n* not a headline\nif a < b:\n\tprint(\"%d\" % 5)\n----\n",
+		"[stem]\n++++\ne^{i\\pi} + 1 = 0\n++++\n",
+		".Chart\nimage::https://example.com/chart.png[]\n",
+		"[quote]\n____\nLess is more\n____\n",
+		"xref:Appendix-6a5f1c9b-1b6d-4f80-8fcd-8b6e5d7c9a0f.adoc[Appendix]\n",
+		".Todo\n[cols=\"1,1,>1,1,1,1\",options=\"header\"]\n|===\n| Name | Done | Estimate | Due | Owner | Created by\n",
+		"| xref:Write-tests-5f4e0b8a-0a5c-4e7f-9ebc-7a5d4c6b8f9e.adoc[Write tests] | ☒ | 1,234.5 | Mar 01, 2024 | @<img src=x onerror=alert(1)> | <img src=x onerror=alert(1)>\n",
+		"| Ship | ☐ |  |  |  | <img src=x onerror=alert(1)>\n|===",
+	}
+	for _, e := range exp {
+		assert.True(t, strings.Contains(s, e), "expected '%s' in:\n%s", e, s)
+	}
+	assert.False(t, strings.Contains(s, ":toc:"))
+}
+
+func TestParagraph(t *testing.T) {
+	assert.Equal(t, "{empty}* not a list +\nsecond line", paragraph("* not a list\nsecond line"))
+	assert.Equal(t, "{empty}1. not a list", paragraph("1. not a list"))
+	assert.Equal(t, "2020 was a year", paragraph("2020 was a year"))
+}
+
+func TestCodeDelimiter(t *testing.T) {
+	assert.Equal(t, "----", codeDelimiter("a\n---"))
+	assert.Equal(t, "------", codeDelimiter("----\nb\n-----"))
+}
+
+func TestAdocLink(t *testing.T) {
+	assert.Equal(t, `link:https://example.com/a%20b%5B1%5D[see [1\]]`, adocLink("https://example.com/a b[1]", "see [1]"))
+}
@@ -0,0 +1,123 @@
+package toasciidoc
+
+import (
+	"strings"
+
+	"github.com/kjk/notionapi"
+)
+
+// escapeTableCell makes a string safe to put inside a cell of a table
+func escapeTableCell(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.Replace(s, "|", `\|`, -1)
+	s = strings.Replace(s, "\r\n", "\n", -1)
+	s = strings.Replace(s, "\n", " ", -1)
+	return s
+}
+
+// TableCellToString returns AsciiDoc text for the content of a table cell
+func (c *Converter) TableCellToString(tv *notionapi.TableView, row, col int) string {
+	ci := tv.Columns[col]
+	spans := tv.CellContent(row, col)
+	text := tv.CellText(row, col)
+	switch ci.Type() {
+	case notionapi.ColumnTypeTitle:
+		title := c.GetInlineContent(spans)
+		if title == "" {
+			title = "Untitled"
+		}
+		rowPage := tv.Rows[row].Page
+		if len(rowPage.ContentIDs) == 0 {
+			// for cosmetic reasons we don't link to empty pages
+			return title
+		}
+		return c.pageLink(text, rowPage.ID, title)
+	case notionapi.ColumnTypeCheckbox:
+		if text == "Yes" {
+			return "☒"
+		}
+		return "☐"
+	case notionapi.ColumnTypeURL:
+		if text == "" {
+			return ""
+		}
+		return adocLink(text, "")
+	case notionapi.ColumnTypeEmail:
+		if text == "" {
+			return ""
+		}
+		return adocLink("mailto:"+text, text)
+	}
+	if ci.IsRichText() {
+		return c.GetInlineContent(spans)
+	}
+	return text
+}
+
+// RenderTableView renders a collection view as a table with a header row.
+// Numbers are aligned to the right
+func (c *Converter) RenderTableView(tv *notionapi.TableView) {
+	nCols := tv.ColumnCount()
+	if nCols == 0 {
+		return
+	}
+	var cols []string
+	var cells []string
+	for _, ci := range tv.Columns {
+		if ci.Schema != nil && ci.Schema.Type == notionapi.ColumnTypeNumber {
+			cols = append(cols, ">1")
+		} else {
+			cols = append(cols, "1")
+		}
+		name := ci.Name()
+		if name == "" {
+			name = ci.ID()
+		}
+		cells = append(cells, "| "+escapeTableCell(name))
+	}
+	c.Printf("[cols=\"%s\",options=\"header\"]\n", strings.Join(cols, ","))
+	c.WriteString("|===\n")
+	c.WriteString(strings.Join(cells, " ") + "\n")
+
+	nRows := tv.RowCount()
+	for row := 0; row < nRows; row++ {
+		cells = nil
+		for col := 0; col < nCols; col++ {
+			cells = append(cells, "| "+escapeTableCell(c.TableCellToString(tv, row, col)))
+		}
+		c.WriteString("\n" + strings.Join(cells, " ") + "\n")
+	}
+	c.WriteString("|===\n")
+}
+
+// RenderCollectionView renders BlockCollectionView
+func (c *Converter) RenderCollectionView(block *notionapi.Block) {
+	if len(block.TableViews) == 0 {
+		return
+	}
+	c.WriteString(blockTitle(c.Page.CollectionName(block)))
+	// render only the first one
+	c.RenderTableView(block.TableViews[0])
+}
+
+// RenderCollectionViewPage renders BlockCollectionViewPage
+func (c *Converter) RenderCollectionViewPage(block *notionapi.Block) {
+	name := c.Page.CollectionName(block)
+	if c.Page.IsRoot(block) {
+		c.Printf("= %s\n", name)
+		if len(block.TableViews) > 0 {
+			c.Newline()
+			c.RenderTableView(block.TableViews[0])
+		}
+		return
+	}
+	if name == "" {
+		name = "Untitled Database"
+	}
+	c.WriteString(c.pageLink(name, block.ID, name) + "\n")
+}
+
+// RenderLinkToCollection renders BlockLinkToCollection as a link
+func (c *Converter) RenderLinkToCollection(block *notionapi.Block) {
+	c.RenderCollectionViewPage(block)
+}
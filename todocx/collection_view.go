@@ -0,0 +1,117 @@
+package todocx
+
+import (
+	"github.com/kjk/notionapi"
+)
+
+// renderTableCell writes runs with the content of a table cell
+func (c *Converter) renderTableCell(tv *notionapi.TableView, row, col int) {
+	ci := tv.Columns[col]
+	text := tv.CellText(row, col)
+	switch ci.Type() {
+	case notionapi.ColumnTypeTitle:
+		if text == "" {
+			text = "Untitled"
+		}
+		rowPage := tv.Rows[row].Page
+		if len(rowPage.ContentIDs) == 0 {
+			// for cosmetic reasons we don't link to empty pages
+			c.writeRun(text, &runProps{})
+			return
+		}
+		c.writeHyperlink(c.pageURL(rowPage.ID), text, &runProps{})
+	case notionapi.ColumnTypeCheckbox:
+		if text == "Yes" {
+			c.writeRun("☒", &runProps{})
+		} else {
+			c.writeRun("☐", &runProps{})
+		}
+	case notionapi.ColumnTypeURL:
+		c.writeHyperlink(text, text, &runProps{})
+	case notionapi.ColumnTypeEmail:
+		if text != "" {
+			c.writeHyperlink("mailto:"+text, text, &runProps{})
+		}
+	default:
+		if ci.IsRichText() {
+			c.RenderInlines(tv.CellContent(row, col))
+		} else {
+			c.writeRun(text, &runProps{})
+		}
+	}
+}
+
+// RenderTableView renders a collection view as a table. The first row,
+// with names of columns, is repeated on each page
+func (c *Converter) RenderTableView(tv *notionapi.TableView) {
+	nCols := tv.ColumnCount()
+	if nCols == 0 {
+		return
+	}
+	c.Buf.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="5000" w:type="pct"/></w:tblPr>`)
+	c.Buf.WriteString("<w:tblGrid>")
+	for i := 0; i < nCols; i++ {
+		c.Buf.WriteString("<w:gridCol/>")
+	}
+	c.Buf.WriteString("</w:tblGrid>\n")
+
+	c.Buf.WriteString("<w:tr><w:trPr><w:tblHeader/></w:trPr>")
+	for _, ci := range tv.Columns {
+		name := ci.Name()
+		if name == "" {
+			name = ci.ID()
+		}
+		c.Buf.WriteString("<w:tc><w:p>")
+		c.writeRun(name, &runProps{bold: true})
+		c.Buf.WriteString("</w:p></w:tc>")
+	}
+	c.Buf.WriteString("</w:tr>\n")
+
+	nRows := tv.RowCount()
+	for row := 0; row < nRows; row++ {
+		c.Buf.WriteString("<w:tr>")
+		for col := 0; col < nCols; col++ {
+			// a cell must have a paragraph, even if empty
+			c.Buf.WriteString("<w:tc><w:p>")
+			if ci := tv.Columns[col]; ci.Schema != nil && ci.Schema.Type == notionapi.ColumnTypeNumber {
+				c.Buf.WriteString(`<w:pPr><w:jc w:val="right"/></w:pPr>`)
+			}
+			c.renderTableCell(tv, row, col)
+			c.Buf.WriteString("</w:p></w:tc>")
+		}
+		c.Buf.WriteString("</w:tr>\n")
+	}
+	c.Buf.WriteString("</w:tbl>\n")
+}
+
+// RenderCollectionView renders BlockCollectionView
+func (c *Converter) RenderCollectionView(block *notionapi.Block) {
+	if len(block.TableViews) == 0 {
+		return
+	}
+	if name := c.Page.CollectionName(block); name != "" {
+		c.startParagraph(&paraProps{})
+		c.writeRun(name, &runProps{bold: true})
+		c.endParagraph()
+	}
+	// render only the first one
+	c.RenderTableView(block.TableViews[0])
+}
+
+// RenderCollectionViewPage renders BlockCollectionViewPage
+func (c *Converter) RenderCollectionViewPage(block *notionapi.Block) {
+	name := c.Page.CollectionName(block)
+	if c.Page.IsRoot(block) {
+		c.startParagraph(&paraProps{style: "Title"})
+		c.writeRun(name, &runProps{})
+		c.endParagraph()
+		if len(block.TableViews) > 0 {
+			c.RenderTableView(block.TableViews[0])
+		}
+		return
+	}
+	if name == "" {
+		name = "Untitled Database"
+	}
+	c.renderLink(c.pageURL(block.ID), name)
+}
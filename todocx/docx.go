@@ -0,0 +1,868 @@
+// Package todocx converts a Notion page to a Word document (Office Open
+// XML, .docx). The document is written directly from the Block tree,
+// without external tools.
+//
+// Headers use Heading1-3 styles, lists use Word numbering, collection
+// views are tables and code uses a monospace style. Images are embedded
+// if Converter.Assets is set, otherwise they are links. Equations are
+// written as TeX source.
+package todocx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	_ "image/gif" // for image.DecodeConfig
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kjk/notionapi"
+	"github.com/kjk/notionapi/assets"
+)
+
+func maybePanic(format string, args ...interface{}) {
+	notionapi.MaybePanic(format, args...)
+}
+
+func logf(format string, args ...interface{}) {
+	notionapi.Logf(format, args...)
+}
+
+const (
+	// EMUs (English Metric Units) per pixel at 96 dpi
+	emuPerPixel = 9525
+	// width of text on a letter page with 1 inch margins, in EMUs
+	maxImageWidth = 6 * 914400
+	// indentation of a nesting level, in twentieths of a point
+	indentStep = 720
+	// Word supports 9 levels of lists
+	maxListLevel = 8
+)
+
+// content types of images that can be embedded, by file extension
+var imageContentTypes = map[string]string{
+	".gif":  "image/gif",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+}
+
+// BlockRenderFunc is a function for rendering a particular block
+type BlockRenderFunc func(block *notionapi.Block) bool
+
+// Converter converts a Page to a Word document
+type Converter struct {
+	Page *notionapi.Page
+
+	// Buf is where content of <w:body> is being written to
+	Buf *bytes.Buffer
+
+	// allows over-riding rendering of specific blocks
+	// return false for default rendering
+	RenderBlockOverride BlockRenderFunc
+
+	// RewriteURL allows re-writing URLs e.g. to convert inter-notion URLs
+	// to destination URLs
+	RewriteURL func(url string) string
+
+	// Assets, if set, downloads images to embed them in the document.
+	// Otherwise images are links to their urls
+	Assets *assets.Bundler
+
+	// PageInfoProvider, if set, is used to get titles of mentioned pages
+	// that are not part of Page
+	PageInfoProvider notionapi.PageInfoProvider
+
+	// Author is written to document properties
+	Author string
+
+	// we need this to properly render lists
+	CurrBlocks   []*notionapi.Block
+	CurrBlockIdx int
+
+	// nesting level of blocks, for indentation of children and levels
+	// of lists
+	level int
+	rels  []*relationship
+	// maps url of a hyperlink or name of an image to relationship id
+	relIDs map[string]string
+	// names of files in word/media
+	images []string
+	// each numbered list is a separate numbering instance so that it
+	// starts at 1. This is the level of each instance
+	numLevels []int
+	// maps id of numbered list block to its numbering instance
+	blockNumID map[string]int
+	// last id of a drawing, must be unique in the document
+	drawingID int
+}
+
+// NewConverter returns a Converter for a page
+func NewConverter(page *notionapi.Page) *Converter {
+	return &Converter{
+		Page: page,
+	}
+}
+
+// PrevBlock is a block preceding current block
+func (c *Converter) PrevBlock() *notionapi.Block {
+	if c.CurrBlockIdx == 0 {
+		return nil
+	}
+	return c.CurrBlocks[c.CurrBlockIdx-1]
+}
+
+// IsPrevBlockOfType returns true if previous block is of a given type
+func (c *Converter) IsPrevBlockOfType(t string) bool {
+	b := c.PrevBlock()
+	return b != nil && b.Type == t
+}
+
+func escapeXML(s string) string {
+	var sb strings.Builder
+	_ = xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
+
+// cssColorToHex converts color from notionapi.HighlightCSS
+// e.g. "color:rgb(224,62,62)" to hex e.g. "E03E3E"
+func cssColorToHex(css string) string {
+	idx := strings.Index(css, "rgb(")
+	if idx < 0 {
+		return ""
+	}
+	s := strings.TrimSuffix(css[idx+len("rgb("):], ")")
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return ""
+	}
+	res := ""
+	for _, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return ""
+		}
+		res += fmt.Sprintf("%02X", n)
+	}
+	return res
+}
+
+// pageURL returns url of a page
+func (c *Converter) pageURL(pageID string) string {
+	uri := "https://www.notion.so/" + notionapi.ToNoDashID(pageID)
+	if c.RewriteURL != nil {
+		return c.RewriteURL(uri)
+	}
+	return uri
+}
+
+// runProps are properties of a run of text
+type runProps struct {
+	style     string
+	bold      bool
+	italic    bool
+	strike    bool
+	underline bool
+	color     string
+	fill      string
+}
+
+// xml returns <w:rPr>. Elements must be in the order of the schema
+func (p *runProps) xml() string {
+	s := ""
+	if p.style != "" {
+		s += `<w:rStyle w:val="` + p.style + `"/>`
+	}
+	if p.bold {
+		s += `<w:b/>`
+	}
+	if p.italic {
+		s += `<w:i/>`
+	}
+	if p.strike {
+		s += `<w:strike/>`
+	}
+	if p.color != "" {
+		s += `<w:color w:val="` + p.color + `"/>`
+	}
+	if p.underline {
+		s += `<w:u w:val="single"/>`
+	}
+	if p.fill != "" {
+		s += `<w:shd w:val="clear" w:color="auto" w:fill="` + p.fill + `"/>`
+	}
+	if s == "" {
+		return ""
+	}
+	return "<w:rPr>" + s + "</w:rPr>"
+}
+
+// writeRun writes a run of text. New lines and tabs become breaks and tabs
+func (c *Converter) writeRun(text string, rp *runProps) {
+	if text == "" {
+		return
+	}
+	c.Buf.WriteString("<w:r>" + rp.xml())
+	text = strings.Replace(text, "\r\n", "\n", -1)
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			c.Buf.WriteString("<w:br/>")
+		}
+		for j, s := range strings.Split(line, "\t") {
+			if j > 0 {
+				c.Buf.WriteString("<w:tab/>")
+			}
+			if s != "" {
+				c.Buf.WriteString(`<w:t xml:space="preserve">` + escapeXML(s) + `</w:t>`)
+			}
+		}
+	}
+	c.Buf.WriteString("</w:r>")
+}
+
+// addRel adds a relationship of word/document.xml and returns its id.
+// target is a hyperlink url or a name of an image
+func (c *Converter) addRel(relType string, target string, external bool) string {
+	if id, ok := c.relIDs[target]; ok {
+		return id
+	}
+	// rId1 and rId2 are styles and numbering
+	id := fmt.Sprintf("rId%d", len(c.rels)+3)
+	c.rels = append(c.rels, &relationship{
+		ID:       id,
+		Type:     relType,
+		Target:   target,
+		External: external,
+	})
+	c.relIDs[target] = id
+	return id
+}
+
+// writeHyperlink writes a run of text as a link to uri
+func (c *Converter) writeHyperlink(uri string, text string, rp *runProps) {
+	if uri == "" {
+		c.writeRun(text, rp)
+		return
+	}
+	id := c.addRel(relTypeHyperlink, uri, true)
+	if rp.style == "" {
+		rp.style = "Hyperlink"
+	}
+	c.Buf.WriteString(`<w:hyperlink r:id="` + id + `" w:history="1">`)
+	c.writeRun(text, rp)
+	c.Buf.WriteString("</w:hyperlink>")
+}
+
+// RenderInline renders a span of text
+func (c *Converter) RenderInline(ts *notionapi.TextSpan) {
+	text := ts.Text
+	uri := ""
+	rp := &runProps{}
+	for _, attr := range ts.Attrs {
+		switch notionapi.AttrGetType(attr) {
+		case notionapi.AttrBold:
+			rp.bold = true
+		case notionapi.AttrItalic:
+			rp.italic = true
+		case notionapi.AttrStrikeThrought:
+			rp.strike = true
+		case notionapi.AttrUnderline:
+			rp.underline = true
+		case notionapi.AttrCode:
+			rp.style = "CodeChar"
+		case notionapi.AttrHighlight:
+			hl := notionapi.AttrGetHighlight(attr)
+			color := cssColorToHex(notionapi.HighlightCSS(hl))
+			if _, isBackground := notionapi.ParseHighlight(hl); isBackground {
+				rp.fill = color
+			} else {
+				rp.color = color
+			}
+		case notionapi.AttrLink:
+			uri = notionapi.AttrGetLink(attr)
+			if c.RewriteURL != nil {
+				uri = c.RewriteURL(uri)
+			}
+		case notionapi.AttrPage:
+			pageID := notionapi.AttrGetPageID(attr)
+			text = notionapi.PageTitle(c.Page, c.PageInfoProvider, pageID)
+			if text == "" {
+				text = "Untitled"
+			}
+			uri = c.pageURL(pageID)
+		case notionapi.AttrUser:
+			text = "@" + notionapi.GetUserNameByID(c.Page, notionapi.AttrGetUserID(attr))
+		case notionapi.AttrDate:
+			text = notionapi.FormatDate(notionapi.AttrGetDate(attr))
+		case notionapi.AttrEquation:
+			text = notionapi.AttrGetEquation(attr)
+			rp.style = "EquationChar"
+		case notionapi.AttrLinkMention:
+			lm := notionapi.AttrGetLinkMention(attr)
+			if lm == nil {
+				continue
+			}
+			uri = lm.Href
+			if c.RewriteURL != nil {
+				uri = c.RewriteURL(uri)
+			}
+			text = lm.Title
+			if text == "" {
+				text = lm.Href
+			}
+		}
+	}
+	if uri != "" {
+		c.writeHyperlink(uri, text, rp)
+		return
+	}
+	c.writeRun(text, rp)
+}
+
+// RenderInlines renders spans of text as runs
+func (c *Converter) RenderInlines(spans []*notionapi.TextSpan) {
+	for _, ts := range spans {
+		c.RenderInline(ts)
+	}
+}
+
+// paraProps are properties of a paragraph
+type paraProps struct {
+	style string
+	// numID is id of numbering instance of a list item, 0 if not a list
+	numID int
+	ilvl  int
+	// bottomBorder is for dividers
+	bottomBorder bool
+	fill         string
+}
+
+// startParagraph writes <w:p> and its properties. Elements of <w:pPr>
+// must be in the order of the schema
+func (c *Converter) startParagraph(pp *paraProps) {
+	s := ""
+	if pp.style != "" {
+		s += `<w:pStyle w:val="` + pp.style + `"/>`
+	}
+	if pp.numID > 0 {
+		s += fmt.Sprintf(`<w:numPr><w:ilvl w:val="%d"/><w:numId w:val="%d"/></w:numPr>`, pp.ilvl, pp.numID)
+	}
+	if pp.bottomBorder {
+		s += `<w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="auto"/></w:pBdr>`
+	}
+	if pp.fill != "" {
+		s += `<w:shd w:val="clear" w:color="auto" w:fill="` + pp.fill + `"/>`
+	}
+	// list items are indented by numbering
+	if pp.numID == 0 && c.level > 0 {
+		s += fmt.Sprintf(`<w:ind w:left="%d"/>`, c.level*indentStep)
+	}
+	c.Buf.WriteString("<w:p>")
+	if s != "" {
+		c.Buf.WriteString("<w:pPr>" + s + "</w:pPr>")
+	}
+}
+
+func (c *Converter) endParagraph() {
+	c.Buf.WriteString("</w:p>\n")
+}
+
+// RenderParagraph renders spans as a paragraph with a given style
+func (c *Converter) RenderParagraph(style string, spans []*notionapi.TextSpan) {
+	c.startParagraph(&paraProps{style: style})
+	c.RenderInlines(spans)
+	c.endParagraph()
+}
+
+// renderLink renders a link as a paragraph
+func (c *Converter) renderLink(uri string, title string) {
+	if uri == "" {
+		return
+	}
+	if title == "" {
+		title = uri
+	}
+	c.startParagraph(&paraProps{})
+	c.writeHyperlink(uri, title, &runProps{})
+	c.endParagraph()
+}
+
+func (c *Converter) renderCaption(block *notionapi.Block) {
+	if caption := block.GetCaption(); len(caption) > 0 {
+		c.RenderParagraph("Caption", caption)
+	}
+}
+
+// renderIndentedChildren renders children of a block one level deeper
+func (c *Converter) renderIndentedChildren(block *notionapi.Block) {
+	c.level++
+	c.RenderChildren(block)
+	c.level--
+}
+
+// RenderPage renders BlockPage. Root page is the title followed by
+// content, sub-pages are links
+func (c *Converter) RenderPage(block *notionapi.Block) {
+	if c.Page.IsRoot(block) {
+		c.RenderParagraph("Title", block.InlineContent)
+		c.RenderChildren(block)
+		return
+	}
+	title := notionapi.TextSpansToString(block.InlineContent)
+	if title == "" {
+		title = "Untitled"
+	}
+	c.renderLink(c.pageURL(block.ID), title)
+}
+
+// RenderText renders BlockText
+func (c *Converter) RenderText(block *notionapi.Block) {
+	c.RenderParagraph("", block.InlineContent)
+	c.renderIndentedChildren(block)
+}
+
+// RenderHeaderLevel renders BlockHeader, SubHeader and SubSubHeader
+// with Heading1, Heading2 and Heading3 style
+func (c *Converter) RenderHeaderLevel(block *notionapi.Block, level int) {
+	c.RenderParagraph(fmt.Sprintf("Heading%d", level), block.InlineContent)
+}
+
+// RenderHeader renders BlockHeader
+func (c *Converter) RenderHeader(block *notionapi.Block) {
+	c.RenderHeaderLevel(block, 1)
+}
+
+// RenderSubHeader renders BlockSubHeader
+func (c *Converter) RenderSubHeader(block *notionapi.Block) {
+	c.RenderHeaderLevel(block, 2)
+}
+
+// RenderSubSubHeader renders BlockSubSubHeader
+func (c *Converter) RenderSubSubHeader(block *notionapi.Block) {
+	c.RenderHeaderLevel(block, 3)
+}
+
+func (c *Converter) listLevel() int {
+	if c.level > maxListLevel {
+		return maxListLevel
+	}
+	return c.level
+}
+
+// renderListItem renders an item of a list with numbering instance numID
+func (c *Converter) renderListItem(block *notionapi.Block, numID int) {
+	c.startParagraph(&paraProps{
+		style: "ListParagraph",
+		numID: numID,
+		ilvl:  c.listLevel(),
+	})
+	c.RenderInlines(block.InlineContent)
+	c.endParagraph()
+	c.renderIndentedChildren(block)
+}
+
+// RenderBulletedList renders BlockBulletedList
+func (c *Converter) RenderBulletedList(block *notionapi.Block) {
+	c.renderListItem(block, bulletNumID)
+}
+
+// RenderNumberedList renders BlockNumberedList. Consecutive blocks are
+// items of the same list
+func (c *Converter) RenderNumberedList(block *notionapi.Block) {
+	numID := 0
+	if c.IsPrevBlockOfType(notionapi.BlockNumberedList) {
+		numID = c.blockNumID[c.PrevBlock().ID]
+	}
+	if numID == 0 {
+		c.numLevels = append(c.numLevels, c.listLevel())
+		// numbering instance 1 is for bullets
+		numID = len(c.numLevels) + bulletNumID
+	}
+	c.blockNumID[block.ID] = numID
+	c.renderListItem(block, numID)
+}
+
+// RenderTodo renders BlockTodo as a paragraph with a check box
+func (c *Converter) RenderTodo(block *notionapi.Block) {
+	c.startParagraph(&paraProps{style: "ListParagraph"})
+	check := "☐ "
+	if block.IsChecked {
+		check = "☒ "
+	}
+	c.writeRun(check, &runProps{})
+	c.RenderInlines(block.InlineContent)
+	c.endParagraph()
+	c.renderIndentedChildren(block)
+}
+
+// RenderToggle renders BlockToggle as a paragraph followed by its children
+func (c *Converter) RenderToggle(block *notionapi.Block) {
+	c.RenderText(block)
+}
+
+// RenderQuote renders BlockQuote
+func (c *Converter) RenderQuote(block *notionapi.Block) {
+	c.RenderParagraph("Quote", block.InlineContent)
+	c.renderIndentedChildren(block)
+}
+
+// RenderCallout renders BlockCallout as a shaded paragraph with a border
+func (c *Converter) RenderCallout(block *notionapi.Block) {
+	col, _ := block.PropAsString("format.block_color")
+	pp := &paraProps{
+		style: "Callout",
+		fill:  cssColorToHex(notionapi.HighlightCSS(col)),
+	}
+	c.startParagraph(pp)
+	icon, _ := block.PropAsString("format.page_icon")
+	if icon != "" && !strings.HasPrefix(icon, "http") {
+		c.writeRun(icon+" ", &runProps{})
+	}
+	c.RenderInlines(block.InlineContent)
+	c.endParagraph()
+	c.renderIndentedChildren(block)
+}
+
+// RenderEquation renders BlockEquation as TeX source
+func (c *Converter) RenderEquation(block *notionapi.Block) {
+	eq := strings.TrimSpace(notionapi.TextSpansToString(block.InlineContent))
+	if eq == "" {
+		return
+	}
+	c.startParagraph(&paraProps{style: "Equation"})
+	c.writeRun(eq, &runProps{})
+	c.endParagraph()
+}
+
+// RenderCode renders BlockCode with Code style
+func (c *Converter) RenderCode(block *notionapi.Block) {
+	c.startParagraph(&paraProps{style: "Code"})
+	c.writeRun(strings.TrimRight(block.Code, "\r\n"), &runProps{})
+	c.endParagraph()
+	c.renderCaption(block)
+}
+
+// RenderDivider renders BlockDivider as an empty paragraph with a border
+func (c *Converter) RenderDivider(block *notionapi.Block) {
+	c.startParagraph(&paraProps{bottomBorder: true})
+	c.endParagraph()
+}
+
+// RenderAlias renders BlockAlias as a link to the page
+func (c *Converter) RenderAlias(block *notionapi.Block) {
+	format := block.FormatAlias()
+	if format == nil || format.Alias == nil {
+		return
+	}
+	id := format.Alias.ID
+	c.renderLink(c.pageURL(id), notionapi.PageTitle(c.Page, c.PageInfoProvider, id))
+}
+
+// RenderLinkToPage renders BlockLinkToPage as a link to the page
+func (c *Converter) RenderLinkToPage(block *notionapi.Block) {
+	id, _ := block.PropAsString("format.alias_pointer.id")
+	if id == "" {
+		return
+	}
+	c.renderLink(c.pageURL(id), notionapi.PageTitle(c.Page, c.PageInfoProvider, id))
+}
+
+// RenderTransclusionReference renders BlockTransclusionReference by
+// rendering content of the referenced block, if we have it
+func (c *Converter) RenderTransclusionReference(block *notionapi.Block) {
+	id, _ := block.PropAsString("format.transclusion_reference_pointer.id")
+	nid := notionapi.NewNotionID(id)
+	if nid == nil {
+		return
+	}
+	ref := c.Page.BlockByID(nid)
+	if ref == nil {
+		return
+	}
+	c.RenderChildren(ref)
+}
+
+func fileName(uri string) string {
+	if idx := strings.IndexAny(uri, "?#"); idx >= 0 {
+		uri = uri[:idx]
+	}
+	return path.Base(uri)
+}
+
+// downloadImage downloads an image with Assets and returns the name of
+// its file in Assets.Dir. Returns "" if it can't be embedded
+func (c *Converter) downloadImage(block *notionapi.Block) string {
+	if c.Assets == nil || !assets.IsFileURL(block.Source) {
+		return ""
+	}
+	local := c.Assets.RewriteFileURL(block.Source, block)
+	if local == block.Source {
+		// failed to download
+		return ""
+	}
+	name := path.Base(local)
+	if imageContentTypes[strings.ToLower(path.Ext(name))] == "" {
+		logf("todocx: not embedding '%s', not a supported image type\n", block.Source)
+		return ""
+	}
+	return name
+}
+
+// imageSize returns size of an image in EMUs, scaled down to fit the page
+func imageSize(d []byte) (int, int, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(d))
+	if err != nil {
+		return 0, 0, err
+	}
+	dx := cfg.Width * emuPerPixel
+	dy := cfg.Height * emuPerPixel
+	if dx > maxImageWidth {
+		dy = int(int64(dy) * maxImageWidth / int64(dx))
+		dx = maxImageWidth
+	}
+	return dx, dy, nil
+}
+
+// writeDrawing writes a run with an inline picture
+func (c *Converter) writeDrawing(relID string, name string, dx, dy int) {
+	c.drawingID++
+	id := c.drawingID
+	fmt.Fprintf(c.Buf, `<w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0">`+
+		`<wp:extent cx="%d" cy="%d"/><wp:docPr id="%d" name="Picture %d"/>`+
+		`<wp:cNvGraphicFramePr><a:graphicFrameLocks noChangeAspect="1"/></wp:cNvGraphicFramePr>`+
+		`<a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture">`+
+		`<pic:pic><pic:nvPicPr><pic:cNvPr id="%d" name="%s"/><pic:cNvPicPr/></pic:nvPicPr>`+
+		`<pic:blipFill><a:blip r:embed="%s"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>`+
+		`<pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr>`+
+		`</pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r>`,
+		dx, dy, id, id, id, escapeXML(name), relID, dx, dy)
+}
+
+// RenderImage renders BlockImage. Images downloaded with Assets are
+// embedded, other are links
+func (c *Converter) RenderImage(block *notionapi.Block) {
+	if name := c.downloadImage(block); name != "" {
+		d, err := os.ReadFile(filepath.Join(c.Assets.Dir, name))
+		var dx, dy int
+		if err == nil {
+			dx, dy, err = imageSize(d)
+		}
+		if err == nil {
+			_, embedded := c.relIDs[name]
+			relID := c.addRel(relTypeImage, name, false)
+			if !embedded {
+				c.images = append(c.images, name)
+			}
+			c.startParagraph(&paraProps{})
+			c.writeDrawing(relID, name, dx, dy)
+			c.endParagraph()
+			c.renderCaption(block)
+			return
+		}
+		logf("todocx: not embedding '%s', error: %s\n", block.Source, err)
+	}
+	c.renderLink(block.Source, fileName(block.Source))
+	c.renderCaption(block)
+}
+
+// RenderFile renders BlockFile, BlockPDF, BlockAudio and BlockVideo as
+// a link
+func (c *Converter) RenderFile(block *notionapi.Block) {
+	name := block.Title
+	if name == "" {
+		name = fileName(block.Source)
+	}
+	c.renderLink(block.Source, name)
+	c.renderCaption(block)
+}
+
+// RenderEmbed renders BlockEmbed and other embeds as a link
+func (c *Converter) RenderEmbed(block *notionapi.Block) {
+	c.renderLink(block.Source, "")
+	c.renderCaption(block)
+}
+
+// RenderBookmark renders BlockBookmark
+func (c *Converter) RenderBookmark(block *notionapi.Block) {
+	c.renderLink(block.Link, notionapi.TextSpansToString(block.InlineContent))
+	c.renderCaption(block)
+}
+
+// RenderDrive renders BlockDrive
+func (c *Converter) RenderDrive(block *notionapi.Block) {
+	docURL, _ := block.PropAsString("format.drive_properties.url")
+	title, _ := block.PropAsString("format.drive_properties.title")
+	c.renderLink(docURL, title)
+}
+
+// RenderColumnList renders BlockColumnList
+// it's children are BlockColumn
+func (c *Converter) RenderColumnList(block *notionapi.Block) {
+	c.RenderChildren(block)
+}
+
+// RenderColumn renders BlockColumn
+// it's parent is BlockColumnList
+func (c *Converter) RenderColumn(block *notionapi.Block) {
+	c.RenderChildren(block)
+}
+
+// DefaultRenderFunc returns a defult rendering function for a type of
+// a given block
+func (c *Converter) DefaultRenderFunc(blockType string) func(*notionapi.Block) {
+	switch blockType {
+	case notionapi.BlockPage:
+		return c.RenderPage
+	case notionapi.BlockText:
+		return c.RenderText
+	case notionapi.BlockEquation:
+		return c.RenderEquation
+	case notionapi.BlockNumberedList:
+		return c.RenderNumberedList
+	case notionapi.BlockBulletedList:
+		return c.RenderBulletedList
+	case notionapi.BlockHeader:
+		return c.RenderHeader
+	case notionapi.BlockSubHeader:
+		return c.RenderSubHeader
+	case notionapi.BlockSubSubHeader:
+		return c.RenderSubSubHeader
+	case notionapi.BlockTodo:
+		return c.RenderTodo
+	case notionapi.BlockToggle:
+		return c.RenderToggle
+	case notionapi.BlockQuote:
+		return c.RenderQuote
+	case notionapi.BlockDivider:
+		return c.RenderDivider
+	case notionapi.BlockCode:
+		return c.RenderCode
+	case notionapi.BlockBookmark:
+		return c.RenderBookmark
+	case notionapi.BlockImage:
+		return c.RenderImage
+	case notionapi.BlockColumnList:
+		return c.RenderColumnList
+	case notionapi.BlockColumn:
+		return c.RenderColumn
+	case notionapi.BlockCollectionView:
+		return c.RenderCollectionView
+	case notionapi.BlockCollectionViewPage:
+		return c.RenderCollectionViewPage
+	case notionapi.BlockEmbed, notionapi.BlockGist, notionapi.BlockMaps,
+		notionapi.BlockCodepen, notionapi.BlockTweet, notionapi.BlockFigma,
+		notionapi.BlockMiro:
+		return c.RenderEmbed
+	case notionapi.BlockVideo, notionapi.BlockAudio, notionapi.BlockFile,
+		notionapi.BlockPDF:
+		return c.RenderFile
+	case notionapi.BlockDrive:
+		return c.RenderDrive
+	case notionapi.BlockCallout:
+		return c.RenderCallout
+	case notionapi.BlockAlias:
+		return c.RenderAlias
+	case notionapi.BlockLinkToPage:
+		return c.RenderLinkToPage
+	case notionapi.BlockTransclusionReference:
+		return c.RenderTransclusionReference
+	case notionapi.BlockTableOfContents, notionapi.BlockBreadcrumb, notionapi.BlockFactory:
+		return nil
+	default:
+		maybePanic("DefaultRenderFunc: unsupported block type '%s' in %s\n", blockType, c.Page.NotionURL())
+	}
+	return nil
+}
+
+func (c *Converter) skipChildren(block *notionapi.Block) bool {
+	if len(block.Content) == 0 {
+		return true
+	}
+	if block.Type == notionapi.BlockPage {
+		// we don't want to render content of links to pages
+		return !c.Page.IsRoot(block)
+	}
+	return false
+}
+
+// RenderChildren renders children of the block
+func (c *Converter) RenderChildren(block *notionapi.Block) {
+	if c.skipChildren(block) {
+		return
+	}
+	currIdx := c.CurrBlockIdx
+	currBlocks := c.CurrBlocks
+	c.CurrBlocks = block.Content
+	for i, child := range block.Content {
+		c.CurrBlockIdx = i
+		c.RenderBlock(child)
+	}
+	c.CurrBlockIdx = currIdx
+	c.CurrBlocks = currBlocks
+}
+
+// RenderBlock renders a block
+func (c *Converter) RenderBlock(block *notionapi.Block) {
+	if block == nil {
+		// a missing block, can happen if we don't have access to a referenced block
+		return
+	}
+	if c.RenderBlockOverride != nil && c.RenderBlockOverride(block) {
+		return
+	}
+	def := c.DefaultRenderFunc(block.Type)
+	if def != nil {
+		def(block)
+	}
+}
+
+func (c *Converter) title() string {
+	root := c.Page.Root()
+	if root.Type == notionapi.BlockCollectionViewPage {
+		return c.Page.CollectionName(root)
+	}
+	return root.Title
+}
+
+// Write writes the document as .docx to w
+func (c *Converter) Write(w io.Writer) error {
+	c.Buf = &bytes.Buffer{}
+	c.level = 0
+	c.rels = nil
+	c.relIDs = map[string]string{}
+	c.images = nil
+	c.numLevels = nil
+	c.blockNumID = map[string]int{}
+	c.drawingID = 0
+	c.RenderBlock(c.Page.Root())
+
+	var modified time.Time
+	if root := c.Page.Root(); root.LastEditedTime > 0 {
+		modified = root.LastEditedOn()
+	}
+	return c.writePackage(w, modified)
+}
+
+// ToDOCX returns the document as .docx
+func (c *Converter) ToDOCX() ([]byte, error) {
+	var buf bytes.Buffer
+	err := c.Write(&buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ToDOCX converts a page to a Word document
+func ToDOCX(page *notionapi.Page) ([]byte, error) {
+	c := NewConverter(page)
+	return c.ToDOCX()
+}
@@ -0,0 +1,132 @@
+package todocx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/kjk/common/assert"
+	"github.com/kjk/notionapi"
+	"github.com/kjk/notionapi/assets"
+	"github.com/kjk/notionapi/internal/testutil"
+)
+
+func readZip(t *testing.T, d []byte) map[string]string {
+	zr, err := zip.NewReader(bytes.NewReader(d), int64(len(d)))
+	assert.NoError(t, err)
+	files := map[string]string{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		assert.NoError(t, err)
+		d, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		files[f.Name] = string(d)
+	}
+	return files
+}
+
+func isWellFormed(s string) error {
+	dec := xml.NewDecoder(strings.NewReader(s))
+	for {
+		_, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func TestToDOCX(t *testing.T) {
+	page := testutil.LoadPage(t, "page.json")
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 20, 10)))
+	assert.NoError(t, err)
+
+	c := NewConverter(page)
+	c.Author = "Jane Doe"
+	d := &testutil.Downloader{
+		Files: map[string][]byte{"https://example.com/chart.png": buf.Bytes()},
+	}
+	c.Assets = assets.New(d, t.TempDir())
+	docx, err := c.ToDOCX()
+	assert.NoError(t, err)
+
+	files := readZip(t, docx)
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "docProps/core.xml", "word/document.xml", "word/styles.xml", "word/numbering.xml", "word/_rels/document.xml.rels"} {
+		s, ok := files[name]
+		assert.True(t, ok, "missing %s", name)
+		assert.NoError(t, isWellFormed(s), "%s is not well-formed:\n%s", name, s)
+	}
+
+	doc := files["word/document.xml"]
+	exp := []string{
+		`<w:pPr><w:pStyle w:val="Title"/></w:pPr><w:r><w:t xml:space="preserve">Report &lt;Q1&gt; &amp; Notes</w:t></w:r>`,
+		`<w:pStyle w:val="Heading1"/>`,
+		`<w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">x_1</w:t></w:r>`,
+		`<w:rPr><w:strike/><w:shd w:val="clear" w:color="auto" w:fill="FBF3DB"/></w:rPr>`,
+		`<w:hyperlink r:id="rId3" w:history="1"><w:r><w:rPr><w:rStyle w:val="Hyperlink"/></w:rPr><w:t xml:space="preserve">docs</w:t></w:r></w:hyperlink>`,
+		// a numbered list nested in a bulleted list starts at 1
+		`<w:numPr><w:ilvl w:val="1"/><w:numId w:val="2"/></w:numPr></w:pPr><w:r><w:t xml:space="preserve">build</w:t>`,
+		// items of the first list share numbering, nested list is one level deeper
+		`<w:numPr><w:ilvl w:val="0"/><w:numId w:val="3"/></w:numPr></w:pPr><w:r><w:t xml:space="preserve">first</w:t>`,
+		`<w:numPr><w:ilvl w:val="0"/><w:numId w:val="3"/></w:numPr></w:pPr><w:r><w:t xml:space="preserve">second</w:t>`,
+		`<w:numPr><w:ilvl w:val="1"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t xml:space="preserve">nested</w:t>`,
+		`<w:numPr><w:ilvl w:val="0"/><w:numId w:val="4"/></w:numPr></w:pPr><w:r><w:t xml:space="preserve">restarted</w:t>`,
+		`<w:pStyle w:val="Code"/></w:pPr><w:r><w:t xml:space="preserve">* not a headline</w:t><w:br/><w:t xml:space="preserve">if a &lt; b:</w:t><w:br/><w:tab/><w:t xml:space="preserve">print(&#34;%d&#34; % 5)</w:t></w:r>`,
+		`<wp:extent cx="190500" cy="95250"/>`,
+		`<w:t xml:space="preserve">missing.png</w:t>`,
+		`<w:t xml:space="preserve">Write tests</w:t></w:r></w:hyperlink></w:p></w:tc><w:tc><w:p><w:r><w:t xml:space="preserve">☒</w:t>`,
+		`<w:t xml:space="preserve">@&lt;img src=x onerror=alert(1)&gt;</w:t>`,
+	}
+	for _, e := range exp {
+		assert.True(t, strings.Contains(doc, e), "expected '%s' in:\n%s", e, doc)
+	}
+
+	rels := files["word/_rels/document.xml.rels"]
+	assert.True(t, strings.Contains(rels, `Target="https://example.com/?a=1&amp;b=2#top" TargetMode="External"`), "got:\n%s", rels)
+	idx := strings.Index(doc, `<a:blip r:embed="`)
+	assert.True(t, idx > 0)
+	relID := strings.Split(doc[idx+len(`<a:blip r:embed="`):], `"`)[0]
+	assert.True(t, strings.Contains(rels, `Id="`+relID+`" Type="`+relTypeImage+`" Target="media/`), "got:\n%s", rels)
+	var media string
+	for name := range files {
+		if strings.HasPrefix(name, "word/media/") {
+			media = name
+		}
+	}
+	assert.Equal(t, buf.String(), files[media])
+	assert.True(t, strings.Contains(files["[Content_Types].xml"], `<Default Extension="png" ContentType="image/png"/>`))
+
+	numbering := files["word/numbering.xml"]
+	assert.True(t, strings.Contains(numbering, `<w:num w:numId="4"><w:abstractNumId w:val="1"/><w:lvlOverride w:ilvl="0"><w:startOverride w:val="1"/>`), "got:\n%s", numbering)
+	assert.True(t, strings.Contains(files["docProps/core.xml"], "<dc:creator>Jane Doe</dc:creator>"))
+}
+
+func TestLinkToPageAndMiro(t *testing.T) {
+	notionapi.PanicOnFailures = true
+	defer func() {
+		notionapi.PanicOnFailures = false
+	}()
+	page := testutil.LoadPage(t, "links.json")
+	c := NewConverter(page)
+	docx, err := c.ToDOCX()
+	assert.NoError(t, err)
+	files := readZip(t, docx)
+	s := files["word/_rels/document.xml.rels"]
+	exp := []string{
+		`Target="https://www.notion.so/6a5f1c9b1b6d4f808fcd8b6e5d7c9a0f"`,
+		`Target="https://miro.com/app/board/o9J_abc=/"`,
+	}
+	for _, e := range exp {
+		assert.True(t, strings.Contains(s, e), "expected '%s' in:\n%s", e, s)
+	}
+	assert.True(t, strings.Contains(files["word/document.xml"], "<w:t xml:space=\"preserve\">Appendix</w:t>"), "got:\n%s", files["word/document.xml"])
+}
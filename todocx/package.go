@@ -0,0 +1,297 @@
+package todocx
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	nsW   = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	nsRel = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+	relTypeOfficeDocument = nsRel + "/officeDocument"
+	relTypeStyles         = nsRel + "/styles"
+	relTypeNumbering      = nsRel + "/numbering"
+	relTypeHyperlink      = nsRel + "/hyperlink"
+	relTypeImage          = nsRel + "/image"
+	relTypeCoreProperties = "http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties"
+
+	// directory, relative to word/, with images
+	mediaDir = "media"
+
+	// numbering instance for bulleted lists
+	bulletNumID = 1
+)
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+type relationship struct {
+	ID       string
+	Type     string
+	Target   string
+	External bool
+}
+
+func relationshipsXML(rels []*relationship) []byte {
+	var w bytes.Buffer
+	w.WriteString(xmlHeader)
+	w.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` + "\n")
+	for _, rel := range rels {
+		mode := ""
+		if rel.External {
+			mode = ` TargetMode="External"`
+		}
+		fmt.Fprintf(&w, `  <Relationship Id="%s" Type="%s" Target="%s"%s/>`+"\n", rel.ID, rel.Type, escapeXML(rel.Target), mode)
+	}
+	w.WriteString("</Relationships>\n")
+	return w.Bytes()
+}
+
+func (c *Converter) contentTypesXML() []byte {
+	exts := map[string]bool{}
+	for _, name := range c.images {
+		exts[strings.ToLower(path.Ext(name))] = true
+	}
+	var sorted []string
+	for ext := range exts {
+		sorted = append(sorted, ext)
+	}
+	sort.Strings(sorted)
+
+	var w bytes.Buffer
+	w.WriteString(xmlHeader)
+	w.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+  <Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+  <Default Extension="xml" ContentType="application/xml"/>
+`)
+	for _, ext := range sorted {
+		fmt.Fprintf(&w, `  <Default Extension="%s" ContentType="%s"/>`+"\n", ext[1:], imageContentTypes[ext])
+	}
+	w.WriteString(`  <Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+  <Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+  <Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>
+  <Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>
+</Types>
+`)
+	return w.Bytes()
+}
+
+func (c *Converter) coreXML(modified time.Time) []byte {
+	var w bytes.Buffer
+	w.WriteString(xmlHeader)
+	w.WriteString(`<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` + "\n")
+	fmt.Fprintf(&w, "  <dc:title>%s</dc:title>\n", escapeXML(c.title()))
+	if c.Author != "" {
+		fmt.Fprintf(&w, "  <dc:creator>%s</dc:creator>\n", escapeXML(c.Author))
+	}
+	if !modified.IsZero() {
+		fmt.Fprintf(&w, "  <dcterms:modified xsi:type=\"dcterms:W3CDTF\">%s</dcterms:modified>\n", modified.UTC().Format(time.RFC3339))
+	}
+	w.WriteString("</cp:coreProperties>\n")
+	return w.Bytes()
+}
+
+func (c *Converter) documentXML() []byte {
+	var w bytes.Buffer
+	w.WriteString(xmlHeader)
+	w.WriteString(`<w:document xmlns:w="` + nsW + `" xmlns:r="` + nsRel + `"` +
+		` xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"` +
+		` xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"` +
+		` xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture">` + "\n")
+	w.WriteString("<w:body>\n")
+	body := c.Buf.Bytes()
+	w.Write(body)
+	if len(body) == 0 || bytes.HasSuffix(body, []byte("</w:tbl>\n")) {
+		// Word expects a paragraph at the end of the body
+		w.WriteString("<w:p/>\n")
+	}
+	// letter page with 1 inch margins
+	w.WriteString(`<w:sectPr><w:pgSz w:w="12240" w:h="15840"/><w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="720" w:footer="720" w:gutter="0"/></w:sectPr>` + "\n")
+	w.WriteString("</w:body>\n</w:document>\n")
+	return w.Bytes()
+}
+
+const stylesXML = xmlHeader + `<w:styles xmlns:w="` + nsW + `">
+  <w:docDefaults>
+    <w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:eastAsia="Calibri" w:hAnsi="Calibri" w:cs="Calibri"/><w:sz w:val="22"/><w:szCs w:val="22"/><w:lang w:val="en-US"/></w:rPr></w:rPrDefault>
+    <w:pPrDefault><w:pPr><w:spacing w:after="120" w:line="264" w:lineRule="auto"/></w:pPr></w:pPrDefault>
+  </w:docDefaults>
+  <w:style w:type="paragraph" w:default="1" w:styleId="Normal">
+    <w:name w:val="Normal"/><w:qFormat/>
+  </w:style>
+  <w:style w:type="paragraph" w:styleId="Title">
+    <w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/>
+    <w:pPr><w:spacing w:after="240"/></w:pPr>
+    <w:rPr><w:b/><w:sz w:val="48"/><w:szCs w:val="48"/></w:rPr>
+  </w:style>
+  <w:style w:type="paragraph" w:styleId="Heading1">
+    <w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/>
+    <w:pPr><w:keepNext/><w:spacing w:before="360" w:after="120"/><w:outlineLvl w:val="0"/></w:pPr>
+    <w:rPr><w:b/><w:sz w:val="36"/><w:szCs w:val="36"/></w:rPr>
+  </w:style>
+  <w:style w:type="paragraph" w:styleId="Heading2">
+    <w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/>
+    <w:pPr><w:keepNext/><w:spacing w:before="280" w:after="120"/><w:outlineLvl w:val="1"/></w:pPr>
+    <w:rPr><w:b/><w:sz w:val="30"/><w:szCs w:val="30"/></w:rPr>
+  </w:style>
+  <w:style w:type="paragraph" w:styleId="Heading3">
+    <w:name w:val="heading 3"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/>
+    <w:pPr><w:keepNext/><w:spacing w:before="240" w:after="120"/><w:outlineLvl w:val="2"/></w:pPr>
+    <w:rPr><w:b/><w:sz w:val="26"/><w:szCs w:val="26"/></w:rPr>
+  </w:style>
+  <w:style w:type="paragraph" w:styleId="ListParagraph">
+    <w:name w:val="List Paragraph"/><w:basedOn w:val="Normal"/><w:qFormat/>
+    <w:pPr><w:spacing w:after="60"/></w:pPr>
+  </w:style>
+  <w:style w:type="paragraph" w:styleId="Quote">
+    <w:name w:val="Quote"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/>
+    <w:pPr><w:pBdr><w:left w:val="single" w:sz="18" w:space="8" w:color="auto"/></w:pBdr><w:ind w:left="240"/></w:pPr>
+  </w:style>
+  <w:style w:type="paragraph" w:styleId="Callout">
+    <w:name w:val="Callout"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/>
+    <w:pPr><w:pBdr><w:top w:val="single" w:sz="4" w:space="4" w:color="E0E0E0"/><w:left w:val="single" w:sz="4" w:space="4" w:color="E0E0E0"/><w:bottom w:val="single" w:sz="4" w:space="4" w:color="E0E0E0"/><w:right w:val="single" w:sz="4" w:space="4" w:color="E0E0E0"/></w:pBdr><w:shd w:val="clear" w:color="auto" w:fill="F1F1EF"/><w:ind w:left="120" w:right="120"/></w:pPr>
+  </w:style>
+  <w:style w:type="paragraph" w:styleId="Code">
+    <w:name w:val="Code"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/>
+    <w:pPr><w:shd w:val="clear" w:color="auto" w:fill="F7F6F3"/><w:spacing w:line="240" w:lineRule="auto"/></w:pPr>
+    <w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/><w:noProof/><w:sz w:val="20"/><w:szCs w:val="20"/></w:rPr>
+  </w:style>
+  <w:style w:type="paragraph" w:styleId="Equation">
+    <w:name w:val="Equation"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/>
+    <w:pPr><w:jc w:val="center"/></w:pPr>
+    <w:rPr><w:rFonts w:ascii="Cambria Math" w:hAnsi="Cambria Math"/><w:noProof/></w:rPr>
+  </w:style>
+  <w:style w:type="paragraph" w:styleId="Caption">
+    <w:name w:val="caption"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/>
+    <w:rPr><w:i/><w:color w:val="595959"/><w:sz w:val="18"/><w:szCs w:val="18"/></w:rPr>
+  </w:style>
+  <w:style w:type="character" w:default="1" w:styleId="DefaultParagraphFont">
+    <w:name w:val="Default Paragraph Font"/><w:uiPriority w:val="1"/><w:semiHidden/><w:unhideWhenUsed/>
+  </w:style>
+  <w:style w:type="character" w:styleId="Hyperlink">
+    <w:name w:val="Hyperlink"/><w:basedOn w:val="DefaultParagraphFont"/>
+    <w:rPr><w:color w:val="0563C1"/><w:u w:val="single"/></w:rPr>
+  </w:style>
+  <w:style w:type="character" w:styleId="CodeChar">
+    <w:name w:val="Code Char"/><w:basedOn w:val="DefaultParagraphFont"/>
+    <w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/><w:noProof/><w:color w:val="EB5757"/><w:sz w:val="20"/><w:szCs w:val="20"/><w:shd w:val="clear" w:color="auto" w:fill="F2F2F2"/></w:rPr>
+  </w:style>
+  <w:style w:type="character" w:styleId="EquationChar">
+    <w:name w:val="Equation Char"/><w:basedOn w:val="DefaultParagraphFont"/>
+    <w:rPr><w:rFonts w:ascii="Cambria Math" w:hAnsi="Cambria Math"/><w:noProof/></w:rPr>
+  </w:style>
+  <w:style w:type="table" w:default="1" w:styleId="TableNormal">
+    <w:name w:val="Normal Table"/><w:uiPriority w:val="99"/><w:semiHidden/><w:unhideWhenUsed/>
+    <w:tblPr><w:tblInd w:w="0" w:type="dxa"/><w:tblCellMar><w:top w:w="0" w:type="dxa"/><w:left w:w="108" w:type="dxa"/><w:bottom w:w="0" w:type="dxa"/><w:right w:w="108" w:type="dxa"/></w:tblCellMar></w:tblPr>
+  </w:style>
+  <w:style w:type="table" w:styleId="TableGrid">
+    <w:name w:val="Table Grid"/><w:basedOn w:val="TableNormal"/>
+    <w:pPr><w:spacing w:before="40" w:after="40" w:line="240" w:lineRule="auto"/></w:pPr>
+    <w:tblPr><w:tblBorders><w:top w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:left w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:bottom w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:right w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:insideH w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/></w:tblBorders></w:tblPr>
+  </w:style>
+</w:styles>
+`
+
+var (
+	bulletTexts   = []string{"•", "◦", "▪"}
+	numberFormats = []string{"decimal", "lowerLetter", "lowerRoman"}
+)
+
+// writeAbstractNum writes a definition of a list with levels 0 to
+// maxListLevel
+func writeAbstractNum(w *bytes.Buffer, id int, bullets bool) {
+	fmt.Fprintf(w, `  <w:abstractNum w:abstractNumId="%d"><w:multiLevelType w:val="hybridMultilevel"/>`+"\n", id)
+	for lvl := 0; lvl <= maxListLevel; lvl++ {
+		numFmt := numberFormats[lvl%len(numberFormats)]
+		text := fmt.Sprintf("%%%d.", lvl+1)
+		if bullets {
+			numFmt = "bullet"
+			text = bulletTexts[lvl%len(bulletTexts)]
+		}
+		fmt.Fprintf(w, `    <w:lvl w:ilvl="%d"><w:start w:val="1"/><w:numFmt w:val="%s"/><w:lvlText w:val="%s"/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="%d" w:hanging="360"/></w:pPr></w:lvl>`+"\n",
+			lvl, numFmt, text, (lvl+1)*indentStep)
+	}
+	w.WriteString("  </w:abstractNum>\n")
+}
+
+// numberingXML returns definitions of lists. Numbering instance
+// bulletNumID is for all bulleted lists, each numbered list has its own
+// instance that restarts numbering at its level
+func (c *Converter) numberingXML() []byte {
+	var w bytes.Buffer
+	w.WriteString(xmlHeader)
+	w.WriteString(`<w:numbering xmlns:w="` + nsW + `">` + "\n")
+	writeAbstractNum(&w, 0, true)
+	writeAbstractNum(&w, 1, false)
+	fmt.Fprintf(&w, `  <w:num w:numId="%d"><w:abstractNumId w:val="0"/></w:num>`+"\n", bulletNumID)
+	for i, lvl := range c.numLevels {
+		fmt.Fprintf(&w, `  <w:num w:numId="%d"><w:abstractNumId w:val="1"/><w:lvlOverride w:ilvl="%d"><w:startOverride w:val="1"/></w:lvlOverride></w:num>`+"\n",
+			i+1+bulletNumID, lvl)
+	}
+	w.WriteString("</w:numbering>\n")
+	return w.Bytes()
+}
+
+func addFile(zw *zip.Writer, name string, d []byte) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(d)
+	return err
+}
+
+// writePackage writes rendered document and other parts of .docx to w
+func (c *Converter) writePackage(w io.Writer, modified time.Time) error {
+	pkgRels := []*relationship{
+		{ID: "rId1", Type: relTypeOfficeDocument, Target: "word/document.xml"},
+		{ID: "rId2", Type: relTypeCoreProperties, Target: "docProps/core.xml"},
+	}
+	docRels := []*relationship{
+		{ID: "rId1", Type: relTypeStyles, Target: "styles.xml"},
+		{ID: "rId2", Type: relTypeNumbering, Target: "numbering.xml"},
+	}
+	for _, rel := range c.rels {
+		if rel.Type == relTypeImage {
+			rel = &relationship{ID: rel.ID, Type: rel.Type, Target: mediaDir + "/" + rel.Target}
+		}
+		docRels = append(docRels, rel)
+	}
+
+	zw := zip.NewWriter(w)
+	parts := []struct {
+		name string
+		d    []byte
+	}{
+		{"[Content_Types].xml", c.contentTypesXML()},
+		{"_rels/.rels", relationshipsXML(pkgRels)},
+		{"docProps/core.xml", c.coreXML(modified)},
+		{"word/document.xml", c.documentXML()},
+		{"word/styles.xml", []byte(stylesXML)},
+		{"word/numbering.xml", c.numberingXML()},
+		{"word/_rels/document.xml.rels", relationshipsXML(docRels)},
+	}
+	for _, part := range parts {
+		if err := addFile(zw, part.name, part.d); err != nil {
+			return err
+		}
+	}
+	for _, name := range c.images {
+		d, err := os.ReadFile(filepath.Join(c.Assets.Dir, name))
+		if err != nil {
+			return err
+		}
+		if err = addFile(zw, "word/"+mediaDir+"/"+name, d); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,478 @@
+// Package toepub converts a Notion page and its sub-pages to an EPUB 3
+// book. Each page is a chapter, rendered with tohtml in XHTML mode. The
+// table of contents is built from the hierarchy of pages and their headers.
+package toepub
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kjk/notionapi"
+	"github.com/kjk/notionapi/assets"
+	"github.com/kjk/notionapi/equation"
+	"github.com/kjk/notionapi/tohtml"
+)
+
+const (
+	// directory in the archive with content of the book
+	contentDir = "OEBPS"
+	// directory, relative to contentDir, with images
+	imagesDir = "images"
+	cssFile   = "style.css"
+	navFile   = "nav.xhtml"
+)
+
+// core media types of images in EPUB 3, by file extension. Images of
+// other types are not included in the book
+var imageMediaTypes = map[string]string{
+	".gif":  "image/gif",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".svg":  "image/svg+xml",
+	".webp": "image/webp",
+}
+
+func logf(format string, args ...interface{}) {
+	notionapi.Logf(format, args...)
+}
+
+// Converter converts a page and its sub-pages to EPUB
+type Converter struct {
+	// Root is the first page of the book
+	Root *notionapi.Page
+	// Pages are sub-pages of Root (e.g. from DownloadPagesRecursively).
+	// It can include Root. In the book, sub-pages follow their parent
+	// page, in order of their links in the parent
+	Pages []*notionapi.Page
+
+	// Title of the book. If empty, title of Root is used
+	Title   string
+	Authors []string
+	// Language of the book as BCP 47 tag, "en" if empty
+	Language string
+	// Identifier is a unique id of the book. If empty, it's
+	// "urn:uuid:" + id of Root
+	Identifier string
+	// Modified is the time the book was modified. If zero, it's the time
+	// of the most recent edit of a page
+	Modified time.Time
+
+	// Assets, if set, downloads images (including page covers and icons)
+	// to include them in the book. Otherwise images link to their urls
+	// which e-readers usually don't show
+	Assets *assets.Bundler
+
+	// CSS of pages. If empty, tohtml.CSS and tohtml.CSSPlus are used
+	CSS string
+
+	// NewHTMLConverter, if set, returns a tohtml.Converter for a page
+	// e.g. to set CodeHighlighter. Fields needed for EPUB (XHTML, FullHTML,
+	// RewriteURL, RewriteFileURL etc.) are over-written
+	NewHTMLConverter func(page *notionapi.Page) *tohtml.Converter
+
+	// pages in reading order
+	pages    []*notionapi.Page
+	idToPage map[string]*notionapi.Page
+	// maps no-dash page id to its file, relative to contentDir
+	idToFile map[string]string
+	// maps name of an image file to its media type
+	images map[string]string
+}
+
+// NewConverter returns a Converter for a book with root page and its
+// sub-pages
+func NewConverter(root *notionapi.Page, pages []*notionapi.Page) *Converter {
+	return &Converter{
+		Root:  root,
+		Pages: pages,
+	}
+}
+
+func pageTitle(page *notionapi.Page) string {
+	title := strings.TrimSpace(page.Root().Title)
+	if title == "" {
+		return "Untitled"
+	}
+	return title
+}
+
+func pageFileName(page *notionapi.Page) string {
+	return "page-" + notionapi.ToNoDashID(page.ID) + ".xhtml"
+}
+
+// orderPages orders pages for reading: depth-first, in order of links
+// to sub-pages. Pages not reachable from the root are last
+func (c *Converter) orderPages() {
+	c.idToPage = map[string]*notionapi.Page{}
+	for _, page := range append([]*notionapi.Page{c.Root}, c.Pages...) {
+		c.idToPage[notionapi.ToNoDashID(page.ID)] = page
+	}
+	c.pages = nil
+	seen := map[string]bool{}
+	var visit func(page *notionapi.Page)
+	visit = func(page *notionapi.Page) {
+		id := notionapi.ToNoDashID(page.ID)
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+		c.pages = append(c.pages, page)
+		for _, subID := range subPageIDs(page) {
+			if sub := c.idToPage[subID]; sub != nil {
+				visit(sub)
+			}
+		}
+	}
+	visit(c.Root)
+	for _, page := range c.Pages {
+		visit(page)
+	}
+
+	c.idToFile = map[string]string{}
+	for _, page := range c.pages {
+		c.idToFile[notionapi.ToNoDashID(page.ID)] = pageFileName(page)
+	}
+}
+
+func isNotionURL(uri string) bool {
+	if strings.HasPrefix(uri, "/") {
+		return true
+	}
+	return strings.Contains(uri, "notion.so/") || strings.Contains(uri, "notion.site/")
+}
+
+// rewriteURL converts links to pages in the book to links to chapters
+func (c *Converter) rewriteURL(uri string) string {
+	if !isNotionURL(uri) {
+		return uri
+	}
+	id := notionapi.ExtractNoDashIDFromNotionURL(uri)
+	res, ok := c.idToFile[id]
+	if !ok {
+		return uri
+	}
+	if idx := strings.Index(uri, "#"); idx != -1 {
+		// links to blocks have no-dash ids but ids of elements are
+		// dashed and changed by XHTML mode
+		frag := uri[idx+1:]
+		if notionapi.IsValidNoDashID(frag) {
+			frag = notionapi.ToDashID(frag)
+		}
+		res += "#" + tohtml.XHTMLID(frag)
+	}
+	return res
+}
+
+func (c *Converter) tableTitleCellURL(tv *notionapi.TableView, row, col int) string {
+	id := notionapi.ToNoDashID(tv.Rows[row].Page.ID)
+	if res, ok := c.idToFile[id]; ok {
+		return res
+	}
+	return "https://www.notion.so/" + id
+}
+
+// rewriteFileURL includes images in the book. Other files (attachments,
+// pdfs, audio and video) are linked to
+func (c *Converter) rewriteFileURL(uri string, block *notionapi.Block) string {
+	if block != nil && uri == block.Source {
+		switch block.Type {
+		case notionapi.BlockFile, notionapi.BlockPDF, notionapi.BlockAudio, notionapi.BlockVideo:
+			return uri
+		}
+	}
+	if c.Assets == nil || !assets.IsFileURL(uri) {
+		return uri
+	}
+	local := c.Assets.RewriteFileURL(uri, block)
+	if local == uri {
+		// failed to download
+		return uri
+	}
+	name := path.Base(local)
+	mediaType := imageMediaTypes[strings.ToLower(path.Ext(name))]
+	if mediaType == "" {
+		logf("toepub: not including '%s', not a supported image type\n", uri)
+		return uri
+	}
+	c.images[name] = mediaType
+	return imagesDir + "/" + name
+}
+
+func (c *Converter) newHTMLConverter(page *notionapi.Page) *tohtml.Converter {
+	var hc *tohtml.Converter
+	if c.NewHTMLConverter != nil {
+		hc = c.NewHTMLConverter(page)
+	} else {
+		hc = tohtml.NewConverter(page)
+	}
+	hc.XHTML = true
+	hc.FullHTML = false
+	hc.NotionCompat = false
+	// srcset would reference images that are not in the book
+	hc.ResponsiveImages = false
+	hc.RewriteURL = c.rewriteURL
+	hc.RewriteFileURL = c.rewriteFileURL
+	hc.TableTitleCellURLOverride = c.tableTitleCellURL
+	hc.PageByIDProvider = tohtml.NewPageByIDFromPages(c.pages)
+	if hc.EquationRenderer == nil && !hc.UseKatexToRenderEquation {
+		// e-readers support MathML
+		hc.EquationRenderer = &equation.MathML{}
+	}
+	return hc
+}
+
+// renderPage returns a chapter for a page
+func (c *Converter) renderPage(page *notionapi.Page) ([]byte, error) {
+	body, err := c.newHTMLConverter(page).ToHTML()
+	if err != nil {
+		return nil, fmt.Errorf("failed to render page '%s'. Error: %s", page.ID, err)
+	}
+	var buf bytes.Buffer
+	c.writeXHTMLHeader(&buf, pageTitle(page))
+	buf.Write(body)
+	buf.WriteString("\n</body>\n</html>\n")
+	return buf.Bytes(), nil
+}
+
+func (c *Converter) language() string {
+	if c.Language == "" {
+		return "en"
+	}
+	return c.Language
+}
+
+func (c *Converter) writeXHTMLHeader(w *bytes.Buffer, title string) {
+	esc := tohtml.EscapeHTML
+	w.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<!DOCTYPE html>\n")
+	fmt.Fprintf(w, `<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="%s" lang="%s">`, esc(c.language()), esc(c.language()))
+	w.WriteString("\n<head>\n<meta charset=\"utf-8\"/>\n")
+	fmt.Fprintf(w, "<title>%s</title>\n", esc(title))
+	fmt.Fprintf(w, "<link rel=\"stylesheet\" type=\"text/css\" href=\"%s\"/>\n", cssFile)
+	w.WriteString("</head>\n<body>\n")
+}
+
+func (c *Converter) title() string {
+	if c.Title != "" {
+		return c.Title
+	}
+	return pageTitle(c.Root)
+}
+
+func (c *Converter) identifier() string {
+	if c.Identifier != "" {
+		return c.Identifier
+	}
+	return "urn:uuid:" + notionapi.ToDashID(c.Root.ID)
+}
+
+func (c *Converter) modified() time.Time {
+	if !c.Modified.IsZero() {
+		return c.Modified
+	}
+	var res time.Time
+	for _, page := range c.pages {
+		if t := page.Root().LastEditedOn(); t.After(res) {
+			res = t
+		}
+	}
+	if res.Unix() <= 0 {
+		return time.Now()
+	}
+	return res
+}
+
+// coverImage returns name of an image file of the cover of Root, if
+// it's included in the book
+func (c *Converter) coverImage() string {
+	cover, _ := c.Root.Root().PropAsString("format.page_cover")
+	if cover == "" || c.Assets == nil {
+		return ""
+	}
+	uri := c.rewriteFileURL(cover, c.Root.Root())
+	if !strings.HasPrefix(uri, imagesDir+"/") {
+		return ""
+	}
+	return strings.TrimPrefix(uri, imagesDir+"/")
+}
+
+// manifestProperties returns properties of a chapter in the manifest
+func manifestProperties(d []byte) string {
+	var props []string
+	if bytes.Contains(d, []byte("<math")) {
+		props = append(props, "mathml")
+	}
+	if bytes.Contains(d, []byte("<svg")) {
+		props = append(props, "svg")
+	}
+	// e.g. images that failed to download
+	if bytes.Contains(d, []byte(`src="http`)) {
+		props = append(props, "remote-resources")
+	}
+	return strings.Join(props, " ")
+}
+
+const containerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+type manifestItem struct {
+	ID         string
+	Href       string
+	MediaType  string
+	Properties string
+}
+
+func (c *Converter) contentOPF(items []*manifestItem, spine []string) []byte {
+	esc := tohtml.EscapeHTML
+	var w bytes.Buffer
+	w.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(&w, `<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="pub-id" xml:lang="%s">`+"\n", esc(c.language()))
+	w.WriteString("  <metadata xmlns:dc=\"http://purl.org/dc/elements/1.1/\">\n")
+	fmt.Fprintf(&w, "    <dc:identifier id=\"pub-id\">%s</dc:identifier>\n", esc(c.identifier()))
+	fmt.Fprintf(&w, "    <dc:title>%s</dc:title>\n", esc(c.title()))
+	fmt.Fprintf(&w, "    <dc:language>%s</dc:language>\n", esc(c.language()))
+	for _, author := range c.Authors {
+		fmt.Fprintf(&w, "    <dc:creator>%s</dc:creator>\n", esc(author))
+	}
+	fmt.Fprintf(&w, "    <meta property=\"dcterms:modified\">%s</meta>\n", c.modified().UTC().Format("2006-01-02T15:04:05Z"))
+	w.WriteString("  </metadata>\n  <manifest>\n")
+	for _, it := range items {
+		props := ""
+		if it.Properties != "" {
+			props = fmt.Sprintf(` properties="%s"`, it.Properties)
+		}
+		fmt.Fprintf(&w, "    <item id=\"%s\" href=\"%s\" media-type=\"%s\"%s/>\n", it.ID, esc(it.Href), it.MediaType, props)
+	}
+	w.WriteString("  </manifest>\n  <spine>\n")
+	for _, id := range spine {
+		fmt.Fprintf(&w, "    <itemref idref=\"%s\"/>\n", id)
+	}
+	w.WriteString("  </spine>\n</package>\n")
+	return w.Bytes()
+}
+
+func addFile(zw *zip.Writer, name string, d []byte) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(d)
+	return err
+}
+
+// Write writes the book as EPUB to w
+func (c *Converter) Write(w io.Writer) error {
+	if c.Root == nil {
+		return fmt.Errorf("toepub: Root page is not set")
+	}
+	c.orderPages()
+	c.images = map[string]string{}
+
+	var chapters [][]byte
+	for _, page := range c.pages {
+		d, err := c.renderPage(page)
+		if err != nil {
+			return err
+		}
+		chapters = append(chapters, d)
+	}
+	cover := c.coverImage()
+
+	zw := zip.NewWriter(w)
+	// mimetype must be the first file and can't be compressed
+	f, err := zw.CreateHeader(&zip.FileHeader{
+		Name:   "mimetype",
+		Method: zip.Store,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write([]byte("application/epub+zip")); err != nil {
+		return err
+	}
+	if err = addFile(zw, "META-INF/container.xml", []byte(containerXML)); err != nil {
+		return err
+	}
+
+	items := []*manifestItem{
+		{ID: "nav", Href: navFile, MediaType: "application/xhtml+xml", Properties: "nav"},
+		{ID: "css", Href: cssFile, MediaType: "text/css"},
+	}
+	var spine []string
+	for i, page := range c.pages {
+		id := fmt.Sprintf("page-%d", i+1)
+		name := c.idToFile[notionapi.ToNoDashID(page.ID)]
+		items = append(items, &manifestItem{
+			ID:         id,
+			Href:       name,
+			MediaType:  "application/xhtml+xml",
+			Properties: manifestProperties(chapters[i]),
+		})
+		spine = append(spine, id)
+		if err = addFile(zw, contentDir+"/"+name, chapters[i]); err != nil {
+			return err
+		}
+	}
+
+	var names []string
+	for name := range c.images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		d, err := os.ReadFile(filepath.Join(c.Assets.Dir, name))
+		if err != nil {
+			return err
+		}
+		it := &manifestItem{
+			ID:        fmt.Sprintf("img-%d", i+1),
+			Href:      imagesDir + "/" + name,
+			MediaType: c.images[name],
+		}
+		if name == cover {
+			it.Properties = "cover-image"
+		}
+		items = append(items, it)
+		if err = addFile(zw, contentDir+"/"+it.Href, d); err != nil {
+			return err
+		}
+	}
+
+	css := c.CSS
+	if css == "" {
+		css = tohtml.CSS + tohtml.CSSPlus
+	}
+	if err = addFile(zw, contentDir+"/"+cssFile, []byte(css)); err != nil {
+		return err
+	}
+	if err = addFile(zw, contentDir+"/"+navFile, c.navXHTML()); err != nil {
+		return err
+	}
+	if err = addFile(zw, contentDir+"/content.opf", c.contentOPF(items, spine)); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ToEPUB returns the book as EPUB
+func (c *Converter) ToEPUB() ([]byte, error) {
+	var buf bytes.Buffer
+	err := c.Write(&buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
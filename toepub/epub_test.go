@@ -0,0 +1,102 @@
+package toepub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/kjk/common/assert"
+	"github.com/kjk/notionapi"
+	"github.com/kjk/notionapi/assets"
+	"github.com/kjk/notionapi/internal/testutil"
+)
+
+const (
+	rootID = "1b0a6f4c-6c1e-4a3b-9a7e-3c1f0e2d4b5a"
+	subID  = "6a5f1c9b-1b6d-4f80-8fcd-8b6e5d7c9a0f"
+)
+
+func readZip(t *testing.T, d []byte) ([]string, map[string]string) {
+	zr, err := zip.NewReader(bytes.NewReader(d), int64(len(d)))
+	assert.NoError(t, err)
+	var names []string
+	files := map[string]string{}
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		r, err := f.Open()
+		assert.NoError(t, err)
+		d, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		files[f.Name] = string(d)
+	}
+	assert.Equal(t, zip.Store, zr.File[0].Method)
+	return names, files
+}
+
+func isWellFormed(s string) error {
+	dec := xml.NewDecoder(strings.NewReader(s))
+	for {
+		_, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func TestToEPUB(t *testing.T) {
+	root := testutil.LoadPage(t, "page.json")
+	sub := testutil.LoadPage(t, "subpage.json")
+
+	c := NewConverter(root, []*notionapi.Page{sub, root})
+	c.Authors = []string{"Jane Doe"}
+	d := &testutil.Downloader{
+		Files: map[string][]byte{"https://example.com/chart.png": []byte("png")},
+	}
+	c.Assets = assets.New(d, t.TempDir())
+	epub, err := c.ToEPUB()
+	assert.NoError(t, err)
+
+	names, files := readZip(t, epub)
+	assert.Equal(t, "mimetype", names[0])
+	assert.Equal(t, "application/epub+zip", files["mimetype"])
+	for name, s := range files {
+		if strings.HasSuffix(name, ".xhtml") || strings.HasSuffix(name, ".opf") || strings.HasSuffix(name, ".xml") {
+			assert.NoError(t, isWellFormed(s), "%s is not well-formed:\n%s", name, s)
+		}
+	}
+
+	rootFile := "page-" + notionapi.ToNoDashID(rootID) + ".xhtml"
+	subFile := "page-" + notionapi.ToNoDashID(subID) + ".xhtml"
+	opf := files["OEBPS/content.opf"]
+	assert.True(t, strings.Contains(opf, "<dc:title>Report &lt;Q1&gt; &amp; Notes</dc:title>"), "got:\n%s", opf)
+	assert.True(t, strings.Contains(opf, "<dc:creator>Jane Doe</dc:creator>"), "got:\n%s", opf)
+	// root is first, followed by its sub-page
+	spine := `<itemref idref="page-1"/>
+    <itemref idref="page-2"/>`
+	assert.True(t, strings.Contains(opf, spine), "got:\n%s", opf)
+	assert.True(t, strings.Contains(opf, `href="`+rootFile+`"`), "got:\n%s", opf)
+	assert.True(t, strings.Contains(opf, `media-type="image/png"`), "got:\n%s", opf)
+
+	page := files["OEBPS/"+rootFile]
+	assert.True(t, strings.Contains(page, `src="images/`), "got:\n%s", page)
+	assert.True(t, strings.Contains(page, `href="`+subFile+`"`), "got:\n%s", page)
+	// ids of elements start with a letter
+	assert.True(t, strings.Contains(page, `id="id-10000000-0000-4000-8000-000000000001"`), "got:\n%s", page)
+	page = files["OEBPS/"+subFile]
+	assert.True(t, strings.Contains(page, `href="`+rootFile+`#id-10000000-0000-4000-8000-000000000001"`), "got:\n%s", page)
+
+	toc := c.TableOfContents()
+	assert.Equal(t, 1, len(toc))
+	assert.Equal(t, "Report <Q1> & Notes", toc[0].Title)
+	assert.Equal(t, 2, len(toc[0].Children))
+	assert.Equal(t, "Plan", toc[0].Children[0].Title)
+	assert.Equal(t, rootFile+"#id-10000000-0000-4000-8000-000000000001", toc[0].Children[0].Href)
+	assert.Equal(t, "Appendix", toc[0].Children[1].Title)
+	assert.Equal(t, subFile, toc[0].Children[1].Href)
+}
@@ -0,0 +1,152 @@
+package toepub
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/kjk/notionapi"
+	"github.com/kjk/notionapi/tohtml"
+)
+
+// TocEntry is an entry in the table of contents: a page or a header
+type TocEntry struct {
+	Title string
+	// Href is a file of the page, followed by #id of a header
+	Href     string
+	Children []*TocEntry
+}
+
+func headerLevel(blockType string) int {
+	switch blockType {
+	case notionapi.BlockHeader:
+		return 1
+	case notionapi.BlockSubHeader:
+		return 2
+	case notionapi.BlockSubSubHeader:
+		return 3
+	}
+	return 0
+}
+
+// forEachPageBlock calls cb for blocks of a page in document order.
+// It doesn't descend into sub-pages
+func forEachPageBlock(page *notionapi.Page, cb func(block *notionapi.Block)) {
+	var visit func(blocks []*notionapi.Block)
+	visit = func(blocks []*notionapi.Block) {
+		for _, block := range blocks {
+			if block == nil {
+				continue
+			}
+			cb(block)
+			if block.Type != notionapi.BlockPage {
+				visit(block.Content)
+			}
+		}
+	}
+	visit(page.Root().Content)
+}
+
+// subPageIDs returns no-dash ids of sub-pages of a page in the order
+// they appear in the page
+func subPageIDs(page *notionapi.Page) []string {
+	var res []string
+	forEachPageBlock(page, func(block *notionapi.Block) {
+		if page.IsSubPage(block) {
+			res = append(res, notionapi.ToNoDashID(block.ID))
+		}
+	})
+	return res
+}
+
+// headerEntries returns headers of a page, nested by their level
+func headerEntries(page *notionapi.Page, file string) []*TocEntry {
+	var res []*TocEntry
+	var stack []*TocEntry
+	var levels []int
+	forEachPageBlock(page, func(block *notionapi.Block) {
+		level := headerLevel(block.Type)
+		if level == 0 {
+			return
+		}
+		title := strings.TrimSpace(notionapi.TextSpansToString(block.InlineContent))
+		if title == "" {
+			return
+		}
+		e := &TocEntry{
+			Title: title,
+			Href:  file + "#" + tohtml.XHTMLID(block.ID),
+		}
+		for len(levels) > 0 && levels[len(levels)-1] >= level {
+			stack = stack[:len(stack)-1]
+			levels = levels[:len(levels)-1]
+		}
+		if len(stack) == 0 {
+			res = append(res, e)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, e)
+		}
+		stack = append(stack, e)
+		levels = append(levels, level)
+	})
+	return res
+}
+
+// TableOfContents returns the table of contents of the book: pages, with
+// their headers followed by their sub-pages
+func (c *Converter) TableOfContents() []*TocEntry {
+	if c.idToFile == nil {
+		c.orderPages()
+	}
+	seen := map[string]bool{}
+	var pageEntry func(page *notionapi.Page) *TocEntry
+	pageEntry = func(page *notionapi.Page) *TocEntry {
+		id := notionapi.ToNoDashID(page.ID)
+		seen[id] = true
+		file := c.idToFile[id]
+		e := &TocEntry{
+			Title:    pageTitle(page),
+			Href:     file,
+			Children: headerEntries(page, file),
+		}
+		for _, subID := range subPageIDs(page) {
+			if sub := c.idToPage[subID]; sub != nil && !seen[subID] {
+				e.Children = append(e.Children, pageEntry(sub))
+			}
+		}
+		return e
+	}
+	var res []*TocEntry
+	for _, page := range c.pages {
+		if !seen[notionapi.ToNoDashID(page.ID)] {
+			res = append(res, pageEntry(page))
+		}
+	}
+	return res
+}
+
+func writeTocEntries(w *bytes.Buffer, entries []*TocEntry, indent string) {
+	w.WriteString(indent + "<ol>\n")
+	for _, e := range entries {
+		fmt.Fprintf(w, `%s  <li><a href="%s">%s</a>`, indent, tohtml.EscapeHTML(e.Href), tohtml.EscapeHTML(e.Title))
+		if len(e.Children) > 0 {
+			w.WriteString("\n")
+			writeTocEntries(w, e.Children, indent+"    ")
+			w.WriteString(indent + "  ")
+		}
+		w.WriteString("</li>\n")
+	}
+	w.WriteString(indent + "</ol>\n")
+}
+
+// navXHTML returns EPUB navigation document with the table of contents
+func (c *Converter) navXHTML() []byte {
+	var w bytes.Buffer
+	c.writeXHTMLHeader(&w, c.title())
+	w.WriteString("<nav epub:type=\"toc\" id=\"toc\">\n")
+	fmt.Fprintf(&w, "  <h1>%s</h1>\n", tohtml.EscapeHTML(c.title()))
+	writeTocEntries(&w, c.TableOfContents(), "  ")
+	w.WriteString("</nav>\n</body>\n</html>\n")
+	return w.Bytes()
+}
@@ -0,0 +1,468 @@
+package tohtml
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kjk/notionapi"
+)
+
+const (
+	// format of the title of a month in calendar view
+	calendarMonthFormat = "January 2006"
+	// max number of days we show a single event in calendar view
+	calendarMaxEventDays = 366
+)
+
+var calendarWeekDays = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+// renderCollectionViewOfType renders a collection view in a way appropriate
+// for its type (table, list, board, gallery, calendar)
+func (c *Converter) renderCollectionViewOfType(id string, tv *notionapi.TableView, showTitle bool) {
+	switch tv.CollectionView.Type {
+	case notionapi.CollectionViewTypeList:
+		c.renderListView(id, tv, showTitle)
+	case notionapi.CollectionViewTypeBoard:
+		c.renderBoardView(id, tv, showTitle)
+	case notionapi.CollectionViewTypeGallery:
+		c.renderGalleryView(id, tv, showTitle)
+	case notionapi.CollectionViewTypeCalendar:
+		c.renderCalendarView(id, tv, showTitle)
+	default:
+		// TODO: timeline is rendered as a table
+		c.renderTableView(id, tv, showTitle)
+	}
+}
+
+func collectionViewName(tv *notionapi.TableView) string {
+	cv := tv.CollectionView
+	if cv.Name != "" {
+		return cv.Name
+	}
+	typ := cv.Type
+	if typ == "" {
+		typ = notionapi.CollectionViewTypeTable
+	}
+	return strings.ToUpper(typ[:1]) + typ[1:]
+}
+
+// renderCollectionViewTabs renders all views of a collection with
+// tabs to switch between them. Tabs are implemented in CSS only
+func (c *Converter) renderCollectionViewTabs(block *notionapi.Block) {
+	name := block.TableViews[0].Collection.GetName()
+	c.Printf(`<div id="%s" class="collection-views">`, block.ID)
+	{
+		c.Printf(`<h4 class="collection-title">%s</h4>`, EscapeHTML(name))
+		c.Printf(`<div class="collection-tabs">`)
+		for i, tv := range block.TableViews {
+			tabID := "tab-" + tv.CollectionView.ID
+			checked := ""
+			if i == 0 {
+				checked = ` checked`
+			}
+			c.Printf(`<input type="radio" class="collection-tab-input" name="tabs-%s" id="%s"%s/>`, block.ID, tabID, checked)
+			c.Printf(`<label class="collection-tab-label" for="%s">%s</label>`, tabID, EscapeHTML(collectionViewName(tv)))
+			c.Printf(`<div class="collection-tab-panel">`)
+			c.renderCollectionViewOfType(tv.CollectionView.ID, tv, false)
+			c.Printf(`</div>`)
+		}
+		c.Printf(`</div>`)
+	}
+	c.Printf(`</div>`)
+}
+
+func titleColumnIdx(tv *notionapi.TableView) int {
+	for i, ci := range tv.Columns {
+		if ci.Schema != nil && ci.Schema.Type == notionapi.ColumnTypeTitle {
+			return i
+		}
+	}
+	return -1
+}
+
+// rowTitle returns HTML for the title of a row, linked to the page
+// of the row
+func (c *Converter) rowTitle(tv *notionapi.TableView, row int) string {
+	rowPage := tv.Rows[row].Page
+	col := titleColumnIdx(tv)
+	if col < 0 {
+		s := c.GetInlineContent(rowPage.GetTitle())
+		if s == "" {
+			s = "Untitled"
+		}
+		return s
+	}
+	s := c.GetInlineContent(tv.CellContent(row, col))
+	if s == "" {
+		s = "Untitled"
+	}
+	if isEmptyBlock(rowPage) {
+		// for cosmetic reasons we don't want to link to empty pages
+		return s
+	}
+	uri := c.attrURL(c.tableTitleCellURL(tv, row, col))
+	return fmt.Sprintf(`<a href="%s">%s</a>`, uri, s)
+}
+
+// renders non-empty values of properties other than title
+func (c *Converter) renderCardProperties(tv *notionapi.TableView, row int, tag string) {
+	titleIdx := titleColumnIdx(tv)
+	for col := range tv.Columns {
+		if col == titleIdx {
+			continue
+		}
+		colVal, colTypeClass := c.cellValue(tv, row, col)
+		if colVal == "" {
+			continue
+		}
+		colNameCls := EscapeHTML(tv.Columns[col].ID())
+		if colTypeClass != "" {
+			colTypeClass = " " + colTypeClass
+		}
+		c.Printf(`<%s class="card-property cell-%s%s">%s</%s>`, tag, colNameCls, colTypeClass, colVal, tag)
+	}
+}
+
+func (c *Converter) renderViewStart(id string, tv *notionapi.TableView, cls string, showTitle bool) {
+	c.Printf(`<div id="%s" class="collection-content %s">`, id, cls)
+	if showTitle {
+		name := tv.Collection.GetName()
+		c.Printf(`<h4 class="collection-title">%s</h4>`, EscapeHTML(name))
+	}
+}
+
+func (c *Converter) renderListView(id string, tv *notionapi.TableView, showTitle bool) {
+	c.renderViewStart(id, tv, "collection-list-view", showTitle)
+	{
+		c.Printf(`<ul class="collection-list">`)
+		for row := range tv.Rows {
+			c.Printf(`<li class="collection-list-item">`)
+			c.Printf(`<span class="card-title">%s</span>`, c.rowTitle(tv, row))
+			c.renderCardProperties(tv, row, "span")
+			c.Printf(`</li>`)
+		}
+		c.Printf(`</ul>`)
+	}
+	c.Printf(`</div>`)
+}
+
+func (c *Converter) renderCard(tv *notionapi.TableView, row int, cls string, coverURL string) {
+	// no id, the same row can be rendered in multiple views and groups
+	c.Printf(`<div class="collection-card %s">`, cls)
+	{
+		if coverURL != "" {
+			c.Printf(`<div class="card-cover"><img src="%s"/></div>`, EscapeHTML(c.safeURL(coverURL)))
+		}
+		c.Printf(`<div class="card-title">%s</div>`, c.rowTitle(tv, row))
+		c.renderCardProperties(tv, row, "div")
+	}
+	c.Printf(`</div>`)
+}
+
+// cardCoverURL returns URL of an image shown as a cover of a card
+// in a gallery or board view
+func (c *Converter) cardCoverURL(cover *notionapi.GalleryCover, rowPage *notionapi.Block) string {
+	if cover == nil {
+		return ""
+	}
+	switch cover.Type {
+	case notionapi.GalleryCoverPageCover:
+		uri, _ := rowPage.PropAsString("format.page_cover")
+		if uri == "" {
+			return ""
+		}
+		return c.fileURL(uri, rowPage, FilePathFromPageCoverURL(uri, rowPage))
+	case notionapi.GalleryCoverProperty:
+		for _, span := range rowPage.GetProperty(cover.Property) {
+			for _, attr := range span.Attrs {
+				if notionapi.AttrGetType(attr) == notionapi.AttrLink {
+					uri := notionapi.AttrGetLink(attr)
+					return c.fileURL(uri, rowPage, getDownloadedFileName(uri, rowPage))
+				}
+			}
+		}
+	}
+	// TODO: GalleryCoverPageContent would require downloading
+	// content of each row
+	return ""
+}
+
+func (c *Converter) renderGalleryView(id string, tv *notionapi.TableView, showTitle bool) {
+	format := tv.CollectionView.Format
+	size := "medium"
+	if format != nil && format.GalleryCoverSize != "" {
+		size = format.GalleryCoverSize
+	}
+	var cover *notionapi.GalleryCover
+	cls := "gallery-card"
+	if format != nil {
+		cover = format.GalleryCover
+		if format.GalleryCoverAspect == "contain" {
+			cls += " card-cover-contain"
+		}
+	}
+	c.renderViewStart(id, tv, "collection-gallery-view", showTitle)
+	{
+		c.Printf(`<div class="collection-gallery gallery-%s">`, cleanClassName(size))
+		for row, tr := range tv.Rows {
+			c.renderCard(tv, row, cls, c.cardCoverURL(cover, tr.Page))
+		}
+		c.Printf(`</div>`)
+	}
+	c.Printf(`</div>`)
+}
+
+// boardGroup is a column in board view
+type boardGroup struct {
+	value string
+	color string
+	rows  []int
+}
+
+// returns id of property by which board is grouped
+func boardGroupByProperty(tv *notionapi.TableView) string {
+	format := tv.CollectionView.Format
+	if format == nil {
+		return ""
+	}
+	if format.BoardColumnsBy != nil && format.BoardColumnsBy.Property != "" {
+		return format.BoardColumnsBy.Property
+	}
+	for _, col := range format.BoardColumns {
+		if col.Property != "" {
+			return col.Property
+		}
+	}
+	return ""
+}
+
+func boardColumnValue(col *notionapi.BoardColumn) string {
+	if col.Value == nil || col.Value.Value == nil {
+		return ""
+	}
+	if s, ok := col.Value.Value.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", col.Value.Value)
+}
+
+// getBoardGroups splits rows into columns of a board, in the order
+// in which Notion shows them
+func getBoardGroups(tv *notionapi.TableView, prop string) []*boardGroup {
+	schema := tv.Collection.Schema[prop]
+	var res []*boardGroup
+	valueToGroup := map[string]*boardGroup{}
+	hidden := map[string]bool{}
+	addGroup := func(value string) *boardGroup {
+		if g := valueToGroup[value]; g != nil {
+			return g
+		}
+		g := &boardGroup{
+			value: value,
+		}
+		if schema != nil {
+			g.color = getMultiSelectoColor(schema.Options, value)
+		}
+		valueToGroup[value] = g
+		res = append(res, g)
+		return g
+	}
+
+	format := tv.CollectionView.Format
+	if len(format.BoardColumns) > 0 {
+		for _, col := range format.BoardColumns {
+			v := boardColumnValue(col)
+			if col.Hidden {
+				hidden[v] = true
+				continue
+			}
+			addGroup(v)
+		}
+	} else {
+		// cards without a value are shown first
+		addGroup("")
+		if schema != nil {
+			for _, opt := range schema.Options {
+				addGroup(opt.Value)
+			}
+		}
+	}
+
+	isMultiSelect := schema != nil && schema.Type == notionapi.ColumnTypeMultiSelect
+	for row, tr := range tv.Rows {
+		v := notionapi.TextSpansToString(tr.Page.GetProperty(prop))
+		values := []string{v}
+		if isMultiSelect && v != "" {
+			values = strings.Split(v, ",")
+		}
+		for _, v := range values {
+			if hidden[v] {
+				continue
+			}
+			g := addGroup(v)
+			g.rows = append(g.rows, row)
+		}
+	}
+	return res
+}
+
+func (c *Converter) renderBoardView(id string, tv *notionapi.TableView, showTitle bool) {
+	prop := boardGroupByProperty(tv)
+	if prop == "" {
+		// shouldn't happen but if it does, a list is the closest thing
+		c.renderListView(id, tv, showTitle)
+		return
+	}
+	propName := prop
+	if schema := tv.Collection.Schema[prop]; schema != nil {
+		propName = schema.Name
+	}
+	var cover *notionapi.GalleryCover
+	if tv.CollectionView.Format != nil {
+		cover = tv.CollectionView.Format.BoardCover
+	}
+	c.renderViewStart(id, tv, "collection-board-view", showTitle)
+	{
+		c.Printf(`<div class="collection-board">`)
+		for _, g := range getBoardGroups(tv, prop) {
+			c.Printf(`<div class="board-column">`)
+			{
+				title := EscapeHTML(g.value)
+				if g.value == "" {
+					title = "No " + EscapeHTML(propName)
+				}
+				cls := "selected-value"
+				if g.color != "" {
+					cls += fmt.Sprintf(" block-color-%s_background", cleanClassName(g.color))
+				}
+				c.Printf(`<div class="board-column-header"><span class="%s">%s</span><span class="board-column-count">%d</span></div>`, cls, title, len(g.rows))
+				for _, row := range g.rows {
+					c.renderCard(tv, row, "board-card", c.cardCoverURL(cover, tv.Rows[row].Page))
+				}
+			}
+			c.Printf(`</div>`)
+		}
+		c.Printf(`</div>`)
+	}
+	c.Printf(`</div>`)
+}
+
+func parseCalendarDay(s string) (time.Time, bool) {
+	t, err := time.Parse("2006-01-02", s)
+	return t, err == nil
+}
+
+// getRowDays returns days on which a row is shown in the calendar
+func getRowDays(tv *notionapi.TableView, row int, prop string) []time.Time {
+	rowPage := tv.Rows[row].Page
+	schema := tv.Collection.Schema[prop]
+	if schema != nil {
+		switch schema.Type {
+		case notionapi.ColumnTypeCreatedTime:
+			return []time.Time{rowPage.CreatedOn().UTC()}
+		case notionapi.ColumnTypeLastEditedTime:
+			return []time.Time{rowPage.LastEditedOn().UTC()}
+		}
+	}
+	for _, span := range rowPage.GetProperty(prop) {
+		for _, attr := range span.Attrs {
+			if notionapi.AttrGetType(attr) != notionapi.AttrDate {
+				continue
+			}
+			d := notionapi.AttrGetDate(attr)
+			if d == nil {
+				continue
+			}
+			start, ok := parseCalendarDay(d.StartDate)
+			if !ok {
+				continue
+			}
+			res := []time.Time{start}
+			end, ok := parseCalendarDay(d.EndDate)
+			if !ok {
+				return res
+			}
+			day := start.AddDate(0, 0, 1)
+			for !day.After(end) && len(res) < calendarMaxEventDays {
+				res = append(res, day)
+				day = day.AddDate(0, 0, 1)
+			}
+			return res
+		}
+	}
+	return nil
+}
+
+func (c *Converter) renderCalendarMonth(tv *notionapi.TableView, month time.Time, dayToRows map[string][]int) {
+	c.Printf(`<div class="calendar-month">`)
+	c.Printf(`<h5 class="calendar-month-title">%s</h5>`, month.Format(calendarMonthFormat))
+	c.Printf(`<table class="collection-calendar">`)
+	{
+		c.Printf(`<thead><tr>`)
+		for _, wd := range calendarWeekDays {
+			c.Printf(`<th>%s</th>`, wd)
+		}
+		c.Printf(`</tr></thead>`)
+		c.Printf(`<tbody>`)
+		c.Printf(`<tr>`)
+		for i := 0; i < int(month.Weekday()); i++ {
+			c.Printf(`<td class="calendar-day calendar-day-empty"></td>`)
+		}
+		day := month
+		for day.Month() == month.Month() {
+			if day.Weekday() == time.Sunday && day.Day() != 1 {
+				c.Printf(`</tr>`)
+				c.Printf(`<tr>`)
+			}
+			c.Printf(`<td class="calendar-day">`)
+			c.Printf(`<div class="calendar-day-number">%d</div>`, day.Day())
+			for _, row := range dayToRows[day.Format("2006-01-02")] {
+				c.Printf(`<div class="calendar-event">%s</div>`, c.rowTitle(tv, row))
+			}
+			c.Printf(`</td>`)
+			day = day.AddDate(0, 0, 1)
+		}
+		for i := int(day.Weekday()); i > 0 && i < 7; i++ {
+			c.Printf(`<td class="calendar-day calendar-day-empty"></td>`)
+		}
+		c.Printf(`</tr>`)
+		c.Printf(`</tbody>`)
+	}
+	c.Printf(`</table>`)
+	c.Printf(`</div>`)
+}
+
+// renderCalendarView renders a month grid for every month that has
+// at least one page in it
+func (c *Converter) renderCalendarView(id string, tv *notionapi.TableView, showTitle bool) {
+	prop := ""
+	if tv.CollectionView.Format != nil {
+		prop = tv.CollectionView.Format.CalendarBy
+	}
+	dayToRows := map[string][]int{}
+	monthsSeen := map[string]bool{}
+	var months []time.Time
+	for row := range tv.Rows {
+		for _, day := range getRowDays(tv, row, prop) {
+			key := day.Format("2006-01-02")
+			dayToRows[key] = append(dayToRows[key], row)
+			month := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+			monthKey := month.Format("2006-01")
+			if !monthsSeen[monthKey] {
+				monthsSeen[monthKey] = true
+				months = append(months, month)
+			}
+		}
+	}
+	sort.Slice(months, func(i, j int) bool {
+		return months[i].Before(months[j])
+	})
+
+	c.renderViewStart(id, tv, "collection-calendar-view", showTitle)
+	for _, month := range months {
+		c.renderCalendarMonth(tv, month, dayToRows)
+	}
+	c.Printf(`</div>`)
+}
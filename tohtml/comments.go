@@ -0,0 +1,110 @@
+package tohtml
+
+import (
+	"github.com/kjk/notionapi"
+)
+
+// discussionNo returns a number of footnote for a discussion with a
+// given id. Returns 0 if the discussion shouldn't be shown
+func (c *Converter) discussionNo(id string) int {
+	if c.discussions == nil {
+		c.discussions = &notionapi.DiscussionNumbers{
+			Page:         c.Page,
+			ShowResolved: c.RenderResolvedComments,
+		}
+	}
+	return c.discussions.No(id)
+}
+
+func (c *Converter) renderDiscussionRef(n int) {
+	c.NoIndentPrintf(`<sup class="discussion-ref"><a href="#discussion-%d">[%d]</a></sup>`, n, n)
+}
+
+// renders references to discussions that end at spans[i]. Text commented
+// on can span multiple TextSpans but we only want to show the reference
+// once, at the end
+func (c *Converter) renderInlineDiscussionRefs(spans []*notionapi.TextSpan, i int) {
+	for _, id := range notionapi.DiscussionIDsEndingAt(spans, i) {
+		if n := c.discussionNo(id); n > 0 {
+			c.renderDiscussionRef(n)
+		}
+	}
+}
+
+// returns numbers of footnotes of discussions about the whole block
+func (c *Converter) blockDiscussionNos(block *notionapi.Block) []int {
+	var res []int
+	for _, id := range block.DiscussionIDs {
+		if n := c.discussionNo(id); n > 0 {
+			res = append(res, n)
+		}
+	}
+	return res
+}
+
+// references to discussions about a block are rendered after its
+// inline content. If a block has no inline content, they are rendered
+// after the block
+func (c *Converter) flushPendingDiscussionRefs() {
+	nums := c.pendingDiscussionNos
+	if len(nums) == 0 {
+		return
+	}
+	c.pendingDiscussionNos = nil
+	c.Printf(`<div class="discussion-refs">`)
+	for _, n := range nums {
+		c.renderDiscussionRef(n)
+	}
+	c.NoIndentPrintf(`</div>`)
+}
+
+func (c *Converter) renderComment(comment *notionapi.Comment) {
+	c.Printf(`<div class="comment">`)
+	{
+		c.indent++
+		author := EscapeHTML(comment.AuthorName())
+		date := comment.CreatedOn().Format(notionapi.CommentTimeFormat)
+		c.Printf(`<div class="comment-header"><span class="comment-author">%s</span> <time>%s</time></div>`, author, date)
+		c.Printf(`<div class="comment-body">`)
+		c.RenderInlines(comment.InlineContent)
+		c.NoIndentPrintf(`</div>`)
+		c.decIndent()
+	}
+	c.Printf(`</div>`)
+}
+
+// RenderDiscussions renders all discussions referenced so far
+// as footnotes
+func (c *Converter) RenderDiscussions() {
+	if c.discussions == nil || len(c.discussions.Discussions) == 0 {
+		return
+	}
+	c.Printf(`<section class="discussions">`)
+	c.indent++
+	c.Printf(`<h2>Comments</h2>`)
+	c.Printf(`<ol>`)
+	// rendering comments can add more discussions (comments can have
+	// inline comments) so we don't use range
+	for i := 0; i < len(c.discussions.Discussions); i++ {
+		d := c.discussions.Discussions[i]
+		cls := "discussion"
+		if d.Resolved {
+			cls += " discussion-resolved"
+		}
+		c.Printf(`<li id="discussion-%d" class="%s">`, i+1, cls)
+		c.indent++
+		if len(d.InlineContext) > 0 {
+			c.Printf(`<blockquote class="discussion-context">`)
+			c.RenderInlines(d.InlineContext)
+			c.NoIndentPrintf(`</blockquote>`)
+		}
+		for _, comment := range d.Content {
+			c.renderComment(comment)
+		}
+		c.decIndent()
+		c.Printf(`</li>`)
+	}
+	c.Printf(`</ol>`)
+	c.decIndent()
+	c.Printf(`</section>`)
+}
@@ -569,4 +569,300 @@ const CSSPlus = `
 .breadcrumbs {
 
 }
+
+.discussion-highlight {
+	background: rgba(255,212,0,0.14);
+	border-bottom: 2px solid rgba(255,212,0,0.8);
+}
+
+.discussion-ref {
+	font-size: 0.75em;
+	margin-left: 1px;
+}
+
+.discussion-ref a {
+	text-decoration: none;
+}
+
+.discussions {
+	margin-top: 2em;
+	border-top: 1px solid rgba(55,53,47,0.09);
+	font-size: 0.9em;
+}
+
+.discussion-resolved {
+	opacity: 0.6;
+}
+
+.discussion-context {
+	color: rgba(55,53,47,0.65);
+	margin: 0.5em 0;
+}
+
+.comment {
+	margin-bottom: 0.5em;
+}
+
+.comment-author {
+	font-weight: 600;
+}
+
+.comment-header time {
+	color: rgba(55,53,47,0.4);
+	margin-left: 0.5em;
+}
+
+.collection-tabs {
+	display: flex;
+	flex-wrap: wrap;
+}
+
+.collection-tab-input {
+	display: none;
+}
+
+.collection-tab-label {
+	order: 1;
+	padding: 0.3em 0.8em;
+	cursor: pointer;
+	color: rgba(55,53,47,0.6);
+	border-bottom: 2px solid transparent;
+}
+
+.collection-tab-panel {
+	order: 2;
+	width: 100%;
+	display: none;
+	border-top: 1px solid rgba(55,53,47,0.09);
+}
+
+.collection-tab-input:checked + .collection-tab-label {
+	color: rgb(55,53,47);
+	border-bottom-color: rgb(55,53,47);
+}
+
+.collection-tab-input:checked + .collection-tab-label + .collection-tab-panel {
+	display: block;
+}
+
+.collection-list {
+	list-style: none;
+	padding: 0;
+}
+
+.collection-list-item {
+	display: flex;
+	flex-wrap: wrap;
+	align-items: baseline;
+	padding: 0.3em 0;
+	border-bottom: 1px solid rgba(55,53,47,0.09);
+}
+
+.collection-list-item .card-title {
+	flex-grow: 1;
+}
+
+.collection-list-item .card-property {
+	margin-left: 0.8em;
+	font-size: 0.85em;
+	color: rgba(55,53,47,0.6);
+}
+
+.collection-board {
+	display: flex;
+	overflow-x: auto;
+	align-items: flex-start;
+}
+
+.board-column {
+	flex: 0 0 260px;
+	margin-right: 16px;
+}
+
+.board-column-header {
+	padding: 0.3em 0;
+}
+
+.board-column-count {
+	margin-left: 0.5em;
+	color: rgba(55,53,47,0.5);
+}
+
+.collection-gallery {
+	display: grid;
+	grid-gap: 16px;
+	grid-template-columns: repeat(auto-fill, minmax(260px, 1fr));
+}
+
+.collection-gallery.gallery-small {
+	grid-template-columns: repeat(auto-fill, minmax(180px, 1fr));
+}
+
+.collection-gallery.gallery-large {
+	grid-template-columns: repeat(auto-fill, minmax(320px, 1fr));
+}
+
+.collection-card {
+	border-radius: 3px;
+	box-shadow: rgba(15,15,15,0.1) 0px 0px 0px 1px, rgba(15,15,15,0.1) 0px 2px 4px;
+	margin-bottom: 8px;
+	overflow: hidden;
+}
+
+.card-cover img {
+	display: block;
+	width: 100%;
+	height: 160px;
+	object-fit: cover;
+}
+
+.card-cover-contain .card-cover img {
+	object-fit: contain;
+}
+
+.collection-card .card-title {
+	font-weight: 500;
+	padding: 8px 10px 4px;
+}
+
+.collection-card .card-property {
+	font-size: 0.85em;
+	padding: 2px 10px;
+}
+
+.collection-card .card-property:last-child {
+	padding-bottom: 8px;
+}
+
+.collection-calendar {
+	width: 100%;
+	table-layout: fixed;
+	border-collapse: collapse;
+}
+
+.collection-calendar th {
+	font-weight: normal;
+	color: rgba(55,53,47,0.5);
+	text-align: left;
+}
+
+.calendar-day {
+	vertical-align: top;
+	height: 100px;
+	border: 1px solid rgba(55,53,47,0.09);
+	padding: 4px;
+}
+
+.calendar-day-empty {
+	background: rgba(55,53,47,0.03);
+}
+
+.calendar-day-number {
+	text-align: right;
+	font-size: 0.85em;
+	color: rgba(55,53,47,0.5);
+}
+
+.calendar-event {
+	font-size: 0.85em;
+	margin-top: 2px;
+	padding: 2px 4px;
+	border-radius: 3px;
+	box-shadow: rgba(15,15,15,0.1) 0px 0px 0px 1px;
+	overflow: hidden;
+	white-space: nowrap;
+	text-overflow: ellipsis;
+}
+
+.code-highlighted {
+	position: relative;
+	font-size: 0.85em;
+}
+
+.code-highlighted pre {
+	padding: 1em;
+	border-radius: 3px;
+	overflow-x: auto;
+}
+
+.code-highlighted code {
+	background: none;
+	padding: 0;
+	font-size: 100%;
+	color: inherit;
+}
+
+.code-highlighted .copy-code {
+	position: absolute;
+	top: 0.5em;
+	right: 0.5em;
+	font-size: 0.8em;
+	cursor: pointer;
+}
+
+.link-mention {
+	text-decoration: none;
+	border-bottom: 0.05em solid rgba(55,53,47,0.25);
+}
+
+.page-mention {
+	text-decoration: none;
+	border-bottom: 0.05em solid rgba(55,53,47,0.25);
+}
+
+img.page-mention-icon {
+	width: 1.1em;
+	height: 1.1em;
+	vertical-align: -0.15em;
+}
+
+.page-mention-icon {
+	margin-right: 0.3em;
+}
+
+.link-mention-icon {
+	width: 1.1em;
+	height: 1.1em;
+	margin-right: 0.3em;
+	vertical-align: -0.15em;
+	border-radius: 3px;
+}
+
+.external-object {
+	padding: 0 0.2em;
+	border-radius: 3px;
+	background: rgba(135,131,120,0.15);
+}
+
+.embed-container iframe {
+	width: 100%;
+	height: 480px;
+	border: none;
+	border-radius: 3px;
+}
+
+/* keeps aspect ratio of images with width and height attributes */
+figure.image img[height] {
+	height: auto;
+}
+
+/* used instead of style attributes when NoInlineStyles is set */
+.underline {
+	border-bottom: 0.05em solid;
+}
+
+.drive-icon {
+	width: 1em;
+	height: 1em;
+	margin-right: 0.5em;
+	vertical-align: text-bottom;
+}
+
+.notion-callout {
+	display: flex;
+}
+
+.notion-callout > div:last-child {
+	width: 100%;
+}
 `
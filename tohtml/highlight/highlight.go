@@ -0,0 +1,154 @@
+// Package highlight implements tohtml.CodeHighlighter using chroma
+// (https://github.com/alecthomas/chroma), a pure Go syntax highlighter.
+//
+// Usage:
+//
+//	c := tohtml.NewConverter(page)
+//	c.CodeHighlighter = highlight.New()
+package highlight
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/kjk/notionapi/tohtml"
+)
+
+var (
+	_ tohtml.CodeHighlighter            = &Highlighter{}
+	_ tohtml.CodeHighlighterWithOptions = &Highlighter{}
+)
+
+// DefaultStyle is the name of the default chroma style
+const DefaultStyle = "github"
+
+// maps names of languages in Notion to names of chroma lexers, for
+// those that chroma doesn't know under Notion's name
+var notionToLexer = map[string]string{
+	"plain text":    "plaintext",
+	"shell":         "bash",
+	"c++":           "cpp",
+	"c#":            "csharp",
+	"f#":            "fsharp",
+	"java/c/c++/c#": "java",
+	"flow":          "javascript",
+	"markup":        "html",
+	"latex":         "tex",
+	"protobuf":      "protobuf",
+	"reason":        "reasonml",
+	"vb.net":        "vb.net",
+	"visual basic":  "vb.net",
+	"basic":         "qbasic",
+	"livescript":    "coffeescript",
+	"mermaid":       "plaintext",
+	"webassembly":   "plaintext",
+}
+
+// LexerForLanguage returns chroma lexer for a language name used by
+// Notion. Returns plain text lexer for unknown languages
+func LexerForLanguage(lang string) chroma.Lexer {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if name, ok := notionToLexer[lang]; ok {
+		lang = name
+	}
+	var lexer chroma.Lexer
+	if lang != "" {
+		lexer = lexers.Get(lang)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	return chroma.Coalesce(lexer)
+}
+
+// Highlighter renders code as html with syntax highlighting
+type Highlighter struct {
+	// name of chroma style, DefaultStyle if empty
+	// see https://xyproto.github.io/splash/docs/
+	Style string
+	// if true, shows line numbers
+	LineNumbers bool
+	// if true, adds a button that copies the code to clipboard
+	CopyButton bool
+	// if true, uses css classes instead of inline styles. You need
+	// to include CSS() in the page
+	Classes bool
+}
+
+// New creates a Highlighter
+func New() *Highlighter {
+	return &Highlighter{
+		Style: DefaultStyle,
+	}
+}
+
+func (h *Highlighter) style() *chroma.Style {
+	if h.Style == "" {
+		return styles.Get(DefaultStyle)
+	}
+	return styles.Get(h.Style)
+}
+
+func (h *Highlighter) formatter(classes bool) *html.Formatter {
+	return html.New(
+		html.WithClasses(classes),
+		html.WithLineNumbers(h.LineNumbers),
+		html.LineNumbersInTable(h.LineNumbers),
+		html.TabWidth(4),
+	)
+}
+
+// copies text of the last <code> element, which is code and not
+// line numbers when showing line numbers in a table
+const copyButtonScript = `var c=this.parentNode.querySelectorAll('code');navigator.clipboard.writeText(c[c.length-1].innerText)`
+
+const copyButtonHTML = `<button class="copy-code" style="position:absolute;top:0.5em;right:0.5em;font-size:0.8em;cursor:pointer" onclick="` + copyButtonScript + `">Copy</button>`
+
+// without style attribute, styled by .copy-code in tohtml.CSSPlus
+const copyButtonNoStyleHTML = `<button class="copy-code" onclick="` + copyButtonScript + `">Copy</button>`
+
+// Highlight returns html for code in a given language
+func (h *Highlighter) Highlight(code string, lang string) (string, error) {
+	return h.HighlightWithOptions(code, lang, &tohtml.HighlightOptions{})
+}
+
+// HighlightWithOptions returns html for code in a given language. When
+// opts.NoScripts is set, there's no copy button. When opts.NoInlineStyles
+// is set, css classes are used instead of styles, as if Classes was true
+func (h *Highlighter) HighlightWithOptions(code string, lang string, opts *tohtml.HighlightOptions) (string, error) {
+	lexer := LexerForLanguage(lang)
+	it, err := lexer.Tokenise(nil, code)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if h.CopyButton && !opts.NoScripts {
+		if opts.NoInlineStyles {
+			buf.WriteString(copyButtonNoStyleHTML)
+		} else {
+			buf.WriteString(copyButtonHTML)
+		}
+	}
+	f := h.formatter(h.Classes || opts.NoInlineStyles)
+	err = f.Format(&buf, h.style(), it)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// CSS returns CSS needed when Classes or tohtml.Converter.NoInlineStyles
+// is true
+func (h *Highlighter) CSS() (string, error) {
+	var buf bytes.Buffer
+	err := h.formatter(true).WriteCSS(&buf, h.style())
+	if err != nil {
+		return "", fmt.Errorf("WriteCSS() failed with '%s'", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,80 @@
+package highlight
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kjk/common/assert"
+	"github.com/kjk/notionapi"
+	"github.com/kjk/notionapi/tohtml"
+)
+
+func TestLexerForLanguage(t *testing.T) {
+	tests := [][]string{
+		{"Plain Text", "plaintext"},
+		{"Shell", "Bash"},
+		{"Bash", "Bash"},
+		{"C++", "C++"},
+		{"C#", "C#"},
+		{"Go", "Go"},
+		{"JavaScript", "JavaScript"},
+		{"Python", "Python"},
+		{"Docker", "Docker"},
+		{"Markup", "HTML"},
+		{"VB.Net", "VB.net"},
+		{"no such language", "fallback"},
+		{"", "fallback"},
+	}
+	for _, test := range tests {
+		got := LexerForLanguage(test[0]).Config().Name
+		assert.Equal(t, test[1], got, "language: '%s'", test[0])
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	h := New()
+	h.CopyButton = true
+	s, err := h.Highlight("package main\n", "Go")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(s, `<button class="copy-code"`))
+	assert.True(t, strings.Contains(s, `<pre`))
+	assert.True(t, strings.Contains(s, `package`))
+}
+
+func TestRenderCode(t *testing.T) {
+	block := &notionapi.Block{
+		ID:           "code",
+		Type:         notionapi.BlockCode,
+		Code:         "package main\n",
+		CodeLanguage: "Go",
+		Properties: map[string]interface{}{
+			"caption": []interface{}{[]interface{}{"main.go"}},
+		},
+	}
+	h := New()
+	h.CopyButton = true
+	render := func(c *tohtml.Converter) string {
+		c.CodeHighlighter = h
+		c.PushNewBuffer()
+		c.RenderCode(block)
+		return c.PopBuffer().String()
+	}
+	c := tohtml.NewConverter(nil)
+	s := render(c)
+	assert.True(t, strings.Contains(s, `<figure id="code" class="code-highlighted">`), "got:\n%s", s)
+	assert.True(t, strings.Contains(s, ` onclick="`), "got:\n%s", s)
+	assert.True(t, strings.Contains(s, `<figcaption>main.go`), "got:\n%s", s)
+
+	c = tohtml.NewConverter(nil)
+	c.Strict = true
+	s = render(c)
+	assert.False(t, strings.Contains(s, `<button`), "got:\n%s", s)
+	assert.True(t, strings.Contains(s, ` style="`), "got:\n%s", s)
+
+	c = tohtml.NewConverter(nil)
+	c.NoInlineStyles = true
+	s = render(c)
+	assert.True(t, strings.Contains(s, `<button class="copy-code" onclick="`), "got:\n%s", s)
+	assert.False(t, strings.Contains(s, ` style="`), "got:\n%s", s)
+	assert.True(t, strings.Contains(s, `<figcaption>main.go`), "got:\n%s", s)
+}
@@ -4,13 +4,12 @@ import (
 	"bytes"
 	"fmt"
 	"html"
-	"os"
-	"os/exec"
+	"html/template"
 	"path"
-	"strconv"
 	"strings"
 
 	"github.com/kjk/notionapi"
+	"github.com/kjk/notionapi/equation"
 )
 
 func maybePanic(format string, args ...interface{}) {
@@ -178,9 +177,18 @@ func getDownloadedFileName(uri string, block *notionapi.Block) string {
 	return name
 }
 
-func getFileOrSourceURL(block *notionapi.Block) string {
+// fileURL returns url of a file (image, attachment, page cover, icon)
+// referenced by block. defURL is used if RewriteFileURL is not set
+func (c *Converter) fileURL(uri string, block *notionapi.Block, defURL string) string {
+	if c.RewriteFileURL != nil {
+		return c.RewriteFileURL(uri, block)
+	}
+	return defURL
+}
+
+func (c *Converter) fileOrSourceURL(block *notionapi.Block) string {
 	if len(block.FileIDs) > 0 {
-		return getDownloadedFileName(block.Source, block)
+		return c.fileURL(block.Source, block, getDownloadedFileName(block.Source, block))
 	}
 	return block.Source
 }
@@ -253,6 +261,11 @@ type Converter struct {
 	// we'll return an error
 	KatexPath string
 
+	// EquationRenderer, if set, is used to render equations. See
+	// equation.MathML, equation.SVG and equation.Katex.
+	// Over-rides UseKatexToRenderEquation
+	EquationRenderer equation.Renderer
+
 	// if true, adds <a href="#{$NotionID}">svg(anchor-icon)</a>
 	// to h1/h2/h3
 	AddHeaderAnchor bool
@@ -265,6 +278,11 @@ type Converter struct {
 	// to destination URLs
 	RewriteURL func(url string) string
 
+	// RewriteFileURL allows re-writing URLs of files (images, attachments,
+	// page covers etc.) e.g. to download them and link to local copies.
+	// See assets.Bundler
+	RewriteFileURL func(uri string, block *notionapi.Block) string
+
 	// Returns URL for a title cell (that links to a page)
 	TableTitleCellURLOverride func(tv *notionapi.TableView, row, col int) string
 
@@ -273,19 +291,84 @@ type Converter struct {
 	FullHTML  bool
 	CustomCSS string
 
+	// Theme, if set, renders FullHTML page with a template and theme's CSS.
+	// See ThemeNotion, ThemeLight, ThemeDark and ThemeAuto
+	Theme *Theme
+	// Template, if set, is used to render FullHTML page. It's executed
+	// with *PageTemplateData. Over-rides Theme.Template
+	Template *template.Template
+	// CSSFiles are urls of CSS files linked from FullHTML page.
+	// If set without Theme or CustomCSS, we don't inline any CSS
+	CSSFiles []string
+
 	// we need this to properly render ordered and numbered lists
 	CurrBlocks   []*notionapi.Block
 	CurrBlockIdx int
 
 	PageByIDProvider PageByIDProvider
+	// PageInfoProvider, if set, is used to get titles and icons of
+	// mentioned pages that are not part of Page or PageByIDProvider
+	// e.g. notionapi.PageInfoFetcher
+	PageInfoProvider notionapi.PageInfoProvider
+
+	// if true, renders comments (discussions). Commented text is
+	// highlighted and discussions are rendered as footnotes at the
+	// end of the page
+	RenderComments bool
+	// if true, also renders resolved discussions
+	RenderResolvedComments bool
+
+	// if set, used to render code blocks with syntax highlighting
+	CodeHighlighter CodeHighlighter
+
+	// if true, renders all views of a collection, with tabs to switch
+	// between them. Otherwise only the first view is rendered
+	RenderAllCollectionViews bool
+
+	// if true, output is well-formed XHTML (see ToXHTML) e.g. for EPUB.
+	// Ids of elements are changed with XHTMLID.
+	// Embeds and gists are rendered as links instead of <iframe> and
+	// <script> tags
+	XHTML bool
+
+	// if true, output is sanitized for publishing untrusted pages: urls
+	// with unsafe schemes (e.g. javascript:) are removed (see IsSafeURL),
+	// text in attributes is escaped and gists are rendered as links
+	// instead of <script> tags. Class names built from page data are
+	// always restricted to [a-zA-Z0-9_-]
+	Strict bool
+	// hosts (e.g. "www.youtube.com") whose embeds are rendered as iframes.
+	// Embeds from other hosts are rendered as links.
+	// See DefaultEmbedHosts
+	EmbedHosts []string
+	// if true, doesn't emit style attributes and <style> tags, so that
+	// output works with Content-Security-Policy that doesn't allow
+	// inline styles. CSS must be provided via CSSFiles
+	NoInlineStyles bool
+
+	// if true, images are rendered with width and height (from block's
+	// format, to avoid layout shift), are lazy-loaded and have srcset
+	// with versions resized to ImageWidths (if available)
+	ResponsiveImages bool
+	// widths of images in srcset. If not set, DefaultImageWidths is used
+	ImageWidths []int
+	// ResizeImageURL returns a url of an image resized to width or "" if
+	// it's not available. If not set, Notion's image proxy is used, unless
+	// RewriteFileURL is set. See assets.Bundler.ResizeImageURL
+	ResizeImageURL func(uri string, block *notionapi.Block, width int) string
 
 	// data provided by they caller, useful when providing
 	// RenderBlockOverride
 	Data interface{}
 
-	didImportKatexCSS bool
-	bufs              []*bytes.Buffer
-	indent            int
+	didImportEquationCSS bool
+	bufs                 []*bytes.Buffer
+	indent               int
+
+	// discussions referenced so far, in order of footnotes
+	discussions *notionapi.DiscussionNumbers
+	// references to discussions about the block being rendered
+	pendingDiscussionNos []int
 }
 
 // NewConverter returns customizable HTML renderer
@@ -303,6 +386,45 @@ func (c *Converter) PageByID(pageID string) *notionapi.Page {
 	return nil
 }
 
+// pageInfo returns info about a page with a given id from records of
+// the page being converted, PageByIDProvider or PageInfoProvider
+func (c *Converter) pageInfo(pageID string) *notionapi.PageInfo {
+	nid := notionapi.NewNotionID(pageID)
+	if nid == nil {
+		return nil
+	}
+	if c.Page != nil {
+		if info := notionapi.PageInfoFromBlock(c.Page.BlockByID(nid)); info != nil {
+			return info
+		}
+	}
+	if page := c.PageByID(pageID); page != nil {
+		if info := notionapi.PageInfoFromBlock(page.Root()); info != nil {
+			return info
+		}
+	}
+	if c.PageInfoProvider != nil {
+		return c.PageInfoProvider.PageInfo(pageID)
+	}
+	return nil
+}
+
+// pageMentionIcon returns html for an icon (emoji or url) of a
+// mentioned page
+func (c *Converter) pageMentionIcon(icon string) string {
+	if icon == "" {
+		return ""
+	}
+	if isURL(icon) || strings.HasPrefix(icon, "/images/") {
+		uri := c.safeURL(c.fileURL(icon, nil, icon))
+		if uri == "" {
+			return ""
+		}
+		return fmt.Sprintf(`<img class="page-mention-icon" src="%s"/>`, EscapeHTML(uri))
+	}
+	return fmt.Sprintf(`<span class="page-mention-icon">%s</span>`, EscapeHTML(icon))
+}
+
 // PushNewBuffer creates a new buffer and sets Buf to it
 func (c *Converter) PushNewBuffer() {
 	c.bufs = append(c.bufs, c.Buf)
@@ -353,7 +475,7 @@ func (c *Converter) A(uri, text, cls string) {
 	// TODO: Notion seems to encode url but it's probably not correct
 	// (it encodes "&" as "&amp;")
 	// at best should only encoede as url
-	uri = EscapeHTML(uri)
+	uri = EscapeHTML(c.safeURL(uri))
 	text = EscapeHTML(text)
 	if cls != "" {
 		cls = fmt.Sprintf(` class="%s"`, cls)
@@ -426,9 +548,52 @@ func (c *Converter) RenderInline(b *notionapi.TextSpan) {
 		switch notionapi.AttrGetType(attr) {
 		case notionapi.AttrHighlight:
 			// TODO: possibly needs to change b.Highlight
-			hl := notionapi.AttrGetHighlight(attr)
+			hl := cleanClassName(strings.TrimSpace(notionapi.AttrGetHighlight(attr)))
 			start += fmt.Sprintf(`<mark class="highlight-%s">`, hl)
 			end = `</mark>` + end
+		case notionapi.AttrUnderline:
+			if c.NoInlineStyles {
+				start += `<span class="underline">`
+			} else {
+				start += `<span style="border-bottom:0.05em solid">`
+			}
+			end = `</span>` + end
+		case notionapi.AttrEquation:
+			tex := notionapi.AttrGetEquation(attr)
+			if htmlStr, ok := c.equationToHTML(tex, false); ok {
+				start += c.equationStyle() + `<span class="equation-inline">` + htmlStr + `</span>`
+			} else {
+				start += `<span class="equation-inline">` + EscapeHTML(tex) + `</span>`
+			}
+			text = ""
+		case notionapi.AttrLinkMention:
+			lm := notionapi.AttrGetLinkMention(attr)
+			if lm == nil {
+				continue
+			}
+			title := lm.Title
+			if title == "" {
+				title = lm.Href
+			}
+			start += fmt.Sprintf(`<a href="%s" class="link-mention">`, EscapeHTML(c.safeURL(c.RewrittenURL(lm.Href))))
+			if iconURL := c.safeURL(lm.IconURL); iconURL != "" {
+				start += fmt.Sprintf(`<img class="link-mention-icon" src="%s"/>`, EscapeHTML(iconURL))
+			}
+			start += EscapeHTML(title) + `</a>`
+			text = ""
+		case notionapi.AttrExternalObject:
+			id := notionapi.AttrGetExternalObjectID(attr)
+			title := ""
+			if nid := notionapi.NewNotionID(id); nid != nil && c.Page != nil {
+				if block := c.Page.BlockByID(nid); block != nil {
+					title = block.Title
+				}
+			}
+			if title == "" {
+				title = "External object"
+			}
+			start += fmt.Sprintf(`<span class="external-object">%s</span>`, EscapeHTML(title))
+			text = ""
 		case notionapi.AttrBold:
 			start += `<strong>`
 			end = `</strong>` + end
@@ -443,23 +608,25 @@ func (c *Converter) RenderInline(b *notionapi.TextSpan) {
 			end = `</code>` + end
 		case notionapi.AttrPage:
 			pageID := notionapi.AttrGetPageID(attr)
-			pageTitle := ""
+			pageTitle, icon := "", ""
 			relURL := notionapi.ToNoDashID(pageID)
-			nid := notionapi.NewNotionID(pageID)
-			block := c.Page.BlockByID(nid)
-			if block != nil {
-				pageTitle = block.Title
+			if info := c.pageInfo(pageID); info != nil {
+				pageTitle, icon = info.Title, info.Icon
 			}
 			if pageTitle != "" {
 				urlName := safeName(pageTitle)
 				urlName = strings.Replace(urlName, " ", "-", -1)
 				relURL = urlName + "-" + relURL
 			}
-			uri := c.RewrittenURL("https://www.notion.so/" + relURL)
-			start += fmt.Sprintf(`<a href="%s">%s</a>`, uri, EscapeHTML(pageTitle))
+			uri := c.attrURL(c.RewrittenURL("https://www.notion.so/" + relURL))
+			if c.NotionCompat {
+				start += fmt.Sprintf(`<a href="%s">%s</a>`, uri, EscapeHTML(pageTitle))
+			} else {
+				start += fmt.Sprintf(`<a href="%s" class="page-mention">%s%s</a>`, uri, c.pageMentionIcon(icon), EscapeHTML(pageTitle))
+			}
 			text = ""
 		case notionapi.AttrLink:
-			uri := c.RewrittenURL(notionapi.AttrGetLink(attr))
+			uri := c.safeURL(c.RewrittenURL(notionapi.AttrGetLink(attr)))
 			if uri == "" {
 				start += `<a>`
 			} else {
@@ -471,12 +638,17 @@ func (c *Converter) RenderInline(b *notionapi.TextSpan) {
 		case notionapi.AttrUser:
 			userID := notionapi.AttrGetUserID(attr)
 			userName := notionapi.GetUserNameByID(c.Page, userID)
-			start += fmt.Sprintf(`<span class="user">@%s</span>`, userName)
+			start += fmt.Sprintf(`<span class="user">@%s</span>`, EscapeHTML(userName))
 			text = ""
 		case notionapi.AttrDate:
 			date := notionapi.AttrGetDate(attr)
 			start += c.FormatDate(date)
 			text = ""
+		case notionapi.AttrComment:
+			if c.RenderComments && c.discussionNo(notionapi.AttrGetComment(attr)) > 0 {
+				start += `<span class="discussion-highlight">`
+				end = `</span>` + end
+			}
 		}
 	}
 	c.NoIndentPrintf(start + EscapeHTML(text) + end)
@@ -484,31 +656,96 @@ func (c *Converter) RenderInline(b *notionapi.TextSpan) {
 
 // RenderInlines renders inline blocks
 func (c *Converter) RenderInlines(blocks []*notionapi.TextSpan) {
-	for _, block := range blocks {
+	for i, block := range blocks {
 		c.RenderInline(block)
+		if c.RenderComments {
+			c.renderInlineDiscussionRefs(blocks, i)
+		}
+	}
+	if c.RenderComments && len(blocks) > 0 && len(c.pendingDiscussionNos) > 0 {
+		for _, n := range c.pendingDiscussionNos {
+			c.renderDiscussionRef(n)
+		}
+		c.pendingDiscussionNos = nil
 	}
 }
 
 // GetInlineContent is like RenderInlines but instead of writing to
-// output buffer, we return it as string
+// output buffer, we return it as string. It doesn't render comments so
+// that it can be used e.g. for table of contents without changing
+// numbering of discussions or consuming references pending for the
+// block being rendered
 func (c *Converter) GetInlineContent(blocks []*notionapi.TextSpan) string {
 	if len(blocks) == 0 {
 		return ""
 	}
+	renderComments := c.RenderComments
+	c.RenderComments = false
 	c.PushNewBuffer()
-	for _, block := range blocks {
-		c.RenderInline(block)
-	}
+	c.RenderInlines(blocks)
+	c.RenderComments = renderComments
 	return c.PopBuffer().String()
 }
 
+// CodeHighlighter converts code to syntax-highlighted HTML
+// See tohtml/highlight package for an implementation
+type CodeHighlighter interface {
+	// Highlight returns HTML for code in a given language. lang
+	// is the name of the language in Notion e.g. "Plain Text", "C++"
+	Highlight(code string, lang string) (string, error)
+}
+
+// HighlightOptions are restrictions on HTML generated by a CodeHighlighter
+type HighlightOptions struct {
+	// if true, HTML must not have scripts e.g. onclick attributes
+	NoScripts bool
+	// if true, HTML must not have style attributes
+	NoInlineStyles bool
+}
+
+// CodeHighlighterWithOptions is implemented by code highlighters that
+// can be used when Strict or NoInlineStyles is set. Other highlighters
+// are not used in those modes
+type CodeHighlighterWithOptions interface {
+	HighlightWithOptions(code string, lang string, opts *HighlightOptions) (string, error)
+}
+
+// highlightCode returns syntax-highlighted HTML for code or "" if
+// CodeHighlighter can't be used
+func (c *Converter) highlightCode(block *notionapi.Block) (string, error) {
+	if !c.Strict && !c.NoInlineStyles {
+		return c.CodeHighlighter.Highlight(block.Code, block.CodeLanguage)
+	}
+	h, ok := c.CodeHighlighter.(CodeHighlighterWithOptions)
+	if !ok {
+		return "", nil
+	}
+	opts := &HighlightOptions{
+		NoScripts:      c.Strict,
+		NoInlineStyles: c.NoInlineStyles,
+	}
+	return h.HighlightWithOptions(block.Code, block.CodeLanguage, opts)
+}
+
 // RenderCode renders BlockCode
 func (c *Converter) RenderCode(block *notionapi.Block) {
+	if c.CodeHighlighter != nil && !c.NotionCompat {
+		html, err := c.highlightCode(block)
+		if err != nil {
+			logf("RenderCode: CodeHighlighter.Highlight() failed with '%s'\n", err)
+		} else if html != "" {
+			c.Printf(`<figure id="%s" class="code-highlighted">`, block.ID)
+			c.NoIndentPrintf("%s", html)
+			c.RenderCaption(block)
+			c.Printf(`</figure>`)
+			return
+		}
+	}
 	cls := "code"
 	if !c.NotionCompat {
 		lang := strings.ToLower(strings.TrimSpace(block.CodeLanguage))
 		if lang != "" {
-			cls += " lang-" + lang
+			cls += " lang-" + cleanClassName(lang)
 		}
 	}
 	c.Printf(`<pre id="%s" class="%s">`, block.ID, cls)
@@ -550,10 +787,11 @@ func (c *Converter) renderPageHeader(block *notionapi.Block) {
 		pageCover, _ := block.PropAsString("format.page_cover")
 		if pageCover != "" {
 			position := (1 - formatPage.PageCoverPosition) * 100
-			coverURL := FilePathFromPageCoverURL(pageCover, block)
+			coverURL := c.fileURL(pageCover, block, FilePathFromPageCoverURL(pageCover, block))
 			// TODO: Notion incorrectly escapes them
-			coverURL = EscapeHTML(coverURL)
-			c.Printf(`<img class="page-cover-image" src="%s" style="object-position:center %v%%"/>`, coverURL, position)
+			coverURL = EscapeHTML(c.safeURL(coverURL))
+			style := c.style(fmt.Sprintf("object-position:center %v%%", position))
+			c.Printf(`<img class="page-cover-image" src="%s"%s/>`, coverURL, style)
 		}
 		pageIcon, _ := block.PropAsString("format.page_icon")
 		if pageIcon != "" {
@@ -566,10 +804,10 @@ func (c *Converter) renderPageHeader(block *notionapi.Block) {
 			c.indent++
 
 			if isURL(pageIcon) {
-				fileName := getDownloadedFileName(pageIcon, block)
-				c.Printf(`<img class="icon" src="%s"/>`, fileName)
+				fileName := c.fileURL(pageIcon, block, getDownloadedFileName(pageIcon, block))
+				c.Printf(`<img class="icon" src="%s"/>`, c.attrURL(fileName))
 			} else {
-				c.Printf(`<span class="icon">%s</span>`, pageIcon)
+				c.Printf(`<span class="icon">%s</span>`, c.escapeStrict(pageIcon))
 			}
 			c.decIndent()
 			c.Printf(`</div>`)
@@ -604,13 +842,16 @@ func (c *Converter) RenderCollectionViewPage(block *notionapi.Block) {
 	{
 		c.indent++
 		filePath := filePathForCollection(c.Page, col)
-		c.Printf(`<a href="%s">`, filePath)
+		if c.RewriteURL != nil && !c.NotionCompat {
+			filePath = c.RewriteURL("https://www.notion.so/" + notionapi.ToNoDashID(block.ID))
+		}
+		c.Printf(`<a href="%s">`, c.attrURL(filePath))
 		{
-			uri := getCollectionDownloadedFileName(c.Page, col, icon)
-			c.Printf(`<img class="icon" src="%s"/>`, uri)
+			uri := c.fileURL(icon, block, getCollectionDownloadedFileName(c.Page, col, icon))
+			c.Printf(`<img class="icon" src="%s"/>`, c.attrURL(uri))
 		}
 		// TODO: should name be inlines?
-		c.Printf(`%s</a>`, name)
+		c.Printf(`%s</a>`, c.escapeStrict(name))
 		c.decIndent()
 	}
 	c.Printf(`</figure>`)
@@ -625,14 +866,14 @@ func (c *Converter) renderLinkToPageNotion(block *notionapi.Block) {
 	c.Printf(`<figure id="%s" class="%s">`, block.ID, cls)
 	{
 		c.indent++
-		c.Printf(`<a href="%s">`, uri)
+		c.Printf(`<a href="%s">`, c.attrURL(uri))
 		pageIcon, ok := block.PropAsString("format.page_icon")
 		if ok {
 			if isURL(pageIcon) {
-				fileName := getDownloadedFileName(pageIcon, block)
-				c.Printf(`<img class="icon" src="%s"/>`, fileName)
+				fileName := c.fileURL(pageIcon, block, getDownloadedFileName(pageIcon, block))
+				c.Printf(`<img class="icon" src="%s"/>`, c.attrURL(fileName))
 			} else {
-				c.Printf(`<span class="icon">%s</span>`, pageIcon)
+				c.Printf(`<span class="icon">%s</span>`, c.escapeStrict(pageIcon))
 			}
 		}
 		// TODO: possibly r.RenderInlines(block.InlineContent)
@@ -649,7 +890,7 @@ func (c *Converter) renderLinkToPage(block *notionapi.Block) {
 		return
 	}
 
-	uri := filePathForPage(block)
+	uri := c.pageURL(block)
 	cls := GetBlockColorClass(block) + " link-to-page"
 	cls = CleanAttributeValue(cls)
 	c.indent++
@@ -657,14 +898,14 @@ func (c *Converter) renderLinkToPage(block *notionapi.Block) {
 	c.Printf(`<div id="%s" class="%s">`, block.ID, cls)
 	{
 		c.indent++
-		c.Printf(`<a href="%s">`, uri)
+		c.Printf(`<a href="%s">`, c.attrURL(uri))
 		pageIcon, ok := block.PropAsString("format.page_icon")
 		if ok {
 			if isURL(pageIcon) {
-				fileName := getDownloadedFileName(pageIcon, block)
-				c.Printf(`<img class="icon" src="%s"/>`, fileName)
+				fileName := c.fileURL(pageIcon, block, getDownloadedFileName(pageIcon, block))
+				c.Printf(`<img class="icon" src="%s"/>`, c.attrURL(fileName))
 			} else {
-				c.Printf(`<span class="icon">%s</span>`, pageIcon)
+				c.Printf(`<span class="icon">%s</span>`, c.escapeStrict(pageIcon))
 			}
 		}
 		// TODO: possibly r.RenderInlines(block.InlineContent)
@@ -675,6 +916,27 @@ func (c *Converter) renderLinkToPage(block *notionapi.Block) {
 	c.Printf(`</div>`)
 }
 
+// needsCSSPlus returns true if the page uses features styled by CSSPlus
+// e.g. comments or collection views other than tables
+func (c *Converter) needsCSSPlus() bool {
+	if c.RenderComments {
+		return true
+	}
+	if c.NotionCompat {
+		return false
+	}
+	if c.CodeHighlighter != nil || c.RenderAllCollectionViews {
+		return true
+	}
+	for _, tv := range c.Page.TableViews {
+		cv := tv.CollectionView
+		if cv != nil && cv.Type != "" && cv.Type != notionapi.CollectionViewTypeTable {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Converter) renderRootPage(block *notionapi.Block) {
 	if c.FullHTML {
 		c.Printf(`<html>`)
@@ -689,6 +951,9 @@ func (c *Converter) renderRootPage(block *notionapi.Block) {
 				styleValue := c.CustomCSS
 				if c.CustomCSS == "" {
 					styleValue = CSS
+					if c.needsCSSPlus() {
+						styleValue += CSSPlus
+					}
 				}
 				c.Printf("<style>%s\t\n</style>", styleValue)
 				c.decIndent()
@@ -704,12 +969,16 @@ func (c *Converter) renderRootPage(block *notionapi.Block) {
 	fp := block.FormatPage()
 	if fp != nil {
 		if fp.PageFont != "" {
-			clsFont = fp.PageFont
+			clsFont = cleanClassName(fp.PageFont)
 		}
 	}
 	c.Printf(`<article id="%s" class="page %s">`, block.ID, clsFont)
 	c.indent++
+	if c.RenderComments {
+		c.pendingDiscussionNos = c.blockDiscussionNos(block)
+	}
 	c.renderPageHeader(block)
+	c.flushPendingDiscussionRefs()
 	{
 		c.indent++
 		c.Printf(`<div class="page-body">`)
@@ -717,6 +986,9 @@ func (c *Converter) renderRootPage(block *notionapi.Block) {
 		c.Printf(`</div>`)
 		c.decIndent()
 	}
+	if c.RenderComments {
+		c.RenderDiscussions()
+	}
 	c.decIndent()
 	c.Printf(`</article>`)
 
@@ -756,7 +1028,7 @@ func GetBlockColorClass(block *notionapi.Block) string {
 	if col == "" {
 		return ""
 	}
-	return "block-color-" + col
+	return "block-color-" + cleanClassName(col)
 }
 
 // RenderText renders BlockText
@@ -778,32 +1050,40 @@ func (c *Converter) RenderText(block *notionapi.Block) {
 	c.Printf(`</div>`)
 }
 
-func equationToHTML(katexPath string, equation string) (string, error) {
-	cmd := exec.Command(katexPath, "-d")
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		return "", err
+func (c *Converter) equationRenderer() equation.Renderer {
+	if c.EquationRenderer != nil {
+		return c.EquationRenderer
 	}
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	err = cmd.Start()
-	if err != nil {
-		return "", err
+	if c.UseKatexToRenderEquation {
+		c.EquationRenderer = &equation.Katex{Path: c.KatexPath}
 	}
-	_, err = stdin.Write([]byte(equation))
-	if err != nil {
-		_ = cmd.Process.Kill()
-		return "", err
+	return c.EquationRenderer
+}
+
+// equationToHTML renders TeX equation with EquationRenderer.
+// Returns false if there's no renderer or rendering failed
+func (c *Converter) equationToHTML(tex string, display bool) (string, bool) {
+	r := c.equationRenderer()
+	if r == nil {
+		return "", false
 	}
-	err = stdin.Close()
+	htmlStr, err := r.RenderEquation(tex, display)
 	if err != nil {
-		return "", err
+		logf("failed to render equation '%s'. Error: %s\n", tex, err)
+		return "", false
 	}
-	if err = cmd.Wait(); err != nil {
-		return "", err
+	return htmlStr, true
+}
+
+// equationStyle returns <style> needed by equations from EquationRenderer.
+// It's only returned once per page
+func (c *Converter) equationStyle() string {
+	ss, ok := c.equationRenderer().(equation.StyleSheeter)
+	if !ok || c.didImportEquationCSS || c.NoInlineStyles {
+		return ""
 	}
-	res := out.String()
-	return res, nil
+	c.didImportEquationCSS = true
+	return fmt.Sprintf(`<style>%s</style>`, ss.StyleSheet())
 }
 
 // RenderEquation renders BlockEquation
@@ -811,34 +1091,17 @@ func (c *Converter) RenderEquation(block *notionapi.Block) {
 	c.indent++
 	defer c.decIndent()
 
-	if !c.UseKatexToRenderEquation {
-		c.Printf(`<figure id="%s" class="equation">`, block.ID)
-		c.RenderInlines(block.InlineContent)
-		c.Printf(`</figure>`)
-		return
-	}
-	ts := block.InlineContent
-	s := notionapi.TextSpansToString(ts)
-	htmlStr, err := equationToHTML(c.KatexPath, s)
-	if err != nil {
-		c.Printf(`<figure id="%s" class="equation">`, block.ID)
-		c.RenderInlines(block.InlineContent)
-		c.Printf(`</figure>`)
-		return
-	}
-
 	c.Printf(`<figure id="%s" class="equation">`, block.ID)
-	{
-		if !c.didImportKatexCSS {
-			c.Printf(`<style>@import url('https://cdnjs.cloudflare.com/ajax/libs/KaTeX/0.10.0/katex.min.css')</style>`)
-			c.didImportKatexCSS = true
+	s := notionapi.TextSpansToString(block.InlineContent)
+	if htmlStr, ok := c.equationToHTML(s, true); ok {
+		if style := c.equationStyle(); style != "" {
+			c.Printf(style)
 		}
 		c.Printf(`<div class="equation-container">`)
-		{
-			c.Printf(htmlStr)
-		}
+		c.Printf(htmlStr)
 		c.Printf(`</div>`)
-
+	} else {
+		c.RenderInlines(block.InlineContent)
 	}
 	c.Printf(`</figure>`)
 }
@@ -989,33 +1252,45 @@ func (c *Converter) RenderQuote(block *notionapi.Block) {
 	c.Printf(`</blockquote>`)
 }
 
-// CleanAttributeValue cleans value of an attribute
+// CleanAttributeValue cleans value of a class attribute: collapses
+// whitespace and replaces characters outside of [a-zA-Z0-9_-] in each
+// class name with '-'
 func CleanAttributeValue(v string) string {
-	v = strings.TrimSpace(v)
-	for {
-		s := strings.Replace(v, "  ", " ", -1)
-		if s == v {
-			return v
-		}
-		v = s
+	parts := strings.Fields(v)
+	for i, part := range parts {
+		parts[i] = cleanClassName(part)
 	}
+	return strings.Join(parts, " ")
+}
+
+// cleanClassName replaces characters outside of [a-zA-Z0-9_-] with '-'
+// so that class names built from page data can't break out of the
+// attribute
+func cleanClassName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return '-'
+	}, s)
 }
 
 // RenderCallout renders BlockCallout
 func (c *Converter) RenderCallout(block *notionapi.Block) {
 	cls := "notion-callout " + GetBlockColorClass(block)
 	cls = CleanAttributeValue(cls)
-	c.Printf(`<figure class="%s" style="display:flex" id="%s">`, cls, block.ID)
+	c.Printf(`<figure class="%s"%s id="%s">`, cls, c.style("display:flex"), block.ID)
 	{
 		c.Printf(`<div class="notion-figure-icon-wrap">`)
 		{
 			pageIcon, _ := block.PropAsString("format.page_icon")
-			c.Printf(`<span class="notion-figure-icon">%s</span>`, pageIcon)
+			c.Printf(`<span class="notion-figure-icon">%s</span>`, c.escapeStrict(pageIcon))
 		}
 		c.Printf(`</div>`)
 
 		{
-			c.Printf("%s", `<div style="width:100%">`)
+			c.Printf(`<div%s>`, c.style("width:100%"))
 			c.RenderInlines(block.InlineContent)
 			c.Printf(`</div>`)
 		}
@@ -1161,7 +1436,7 @@ func (c *Converter) RenderAudio(block *notionapi.Block) {
 			source := block.Source
 			fileName := source
 			if len(block.FileIDs) > 0 {
-				fileName = getDownloadedFileName(source, block)
+				fileName = c.fileURL(source, block, getDownloadedFileName(source, block))
 			}
 			if source == "" {
 				c.Printf(`<a></a>`)
@@ -1177,6 +1452,10 @@ func (c *Converter) RenderAudio(block *notionapi.Block) {
 
 // RenderVideo renders BlockVideo
 func (c *Converter) RenderVideo(block *notionapi.Block) {
+	// videos uploaded to Notion are files, not embeds
+	if len(block.FileIDs) == 0 && c.renderIframe(block, block.Source) {
+		return
+	}
 	c.Printf(`<figure id="%s">`, block.ID)
 	{
 		c.Printf(`<div class="source">`)
@@ -1184,7 +1463,7 @@ func (c *Converter) RenderVideo(block *notionapi.Block) {
 			source := block.Source
 			fileName := source
 			if len(block.FileIDs) > 0 {
-				fileName = getDownloadedFileName(source, block)
+				fileName = c.fileURL(source, block, getDownloadedFileName(source, block))
 			}
 			if source == "" {
 				c.Printf(`<a></a>`)
@@ -1199,6 +1478,9 @@ func (c *Converter) RenderVideo(block *notionapi.Block) {
 }
 
 func (c *Converter) renderEmbed(block *notionapi.Block) {
+	if c.renderIframe(block, block.Source) {
+		return
+	}
 	c.Printf(`<figure id="%s">`, block.ID)
 	{
 		c.Printf(`<div class="source">`)
@@ -1214,11 +1496,14 @@ func (c *Converter) renderEmbed(block *notionapi.Block) {
 
 // RenderEmbed renders BlockEmbed
 func (c *Converter) RenderEmbed(block *notionapi.Block) {
+	if c.renderIframe(block, block.Source) {
+		return
+	}
 	c.Printf(`<figure id="%s">`, block.ID)
 	{
 		c.Printf(`<div class="source">`)
 		{
-			uri := getFileOrSourceURL(block)
+			uri := c.fileOrSourceURL(block)
 			text := block.Source
 			c.A(uri, text, "")
 		}
@@ -1235,7 +1520,7 @@ func (c *Converter) RenderTweet(block *notionapi.Block) {
 
 // RenderGist renders BlockGist
 func (c *Converter) RenderGist(block *notionapi.Block) {
-	if c.NotionCompat {
+	if c.NotionCompat || c.Strict || c.XHTML {
 		c.renderEmbed(block)
 	} else {
 		uri := block.Source + ".js"
@@ -1263,7 +1548,7 @@ func (c *Converter) RenderFigma(block *notionapi.Block) {
 		c.Printf(`<div class="source">`)
 		{
 			uri := block.Source
-			c.Printf(`<a href="%s">%s</a>`, uri, uri)
+			c.Printf(`<a href="%s">%s</a>`, c.attrURL(uri), c.escapeStrict(uri))
 		}
 
 		c.Printf(`</div>`)
@@ -1278,7 +1563,7 @@ func (c *Converter) RenderFile(block *notionapi.Block) {
 	{
 		c.Printf(`<div class="source">`)
 		{
-			uri := getDownloadedFileName(block.Source, block)
+			uri := c.fileURL(block.Source, block, getDownloadedFileName(block.Source, block))
 			c.A(uri, block.Source, "")
 		}
 		c.Printf(`</div>`)
@@ -1294,11 +1579,16 @@ func (c *Converter) RenderDrive(block *notionapi.Block) {
 		c.Printf(`<div class="bookmark source">`)
 		{
 			icon, _ := block.PropAsString("format.drive_properties.icon")
-			c.Printf(`<img style="width:1em;height:1em;margin-right:0.5em;vertical-align:text-bottom" src="%s"/>`, icon)
+			style := c.style("width:1em;height:1em;margin-right:0.5em;vertical-align:text-bottom")
+			if c.NoInlineStyles {
+				style = ` class="drive-icon"`
+			}
+			c.Printf(`<img%s src="%s"/>`, style, c.attrURL(icon))
 
 			docURL, _ := block.PropAsString("format.drive_properties.url")
 			title, _ := block.PropAsString("format.drive_properties.title")
-			c.Printf(`<a href="%s">%s</a>`, docURL, title)
+			docURL = c.attrURL(docURL)
+			c.Printf(`<a href="%s">%s</a>`, docURL, c.escapeStrict(title))
 			c.Printf(`<br/>`)
 			c.Printf(`<a class="bookmark-href" href="%s">%s</a>`, docURL, docURL)
 		}
@@ -1313,7 +1603,7 @@ func (c *Converter) RenderPDF(block *notionapi.Block) {
 	c.Printf(`<figure id="%s">`, block.ID)
 	{
 		c.Printf(`<div class="source">`)
-		uri := getDownloadedFileName(block.Source, block)
+		uri := c.fileURL(block.Source, block, getDownloadedFileName(block.Source, block))
 		c.A(uri, block.Source, "")
 		c.Printf(`</div>`)
 		c.RenderCaption(block)
@@ -1334,10 +1624,17 @@ func (c *Converter) RenderImage(block *notionapi.Block) {
 	c.Printf(`<figure id="%s" class="image">`, block.ID)
 	{
 		// TODO: this might not work for images hosted on notion.so
-		uri := block.Source
+		uri := c.attrURL(c.fileURL(block.Source, block, block.Source))
 		style := getImageStyle(block)
+		if c.NoInlineStyles {
+			style = ""
+		}
 		c.Printf(`<a href="%s">`, uri)
-		c.Printf(`<img %ssrc="%s"/>`, style, uri)
+		if c.ResponsiveImages {
+			c.Printf(`<img %ssrc="%s"%s/>`, style, uri, c.responsiveImageAttrs(block))
+		} else {
+			c.Printf(`<img %ssrc="%s"/>`, style, uri)
+		}
 		c.Printf(`</a>`)
 
 		c.RenderCaption(block)
@@ -1366,7 +1663,8 @@ func (c *Converter) RenderColumn(block *notionapi.Block) {
 	if fc != nil {
 		colRatio = fc.ColumnRatio * 100
 	}
-	c.Printf(`<div id="%s" style="width:%v%%" class="column">`, block.ID, colRatio)
+	style := c.style(fmt.Sprintf("width:%v%%", colRatio))
+	c.Printf(`<div id="%s"%s class="column">`, block.ID, style)
 	c.RenderChildren(block)
 	c.Printf("</div>")
 }
@@ -1388,6 +1686,25 @@ func (c *Converter) findParentPageID(page *notionapi.Page, id string) string {
 	}
 }
 
+// breadcrumbPages returns parent pages of the current page, starting
+// with the top-most. We only know pages provided by PageByIDProvider
+func (c *Converter) breadcrumbPages() []*notionapi.Page {
+	var pages []*notionapi.Page
+	curr := c.Page
+	for {
+		id := curr.Root().ParentID
+		id = c.findParentPageID(curr, id)
+		parent := c.PageByID(id)
+		if parent == nil {
+			break
+		}
+		// we traverse upwards so prepend
+		pages = append([]*notionapi.Page{parent}, pages...)
+		curr = parent
+	}
+	return pages
+}
+
 func (c *Converter) RenderTransclusionReference(block *notionapi.Block) {
 	// TODO: implement me
 }
@@ -1407,34 +1724,18 @@ func (c *Converter) RenderBreadcrumb(block *notionapi.Block) {
 		return
 	}
 	c.Printf(`<div class="breadcrumbs">`)
-	pages := []*notionapi.Page{}
-	curr := c.Page
-	for {
-		id := curr.Root().ParentID
-		id = c.findParentPageID(curr, id)
-		parent := c.PageByID(id)
-		if parent == nil {
-			break
-		}
-		pages = append(pages, parent)
-		curr = parent
-	}
 	// TODO: add icon
-	// we traverse from the end because they were put
-	// in reverse order
-	idx := len(pages) - 1
-	for i := idx; i >= 0; i-- {
-		page := pages[i]
+	for _, page := range c.breadcrumbPages() {
 		title := page.Root().Title
 		pageID := notionapi.ToNoDashID(page.Root().ID)
 		uri := "https://www.notion.so/" + pageID
-		uri = c.RewriteURL(uri)
-		uri = EscapeHTML(uri)
-		c.Printf(`<div><a href="%s">%s</a></div>`, uri, title)
+		uri = c.RewrittenURL(uri)
+		uri = EscapeHTML(c.safeURL(uri))
+		c.Printf(`<div><a href="%s">%s</a></div>`, uri, c.escapeStrict(title))
 		c.Printf("<div>/</div>")
 	}
 	title := c.Page.Root().Title
-	c.Printf(`<div>%s</div>`, title)
+	c.Printf(`<div>%s</div>`, c.escapeStrict(title))
 	c.Printf(`</div>`)
 }
 
@@ -1468,11 +1769,12 @@ func isEmptyBlock(block *notionapi.Block) bool {
 	return len(block.ContentIDs) == 0
 }
 
-func (c *Converter) renderTableCell(tv *notionapi.TableView, row, col int) {
+// cellValue returns HTML for a value of a cell and a css class
+// for the type of the column
+func (c *Converter) cellValue(tv *notionapi.TableView, row, col int) (string, string) {
 	ci := tv.Columns[col]
 	tr := tv.Rows[row]
 	rowPage := tr.Page
-	colName := ci.ID()
 	schema := ci.Schema
 	textSpans := tv.CellContent(row, col)
 	colVal := c.GetInlineContent(textSpans)
@@ -1482,12 +1784,7 @@ func (c *Converter) renderTableCell(tv *notionapi.TableView, row, col int) {
 	// the value comes from page and their schema has to be fished out
 
 	if schema == nil {
-		colNameCls := EscapeHTML(colName)
-		if colVal == "" {
-			colVal = "&nbsp;"
-		}
-		c.Printf(`<td class="cell-%s">%s</td>`, colNameCls, colVal)
-		return
+		return colVal, ""
 	}
 
 	typ := schema.Type
@@ -1499,7 +1796,7 @@ func (c *Converter) renderTableCell(tv *notionapi.TableView, row, col int) {
 			// row here is a page. For cosmetic reasons we don't want
 			// to link to empty pages.
 		} else {
-			uri := c.tableTitleCellURL(tv, row, col)
+			uri := c.attrURL(c.tableTitleCellURL(tv, row, col))
 			if colVal == "" {
 				colVal = "Untitled"
 			}
@@ -1519,7 +1816,7 @@ func (c *Converter) renderTableCell(tv *notionapi.TableView, row, col int) {
 			if col == "" {
 				s += fmt.Sprintf(`<span class="selected-value">%s</span>`, v)
 			} else {
-				s += fmt.Sprintf(`<span class="selected-value block-color-%s_background">%s</span>`, col, v)
+				s += fmt.Sprintf(`<span class="selected-value block-color-%s_background">%s</span>`, cleanClassName(col), v)
 			}
 		}
 		colVal = s
@@ -1539,11 +1836,11 @@ func (c *Converter) renderTableCell(tv *notionapi.TableView, row, col int) {
 	} else if typ == notionapi.ColumnTypeLastEditedBy {
 		colTypeClass = "col-type-last-edited-by"
 		uid := rowPage.LastEditedBy
-		colVal = notionapi.GetUserNameByID(tv.Page, uid)
+		colVal = EscapeHTML(notionapi.GetUserNameByID(tv.Page, uid))
 	} else if typ == notionapi.ColumnTypeCreatedBy {
 		colTypeClass = "col-type-created-by"
 		uid := rowPage.CreatedBy
-		colVal = notionapi.GetUserNameByID(tv.Page, uid)
+		colVal = EscapeHTML(notionapi.GetUserNameByID(tv.Page, uid))
 	} else if schema.Type == notionapi.ColumnTypeRelation {
 		colTypeClass = "col-type-relation"
 		// TODO: not sure how to format relations
@@ -1557,7 +1854,12 @@ func (c *Converter) renderTableCell(tv *notionapi.TableView, row, col int) {
 		colTypeClass = "col-type-url"
 	}
 	// TODO: there are more types
+	return colVal, colTypeClass
+}
 
+func (c *Converter) renderTableCell(tv *notionapi.TableView, row, col int) {
+	colName := tv.Columns[col].ID()
+	colVal, colTypeClass := c.cellValue(tv, row, col)
 	colNameCls := EscapeHTML(colName)
 	if colVal == "" {
 		colVal = "&nbsp;"
@@ -1570,55 +1872,11 @@ func (c *Converter) renderTableCell(tv *notionapi.TableView, row, col int) {
 
 // very crude way of formatting "1234.33" => "1,234.33"
 func fmtNumberWithCommas(v string) string {
-	if v == "" {
-		return v
-	}
-	parts := strings.Split(v, ".")
-	s := parts[0]
-	var a []string
-	for len(s) > 3 {
-		n := len(s)
-		subs := s[n-3 : n]
-		a = append(a, subs)
-		s = s[0 : n-3]
-	}
-	if len(s) > 0 {
-		a = append(a, s)
-	}
-	// reverse an array
-	al := len(a)
-	n := al / 2
-	for i := 0; i < n; i++ {
-		a[i], a[al-i-1] = a[al-i-1], a[i]
-	}
-	res := strings.Join(a, ",")
-	if len(parts) == 2 {
-		return res + "." + parts[1]
-	}
-	return res
+	return notionapi.FormatNumberWithCommas(v)
 }
 
-// TODO: mmore formats
 func fmtNumber(v string, numFmt string) string {
-	if numFmt == "dollar" {
-		v = strings.TrimPrefix(v, "$")
-		f, err := strconv.ParseFloat(v, 64)
-		if err != nil {
-			return v
-		}
-		return fmt.Sprintf("$%.02f", f)
-	}
-	if numFmt == "percent" {
-		f, err := strconv.ParseFloat(v, 64)
-		if err != nil {
-			return v
-		}
-		return fmt.Sprintf("%.02f%%", f*100)
-	}
-	if numFmt == "number_with_commas" {
-		return fmtNumberWithCommas(v)
-	}
-	return v
+	return notionapi.FormatNumber(v, numFmt)
 }
 
 func getMultiSelectoColor(opts []*notionapi.CollectionColumnOption, val string) string {
@@ -1651,9 +1909,20 @@ func (c *Converter) RenderCollectionView(block *notionapi.Block) {
 		logf("missing block.CollectionViews for block %s %s in page %s\n", block.ID, block.Type, pageID)
 		return
 	}
-	// render only the first one
-	tv := block.TableViews[0]
+	if c.NotionCompat {
+		// Notion's export only renders the first view, as a table
+		c.renderTableView(block.ID, block.TableViews[0], true)
+		return
+	}
+	if c.RenderAllCollectionViews && len(block.TableViews) > 1 {
+		c.renderCollectionViewTabs(block)
+		return
+	}
+	c.renderCollectionViewOfType(block.ID, block.TableViews[0], true)
+}
 
+// renderTableView renders a collection view as a table
+func (c *Converter) renderTableView(id string, tv *notionapi.TableView, showTitle bool) {
 	nCols := tv.ColumnCount()
 	if nCols == 0 {
 		logf("didn't find columns inof in block '%s'\n", tv.CollectionView.ID)
@@ -1662,12 +1931,14 @@ func (c *Converter) RenderCollectionView(block *notionapi.Block) {
 	isList := tv.CollectionView.Type == notionapi.CollectionViewTypeList
 	//hasTitle := hasTitleColumn(tv.Columns)
 
-	c.Printf(`<div id="%s" class="collection-content">`, block.ID)
+	c.Printf(`<div id="%s" class="collection-content">`, id)
 	{
-		name := tv.Collection.GetName()
-		c.Printf(`<h4 class="collection-title">%s</h4>`, name)
+		if showTitle {
+			name := tv.Collection.GetName()
+			c.Printf(`<h4 class="collection-title">%s</h4>`, EscapeHTML(name))
+		}
 		if isList {
-			c.Printf("%s", `<table class="collection-content" style="width: 100%">`)
+			c.Printf(`<table class="collection-content"%s>`, c.style("width: 100%"))
 		} else {
 			c.Printf(`<table class="collection-content">`)
 		}
@@ -1836,24 +2107,23 @@ func (c *Converter) RenderBlock(block *notionapi.Block) {
 		}
 	}
 	def := c.DefaultRenderFunc(block.Type)
-	if def != nil {
-		def(block)
+	if def == nil {
+		return
 	}
+	// references for the parent block without inline content
+	c.flushPendingDiscussionRefs()
+	// discussions about the root page are rendered after page header
+	if c.RenderComments && !c.Page.IsRoot(block) {
+		c.pendingDiscussionNos = c.blockDiscussionNos(block)
+	}
+	def(block)
+	c.flushPendingDiscussionRefs()
 }
 
 func (c *Converter) detectKatex() error {
-	katexPath := c.KatexPath
-	if katexPath != "" {
-		if _, err := os.Stat(c.KatexPath); err == nil {
-			return nil
-		}
-	}
-	katexPath, err := exec.LookPath("katex")
+	katexPath, err := equation.DetectKatex(c.KatexPath)
 	if err != nil {
-		if c.KatexPath != "" {
-			return fmt.Errorf("UseKatexToRenderEquation is set but KatexPath ('%s') doesn't exist", c.KatexPath)
-		}
-		return fmt.Errorf("UseKatexToRenderEquation is set but couldn't locate katex binary (see https://katex.org/). You can install Katex with `npm install -g katex`. You can provide the path to katex binary via KatexPath. ")
+		return fmt.Errorf("UseKatexToRenderEquation is set but %s. You can provide the path to katex binary via KatexPath", err)
 	}
 	c.KatexPath = katexPath
 	return nil
@@ -1861,18 +2131,36 @@ func (c *Converter) detectKatex() error {
 
 // ToHTML renders a page to html
 func (c *Converter) ToHTML() ([]byte, error) {
-	if c.NotionCompat {
-		c.UseKatexToRenderEquation = true
-	}
-	if c.UseKatexToRenderEquation {
-		if err := c.detectKatex(); err != nil {
-			return nil, err
+	if c.EquationRenderer == nil {
+		if c.UseKatexToRenderEquation {
+			if err := c.detectKatex(); err != nil {
+				return nil, err
+			}
+		} else if c.NotionCompat {
+			// Notion uses katex but we don't want to fail if it's not
+			// installed so we fall back to MathML
+			if err := c.detectKatex(); err == nil {
+				c.UseKatexToRenderEquation = true
+			} else {
+				logf("%s. Using MathML to render equations\n", err)
+				c.EquationRenderer = &equation.MathML{}
+			}
 		}
 	}
 
+	if c.usesTemplate() {
+		d, err := c.toHTMLWithTemplate()
+		if err != nil || !c.XHTML {
+			return d, err
+		}
+		return toXHTML(d, true, true)
+	}
 	c.PushNewBuffer()
 	c.RenderBlock(c.Page.Root())
 	buf := c.PopBuffer()
+	if c.XHTML {
+		return toXHTML(buf.Bytes(), c.FullHTML, true)
+	}
 	return buf.Bytes(), nil
 }
 
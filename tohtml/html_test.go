@@ -1,9 +1,17 @@
 package tohtml
 
 import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/kjk/common/assert"
+	"github.com/kjk/notionapi"
+	"github.com/kjk/notionapi/equation"
+	"github.com/kjk/notionapi/internal/testutil"
 )
 
 func TestHTMLFileNameForPage(t *testing.T) {
@@ -34,3 +42,491 @@ func TestFmtNumberWithCommas(t *testing.T) {
 		assert.Equal(t, exp, got)
 	}
 }
+
+func mkRowWithProp(prop string, v interface{}) *notionapi.TableRow {
+	b := &notionapi.Block{
+		Properties: map[string]interface{}{
+			prop: v,
+		},
+	}
+	return &notionapi.TableRow{Page: b}
+}
+
+func TestBoardGroups(t *testing.T) {
+	tv := &notionapi.TableView{
+		Collection: &notionapi.Collection{
+			Schema: map[string]*notionapi.ColumnSchema{
+				"st": {
+					Name: "Status",
+					Type: notionapi.ColumnTypeSelect,
+					Options: []*notionapi.CollectionColumnOption{
+						{Value: "Todo", Color: "red"},
+						{Value: "Done", Color: "green"},
+					},
+				},
+			},
+		},
+		CollectionView: &notionapi.CollectionView{
+			Type: notionapi.CollectionViewTypeBoard,
+			Format: &notionapi.FormatTable{
+				BoardColumnsBy: &notionapi.BoardColumnsBy{Type: "select", Property: "st"},
+			},
+		},
+	}
+	tv.Rows = []*notionapi.TableRow{
+		mkRowWithProp("st", []interface{}{[]interface{}{"Done"}}),
+		mkRowWithProp("other", []interface{}{[]interface{}{"x"}}),
+		mkRowWithProp("st", []interface{}{[]interface{}{"Done"}}),
+	}
+	assert.Equal(t, "st", boardGroupByProperty(tv))
+	groups := getBoardGroups(tv, "st")
+	assert.Equal(t, 3, len(groups))
+	assert.Equal(t, "", groups[0].value)
+	assert.Equal(t, []int{1}, groups[0].rows)
+	assert.Equal(t, "Todo", groups[1].value)
+	assert.Equal(t, 0, len(groups[1].rows))
+	assert.Equal(t, "green", groups[2].color)
+	assert.Equal(t, []int{0, 2}, groups[2].rows)
+}
+
+func TestBoardCardIDs(t *testing.T) {
+	tv := &notionapi.TableView{
+		Collection: &notionapi.Collection{
+			Schema: map[string]*notionapi.ColumnSchema{
+				"tags": {Name: "Tags", Type: notionapi.ColumnTypeMultiSelect},
+			},
+		},
+		CollectionView: &notionapi.CollectionView{
+			Type: notionapi.CollectionViewTypeBoard,
+			Format: &notionapi.FormatTable{
+				BoardColumnsBy: &notionapi.BoardColumnsBy{Type: "multi_select", Property: "tags"},
+			},
+		},
+	}
+	row := mkRowWithProp("tags", []interface{}{[]interface{}{"a,b"}})
+	row.Page.ID = "row-page-id"
+	tv.Rows = []*notionapi.TableRow{row}
+	c := NewConverter(nil)
+	c.PushNewBuffer()
+	c.renderBoardView("view-id", tv, false)
+	s := c.PopBuffer().String()
+	// a card is rendered in both groups so it can't have an id
+	assert.Equal(t, 2, strings.Count(s, `class="collection-card`), "got:\n%s", s)
+	assert.False(t, strings.Contains(s, "row-page-id"), "got:\n%s", s)
+}
+
+func TestCalendarRowDays(t *testing.T) {
+	date := map[string]interface{}{
+		"type":       "daterange",
+		"start_date": "2020-01-30",
+		"end_date":   "2020-02-02",
+	}
+	v := []interface{}{
+		[]interface{}{"‣", []interface{}{[]interface{}{"d", date}}},
+	}
+	tv := &notionapi.TableView{
+		Collection: &notionapi.Collection{},
+		Rows:       []*notionapi.TableRow{mkRowWithProp("when", v)},
+	}
+	days := getRowDays(tv, 0, "when")
+	assert.Equal(t, 4, len(days))
+	assert.Equal(t, "2020-02-02", days[3].Format("2006-01-02"))
+}
+
+func TestDefaultTemplate(t *testing.T) {
+	data := &PageTemplateData{
+		ID:           "6682351e-44bb-4f9c-a0e1-49b703265bdb",
+		Title:        "Test & headers",
+		TitleHTML:    template.HTML("<strong>Test</strong> &amp; headers"),
+		Icon:         "🚀",
+		CoverURL:     "cover.jpg",
+		Font:         "serif",
+		LastEditedOn: time.Date(2019, 5, 20, 22, 41, 0, 0, time.UTC),
+		Breadcrumbs: []*PageLink{
+			{Title: "Parent", URL: "parent.html"},
+		},
+		Body:     template.HTML("<p>body</p>"),
+		CSS:      template.CSS(ThemeDark.CSS),
+		CSSFiles: []string{"site.css"},
+	}
+	var buf bytes.Buffer
+	err := defaultTemplate.Execute(&buf, data)
+	assert.NoError(t, err)
+	s := buf.String()
+	exp := []string{
+		`<title>Test &amp; headers</title>`,
+		`<link rel="stylesheet" href="site.css"/>`,
+		`<article id="6682351e-44bb-4f9c-a0e1-49b703265bdb" class="page serif">`,
+		`<div><a href="parent.html">Parent</a></div>`,
+		`<img class="page-cover-image" src="cover.jpg"`,
+		`<div class="page-header-icon page-header-icon-with-cover"><span class="icon">🚀</span></div>`,
+		`<h1 class="page-title"><strong>Test</strong> &amp; headers</h1>`,
+		`<p>body</p>`,
+		`Last edited <time>May 20, 2019</time>`,
+	}
+	for _, e := range exp {
+		assert.True(t, strings.Contains(s, e), "missing '%s' in:\n%s", e, s)
+	}
+	assert.False(t, strings.Contains(s, "Created"))
+}
+
+func TestThemeByName(t *testing.T) {
+	assert.Equal(t, ThemeDark, ThemeByName("dark"))
+	assert.Nil(t, ThemeByName("no-such-theme"))
+}
+
+func TestRenderEquation(t *testing.T) {
+	block := &notionapi.Block{
+		ID:            "eq",
+		InlineContent: []*notionapi.TextSpan{{Text: `\frac{1}{2}`}},
+	}
+	c := NewConverter(nil)
+	c.EquationRenderer = &equation.MathML{NoAnnotation: true}
+	c.PushNewBuffer()
+	c.RenderEquation(block)
+	s := c.PopBuffer().String()
+	exp := []string{
+		`<figure id="eq" class="equation">`,
+		`<div class="equation-container">`,
+		`<math xmlns="http://www.w3.org/1998/Math/MathML" display="block"><mfrac><mn>1</mn><mn>2</mn></mfrac></math>`,
+	}
+	for _, e := range exp {
+		assert.True(t, strings.Contains(s, e), "missing '%s' in:\n%s", e, s)
+	}
+
+	// falls back to TeX source if equation can't be rendered
+	block.InlineContent = []*notionapi.TextSpan{{Text: `\frac{1}{2`}}
+	c.PushNewBuffer()
+	c.RenderEquation(block)
+	s = c.PopBuffer().String()
+	assert.True(t, strings.Contains(s, `\frac{1}{2`), "got:\n%s", s)
+	assert.False(t, strings.Contains(s, `<math`))
+}
+
+func TestRenderInline(t *testing.T) {
+	tests := []struct {
+		span *notionapi.TextSpan
+		exp  string
+	}{
+		{
+			&notionapi.TextSpan{Text: "u", Attrs: []notionapi.TextAttr{{notionapi.AttrUnderline}}},
+			`<span style="border-bottom:0.05em solid">u</span>`,
+		},
+		{
+			&notionapi.TextSpan{Text: notionapi.TextSpanEquation, Attrs: []notionapi.TextAttr{{notionapi.AttrEquation, "x<1"}}},
+			`<span class="equation-inline">x&lt;1</span>`,
+		},
+		{
+			&notionapi.TextSpan{Text: notionapi.TextSpanSpecial, Attrs: []notionapi.TextAttr{{notionapi.AttrLinkMention, `{"href":"https://github.com","title":"GitHub"}`}}},
+			`<a href="https://github.com" class="link-mention">GitHub</a>`,
+		},
+		{
+			&notionapi.TextSpan{Text: "?", Attrs: []notionapi.TextAttr{{"no-such-attr"}}},
+			`?`,
+		},
+	}
+	c := NewConverter(nil)
+	for _, test := range tests {
+		got := c.GetInlineContent([]*notionapi.TextSpan{test.span})
+		assert.Equal(t, test.exp, got)
+	}
+
+	c.EquationRenderer = &equation.MathML{NoAnnotation: true}
+	got := c.GetInlineContent([]*notionapi.TextSpan{tests[1].span})
+	assert.Equal(t, `<span class="equation-inline"><math xmlns="http://www.w3.org/1998/Math/MathML"><mrow><mi>x</mi><mo>&lt;</mo><mn>1</mn></mrow></math></span>`, got)
+}
+
+func TestIsSafeURL(t *testing.T) {
+	tests := []struct {
+		uri  string
+		safe bool
+	}{
+		{"https://blog.kowalczyk.info", true},
+		{"http://example.com/a:b", true},
+		{"mailto:foo@example.com", true},
+		{"page.html", true},
+		{"#header", true},
+		{"/foo/bar:baz", true},
+		{"javascript:alert(1)", false},
+		{"JavaScript:alert(1)", false},
+		{" java\tscript:alert(1)", false},
+		{"data:text/html;base64,PHNjcmlwdD4=", false},
+		{"vbscript:msgbox", false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.safe, IsSafeURL(test.uri), "uri: '%s'", test.uri)
+	}
+}
+
+func TestStrict(t *testing.T) {
+	link := &notionapi.TextSpan{Text: "click", Attrs: []notionapi.TextAttr{{notionapi.AttrLink, "javascript:alert(1)"}}}
+	c := NewConverter(nil)
+	assert.Equal(t, `<a href="javascript:alert(1)">click</a>`, c.GetInlineContent([]*notionapi.TextSpan{link}))
+	c.Strict = true
+	assert.Equal(t, `<a>click</a>`, c.GetInlineContent([]*notionapi.TextSpan{link}))
+
+	underline := &notionapi.TextSpan{Text: "u", Attrs: []notionapi.TextAttr{{notionapi.AttrUnderline}}}
+	c.NoInlineStyles = true
+	assert.Equal(t, `<span class="underline">u</span>`, c.GetInlineContent([]*notionapi.TextSpan{underline}))
+
+	// user names are escaped in mentions and in created by columns
+	c = NewConverter(testutil.LoadPage(t, "page.json"))
+	c.Strict = true
+	d, err := c.ToHTML()
+	assert.NoError(t, err)
+	s := string(d)
+	assert.False(t, strings.Contains(s, "<img src=x"), "got:\n%s", s)
+	assert.True(t, strings.Contains(s, `<span class="user">@&lt;img src=x onerror=alert(1)&gt;</span>`), "got:\n%s", s)
+	assert.True(t, strings.Contains(s, `>&lt;img src=x onerror=alert(1)&gt;</td>`), "got:\n%s", s)
+}
+
+func TestStrictClassNames(t *testing.T) {
+	render := func(c *Converter, fn func(*notionapi.Block), block *notionapi.Block) string {
+		c.PushNewBuffer()
+		fn(block)
+		return c.PopBuffer().String()
+	}
+	c := NewConverter(nil)
+	c.Strict = true
+	c.NoInlineStyles = true
+
+	hl := &notionapi.TextSpan{Text: "hl", Attrs: []notionapi.TextAttr{{notionapi.AttrHighlight, `red" onmouseover="x`}}}
+	assert.Equal(t, `<mark class="highlight-red--onmouseover--x">hl</mark>`, c.GetInlineContent([]*notionapi.TextSpan{hl}))
+
+	code := &notionapi.Block{ID: "code", Type: notionapi.BlockCode, Code: "x", CodeLanguage: `Go" onclick="x`}
+	s := render(c, c.RenderCode, code)
+	assert.True(t, strings.Contains(s, `<pre id="code" class="code lang-go--onclick--x">`), "got:\n%s", s)
+
+	callout := &notionapi.Block{
+		ID:   "callout",
+		Type: notionapi.BlockCallout,
+		RawJSON: map[string]interface{}{
+			"format": map[string]interface{}{
+				"block_color": `red" x="`,
+			},
+		},
+	}
+	s = render(c, c.RenderCallout, callout)
+	assert.True(t, strings.Contains(s, `<figure class="notion-callout block-color-red--x--" id="callout">`), "got:\n%s", s)
+	text := &notionapi.Block{ID: "text", Type: notionapi.BlockText, RawJSON: callout.RawJSON}
+	s = render(c, c.RenderText, text)
+	assert.True(t, strings.Contains(s, `class="block-color-red--x--"`), "got:\n%s", s)
+
+	assert.Equal(t, "collection-gallery gallery-large--x", CleanAttributeValue(` collection-gallery  gallery-large"<x `))
+}
+
+func TestEmbedHosts(t *testing.T) {
+	render := func(c *Converter, uri string) string {
+		block := &notionapi.Block{ID: "embed", Source: uri}
+		c.PushNewBuffer()
+		c.RenderEmbed(block)
+		return c.PopBuffer().String()
+	}
+	c := NewConverter(nil)
+	youtube := "https://www.youtube.com/embed/dQw4w9WgXcQ"
+	s := render(c, youtube)
+	assert.False(t, strings.Contains(s, "<iframe"))
+
+	c.EmbedHosts = DefaultEmbedHosts
+	s = render(c, youtube)
+	assert.True(t, strings.Contains(s, `<iframe src="https://www.youtube.com/embed/dQw4w9WgXcQ"`), "got:\n%s", s)
+	s = render(c, "https://evil.example.com/embed")
+	assert.False(t, strings.Contains(s, "<iframe"))
+	s = render(c, "http://www.youtube.com/embed/dQw4w9WgXcQ")
+	assert.False(t, strings.Contains(s, "<iframe"))
+}
+
+func TestResponsiveImages(t *testing.T) {
+	block := &notionapi.Block{
+		ID:          "8511412c-fc1a-4d2a-b3ab-b3ab3a4d2a64",
+		Type:        notionapi.BlockImage,
+		ParentTable: "block",
+		Source:      "https://s3-us-west-2.amazonaws.com/secure.notion-static.com/e5470cfd/image.png",
+		RawJSON: map[string]interface{}{
+			"format": map[string]interface{}{
+				"block_width":        float64(500),
+				"block_aspect_ratio": 0.5,
+			},
+		},
+	}
+	render := func(c *Converter) string {
+		c.PushNewBuffer()
+		c.RenderImage(block)
+		return c.PopBuffer().String()
+	}
+	c := NewConverter(nil)
+	s := render(c)
+	assert.False(t, strings.Contains(s, "srcset"))
+
+	c.ResponsiveImages = true
+	s = render(c)
+	assert.True(t, strings.Contains(s, ` width="500" height="250" loading="lazy"`), "got:\n%s", s)
+	assert.True(t, strings.Contains(s, `&amp;width=480 480w, https://www.notion.so/image/`), "got:\n%s", s)
+	assert.True(t, strings.Contains(s, `&amp;width=800 800w"`), "got:\n%s", s)
+	assert.True(t, strings.Contains(s, ` sizes="(max-width: 500px) 100vw, 500px"`), "got:\n%s", s)
+
+	// local files without resized versions
+	c.RewriteFileURL = func(uri string, block *notionapi.Block) string {
+		return "files/image.png"
+	}
+	s = render(c)
+	assert.True(t, strings.Contains(s, `src="files/image.png" width="500" height="250" loading="lazy" decoding="async"/>`), "got:\n%s", s)
+
+	c.ResizeImageURL = func(uri string, block *notionapi.Block, width int) string {
+		if width > 800 {
+			return ""
+		}
+		return fmt.Sprintf("files/image-%dw.png", width)
+	}
+	c.ImageWidths = []int{1600, 400, 800}
+	s = render(c)
+	assert.True(t, strings.Contains(s, ` srcset="files/image-400w.png 400w, files/image-800w.png 800w"`), "got:\n%s", s)
+}
+
+type testPageInfos map[string]*notionapi.PageInfo
+
+func (p testPageInfos) PageInfo(id string) *notionapi.PageInfo {
+	return p[notionapi.ToNoDashID(id)]
+}
+
+func TestPageMention(t *testing.T) {
+	span := &notionapi.TextSpan{
+		Text:  notionapi.TextSpanSpecial,
+		Attrs: []notionapi.TextAttr{{notionapi.AttrPage, "3b617da4-0945-4a52-bc3a-920ba8832bf7"}},
+	}
+	render := func(c *Converter) string {
+		c.PushNewBuffer()
+		c.RenderInline(span)
+		return c.PopBuffer().String()
+	}
+	c := NewConverter(nil)
+	c.PageInfoProvider = testPageInfos{
+		"3b617da409454a52bc3a920ba8832bf7": {Title: "Employee <Handbook>", Icon: "📖"},
+	}
+	s := render(c)
+	exp := `<a href="https://www.notion.so/Employee-Handbook-3b617da409454a52bc3a920ba8832bf7" class="page-mention"><span class="page-mention-icon">📖</span>Employee &lt;Handbook&gt;</a>`
+	assert.Equal(t, exp, s)
+
+	c.NotionCompat = true
+	s = render(c)
+	exp = `<a href="https://www.notion.so/Employee-Handbook-3b617da409454a52bc3a920ba8832bf7">Employee &lt;Handbook&gt;</a>`
+	assert.Equal(t, exp, s)
+}
+
+func TestToXHTML(t *testing.T) {
+	s := `<div class="a">x&nbsp;y<br><input type="radio" checked><img src="a.png"></div>` +
+		`<svg viewBox="0 0 8 8"><path d="M0 0"></path></svg><math><mi>x</mi></math>`
+	d, err := ToXHTML([]byte(s), false)
+	assert.NoError(t, err)
+	exp := `<div class="a">x` + "\u00a0" + `y<br/><input type="radio" checked=""/><img src="a.png"/></div>` +
+		`<svg viewBox="0 0 8 8" xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"><path d="M0 0"></path></svg>` +
+		`<math xmlns="http://www.w3.org/1998/Math/MathML"><mi>x</mi></math>`
+	assert.Equal(t, exp, string(d))
+
+	d, err = ToXHTML([]byte(`<!DOCTYPE html><html><head><title>a &amp; b</title></head><body><hr></body></html>`), true)
+	assert.NoError(t, err)
+	assert.Equal(t, `<!DOCTYPE html><html xmlns="http://www.w3.org/1999/xhtml"><head><title>a &amp; b</title></head><body><hr/></body></html>`, string(d))
+
+	// embeds and gists are links
+	c := NewConverter(nil)
+	c.XHTML = true
+	c.EmbedHosts = DefaultEmbedHosts
+	c.PushNewBuffer()
+	c.RenderEmbed(&notionapi.Block{ID: "embed", Source: "https://www.youtube.com/embed/dQw4w9WgXcQ"})
+	c.RenderGist(&notionapi.Block{ID: "gist", Source: "https://gist.github.com/kjk/abc"})
+	s = c.PopBuffer().String()
+	assert.False(t, strings.Contains(s, "<iframe"), "got:\n%s", s)
+	assert.False(t, strings.Contains(s, "<script"), "got:\n%s", s)
+
+	// ids must start with a letter
+	assert.Equal(t, "id-2c1b7e5d", XHTMLID("2c1b7e5d"))
+	assert.Equal(t, "discussion-1", XHTMLID("discussion-1"))
+	c = NewConverter(testutil.LoadPage(t, "page.json"))
+	c.XHTML = true
+	d, err = c.ToHTML()
+	assert.NoError(t, err)
+	s = string(d)
+	assert.True(t, strings.Contains(s, `<h1 id="id-10000000-0000-4000-8000-000000000001"`), "got:\n%s", s)
+	assert.True(t, strings.Contains(s, `<article id="id-1b0a6f4c-6c1e-4a3b-9a7e-3c1f0e2d4b5a"`), "got:\n%s", s)
+}
+
+func TestCollectionViewCSS(t *testing.T) {
+	page := testutil.LoadPage(t, "page.json")
+	tv := page.TableViews[0]
+	tv.Collection.Name = []interface{}{[]interface{}{"<b>Todo</b>"}}
+	render := func() string {
+		c := NewConverter(page)
+		c.FullHTML = true
+		d, err := c.ToHTML()
+		assert.NoError(t, err)
+		return string(d)
+	}
+	s := render()
+	assert.True(t, strings.Contains(s, `<h4 class="collection-title">&lt;b&gt;Todo&lt;/b&gt;</h4>`), "got:\n%s", s)
+	// CSSPlus is only included when needed
+	assert.False(t, strings.Contains(s, ".collection-list"), "got:\n%s", s)
+
+	tv.CollectionView.Type = notionapi.CollectionViewTypeList
+	s = render()
+	assert.True(t, strings.Contains(s, `<h4 class="collection-title">&lt;b&gt;Todo&lt;/b&gt;</h4>`), "got:\n%s", s)
+	assert.True(t, strings.Contains(s, ".collection-list"), "got:\n%s", s)
+}
+
+func TestComments(t *testing.T) {
+	page := testutil.LoadPage(t, "comments.json")
+	c := NewConverter(page)
+	c.RenderComments = true
+	d, err := c.ToHTML()
+	assert.NoError(t, err)
+	s := string(d)
+	exp := []string{
+		// table of contents doesn't reference discussions
+		`<a class="table_of_contents-link" href="#30000000-0000-4000-8000-000000000002">Introduction</a>`,
+		`Intro<span class="discussion-highlight">duction</span><sup class="discussion-ref"><a href="#discussion-1">[1]</a></sup>`,
+		// a discussion about text spanning 2 spans is referenced once.
+		// resolved discussions are not shown
+		`See <span class="discussion-highlight">this</span><span class="discussion-highlight"><strong> part</strong></span><sup class="discussion-ref"><a href="#discussion-2">[2]</a></sup>, old.`,
+		// discussions without comments are not shown
+		`Whole block<sup class="discussion-ref"><a href="#discussion-3">[3]</a></sup>`,
+		`<li id="discussion-2" class="discussion">`,
+		`<blockquote class="discussion-context">this part</blockquote>`,
+		`<span class="comment-author">Ann &lt;Lee&gt;</span> <time>Mar 2, 2024</time>`,
+		`<div class="comment-body">Split it</div>`,
+	}
+	for _, e := range exp {
+		assert.True(t, strings.Contains(s, e), "expected '%s' in:\n%s", e, s)
+	}
+	assert.False(t, strings.Contains(s, "discussion-4"))
+	assert.False(t, strings.Contains(s, "Fixed"))
+
+	c = NewConverter(page)
+	c.RenderComments = true
+	c.RenderResolvedComments = true
+	d, err = c.ToHTML()
+	assert.NoError(t, err)
+	s = string(d)
+	exp = []string{
+		`<span class="discussion-highlight">old</span><sup class="discussion-ref"><a href="#discussion-3">[3]</a></sup>`,
+		`Whole block<sup class="discussion-ref"><a href="#discussion-4">[4]</a></sup>`,
+		`<li id="discussion-3" class="discussion discussion-resolved">`,
+		`<div class="comment-body">Fixed</div>`,
+	}
+	for _, e := range exp {
+		assert.True(t, strings.Contains(s, e), "expected '%s' in:\n%s", e, s)
+	}
+}
+
+func TestGetInlineContentWithComments(t *testing.T) {
+	page := testutil.LoadPage(t, "comments.json")
+	c := NewConverter(page)
+	c.RenderComments = true
+	block := page.BlockByID(notionapi.NewNotionID("30000000-0000-4000-8000-000000000004"))
+	c.pendingDiscussionNos = c.blockDiscussionNos(block)
+	assert.Equal(t, "Whole block", c.GetInlineContent(block.InlineContent))
+	// references pending for the block are not consumed
+	assert.Equal(t, []int{1}, c.pendingDiscussionNos)
+
+	header := page.BlockByID(notionapi.NewNotionID("30000000-0000-4000-8000-000000000002"))
+	assert.Equal(t, "Introduction", c.GetInlineContent(header.InlineContent))
+	assert.Equal(t, 1, len(c.discussions.Discussions))
+}
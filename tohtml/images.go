@@ -0,0 +1,102 @@
+package tohtml
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/kjk/notionapi"
+)
+
+// DefaultImageWidths are widths of images in srcset when
+// Converter.ResponsiveImages is set
+var DefaultImageWidths = []int{480, 800, 1200, 1800}
+
+// default width of page content, in css pixels
+const contentWidth = 900
+
+// imageSize returns width and height of an image as shown in Notion
+// or 0, 0 if not known
+func imageSize(block *notionapi.Block) (int, int) {
+	f := block.FormatImage()
+	if f == nil || f.BlockWidth <= 0 {
+		return 0, 0
+	}
+	w := int(math.Round(f.BlockWidth))
+	h := 0
+	if f.BlockAspectRatio > 0 {
+		// aspect ratio is height / width
+		h = int(math.Round(f.BlockWidth * f.BlockAspectRatio))
+	} else if f.BlockHeight > 0 {
+		h = int(math.Round(f.BlockHeight))
+	}
+	if h <= 0 {
+		return 0, 0
+	}
+	return w, h
+}
+
+func (c *Converter) resizeImageURL(uri string, block *notionapi.Block, width int) string {
+	if c.ResizeImageURL != nil {
+		return c.ResizeImageURL(uri, block, width)
+	}
+	if c.RewriteFileURL != nil {
+		// files are re-written e.g. to local copies, we don't want
+		// to link to Notion's copies
+		return ""
+	}
+	return notionapi.ResizedImageURL(uri, block, width)
+}
+
+// imageSrcset returns value of srcset attribute for an image or "" if
+// resized versions are not available
+func (c *Converter) imageSrcset(block *notionapi.Block, displayWidth int) string {
+	widths := c.ImageWidths
+	if len(widths) == 0 {
+		widths = DefaultImageWidths
+	}
+	widths = append([]int(nil), widths...)
+	sort.Ints(widths)
+	var parts []string
+	seen := map[string]bool{}
+	for i, w := range widths {
+		// no point in images more than 2x wider than shown (on hidpi screens)
+		if i > 0 && displayWidth > 0 && w > 2*displayWidth {
+			break
+		}
+		uri := c.resizeImageURL(block.Source, block, w)
+		if uri == "" || seen[uri] {
+			continue
+		}
+		seen[uri] = true
+		uri = c.safeURL(uri)
+		if uri == "" {
+			continue
+		}
+		// urls in srcset are separated by commas and can't have spaces
+		uri = strings.ReplaceAll(uri, ",", "%2C")
+		uri = strings.ReplaceAll(uri, " ", "%20")
+		parts = append(parts, fmt.Sprintf("%s %dw", EscapeHTML(uri), w))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// responsiveImageAttrs returns width, height, loading, srcset and sizes
+// attributes (with a leading space) for an image
+func (c *Converter) responsiveImageAttrs(block *notionapi.Block) string {
+	w, h := imageSize(block)
+	var sb strings.Builder
+	if w > 0 {
+		fmt.Fprintf(&sb, ` width="%d" height="%d"`, w, h)
+	}
+	sb.WriteString(` loading="lazy" decoding="async"`)
+	if srcset := c.imageSrcset(block, w); srcset != "" {
+		sizesWidth := contentWidth
+		if w > 0 && w < contentWidth {
+			sizesWidth = w
+		}
+		fmt.Fprintf(&sb, ` srcset="%s" sizes="(max-width: %dpx) 100vw, %dpx"`, srcset, sizesWidth, sizesWidth)
+	}
+	return sb.String()
+}
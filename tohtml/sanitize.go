@@ -0,0 +1,135 @@
+package tohtml
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/kjk/notionapi"
+)
+
+// SafeURLSchemes are URL schemes allowed in Strict mode. Relative
+// URLs (e.g. "foo.html" or "#id") are always allowed
+var SafeURLSchemes = []string{"http", "https", "mailto", "tel"}
+
+// DefaultEmbedHosts are hosts of popular services whose embeds can be
+// safely rendered as iframes. Use with Converter.EmbedHosts
+var DefaultEmbedHosts = []string{
+	"www.youtube.com",
+	"www.youtube-nocookie.com",
+	"player.vimeo.com",
+	"codepen.io",
+	"www.figma.com",
+	"www.google.com",
+	"maps.google.com",
+	"docs.google.com",
+	"drive.google.com",
+	"open.spotify.com",
+	"www.loom.com",
+}
+
+// urlScheme returns lower-cased scheme of uri or "" for relative urls.
+// Browsers ignore whitespace and control characters in schemes (e.g.
+// "java\tscript:") so we do too
+func urlScheme(uri string) string {
+	var sb strings.Builder
+	for _, r := range uri {
+		if r <= ' ' {
+			continue
+		}
+		switch {
+		case r == ':':
+			return strings.ToLower(sb.String())
+		case r == '/', r == '?', r == '#':
+			return ""
+		}
+		sb.WriteRune(r)
+	}
+	return ""
+}
+
+// IsSafeURL returns true if uri is relative or uses one of SafeURLSchemes
+func IsSafeURL(uri string) bool {
+	scheme := urlScheme(uri)
+	if scheme == "" {
+		return true
+	}
+	for _, s := range SafeURLSchemes {
+		if scheme == s {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmbedHostAllowed returns true if uri is an https:// url whose
+// host is one of hosts
+func isEmbedHostAllowed(uri string, hosts []string) bool {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, h := range hosts {
+		if host == strings.ToLower(h) {
+			return true
+		}
+	}
+	return false
+}
+
+// safeURL returns "" for urls with unsafe schemes in Strict mode
+func (c *Converter) safeURL(uri string) string {
+	if c.Strict && !IsSafeURL(uri) {
+		logf("removed unsafe url '%s'\n", uri)
+		return ""
+	}
+	return uri
+}
+
+// attrURL returns uri that can be used as href or src attribute.
+// In Strict mode it's checked with IsSafeURL and escaped. Otherwise
+// it's returned as is, to match Notion's HTML export
+func (c *Converter) attrURL(uri string) string {
+	if !c.Strict {
+		return uri
+	}
+	return EscapeHTML(c.safeURL(uri))
+}
+
+// escapeStrict escapes s in Strict mode. Otherwise it's returned as is,
+// to match Notion's HTML export
+func (c *Converter) escapeStrict(s string) string {
+	if c.Strict {
+		return EscapeHTML(s)
+	}
+	return s
+}
+
+// style returns style attribute (with a leading space) unless
+// NoInlineStyles is set
+func (c *Converter) style(s string) string {
+	if c.NoInlineStyles {
+		return ""
+	}
+	return ` style="` + s + `"`
+}
+
+// renderIframe renders an embed as an iframe if its host is in EmbedHosts.
+// Returns false if it wasn't rendered
+func (c *Converter) renderIframe(block *notionapi.Block, uri string) bool {
+	if c.NotionCompat || c.XHTML || len(c.EmbedHosts) == 0 {
+		return false
+	}
+	if !isEmbedHostAllowed(uri, c.EmbedHosts) {
+		return false
+	}
+	c.Printf(`<figure id="%s" class="embed">`, block.ID)
+	{
+		c.Printf(`<div class="embed-container">`)
+		c.Printf(`<iframe src="%s" sandbox="allow-scripts allow-same-origin allow-popups allow-presentation" allowfullscreen loading="lazy" referrerpolicy="no-referrer" frameborder="0"></iframe>`, EscapeHTML(uri))
+		c.Printf(`</div>`)
+		c.RenderCaption(block)
+	}
+	c.Printf(`</figure>`)
+	return true
+}
@@ -0,0 +1,477 @@
+package tohtml
+
+import (
+	"bytes"
+	"html/template"
+	"time"
+
+	"github.com/kjk/notionapi"
+)
+
+// PageLink is a link to a page, used in PageTemplateData
+type PageLink struct {
+	ID    string
+	Title string
+	URL   string
+	// emoji icon
+	Icon string
+	// url of an image icon
+	IconURL string
+}
+
+// TOCEntry is an entry in table of contents of a page
+type TOCEntry struct {
+	// id of the header block, can be used as an anchor
+	ID        string
+	Title     string
+	TitleHTML template.HTML
+	// 1 for header, 2 for sub-header, 3 for sub-sub-header
+	Level int
+	// indentation level, same as in table_of_contents-indent-${n} class
+	Indent int
+}
+
+// PageTemplateData is what we pass to a template when rendering a full html
+// page with a Theme or Template
+type PageTemplateData struct {
+	Page *notionapi.Page
+	ID   string
+
+	Title     string
+	TitleHTML template.HTML
+	// emoji icon
+	Icon string
+	// url of an image icon
+	IconURL  string
+	CoverURL string
+	// vertical position of the cover image, in percent
+	CoverPosition float64
+	// "sans", "serif" or "mono"
+	Font string
+
+	CreatedOn    time.Time
+	LastEditedOn time.Time
+	// names of people who created or edited blocks of the page,
+	// the page's creator is first
+	Authors []string
+
+	// parent pages, starting at the top-most. Only pages known to
+	// PageByIDProvider are included
+	Breadcrumbs []*PageLink
+	SubPages    []*PageLink
+	TOC         []*TOCEntry
+
+	// rendered content of the page
+	Body template.HTML
+
+	// inline CSS (CustomCSS or theme's CSS)
+	CSS template.CSS
+	// urls of external CSS files
+	CSSFiles []string
+	// if true, the template shouldn't use style attributes or tags
+	NoInlineStyles bool
+}
+
+// Theme is a CSS and, optionally, a template for rendering a full
+// html page
+type Theme struct {
+	Name string
+	CSS  string
+	// if nil, we use DefaultTemplate
+	Template *template.Template
+}
+
+// TemplateFuncs are functions available in DefaultTemplate. Add them to
+// your template with template.New("").Funcs(TemplateFuncs)
+var TemplateFuncs = template.FuncMap{
+	"formatDate": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("Jan 2, 2006")
+	},
+}
+
+// DefaultTemplate is the template used by built-in themes.
+// It's executed with *PageTemplateData
+const DefaultTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta http-equiv="Content-Type" content="text/html; charset=utf-8"/>
+<meta name="viewport" content="width=device-width, initial-scale=1"/>
+<title>{{.Title}}</title>
+{{- range .CSSFiles}}
+<link rel="stylesheet" href="{{.}}"/>
+{{- end}}
+{{- if .CSS}}
+<style>{{.CSS}}</style>
+{{- end}}
+</head>
+<body>
+<article id="{{.ID}}" class="page {{.Font}}">
+{{template "breadcrumbs" .}}
+{{template "header" .}}
+<div class="page-body">
+{{.Body}}
+</div>
+{{template "footer" .}}
+</article>
+</body>
+</html>
+{{define "breadcrumbs"}}
+{{- if .Breadcrumbs}}<nav class="breadcrumbs">
+{{- range .Breadcrumbs}}
+<div><a href="{{.URL}}">{{.Title}}</a></div>
+<div>/</div>
+{{- end}}
+<div>{{.Title}}</div>
+</nav>{{end}}
+{{- end}}
+{{define "header"}}<header>
+{{- if .CoverURL}}
+<img class="page-cover-image" src="{{.CoverURL}}"{{if not .NoInlineStyles}} style="object-position:center {{.CoverPosition}}%"{{end}}/>
+{{- end}}
+{{- if or .IconURL .Icon}}
+<div class="page-header-icon{{if .CoverURL}} page-header-icon-with-cover{{end}}">
+{{- if .IconURL}}<img class="icon" src="{{.IconURL}}"/>{{else}}<span class="icon">{{.Icon}}</span>{{end -}}
+</div>
+{{- end}}
+<h1 class="page-title">{{.TitleHTML}}</h1>
+</header>
+{{- end}}
+{{define "footer"}}<footer class="page-footer">
+{{- if not .CreatedOn.IsZero}}
+<div>Created <time>{{formatDate .CreatedOn}}</time>{{if .Authors}} by {{index .Authors 0}}{{end}}</div>
+{{- end}}
+{{- if not .LastEditedOn.IsZero}}
+<div>Last edited <time>{{formatDate .LastEditedOn}}</time></div>
+{{- end}}
+</footer>
+{{- end}}
+`
+
+var defaultTemplate = template.Must(template.New("page").Funcs(TemplateFuncs).Parse(DefaultTemplate))
+
+const cssThemeLight = `
+body {
+	font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, "Apple Color Emoji", Arial, sans-serif, "Segoe UI Emoji", "Segoe UI Symbol";
+	line-height: 1.5;
+}
+
+@media only screen {
+	body {
+		margin: 2em auto;
+		padding: 0 1em;
+		max-width: 900px;
+	}
+}
+
+.breadcrumbs {
+	display: flex;
+	flex-wrap: wrap;
+	gap: 0.5em;
+	font-size: 0.9em;
+	color: rgba(55, 53, 47, 0.6);
+}
+
+.page-footer {
+	margin-top: 3em;
+	padding-top: 1em;
+	border-top: 1px solid rgba(55, 53, 47, 0.09);
+	font-size: 0.85em;
+	color: rgba(55, 53, 47, 0.6);
+}
+`
+
+const cssThemeDark = `
+html, body {
+	background: rgb(25, 25, 25);
+	color: rgba(255, 255, 255, 0.81);
+}
+
+@media only screen {
+	body {
+		color: rgba(255, 255, 255, 0.81);
+	}
+}
+
+a, a.visited {
+	color: inherit;
+}
+
+code {
+	background: rgba(135, 131, 120, 0.15);
+	color: #eb5757;
+}
+
+pre, .code {
+	background: rgb(37, 37, 37);
+}
+
+hr {
+	border-bottom: 1px solid rgba(255, 255, 255, 0.13);
+}
+
+table, th, td {
+	border-color: rgba(255, 255, 255, 0.13);
+}
+
+th {
+	color: rgba(255, 255, 255, 0.6);
+}
+
+blockquote {
+	border-left-color: currentcolor;
+}
+
+.callout {
+	background: rgb(37, 37, 37);
+}
+
+.bookmark {
+	border-color: rgba(255, 255, 255, 0.13);
+}
+
+.breadcrumbs, .page-footer, .discussion-context, .comment-header time {
+	color: rgba(255, 255, 255, 0.45);
+}
+
+.page-footer, .discussions {
+	border-top-color: rgba(255, 255, 255, 0.13);
+}
+
+.highlight-gray_background, .block-color-gray_background {
+	background: rgb(47, 47, 47);
+}
+.highlight-brown_background, .block-color-brown_background {
+	background: rgb(74, 50, 40);
+}
+.highlight-orange_background, .block-color-orange_background {
+	background: rgb(92, 59, 35);
+}
+.highlight-yellow_background, .block-color-yellow_background {
+	background: rgb(86, 67, 40);
+}
+.highlight-teal_background, .block-color-teal_background {
+	background: rgb(36, 61, 48);
+}
+.highlight-blue_background, .block-color-blue_background {
+	background: rgb(20, 58, 78);
+}
+.highlight-purple_background, .block-color-purple_background {
+	background: rgb(60, 45, 73);
+}
+.highlight-pink_background, .block-color-pink_background {
+	background: rgb(78, 44, 60);
+}
+.highlight-red_background, .block-color-red_background {
+	background: rgb(82, 46, 42);
+}
+`
+
+var (
+	// ThemeNotion looks like the default FullHTML output
+	ThemeNotion = &Theme{
+		Name: "notion",
+		CSS:  CSS + CSSPlus,
+	}
+	// ThemeLight is a light theme
+	ThemeLight = &Theme{
+		Name: "light",
+		CSS:  CSS + CSSPlus + cssThemeLight,
+	}
+	// ThemeDark is a dark theme
+	ThemeDark = &Theme{
+		Name: "dark",
+		CSS:  CSS + CSSPlus + cssThemeLight + cssThemeDark,
+	}
+	// ThemeAuto is light or dark, depending on user's system preference
+	ThemeAuto = &Theme{
+		Name: "auto",
+		CSS:  CSS + CSSPlus + cssThemeLight + "\n@media (prefers-color-scheme: dark) {\n" + cssThemeDark + "}\n",
+	}
+
+	// Themes are built-in themes
+	Themes = []*Theme{ThemeNotion, ThemeLight, ThemeDark, ThemeAuto}
+)
+
+// ThemeByName returns a built-in theme with a given name or nil
+func ThemeByName(name string) *Theme {
+	for _, t := range Themes {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// usesTemplate returns true if we render full html page with a template
+func (c *Converter) usesTemplate() bool {
+	if !c.FullHTML || c.NotionCompat {
+		return false
+	}
+	return c.Theme != nil || c.Template != nil || len(c.CSSFiles) > 0 || c.NoInlineStyles
+}
+
+func (c *Converter) pageURL(block *notionapi.Block) string {
+	if c.RewriteURL != nil {
+		return c.RewriteURL("https://www.notion.so/" + notionapi.ToNoDashID(block.ID))
+	}
+	return filePathForPage(block)
+}
+
+func (c *Converter) pageLink(block *notionapi.Block) *PageLink {
+	res := &PageLink{
+		ID:    block.ID,
+		Title: block.Title,
+		URL:   c.pageURL(block),
+	}
+	if icon, _ := block.PropAsString("format.page_icon"); icon != "" {
+		if isURL(icon) {
+			res.IconURL = c.fileURL(icon, block, getDownloadedFileName(icon, block))
+		} else {
+			res.Icon = icon
+		}
+	}
+	return res
+}
+
+// getAuthors returns names of users who created or edited blocks of
+// the page, with the creator of the page first
+func (c *Converter) getAuthors(root *notionapi.Block) []string {
+	var res []string
+	seen := map[string]bool{}
+	add := func(userID string) {
+		nid := notionapi.NewNotionID(userID)
+		if nid == nil || seen[nid.DashID] {
+			return
+		}
+		seen[nid.DashID] = true
+		if c.Page.NotionUserByID(nid) == nil {
+			return
+		}
+		res = append(res, notionapi.GetUserNameByID(c.Page, nid.DashID))
+	}
+	blocks := []*notionapi.Block{root}
+	notionapi.ForEachBlock(blocks, func(b *notionapi.Block) {
+		add(b.CreatedBy)
+		add(b.CreatedByID)
+		add(b.LastEditedBy)
+		add(b.LastEditedByID)
+	})
+	return res
+}
+
+func (c *Converter) getTOC(root *notionapi.Block) []*TOCEntry {
+	seen := map[string]bool{}
+	blocks := getHeaderBlocks(root.Content, seen)
+	var res []*TOCEntry
+	indent := 0
+	for i, b := range blocks {
+		indent += adjustIndent(blocks, i)
+		level := 1
+		switch b.Type {
+		case notionapi.BlockSubHeader:
+			level = 2
+		case notionapi.BlockSubSubHeader:
+			level = 3
+		}
+		e := &TOCEntry{
+			ID:        b.ID,
+			Title:     notionapi.TextSpansToString(b.InlineContent),
+			TitleHTML: template.HTML(c.GetInlineContent(b.InlineContent)),
+			Level:     level,
+			Indent:    indent,
+		}
+		res = append(res, e)
+	}
+	return res
+}
+
+func (c *Converter) newPageTemplateData(root *notionapi.Block, body []byte) *PageTemplateData {
+	res := &PageTemplateData{
+		Page:         c.Page,
+		ID:           root.ID,
+		Title:        root.Title,
+		TitleHTML:    template.HTML(c.GetInlineContent(root.InlineContent)),
+		Font:         "sans",
+		CreatedOn:    root.CreatedOn(),
+		LastEditedOn: root.LastEditedOn(),
+		Authors:      c.getAuthors(root),
+		TOC:          c.getTOC(root),
+		Body:         template.HTML(body),
+		CSSFiles:     c.CSSFiles,
+
+		NoInlineStyles: c.NoInlineStyles,
+	}
+	if fp := root.FormatPage(); fp != nil {
+		if fp.PageFont != "" {
+			res.Font = fp.PageFont
+		}
+		res.CoverPosition = (1 - fp.PageCoverPosition) * 100
+	}
+	if cover, _ := root.PropAsString("format.page_cover"); cover != "" {
+		res.CoverURL = c.fileURL(cover, root, FilePathFromPageCoverURL(cover, root))
+	}
+	if icon, _ := root.PropAsString("format.page_icon"); icon != "" {
+		if isURL(icon) {
+			res.IconURL = c.fileURL(icon, root, getDownloadedFileName(icon, root))
+		} else {
+			res.Icon = icon
+		}
+	}
+	for _, page := range c.breadcrumbPages() {
+		res.Breadcrumbs = append(res.Breadcrumbs, c.pageLink(page.Root()))
+	}
+	for _, nid := range c.Page.GetSubPages() {
+		if b := c.Page.BlockByID(nid); b != nil {
+			res.SubPages = append(res.SubPages, c.pageLink(b))
+		}
+	}
+
+	css := c.CustomCSS
+	if css == "" && c.Theme != nil {
+		css = c.Theme.CSS
+	}
+	if css == "" && c.Theme == nil && len(c.CSSFiles) == 0 {
+		css = ThemeNotion.CSS
+	}
+	if !c.NoInlineStyles {
+		res.CSS = template.CSS(css)
+	}
+	return res
+}
+
+// toHTMLWithTemplate renders a full html page using a template
+func (c *Converter) toHTMLWithTemplate() ([]byte, error) {
+	root := c.Page.Root()
+	c.PushNewBuffer()
+	if root.Type == notionapi.BlockPage {
+		if c.RenderComments {
+			c.pendingDiscussionNos = c.blockDiscussionNos(root)
+			c.flushPendingDiscussionRefs()
+		}
+		c.RenderChildren(root)
+		if c.RenderComments {
+			c.RenderDiscussions()
+		}
+	} else {
+		c.RenderBlock(root)
+	}
+	body := c.PopBuffer()
+
+	data := c.newPageTemplateData(root, body.Bytes())
+	tmpl := c.Template
+	if tmpl == nil && c.Theme != nil {
+		tmpl = c.Theme.Template
+	}
+	if tmpl == nil {
+		tmpl = defaultTemplate
+	}
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, data)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
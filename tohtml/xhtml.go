@@ -0,0 +1,128 @@
+package tohtml
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// namespaces of elements that must be declared in XHTML
+var xhtmlNamespaces = map[string]string{
+	"":     "http://www.w3.org/1999/xhtml",
+	"svg":  "http://www.w3.org/2000/svg",
+	"math": "http://www.w3.org/1998/Math/MathML",
+}
+
+func hasAttr(n *html.Node, namespace string, key string) bool {
+	for _, a := range n.Attr {
+		if a.Namespace == namespace && a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// declareNamespaces adds xmlns to <html> and to <svg> and <math> elements
+// embedded in html, as XML parsers don't infer them
+func declareNamespaces(n *html.Node, parentNS string) {
+	if n.Type == html.ElementNode {
+		if n.Namespace != parentNS || n.DataAtom == atom.Html {
+			if ns, ok := xhtmlNamespaces[n.Namespace]; ok && !hasAttr(n, "", "xmlns") {
+				n.Attr = append(n.Attr, html.Attribute{Key: "xmlns", Val: ns})
+			}
+			// the parser stores xmlns:xlink as xlink in xmlns namespace
+			if n.Namespace == "svg" && !hasAttr(n, "xmlns", "xlink") {
+				n.Attr = append(n.Attr, html.Attribute{Namespace: "xmlns", Key: "xlink", Val: "http://www.w3.org/1999/xlink"})
+			}
+		}
+		parentNS = n.Namespace
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		declareNamespaces(child, parentNS)
+	}
+}
+
+// XHTMLID returns id of an element in XHTML mode. Ids in XHTML must
+// start with a letter but block ids (UUIDs) might start with a digit
+func XHTMLID(id string) string {
+	if id == "" {
+		return id
+	}
+	c := id[0]
+	if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' {
+		return id
+	}
+	return "id-" + id
+}
+
+// fixXHTMLIDs changes id attributes and links to them with XHTMLID
+func fixXHTMLIDs(n *html.Node) {
+	if n.Type == html.ElementNode {
+		for i, a := range n.Attr {
+			if a.Namespace != "" {
+				continue
+			}
+			switch a.Key {
+			case "id":
+				n.Attr[i].Val = XHTMLID(a.Val)
+			case "href":
+				if strings.HasPrefix(a.Val, "#") {
+					n.Attr[i].Val = "#" + XHTMLID(a.Val[1:])
+				}
+			}
+		}
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		fixXHTMLIDs(child)
+	}
+}
+
+// ToXHTML converts html to well-formed XHTML: void elements are closed,
+// attributes have values, entities are replaced with characters and
+// namespaces of <svg> and <math> are declared. If fullDocument is
+// false, d is a fragment of <body>
+func ToXHTML(d []byte, fullDocument bool) ([]byte, error) {
+	return toXHTML(d, fullDocument, false)
+}
+
+// toXHTML is ToXHTML that, if fixIDs is true, also makes ids valid
+// (see XHTMLID)
+func toXHTML(d []byte, fullDocument bool, fixIDs bool) ([]byte, error) {
+	var buf bytes.Buffer
+	if fullDocument {
+		doc, err := html.Parse(bytes.NewReader(d))
+		if err != nil {
+			return nil, err
+		}
+		declareNamespaces(doc, "")
+		if fixIDs {
+			fixXHTMLIDs(doc)
+		}
+		if err = html.Render(&buf, doc); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	body := &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	}
+	nodes, err := html.ParseFragment(bytes.NewReader(d), body)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range nodes {
+		declareNamespaces(n, "")
+		if fixIDs {
+			fixXHTMLIDs(n)
+		}
+		if err = html.Render(&buf, n); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
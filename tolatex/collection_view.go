@@ -0,0 +1,119 @@
+package tolatex
+
+import (
+	"strings"
+
+	"github.com/kjk/notionapi"
+)
+
+// TableCellToString returns LaTeX for the content of a table cell
+func (c *Converter) TableCellToString(tv *notionapi.TableView, row, col int) string {
+	ci := tv.Columns[col]
+	spans := tv.CellContent(row, col)
+	text := tv.CellText(row, col)
+	switch ci.Type() {
+	case notionapi.ColumnTypeTitle:
+		title := c.GetInlineContent(spans)
+		if title == "" {
+			title = "Untitled"
+		}
+		rowPage := tv.Rows[row].Page
+		if len(rowPage.ContentIDs) == 0 {
+			// for cosmetic reasons we don't link to empty pages
+			return title
+		}
+		return href(c.pageURL(rowPage.ID), title)
+	case notionapi.ColumnTypeCheckbox:
+		if text == "Yes" {
+			return `$\boxtimes$`
+		}
+		return `$\square$`
+	case notionapi.ColumnTypeURL:
+		if text == "" {
+			return ""
+		}
+		return href(text, EscapeLaTeX(text))
+	case notionapi.ColumnTypeEmail:
+		if text == "" {
+			return ""
+		}
+		return href("mailto:"+text, EscapeLaTeX(text))
+	}
+	if ci.IsRichText() {
+		return c.GetInlineContent(spans)
+	}
+	return EscapeLaTeX(text)
+}
+
+// RenderTableView renders a collection view as tabular
+func (c *Converter) RenderTableView(tv *notionapi.TableView) {
+	nCols := tv.ColumnCount()
+	if nCols == 0 {
+		return
+	}
+	var cells []string
+	writeRow := func() {
+		c.WriteString(strings.Join(cells, " & ") + ` \\` + "\n")
+		cells = nil
+	}
+	spec := ""
+	for _, ci := range tv.Columns {
+		if ci.Schema != nil && ci.Schema.Type == notionapi.ColumnTypeNumber {
+			spec += "r"
+		} else {
+			spec += "l"
+		}
+	}
+	c.Newline()
+	c.Printf(`\noindent\begin{tabular}{%s}`+"\n", spec)
+	c.WriteString(`\toprule` + "\n")
+	for _, ci := range tv.Columns {
+		name := ci.Name()
+		if name == "" {
+			name = ci.ID()
+		}
+		cells = append(cells, `\textbf{`+EscapeLaTeX(name)+`}`)
+	}
+	writeRow()
+	c.WriteString(`\midrule` + "\n")
+	nRows := tv.RowCount()
+	for row := 0; row < nRows; row++ {
+		for col := 0; col < nCols; col++ {
+			// line breaks are not allowed in l and r columns
+			s := strings.Replace(c.TableCellToString(tv, row, col), lineBreak, " ", -1)
+			cells = append(cells, s)
+		}
+		writeRow()
+	}
+	c.WriteString(`\bottomrule` + "\n")
+	c.WriteString(`\end{tabular}` + "\n")
+}
+
+// RenderCollectionView renders BlockCollectionView
+func (c *Converter) RenderCollectionView(block *notionapi.Block) {
+	if len(block.TableViews) == 0 {
+		return
+	}
+	// render only the first one
+	if name := c.Page.CollectionName(block); name != "" {
+		c.Newline()
+		c.Printf(`\textbf{%s}`+"\n", EscapeLaTeX(name))
+	}
+	c.RenderTableView(block.TableViews[0])
+}
+
+// RenderCollectionViewPage renders BlockCollectionViewPage
+func (c *Converter) RenderCollectionViewPage(block *notionapi.Block) {
+	if c.Page.IsRoot(block) {
+		// the name is the title of the document
+		if len(block.TableViews) > 0 {
+			c.RenderTableView(block.TableViews[0])
+		}
+		return
+	}
+	name := c.Page.CollectionName(block)
+	if name == "" {
+		name = "Untitled Database"
+	}
+	c.renderLink(c.pageURL(block.ID), name, block)
+}
@@ -0,0 +1,971 @@
+// Package tolatex converts a Notion page to a LaTeX document.
+//
+// Images are included with \includegraphics only if they are local png,
+// jpeg or pdf files, so to get a document that compiles, download them
+// with assets.Bundler:
+//
+//	b := assets.New(client, filepath.Join(dir, "assets"))
+//	b.URLPrefix = "assets"
+//	c := tolatex.NewConverter(page)
+//	c.RewriteFileURL = b.RewriteFileURL
+//	d := c.ToLaTeX()
+//	// write d to filepath.Join(dir, tolatex.TeXFileNameForPage(page))
+//
+// Other images, files and embeds are rendered as links.
+package tolatex
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kjk/notionapi"
+)
+
+func maybePanic(format string, args ...interface{}) {
+	notionapi.MaybePanic(format, args...)
+}
+
+const (
+	// CodeListings renders code with listings package. It's the default
+	CodeListings = "listings"
+	// CodeMinted renders code with minted package, which highlights more
+	// languages but needs -shell-escape and Pygments
+	CodeMinted = "minted"
+)
+
+// TeXFileNameForPage returns file name for .tex file
+func TeXFileNameForPage(page *notionapi.Page) string {
+	s := notionapi.SafeName(page.Root().Title)
+	return s + "-" + notionapi.ToDashID(page.ID) + ".tex"
+}
+
+// BlockRenderFunc is a function for rendering a particular block
+type BlockRenderFunc func(block *notionapi.Block) bool
+
+// Converter converts a Page to LaTeX
+type Converter struct {
+	Page *notionapi.Page
+
+	// Buf is where LaTeX is being written to
+	Buf *bytes.Buffer
+
+	// allows over-riding rendering of specific blocks
+	// return false for default rendering
+	RenderBlockOverride BlockRenderFunc
+
+	// RewriteURL allows re-writing URLs e.g. to convert inter-notion URLs
+	// to destination URLs
+	RewriteURL func(url string) string
+
+	// RewriteFileURL allows re-writing URLs of files (images, attachments
+	// etc.) e.g. to download them and link to local copies.
+	// See assets.Bundler
+	RewriteFileURL func(uri string, block *notionapi.Block) string
+
+	// data provided by they caller, useful when providing
+	// RenderBlockOverride
+	Data interface{}
+
+	// we need this to properly render lists
+	CurrBlocks   []*notionapi.Block
+	CurrBlockIdx int
+
+	// PageInfoProvider, if set, is used to get titles of mentioned pages
+	// that are not part of Page
+	PageInfoProvider notionapi.PageInfoProvider
+
+	// Code is a package used to render code, CodeListings (default)
+	// or CodeMinted
+	Code string
+
+	// DocumentClass is a class of the document, "article" if not set
+	DocumentClass string
+
+	// if true, ToLaTeX only returns the body of the document, without the
+	// preamble, e.g. to \input it in another document
+	Fragment bool
+
+	// CodeDir, if set, is a directory where code blocks that can't be
+	// put in the document (because they contain \end{lstlisting} or
+	// \end{minted}) are written, to include them with \lstinputlisting
+	// or \inputminted. Otherwise they are rendered as plain text.
+	// CodeURLPrefix is a path of CodeDir relative to the .tex file
+	// e.g. "assets"
+	CodeDir       string
+	CodeURLPrefix string
+
+	bufs []*bytes.Buffer
+}
+
+// NewConverter returns customizable LaTeX renderer
+func NewConverter(page *notionapi.Page) *Converter {
+	return &Converter{
+		Page: page,
+	}
+}
+
+// PushNewBuffer creates a new buffer and sets Buf to it
+func (c *Converter) PushNewBuffer() {
+	c.bufs = append(c.bufs, c.Buf)
+	c.Buf = &bytes.Buffer{}
+}
+
+// PopBuffer pops a buffer
+func (c *Converter) PopBuffer() *bytes.Buffer {
+	res := c.Buf
+	n := len(c.bufs)
+	c.Buf = c.bufs[n-1]
+	c.bufs = c.bufs[:n-1]
+	return res
+}
+
+// Eol writes end-of-line to the buffer. Doesn't write multiple.
+func (c *Converter) Eol() {
+	d := c.Buf.Bytes()
+	n := len(d)
+	if n > 0 && d[n-1] != '\n' {
+		c.Buf.WriteByte('\n')
+	}
+}
+
+// Newline writes an empty line, which ends a paragraph in LaTeX.
+// It'll suppress multiple empty lines.
+func (c *Converter) Newline() {
+	d := c.Buf.Bytes()
+	n := 0
+	idx := len(d) - 1
+	for idx >= 0 && d[idx] == '\n' {
+		n++
+		idx--
+	}
+	if idx < 0 {
+		return
+	}
+	switch n {
+	case 0:
+		c.Buf.WriteString("\n\n")
+	case 1:
+		c.Buf.WriteByte('\n')
+	}
+}
+
+// WriteString writes a string to the buffer
+func (c *Converter) WriteString(s string) {
+	c.Buf.WriteString(s)
+}
+
+// Printf writes formatted string to the buffer
+func (c *Converter) Printf(format string, args ...interface{}) {
+	s := format
+	if len(args) > 0 {
+		s = fmt.Sprintf(format, args...)
+	}
+	c.Buf.WriteString(s)
+}
+
+// PrevBlock is a block preceding current block
+func (c *Converter) PrevBlock() *notionapi.Block {
+	if c.CurrBlockIdx == 0 {
+		return nil
+	}
+	return c.CurrBlocks[c.CurrBlockIdx-1]
+}
+
+// NextBlock is a block following current block
+func (c *Converter) NextBlock() *notionapi.Block {
+	nextIdx := c.CurrBlockIdx + 1
+	if nextIdx >= len(c.CurrBlocks) {
+		return nil
+	}
+	return c.CurrBlocks[nextIdx]
+}
+
+// IsPrevBlockOfType returns true if previous block is of a given type
+func (c *Converter) IsPrevBlockOfType(t string) bool {
+	b := c.PrevBlock()
+	return b != nil && b.Type == t
+}
+
+// IsNextBlockOfType returns true if next block is of a given type
+func (c *Converter) IsNextBlockOfType(t string) bool {
+	b := c.NextBlock()
+	return b != nil && b.Type == t
+}
+
+var latexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`$`, `\$`,
+	`&`, `\&`,
+	`#`, `\#`,
+	`%`, `\%`,
+	`_`, `\_`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+	"\u00a0", `~`,
+)
+
+// EscapeLaTeX escapes characters that have special meaning in LaTeX
+func EscapeLaTeX(s string) string {
+	return latexEscaper.Replace(s)
+}
+
+var urlEscaper = strings.NewReplacer(
+	`\`, `%5C`,
+	`{`, `%7B`,
+	`}`, `%7D`,
+	`#`, `\#`,
+	`%`, `\%`,
+)
+
+// href returns a link. text must already be escaped
+func href(uri string, text string) string {
+	return `\href{` + urlEscaper.Replace(uri) + `}{` + text + `}`
+}
+
+// line breaks inside a paragraph
+const lineBreak = `\newline` + "\n"
+
+// trimLineBreaks removes whitespace and line breaks at the start and
+// the end of s, as \newline outside of a line is an error
+func trimLineBreaks(s string) string {
+	for {
+		prev := s
+		s = strings.TrimSpace(s)
+		s = strings.TrimPrefix(s, `\newline`)
+		s = strings.TrimSuffix(s, `\newline`)
+		if s == prev {
+			return s
+		}
+	}
+}
+
+// pageURL returns url of a page
+func (c *Converter) pageURL(pageID string) string {
+	uri := "https://www.notion.so/" + notionapi.ToNoDashID(pageID)
+	if c.RewriteURL != nil {
+		return c.RewriteURL(uri)
+	}
+	return uri
+}
+
+// InlineToString renders inline block
+func (c *Converter) InlineToString(b *notionapi.TextSpan) string {
+	text := strings.Replace(EscapeLaTeX(b.Text), "\n", lineBreak, -1)
+	var start, end string
+	for _, attr := range b.Attrs {
+		switch notionapi.AttrGetType(attr) {
+		case notionapi.AttrBold:
+			start += `\textbf{`
+			end = "}" + end
+		case notionapi.AttrItalic:
+			start += `\emph{`
+			end = "}" + end
+		case notionapi.AttrStrikeThrought:
+			start += `\sout{`
+			end = "}" + end
+		case notionapi.AttrUnderline:
+			start += `\uline{`
+			end = "}" + end
+		case notionapi.AttrCode:
+			start += `\texttt{`
+			end = "}" + end
+		case notionapi.AttrPage:
+			pageID := notionapi.AttrGetPageID(attr)
+			title := notionapi.PageTitle(c.Page, c.PageInfoProvider, pageID)
+			if title == "" {
+				title = "Untitled"
+			}
+			text = href(c.pageURL(pageID), EscapeLaTeX(title))
+		case notionapi.AttrLink:
+			uri := notionapi.AttrGetLink(attr)
+			if c.RewriteURL != nil {
+				uri = c.RewriteURL(uri)
+			}
+			text = href(uri, text)
+		case notionapi.AttrUser:
+			userID := notionapi.AttrGetUserID(attr)
+			text = "@" + EscapeLaTeX(notionapi.GetUserNameByID(c.Page, userID))
+		case notionapi.AttrDate:
+			text = EscapeLaTeX(notionapi.FormatDate(notionapi.AttrGetDate(attr)))
+		case notionapi.AttrEquation:
+			text = "$" + notionapi.AttrGetEquation(attr) + "$"
+		case notionapi.AttrLinkMention:
+			lm := notionapi.AttrGetLinkMention(attr)
+			if lm == nil {
+				continue
+			}
+			uri := lm.Href
+			if c.RewriteURL != nil {
+				uri = c.RewriteURL(uri)
+			}
+			title := lm.Title
+			if title == "" {
+				title = lm.Href
+			}
+			text = href(uri, EscapeLaTeX(title))
+		}
+	}
+	return start + text + end
+}
+
+// GetInlineContent returns LaTeX for inline blocks
+func (c *Converter) GetInlineContent(blocks []*notionapi.TextSpan) string {
+	var sb strings.Builder
+	for _, b := range blocks {
+		sb.WriteString(c.InlineToString(b))
+	}
+	return trimLineBreaks(sb.String())
+}
+
+// RenderInlines renders inline blocks
+func (c *Converter) RenderInlines(blocks []*notionapi.TextSpan) {
+	c.WriteString(c.GetInlineContent(blocks))
+}
+
+func (c *Converter) renderCaption(block *notionapi.Block) {
+	caption := c.GetInlineContent(block.GetCaption())
+	if caption == "" {
+		return
+	}
+	c.Newline()
+	c.Printf(`\emph{%s}`+"\n", caption)
+}
+
+// renderLink renders a link as a paragraph
+func (c *Converter) renderLink(uri string, title string, block *notionapi.Block) {
+	if uri == "" {
+		return
+	}
+	if title == "" {
+		title = uri
+	}
+	c.Newline()
+	c.WriteString(href(uri, EscapeLaTeX(title)) + "\n")
+	c.renderCaption(block)
+}
+
+func (c *Converter) renderRootPage(block *notionapi.Block) {
+	c.RenderChildren(block)
+}
+
+// RenderPage renders BlockPage
+func (c *Converter) RenderPage(block *notionapi.Block) {
+	if c.Page.IsRoot(block) {
+		c.renderRootPage(block)
+		return
+	}
+	title := c.GetInlineContent(block.InlineContent)
+	if title == "" {
+		title = "Untitled"
+	}
+	c.Newline()
+	c.WriteString(href(c.pageURL(block.ID), title) + "\n")
+}
+
+// RenderText renders BlockText
+func (c *Converter) RenderText(block *notionapi.Block) {
+	if text := c.GetInlineContent(block.InlineContent); text != "" {
+		c.Newline()
+		c.WriteString(text + "\n")
+	}
+	c.RenderChildren(block)
+}
+
+// RenderHeaderLevel renders BlockHeader, SubHeader and SubSubHeader
+// as \section, \subsection and \subsubsection
+func (c *Converter) RenderHeaderLevel(block *notionapi.Block, level int) {
+	title := c.GetInlineContent(block.InlineContent)
+	if title == "" {
+		return
+	}
+	// can't have line breaks in sectioning commands
+	title = strings.Replace(title, lineBreak, " ", -1)
+	cmd := "section"
+	switch level {
+	case 2:
+		cmd = "subsection"
+	case 3:
+		cmd = "subsubsection"
+	}
+	c.Newline()
+	c.Printf(`\%s{%s}`+"\n", cmd, title)
+}
+
+// RenderHeader renders BlockHeader
+func (c *Converter) RenderHeader(block *notionapi.Block) {
+	c.RenderHeaderLevel(block, 1)
+}
+
+// RenderSubHeader renders BlockSubHeader
+func (c *Converter) RenderSubHeader(block *notionapi.Block) {
+	c.RenderHeaderLevel(block, 2)
+}
+
+// RenderSubSubHeader renders BlockSubSubHeader
+func (c *Converter) RenderSubSubHeader(block *notionapi.Block) {
+	c.RenderHeaderLevel(block, 3)
+}
+
+// renderListItem renders an \item of env. Consecutive blocks of the same
+// type are items of the same list
+func (c *Converter) renderListItem(block *notionapi.Block, env string, item string) {
+	if !c.IsPrevBlockOfType(block.Type) {
+		c.Newline()
+		c.Printf(`\begin{%s}`+"\n", env)
+	}
+	c.Eol()
+	c.WriteString(item + c.GetInlineContent(block.InlineContent) + "\n")
+	c.RenderChildren(block)
+	if !c.IsNextBlockOfType(block.Type) {
+		c.Eol()
+		c.Printf(`\end{%s}`+"\n", env)
+	}
+}
+
+// RenderBulletedList renders BlockBulletedList as itemize
+func (c *Converter) RenderBulletedList(block *notionapi.Block) {
+	c.renderListItem(block, "itemize", `\item `)
+}
+
+// RenderNumberedList renders BlockNumberedList as enumerate
+func (c *Converter) RenderNumberedList(block *notionapi.Block) {
+	c.renderListItem(block, "enumerate", `\item `)
+}
+
+// RenderTodo renders BlockTodo as itemize with check boxes
+func (c *Converter) RenderTodo(block *notionapi.Block) {
+	item := `\item[$\square$] `
+	if block.IsChecked {
+		item = `\item[$\boxtimes$] `
+	}
+	c.renderListItem(block, "itemize", item)
+}
+
+// RenderToggle renders BlockToggle as a paragraph followed by its children
+func (c *Converter) RenderToggle(block *notionapi.Block) {
+	c.RenderText(block)
+}
+
+// RenderQuote renders BlockQuote
+func (c *Converter) RenderQuote(block *notionapi.Block) {
+	c.Newline()
+	c.WriteString(`\begin{quote}` + "\n")
+	c.RenderInlines(block.InlineContent)
+	c.RenderChildren(block)
+	c.Eol()
+	c.WriteString(`\end{quote}` + "\n")
+}
+
+// calloutColors maps color of a callout to a color of xcolor package
+var calloutColors = map[string]string{
+	"gray":   "gray",
+	"brown":  "brown",
+	"orange": "orange",
+	"yellow": "yellow",
+	"green":  "green",
+	"blue":   "blue",
+	"purple": "purple",
+	"pink":   "pink",
+	"red":    "red",
+}
+
+// RenderCallout renders BlockCallout as tcolorbox. The icon is skipped
+// as emojis need special fonts
+func (c *Converter) RenderCallout(block *notionapi.Block) {
+	col, _ := block.PropAsString("format.block_color")
+	col = calloutColors[strings.TrimSuffix(col, "_background")]
+	if col == "" {
+		col = "gray"
+	}
+	c.Newline()
+	c.Printf(`\begin{tcolorbox}[colback=%s!10!white,colframe=%s!50!white]`+"\n", col, col)
+	c.RenderInlines(block.InlineContent)
+	c.RenderChildren(block)
+	c.Eol()
+	c.WriteString(`\end{tcolorbox}` + "\n")
+}
+
+// RenderEquation renders BlockEquation as equation*
+func (c *Converter) RenderEquation(block *notionapi.Block) {
+	eq := strings.TrimSpace(notionapi.TextSpansToString(block.InlineContent))
+	if eq == "" {
+		return
+	}
+	c.Newline()
+	c.WriteString(`\begin{equation*}` + "\n")
+	c.WriteString(eq + "\n")
+	c.WriteString(`\end{equation*}` + "\n")
+}
+
+// listingsLanguages maps names of languages in Notion to names of
+// languages supported by listings package
+var listingsLanguages = map[string]string{
+	"bash":         "bash",
+	"c":            "C",
+	"c++":          "C++",
+	"clojure":      "Lisp",
+	"cobol":        "Cobol",
+	"elixir":       "erlang",
+	"erlang":       "erlang",
+	"fortran":      "Fortran",
+	"haskell":      "Haskell",
+	"html":         "HTML",
+	"java":         "Java",
+	"latex":        "TeX",
+	"lisp":         "Lisp",
+	"lua":          "Lua",
+	"makefile":     "make",
+	"markup":       "HTML",
+	"matlab":       "Matlab",
+	"objective-c":  "[Objective]C",
+	"ocaml":        "ML",
+	"pascal":       "Pascal",
+	"perl":         "Perl",
+	"php":          "PHP",
+	"prolog":       "Prolog",
+	"python":       "Python",
+	"r":            "R",
+	"ruby":         "Ruby",
+	"scala":        "Scala",
+	"scheme":       "Lisp",
+	"shell":        "bash",
+	"sql":          "SQL",
+	"tex":          "TeX",
+	"verilog":      "Verilog",
+	"vhdl":         "VHDL",
+	"visual basic": "[Visual]Basic",
+	"xml":          "XML",
+}
+
+// mintedLanguages maps names of languages in Notion to names of Pygments
+// lexers, when lower-cased name isn't the name of the lexer
+var mintedLanguages = map[string]string{
+	"c#":           "csharp",
+	"c++":          "cpp",
+	"f#":           "fsharp",
+	"markup":       "html",
+	"plain text":   "text",
+	"shell":        "bash",
+	"vb.net":       "vbnet",
+	"visual basic": "vbnet",
+}
+
+func isLexerName(s string) bool {
+	for _, r := range s {
+		isLetter := (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9')
+		if !isLetter && r != '-' && r != '+' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// writeCodeFile writes code to a file in CodeDir and returns its path
+// relative to the .tex file
+func (c *Converter) writeCodeFile(block *notionapi.Block, code string) (string, error) {
+	if c.CodeDir == "" {
+		return "", fmt.Errorf("CodeDir not set")
+	}
+	name := "code-" + notionapi.ToNoDashID(block.ID) + ".txt"
+	err := os.MkdirAll(c.CodeDir, 0755)
+	if err != nil {
+		return "", err
+	}
+	err = os.WriteFile(filepath.Join(c.CodeDir, name), []byte(code+"\n"), 0644)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(c.CodeURLPrefix, name), nil
+}
+
+// renderCodeAsText renders code in monospace font, escaped, for code
+// that can't be put inside lstlisting or minted
+func (c *Converter) renderCodeAsText(code string) {
+	var lines []string
+	for _, line := range strings.Split(code, "\n") {
+		line = strings.Replace(line, "\t", "    ", -1)
+		line = strings.Replace(EscapeLaTeX(line), " ", `\ `, -1)
+		lines = append(lines, `\mbox{}`+line)
+	}
+	c.WriteString(`\begin{flushleft}\ttfamily\small` + "\n")
+	c.WriteString(strings.Join(lines, `\\`+"\n") + "\n")
+	c.WriteString(`\end{flushleft}` + "\n")
+}
+
+// RenderCode renders BlockCode with listings or minted
+func (c *Converter) RenderCode(block *notionapi.Block) {
+	lang := strings.ToLower(strings.TrimSpace(block.CodeLanguage))
+	code := strings.TrimRight(strings.Replace(block.Code, "\r\n", "\n", -1), "\n")
+	c.Newline()
+	endMarker := `\end{lstlisting}`
+	if c.Code == CodeMinted {
+		endMarker = `\end{minted}`
+	}
+	file := ""
+	if strings.Contains(code, endMarker) {
+		// code would end the environment early
+		var err error
+		file, err = c.writeCodeFile(block, code)
+		if err != nil {
+			notionapi.Logf("tolatex: can't write code of block %s to a file, rendering as text. Error: %s\n", block.ID, err)
+			c.renderCodeAsText(code)
+			c.renderCaption(block)
+			return
+		}
+	}
+	if c.Code == CodeMinted {
+		if l, ok := mintedLanguages[lang]; ok {
+			lang = l
+		}
+		if !isLexerName(lang) {
+			lang = "text"
+		}
+		if file != "" {
+			c.Printf(`\inputminted{%s}{%s}`+"\n", lang, file)
+		} else {
+			c.Printf(`\begin{minted}{%s}`+"\n", lang)
+			c.WriteString(code + "\n")
+			c.WriteString(`\end{minted}` + "\n")
+		}
+	} else {
+		opts := ""
+		if l := listingsLanguages[lang]; l != "" {
+			opts = "[language=" + l + "]"
+		}
+		if file != "" {
+			c.Printf(`\lstinputlisting%s{%s}`+"\n", opts, file)
+		} else {
+			c.Printf(`\begin{lstlisting}%s`+"\n", opts)
+			c.WriteString(code + "\n")
+			c.WriteString(`\end{lstlisting}` + "\n")
+		}
+	}
+	c.renderCaption(block)
+}
+
+// RenderDivider renders BlockDivider
+func (c *Converter) RenderDivider(block *notionapi.Block) {
+	c.Newline()
+	c.WriteString(`\noindent\rule{\linewidth}{0.4pt}` + "\n")
+}
+
+// RenderTableOfContents renders BlockTableOfContents
+func (c *Converter) RenderTableOfContents(block *notionapi.Block) {
+	c.Newline()
+	c.WriteString(`\tableofcontents` + "\n")
+}
+
+// RenderAlias renders BlockAlias as a link to the page
+func (c *Converter) RenderAlias(block *notionapi.Block) {
+	format := block.FormatAlias()
+	if format == nil || format.Alias == nil {
+		return
+	}
+	id := format.Alias.ID
+	c.renderLink(c.pageURL(id), notionapi.PageTitle(c.Page, c.PageInfoProvider, id), block)
+}
+
+// RenderLinkToPage renders BlockLinkToPage as a link to the page
+func (c *Converter) RenderLinkToPage(block *notionapi.Block) {
+	id, _ := block.PropAsString("format.alias_pointer.id")
+	if id == "" {
+		return
+	}
+	c.renderLink(c.pageURL(id), notionapi.PageTitle(c.Page, c.PageInfoProvider, id), block)
+}
+
+// RenderTransclusionReference renders BlockTransclusionReference by
+// rendering content of the referenced block, if we have it
+func (c *Converter) RenderTransclusionReference(block *notionapi.Block) {
+	id, _ := block.PropAsString("format.transclusion_reference_pointer.id")
+	nid := notionapi.NewNotionID(id)
+	if nid == nil {
+		return
+	}
+	ref := c.Page.BlockByID(nid)
+	if ref == nil {
+		return
+	}
+	c.RenderChildren(ref)
+}
+
+// fileURL returns url of a file (image, attachment etc.) of the block
+func (c *Converter) fileURL(block *notionapi.Block) string {
+	if c.RewriteFileURL != nil && block.Source != "" {
+		return c.RewriteFileURL(block.Source, block)
+	}
+	return block.Source
+}
+
+func fileName(uri string) string {
+	if idx := strings.IndexAny(uri, "?#"); idx >= 0 {
+		uri = uri[:idx]
+	}
+	return path.Base(uri)
+}
+
+// canIncludeGraphics returns true if uri is a local file that
+// \includegraphics supports
+func canIncludeGraphics(uri string) bool {
+	if uri == "" || strings.Contains(uri, "://") || strings.HasPrefix(uri, "data:") {
+		return false
+	}
+	switch strings.ToLower(path.Ext(uri)) {
+	case ".png", ".jpg", ".jpeg", ".pdf":
+		return true
+	}
+	return false
+}
+
+// RenderImage renders BlockImage. Only local images are included, other
+// are rendered as links
+func (c *Converter) RenderImage(block *notionapi.Block) {
+	uri := c.fileURL(block)
+	if !canIncludeGraphics(uri) {
+		c.renderLink(uri, fileName(uri), block)
+		return
+	}
+	c.Newline()
+	c.WriteString(`\begin{center}` + "\n")
+	c.Printf(`\includegraphics[max width=\linewidth]{%s}`+"\n", uri)
+	if caption := c.GetInlineContent(block.GetCaption()); caption != "" {
+		c.Printf(`\\ \small %s`+"\n", caption)
+	}
+	c.WriteString(`\end{center}` + "\n")
+}
+
+// RenderFile renders BlockFile, BlockPDF, BlockAudio and BlockVideo as
+// a link
+func (c *Converter) RenderFile(block *notionapi.Block) {
+	uri := c.fileURL(block)
+	name := block.Title
+	if name == "" {
+		name = fileName(block.Source)
+	}
+	c.renderLink(uri, name, block)
+}
+
+// RenderEmbed renders BlockEmbed and other embeds as a link
+func (c *Converter) RenderEmbed(block *notionapi.Block) {
+	c.renderLink(block.Source, "", block)
+}
+
+// RenderBookmark renders BlockBookmark
+func (c *Converter) RenderBookmark(block *notionapi.Block) {
+	c.renderLink(block.Link, notionapi.TextSpansToString(block.InlineContent), block)
+}
+
+// RenderDrive renders BlockDrive
+func (c *Converter) RenderDrive(block *notionapi.Block) {
+	docURL, _ := block.PropAsString("format.drive_properties.url")
+	title, _ := block.PropAsString("format.drive_properties.title")
+	c.renderLink(docURL, title, block)
+}
+
+// RenderColumnList renders BlockColumnList
+// it's children are BlockColumn
+func (c *Converter) RenderColumnList(block *notionapi.Block) {
+	c.RenderChildren(block)
+}
+
+// RenderColumn renders BlockColumn
+// it's parent is BlockColumnList
+func (c *Converter) RenderColumn(block *notionapi.Block) {
+	c.RenderChildren(block)
+}
+
+// DefaultRenderFunc returns a defult rendering function for a type of
+// a given block
+func (c *Converter) DefaultRenderFunc(blockType string) func(*notionapi.Block) {
+	switch blockType {
+	case notionapi.BlockPage:
+		return c.RenderPage
+	case notionapi.BlockText:
+		return c.RenderText
+	case notionapi.BlockEquation:
+		return c.RenderEquation
+	case notionapi.BlockNumberedList:
+		return c.RenderNumberedList
+	case notionapi.BlockBulletedList:
+		return c.RenderBulletedList
+	case notionapi.BlockHeader:
+		return c.RenderHeader
+	case notionapi.BlockSubHeader:
+		return c.RenderSubHeader
+	case notionapi.BlockSubSubHeader:
+		return c.RenderSubSubHeader
+	case notionapi.BlockTodo:
+		return c.RenderTodo
+	case notionapi.BlockToggle:
+		return c.RenderToggle
+	case notionapi.BlockQuote:
+		return c.RenderQuote
+	case notionapi.BlockDivider:
+		return c.RenderDivider
+	case notionapi.BlockCode:
+		return c.RenderCode
+	case notionapi.BlockBookmark:
+		return c.RenderBookmark
+	case notionapi.BlockImage:
+		return c.RenderImage
+	case notionapi.BlockColumnList:
+		return c.RenderColumnList
+	case notionapi.BlockColumn:
+		return c.RenderColumn
+	case notionapi.BlockCollectionView:
+		return c.RenderCollectionView
+	case notionapi.BlockCollectionViewPage:
+		return c.RenderCollectionViewPage
+	case notionapi.BlockEmbed, notionapi.BlockGist, notionapi.BlockMaps,
+		notionapi.BlockCodepen, notionapi.BlockTweet, notionapi.BlockFigma,
+		notionapi.BlockMiro:
+		return c.RenderEmbed
+	case notionapi.BlockVideo, notionapi.BlockAudio, notionapi.BlockFile,
+		notionapi.BlockPDF:
+		return c.RenderFile
+	case notionapi.BlockDrive:
+		return c.RenderDrive
+	case notionapi.BlockCallout:
+		return c.RenderCallout
+	case notionapi.BlockTableOfContents:
+		return c.RenderTableOfContents
+	case notionapi.BlockAlias:
+		return c.RenderAlias
+	case notionapi.BlockLinkToPage:
+		return c.RenderLinkToPage
+	case notionapi.BlockTransclusionReference:
+		return c.RenderTransclusionReference
+	case notionapi.BlockBreadcrumb, notionapi.BlockFactory:
+		return nil
+	default:
+		maybePanic("DefaultRenderFunc: unsupported block type '%s' in %s\n", blockType, c.Page.NotionURL())
+	}
+	return nil
+}
+
+func (c *Converter) skipChildren(block *notionapi.Block) bool {
+	if len(block.Content) == 0 {
+		return true
+	}
+	if block.Type == notionapi.BlockPage {
+		// we don't want to render content of links to pages
+		return !c.Page.IsRoot(block)
+	}
+	return false
+}
+
+// RenderChildren renders children of the block
+func (c *Converter) RenderChildren(block *notionapi.Block) {
+	if c.skipChildren(block) {
+		return
+	}
+	currIdx := c.CurrBlockIdx
+	currBlocks := c.CurrBlocks
+	c.CurrBlocks = block.Content
+	for i, child := range block.Content {
+		c.CurrBlockIdx = i
+		c.RenderBlock(child)
+	}
+	c.CurrBlockIdx = currIdx
+	c.CurrBlocks = currBlocks
+}
+
+// RenderBlock renders a block to LaTeX
+func (c *Converter) RenderBlock(block *notionapi.Block) {
+	if block == nil {
+		// a missing block, can happen if we don't have access to a referenced block
+		return
+	}
+	if c.RenderBlockOverride != nil && c.RenderBlockOverride(block) {
+		return
+	}
+	def := c.DefaultRenderFunc(block.Type)
+	if def != nil {
+		def(block)
+	}
+}
+
+// title returns LaTeX for the title of the page
+func (c *Converter) title() string {
+	root := c.Page.Root()
+	title := c.GetInlineContent(root.InlineContent)
+	if root.Type == notionapi.BlockCollectionViewPage {
+		title = EscapeLaTeX(c.Page.CollectionName(root))
+	}
+	return strings.Replace(title, lineBreak, " ", -1)
+}
+
+// Preamble returns the preamble of the document, up to and including
+// \begin{document}
+func (c *Converter) Preamble() string {
+	class := c.DocumentClass
+	if class == "" {
+		class = "article"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `\documentclass{%s}`+"\n", class)
+	sb.WriteString(`\usepackage{iftex}
+\ifPDFTeX
+  \usepackage[utf8]{inputenc}
+  \usepackage[T1]{fontenc}
+\else
+  \usepackage{fontspec}
+\fi
+\usepackage{amsmath}
+\usepackage{amssymb}
+\usepackage{graphicx}
+\usepackage[export]{adjustbox}
+\usepackage{booktabs}
+\usepackage[normalem]{ulem}
+\usepackage{xcolor}
+\usepackage{tcolorbox}
+`)
+	if c.Code == CodeMinted {
+		sb.WriteString(`\usepackage{minted}
+\setminted{breaklines}
+`)
+	} else {
+		sb.WriteString(`\usepackage{listings}
+\lstset{basicstyle=\ttfamily\small,breaklines=true,columns=fullflexible}
+`)
+	}
+	// hyperref should be loaded last
+	sb.WriteString(`\usepackage{hyperref}
+
+`)
+	fmt.Fprintf(&sb, `\title{%s}`+"\n", c.title())
+	sb.WriteString(`\date{}
+
+\begin{document}
+\maketitle
+`)
+	return sb.String()
+}
+
+// ToLaTeX returns LaTeX document for the page
+func (c *Converter) ToLaTeX() []byte {
+	c.PushNewBuffer()
+	c.RenderBlock(c.Page.Root())
+	body := bytes.TrimSpace(c.PopBuffer().Bytes())
+	if c.Fragment {
+		return body
+	}
+	var buf bytes.Buffer
+	buf.WriteString(c.Preamble())
+	buf.WriteString("\n")
+	buf.Write(body)
+	buf.WriteString("\n\n" + `\end{document}` + "\n")
+	return buf.Bytes()
+}
+
+// ToLaTeX converts a page to LaTeX document
+func ToLaTeX(page *notionapi.Page) []byte {
+	c := NewConverter(page)
+	return c.ToLaTeX()
+}
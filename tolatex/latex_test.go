@@ -0,0 +1,112 @@
+package tolatex
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kjk/common/assert"
+	"github.com/kjk/notionapi"
+	"github.com/kjk/notionapi/internal/testutil"
+)
+
+func TestEscapeLaTeX(t *testing.T) {
+	tests := [][]string{
+		{"foo", "foo"},
+		{"50% & $5", `50\% \& \$5`},
+		{`a_b #1 {x}`, `a\_b \#1 \{x\}`},
+		{`\ ~ ^`, `\textbackslash{} \textasciitilde{} \textasciicircum{}`},
+	}
+	for _, test := range tests {
+		got := EscapeLaTeX(test[0])
+		assert.Equal(t, test[1], got)
+	}
+}
+
+func TestToLaTeX(t *testing.T) {
+	page := testutil.LoadPage(t, "page.json")
+
+	c := NewConverter(page)
+	c.RewriteFileURL = func(uri string, block *notionapi.Block) string {
+		if strings.HasSuffix(uri, "/chart.png") {
+			return "assets/chart.png"
+		}
+		return uri
+	}
+	s := string(c.ToLaTeX())
+	exp := []string{
+		`\title{Report <Q1> \& Notes}`,
+		`\section{Plan}`,
+		`Costs 50\% of \textbf{x\_1} and \sout{marked} see \href{https://example.com/?a=1&b=2\#top}{docs} and run \texttt{make}`,
+		"\\begin{itemize}\n\\item[$\\boxtimes$] Write spec\n\\item[$\\square$] Ship it\n\\end{itemize}",
+		"\\begin{enumerate}\n\\item first\n\\item second\n\n\\begin{itemize}\n\\item nested\n\\end{itemize}\n\\end{enumerate}",
+		"\\begin{equation*}\ne^{i\\pi} + 1 = 0\n\\end{equation*}",
+		"\\begin{lstlisting}[language=Python]\n* not a headline\nif a < b:\n\tprint(\"%d\" % 5)\n\\end{lstlisting}",
+		"\\begin{tcolorbox}[colback=yellow!10!white,colframe=yellow!50!white]\nMind the gap\n\\end{tcolorbox}",
+		"\\includegraphics[max width=\\linewidth]{assets/chart.png}\n\\\\ \\small Chart",
+		`\href{https://example.com/missing.png}{missing.png}`,
+		"\\textbf{Todo}\n\n\\noindent\\begin{tabular}{llrlll}",
+		`Ship & $\square$ &  &  &  & <img src=x onerror=alert(1)> \\`,
+	}
+	for _, e := range exp {
+		assert.True(t, strings.Contains(s, e), "expected '%s' in:\n%s", e, s)
+	}
+	assert.True(t, strings.HasSuffix(s, "\\end{document}\n"))
+
+	// without local copy, an image is a link
+	c = NewConverter(page)
+	c.Code = CodeMinted
+	c.Fragment = true
+	s = string(c.ToLaTeX())
+	assert.True(t, strings.HasPrefix(s, `\section{Plan}`), "got:\n%s", s)
+	assert.True(t, strings.Contains(s, `\href{https://example.com/chart.png}{chart.png}`), "got:\n%s", s)
+	assert.True(t, strings.Contains(s, "\\begin{minted}{python}\n"), "got:\n%s", s)
+}
+
+func TestRenderCodeWithEndMarker(t *testing.T) {
+	page := testutil.LoadPage(t, "page.json")
+	block := page.BlockByID(notionapi.NewNotionID("10000000-0000-4000-8000-000000000011"))
+	block.Code = "s = r'''\n\\end{lstlisting}\n\\end{minted}\n'''"
+
+	c := NewConverter(page)
+	c.Fragment = true
+	s := string(c.ToLaTeX())
+	assert.False(t, strings.Contains(s, "\\end{lstlisting}\n\\end{minted}"), "got:\n%s", s)
+	exp := "\\begin{flushleft}\\ttfamily\\small\n\\mbox{}s\\ =\\ r'''\\\\\n\\mbox{}\\textbackslash{}end\\{lstlisting\\}\\\\\n"
+	assert.True(t, strings.Contains(s, exp), "got:\n%s", s)
+
+	dir := t.TempDir()
+	c = NewConverter(page)
+	c.Fragment = true
+	c.CodeDir = dir
+	c.CodeURLPrefix = "assets"
+	s = string(c.ToLaTeX())
+	name := "code-10000000000040008000000000000011.txt"
+	assert.True(t, strings.Contains(s, `\lstinputlisting[language=Python]{assets/`+name+`}`), "got:\n%s", s)
+	d, err := os.ReadFile(filepath.Join(dir, name))
+	assert.NoError(t, err)
+	assert.Equal(t, block.Code+"\n", string(d))
+
+	c.Code = CodeMinted
+	s = string(c.ToLaTeX())
+	assert.True(t, strings.Contains(s, `\inputminted{python}{assets/`+name+`}`), "got:\n%s", s)
+}
+
+func TestLinkToPageAndMiro(t *testing.T) {
+	notionapi.PanicOnFailures = true
+	defer func() {
+		notionapi.PanicOnFailures = false
+	}()
+	page := testutil.LoadPage(t, "links.json")
+	c := NewConverter(page)
+	c.Fragment = true
+	s := string(c.ToLaTeX())
+	exp := []string{
+		`\href{https://www.notion.so/6a5f1c9b1b6d4f808fcd8b6e5d7c9a0f}{Appendix}`,
+		`\href{https://miro.com/app/board/o9J_abc=/}`,
+	}
+	for _, e := range exp {
+		assert.True(t, strings.Contains(s, e), "expected '%s' in:\n%s", e, s)
+	}
+}
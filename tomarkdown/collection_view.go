@@ -0,0 +1,133 @@
+package tomarkdown
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/kjk/notionapi"
+)
+
+// escapeTableCell makes a string safe to put inside a cell of
+// GitHub Flavored Markdown table
+func escapeTableCell(s string) string {
+	s = strings.TrimSpace(s)
+	// in dialects text is already escaped
+	s = strings.Replace(s, `\|`, "|", -1)
+	s = strings.Replace(s, "|", `\|`, -1)
+	s = strings.Replace(s, "\r\n", "\n", -1)
+	s = strings.Replace(s, "\n", "<br>", -1)
+	return s
+}
+
+// TableCellToString returns markdown for the content of a table cell
+func (c *Converter) TableCellToString(tv *notionapi.TableView, row, col int) string {
+	ci := tv.Columns[col]
+	spans := tv.CellContent(row, col)
+	text := tv.CellText(row, col)
+	switch ci.Type() {
+	case notionapi.ColumnTypeTitle:
+		title := c.GetInlineContent(spans, true)
+		if title == "" {
+			title = "Untitled"
+		}
+		rowPage := tv.Rows[row].Page
+		if len(rowPage.ContentIDs) == 0 {
+			// for cosmetic reasons we don't link to empty pages
+			return title
+		}
+		uri := c.pageURL(text, rowPage.ID)
+		return fmt.Sprintf("[%s](%s)", escapeMarkdownLinkText(title), uri)
+	case notionapi.ColumnTypeCheckbox:
+		if text == "Yes" {
+			return "[x]"
+		}
+		return "[ ]"
+	case notionapi.ColumnTypeURL:
+		if text == "" {
+			return ""
+		}
+		return fmt.Sprintf("<%s>", text)
+	case notionapi.ColumnTypeEmail:
+		if text == "" {
+			return ""
+		}
+		return fmt.Sprintf("[%s](mailto:%s)", escapeMarkdownText(text), url.PathEscape(text))
+	}
+	if ci.IsRichText() {
+		return c.GetInlineContent(spans, true)
+	}
+	return text
+}
+
+// RenderTableView renders a collection view as GitHub Flavored Markdown table
+func (c *Converter) RenderTableView(tv *notionapi.TableView) {
+	nCols := tv.ColumnCount()
+	if nCols == 0 {
+		return
+	}
+	var cells []string
+	writeRow := func() {
+		c.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		cells = nil
+	}
+	for _, ci := range tv.Columns {
+		name := ci.Name()
+		if name == "" {
+			name = ci.ID()
+		}
+		cells = append(cells, escapeTableCell(name))
+	}
+	writeRow()
+	c.WriteString(c.Indent)
+	for col := 0; col < nCols; col++ {
+		sep := "---"
+		if ci := tv.Columns[col]; ci.Schema != nil && ci.Schema.Type == notionapi.ColumnTypeNumber {
+			sep = "---:"
+		}
+		cells = append(cells, sep)
+	}
+	writeRow()
+	nRows := tv.RowCount()
+	for row := 0; row < nRows; row++ {
+		c.WriteString(c.Indent)
+		for col := 0; col < nCols; col++ {
+			cells = append(cells, escapeTableCell(c.TableCellToString(tv, row, col)))
+		}
+		writeRow()
+	}
+}
+
+// RenderCollectionView renders BlockCollectionView
+func (c *Converter) RenderCollectionView(block *notionapi.Block) {
+	if len(block.TableViews) == 0 {
+		return
+	}
+	// render only the first one
+	tv := block.TableViews[0]
+	if name := c.Page.CollectionName(block); name != "" {
+		c.Printf("**%s**", escapeMarkdownText(name))
+		c.Newline()
+		c.WriteString(c.Indent)
+	}
+	c.RenderTableView(tv)
+}
+
+// RenderCollectionViewPage renders BlockCollectionViewPage
+func (c *Converter) RenderCollectionViewPage(block *notionapi.Block) {
+	name := c.Page.CollectionName(block)
+	if c.Page.IsRoot(block) {
+		c.Printf("# %s", name)
+		c.Newline()
+		if len(block.TableViews) > 0 {
+			c.RenderTableView(block.TableViews[0])
+		}
+		return
+	}
+	if name == "" {
+		name = "Untitled Database"
+	}
+	uri := c.pageURL(name, block.ID)
+	c.Printf("[%s](%s)", escapeMarkdownLinkText(name), uri)
+	c.Eol()
+}
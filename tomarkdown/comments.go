@@ -0,0 +1,107 @@
+package tomarkdown
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/kjk/notionapi"
+)
+
+// discussionNo returns a number of footnote for a discussion with a
+// given id. Returns 0 if the discussion shouldn't be shown
+func (c *Converter) discussionNo(id string) int {
+	if c.discussions == nil {
+		c.discussions = &notionapi.DiscussionNumbers{
+			Page:         c.Page,
+			ShowResolved: c.RenderResolvedComments,
+		}
+	}
+	return c.discussions.No(id)
+}
+
+// returns footnote references to discussions that end at spans[i].
+// Text commented on can span multiple TextSpans but we only want
+// to show the reference once, at the end
+func (c *Converter) inlineDiscussionRefs(spans []*notionapi.TextSpan, i int) string {
+	s := ""
+	for _, id := range notionapi.DiscussionIDsEndingAt(spans, i) {
+		if n := c.discussionNo(id); n > 0 {
+			s += fmt.Sprintf("[^%d]", n)
+		}
+	}
+	return s
+}
+
+// returns footnote references to discussions about the whole block
+func (c *Converter) blockDiscussionRefs(block *notionapi.Block) string {
+	s := ""
+	for _, id := range block.DiscussionIDs {
+		if n := c.discussionNo(id); n > 0 {
+			s += fmt.Sprintf("[^%d]", n)
+		}
+	}
+	return s
+}
+
+// references to discussions about a block are rendered after its
+// inline content. If a block has no inline content, they are rendered
+// after what was rendered so far
+func (c *Converter) flushPendingDiscussionRefs() {
+	s := c.pendingDiscussionRefs
+	if s == "" {
+		return
+	}
+	c.pendingDiscussionRefs = ""
+	d := c.Buf.Bytes()
+	n := len(d)
+	for n > 0 && d[n-1] == '\n' {
+		n--
+	}
+	if bytes.HasSuffix(d[:n], []byte("```")) {
+		// can't put it after closing ```
+		c.Newline()
+		c.WriteString(c.Indent + s)
+		c.Newline()
+		return
+	}
+	nl := string(d[n:])
+	c.Buf.Truncate(n)
+	c.trimWhitespaceRight()
+	c.WriteString(" " + s + nl)
+}
+
+func (c *Converter) commentToString(comment *notionapi.Comment) string {
+	author := comment.AuthorName()
+	date := comment.CreatedOn().Format(notionapi.CommentTimeFormat)
+	text := c.inlineContent(comment.InlineContent, true)
+	// continuation lines of a footnote must be indented
+	text = strings.Replace(text, "\n", "\n    ", -1)
+	return fmt.Sprintf("**%s** (%s): %s", author, date, text)
+}
+
+// RenderDiscussions renders all discussions referenced so far
+// as footnotes
+func (c *Converter) RenderDiscussions() {
+	if c.discussions == nil {
+		return
+	}
+	// rendering comments can add more discussions (comments can have
+	// inline comments) so we don't use range
+	for i := 0; i < len(c.discussions.Discussions); i++ {
+		d := c.discussions.Discussions[i]
+		c.Newline()
+		s := fmt.Sprintf("[^%d]: ", i+1)
+		if d.Resolved {
+			s += "(resolved) "
+		}
+		for j, comment := range d.Content {
+			if j > 0 {
+				s += "\n\n    "
+			}
+			s += c.commentToString(comment)
+		}
+		c.WriteString(s)
+		c.Eol()
+	}
+}
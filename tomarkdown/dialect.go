@@ -0,0 +1,329 @@
+package tomarkdown
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+
+	"github.com/kjk/notionapi"
+)
+
+// Dialects of markdown, see Converter.Dialect
+const (
+	// DialectDefault mirrors Notion's markdown export
+	DialectDefault = ""
+	// DialectCommonMark is strict CommonMark: text is escaped, toggles are
+	// <details> and things CommonMark doesn't support (strikethrough,
+	// underline) use inline HTML. Tables are rendered as in GFM
+	DialectCommonMark = "commonmark"
+	// DialectGFM is GitHub Flavored Markdown: task lists, tables,
+	// strikethrough, <details> for toggles and alerts (> [!NOTE])
+	// for callouts
+	DialectGFM = "gfm"
+	// DialectObsidian uses [[wikilinks]] for links to pages, callouts
+	// (> [!note]), ==highlight== and ![](url) embeds of videos and tweets
+	DialectObsidian = "obsidian"
+	// DialectHugo is GFM (as rendered by Hugo's goldmark) that uses
+	// shortcodes for YouTube and Vimeo videos, tweets and gists
+	DialectHugo = "hugo"
+)
+
+// isDialect returns true if c.Dialect is not DialectDefault. In those
+// dialects text is escaped so that it renders literally
+func (c *Converter) isDialect() bool {
+	return c.Dialect != DialectDefault
+}
+
+func isMarkdownPunct(r rune) bool {
+	switch r {
+	case '\\', '`', '*', '_', '{', '}', '[', ']', '<', '>', '!', '|', '~', '&', '$', '=', '#', '%':
+		return true
+	}
+	return false
+}
+
+// escapeMarkdownText escapes characters with special meaning in markdown
+// so that s is rendered literally. Characters that only have special
+// meaning at the start of a line (-, + and "1.") are only escaped there
+func escapeMarkdownText(s string) string {
+	var sb strings.Builder
+	lineStart := true
+	digits := false
+	for _, r := range s {
+		switch {
+		case isMarkdownPunct(r):
+			sb.WriteByte('\\')
+		case lineStart && (r == '-' || r == '+'):
+			sb.WriteByte('\\')
+		case digits && (r == '.' || r == ')'):
+			sb.WriteByte('\\')
+		}
+		if lineStart && r >= '0' && r <= '9' {
+			digits = true
+		} else if !(digits && r >= '0' && r <= '9') {
+			digits = false
+		}
+		sb.WriteRune(r)
+		lineStart = r == '\n' || (lineStart && (r == ' ' || r == '\t'))
+	}
+	return sb.String()
+}
+
+// codeSpan returns s as a code span, using as many backticks as needed
+func codeSpan(s string) string {
+	longest, n := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			n++
+			if n > longest {
+				longest = n
+			}
+		} else {
+			n = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+// linkDestination returns uri that can be used as a destination of a
+// markdown link
+func linkDestination(uri string) string {
+	if strings.ContainsAny(uri, " ()<>") {
+		uri = strings.Replace(uri, "<", "%3C", -1)
+		uri = strings.Replace(uri, ">", "%3E", -1)
+		return "<" + uri + ">"
+	}
+	return uri
+}
+
+// wikiLink returns Obsidian's [[title]] link. Characters that are not
+// allowed in names of notes are removed
+func wikiLink(title string) string {
+	title = strings.Map(func(r rune) rune {
+		switch r {
+		case '[', ']', '|', '#', '^', '\n':
+			return ' '
+		}
+		return r
+	}, title)
+	return "[[" + strings.TrimSpace(title) + "]]"
+}
+
+// calloutTypes maps color of a callout to a type of GitHub alert and
+// Obsidian callout
+var calloutTypes = map[string][2]string{
+	"":       {"NOTE", "note"},
+	"gray":   {"NOTE", "note"},
+	"brown":  {"NOTE", "note"},
+	"blue":   {"NOTE", "info"},
+	"green":  {"TIP", "tip"},
+	"purple": {"IMPORTANT", "important"},
+	"pink":   {"IMPORTANT", "important"},
+	"yellow": {"WARNING", "warning"},
+	"orange": {"WARNING", "warning"},
+	"red":    {"CAUTION", "danger"},
+}
+
+// calloutHeader returns the first line of a callout in GFM and Obsidian
+// e.g. "[!NOTE]" or "" if dialect doesn't support callouts
+func (c *Converter) calloutHeader(block *notionapi.Block) string {
+	col, _ := block.PropAsString("format.block_color")
+	col, _ = notionapi.ParseHighlight(col)
+	types, ok := calloutTypes[col]
+	if !ok {
+		types = calloutTypes[""]
+	}
+	switch c.Dialect {
+	case DialectGFM:
+		return "[!" + types[0] + "]"
+	case DialectObsidian:
+		return "[!" + types[1] + "]"
+	}
+	return ""
+}
+
+// renderToggleDetails renders BlockToggle as <details>
+func (c *Converter) renderToggleDetails(block *notionapi.Block) {
+	summary := notionapi.TextSpansToString(block.InlineContent)
+	c.Printf("<details>\n")
+	c.WriteString(c.Indent + "<summary>" + html.EscapeString(summary) + "</summary>\n")
+	c.RenderChildren(block)
+	c.Newline()
+	c.WriteString(c.Indent + "</details>\n")
+}
+
+// renderQuoted renders content of the block and its children as a quote.
+// header, if given, is the first line of the quote
+func (c *Converter) renderQuoted(block *notionapi.Block, header string, text string) {
+	indent := c.Indent
+	c.Indent = ""
+	c.PushNewBuffer()
+	c.WriteString(text)
+	c.Newline()
+	c.RenderChildren(block)
+	s := strings.TrimSpace(c.PopBuffer().String())
+	c.Indent = indent
+
+	lines := strings.Split(strings.Replace(s, "\r\n", "\n", -1), "\n")
+	if header != "" {
+		lines = append([]string{header}, lines...)
+	}
+	for i, line := range lines {
+		if i > 0 {
+			c.WriteString(c.Indent)
+		}
+		if line == "" {
+			c.WriteString(">\n")
+		} else {
+			c.WriteString("> " + line + "\n")
+		}
+	}
+}
+
+// youtubeID returns id of a YouTube video or ""
+func youtubeID(u *url.URL) string {
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	switch host {
+	case "youtu.be":
+		return strings.Trim(u.Path, "/")
+	case "youtube.com", "m.youtube.com", "youtube-nocookie.com":
+		if v := u.Query().Get("v"); v != "" {
+			return v
+		}
+		for _, prefix := range []string{"/embed/", "/shorts/", "/v/"} {
+			if strings.HasPrefix(u.Path, prefix) {
+				return strings.Trim(strings.TrimPrefix(u.Path, prefix), "/")
+			}
+		}
+	}
+	return ""
+}
+
+// pathParts returns non-empty elements of url's path
+func pathParts(u *url.URL) []string {
+	var res []string
+	for _, s := range strings.Split(u.Path, "/") {
+		if s != "" {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// hugoShortcode returns Hugo's shortcode for embedding uri or "" if there
+// isn't one
+func hugoShortcode(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return ""
+	}
+	if id := youtubeID(u); id != "" {
+		return fmt.Sprintf("{{< youtube %s >}}", id)
+	}
+	parts := pathParts(u)
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	switch host {
+	case "vimeo.com":
+		if len(parts) > 0 {
+			return fmt.Sprintf("{{< vimeo %s >}}", parts[len(parts)-1])
+		}
+	case "player.vimeo.com":
+		if len(parts) == 2 && parts[0] == "video" {
+			return fmt.Sprintf("{{< vimeo %s >}}", parts[1])
+		}
+	case "twitter.com", "x.com", "mobile.twitter.com":
+		if len(parts) >= 3 && parts[1] == "status" {
+			return fmt.Sprintf(`{{< tweet user="%s" id="%s" >}}`, parts[0], parts[2])
+		}
+	case "gist.github.com":
+		if len(parts) >= 2 {
+			return fmt.Sprintf("{{< gist %s %s >}}", parts[0], strings.TrimSuffix(parts[1], ".js"))
+		}
+	}
+	return ""
+}
+
+// renderDialectEmbed renders an embed, tweet, gist or video (that is
+// not a file) in a dialect-specific way. Returns false if it wasn't rendered
+func (c *Converter) renderDialectEmbed(block *notionapi.Block) bool {
+	uri := block.Source
+	if uri == "" {
+		return false
+	}
+	switch c.Dialect {
+	case DialectHugo:
+		s := hugoShortcode(uri)
+		if s == "" {
+			return false
+		}
+		c.Printf("%s\n", s)
+	case DialectObsidian:
+		// Obsidian embeds videos and tweets with image syntax
+		switch block.Type {
+		case notionapi.BlockVideo, notionapi.BlockTweet:
+		default:
+			return false
+		}
+		c.Printf("![](%s)\n", linkDestination(uri))
+	default:
+		return false
+	}
+	c.renderCaption(block)
+	return true
+}
+
+// escapeText escapes s if the dialect requires it
+func (c *Converter) escapeText(s string) string {
+	if c.isDialect() {
+		return escapeMarkdownText(s)
+	}
+	return s
+}
+
+// dialectInlineText returns text of a span escaped or, for code, as
+// a code span
+func (c *Converter) dialectInlineText(b *notionapi.TextSpan) string {
+	for _, attr := range b.Attrs {
+		if notionapi.AttrGetType(attr) == notionapi.AttrCode {
+			before, text, after := shuffleWhitespace(b.Text)
+			if text == "" {
+				return b.Text
+			}
+			return before + codeSpan(text) + after
+		}
+	}
+	return escapeMarkdownText(b.Text)
+}
+
+// pageMention returns a link to a mentioned page
+func (c *Converter) pageMention(pageID string) string {
+	title := notionapi.PageTitle(c.Page, c.PageInfoProvider, pageID)
+	if c.Dialect == DialectObsidian {
+		if title == "" {
+			title = notionapi.ToNoDashID(pageID)
+		}
+		return wikiLink(title)
+	}
+	uri := "https://www.notion.so/" + notionapi.ToNoDashID(pageID)
+	if c.RewriteURL != nil {
+		uri = c.RewriteURL(uri)
+	}
+	if title == "" {
+		title = uri
+	}
+	return fmt.Sprintf("[%s](%s)", escapeMarkdownText(title), linkDestination(uri))
+}
+
+// isToggleList returns true if toggles are rendered as lists
+func (c *Converter) isToggleList() bool {
+	switch c.Dialect {
+	case DialectDefault, DialectHugo:
+		return true
+	}
+	return false
+}
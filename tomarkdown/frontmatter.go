@@ -0,0 +1,392 @@
+package tomarkdown
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/kjk/notionapi"
+)
+
+const (
+	// FrontMatterYAML writes front matter as YAML, delimited by ---
+	FrontMatterYAML = "yaml"
+	// FrontMatterTOML writes front matter as TOML, delimited by +++
+	FrontMatterTOML = "toml"
+)
+
+// names of front matter fields, passed to Converter.FrontMatterKey
+const (
+	FieldTitle      = "title"
+	FieldID         = "id"
+	FieldNotionURL  = "notion_url"
+	FieldCreated    = "created"
+	FieldLastEdited = "last_edited"
+	FieldAuthors    = "authors"
+	FieldIcon       = "icon"
+	FieldCover      = "cover"
+)
+
+// frontMatterDate is a date (and optional time) of a date property.
+// It's written without quotes, as YAML and TOML have native dates, so
+// it must be a valid date
+type frontMatterDate string
+
+type frontMatterField struct {
+	Key string
+	// string, bool, float64, time.Time, frontMatterDate or a slice of those
+	Value interface{}
+}
+
+// DefaultFrontMatterKey is used for names of front matter fields if
+// Converter.FrontMatterKey is not set. It lower-cases the name and
+// replaces non-alphanumeric characters with "_" e.g. property
+// "Due Date" becomes "due_date"
+func DefaultFrontMatterKey(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		} else if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "_") {
+			sb.WriteByte('_')
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "_")
+}
+
+// HugoFrontMatterKey is like DefaultFrontMatterKey but uses names of
+// fields Hugo understands: date and lastmod. It's used for DialectHugo
+// if Converter.FrontMatterKey is not set
+func HugoFrontMatterKey(name string) string {
+	switch name {
+	case FieldCreated:
+		return "date"
+	case FieldLastEdited:
+		return "lastmod"
+	}
+	return DefaultFrontMatterKey(name)
+}
+
+func (c *Converter) frontMatterKey(name string) string {
+	if c.FrontMatterKey != nil {
+		return c.FrontMatterKey(name)
+	}
+	if c.Dialect == DialectHugo {
+		return HugoFrontMatterKey(name)
+	}
+	return DefaultFrontMatterKey(name)
+}
+
+func (c *Converter) pageFileURL(uri string, block *notionapi.Block) string {
+	if strings.HasPrefix(uri, "/images/") {
+		uri = "https://www.notion.so" + uri
+	}
+	if c.RewriteFileURL != nil && strings.HasPrefix(uri, "http") {
+		return c.RewriteFileURL(uri, block)
+	}
+	return uri
+}
+
+// rowCollection returns a collection if the page is a row in it
+func (c *Converter) rowCollection(block *notionapi.Block) *notionapi.Collection {
+	if block.ParentTable != notionapi.TableCollection {
+		return nil
+	}
+	return c.Page.CollectionByID(notionapi.NewNotionID(block.ParentID))
+}
+
+func spansDate(spans []*notionapi.TextSpan) *notionapi.Date {
+	for _, ts := range spans {
+		for _, attr := range ts.Attrs {
+			if notionapi.AttrGetType(attr) == notionapi.AttrDate {
+				return notionapi.AttrGetDate(attr)
+			}
+		}
+	}
+	return nil
+}
+
+// toFrontMatterDate returns a date (and optional time) of a date property
+// or nil if date is empty. A time with a time zone is returned as
+// time.Time. Values that don't parse are returned as strings, which
+// are quoted
+func toFrontMatterDate(date string, t string, timeZone *string) interface{} {
+	if date == "" {
+		return nil
+	}
+	if t == "" {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return date
+		}
+		return frontMatterDate(date)
+	}
+	s := date + " " + t
+	tm, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		return s
+	}
+	if timeZone != nil && *timeZone != "" {
+		loc, err := time.LoadLocation(*timeZone)
+		if err == nil {
+			return time.Date(tm.Year(), tm.Month(), tm.Day(), tm.Hour(), tm.Minute(), 0, 0, loc)
+		}
+		logf("tomarkdown: unknown time zone '%s', err: '%s'\n", *timeZone, err)
+	}
+	return frontMatterDate(date + "T" + t + ":00")
+}
+
+func spansAttrValues(spans []*notionapi.TextSpan, attrType string) []string {
+	var res []string
+	for _, ts := range spans {
+		for _, attr := range ts.Attrs {
+			if notionapi.AttrGetType(attr) == attrType && len(attr) > 1 {
+				res = append(res, attr[1])
+			}
+		}
+	}
+	return res
+}
+
+func splitMultiSelect(s string) []string {
+	var res []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// propertyValue returns a value of a property of a page that is a row
+// in a collection or nil if it's empty
+func (c *Converter) propertyValue(block *notionapi.Block, id string, schema *notionapi.ColumnSchema) interface{} {
+	spans := block.GetProperty(id)
+	s := notionapi.TextSpansToString(spans)
+	switch schema.Type {
+	case notionapi.ColumnTypeCreatedTime:
+		return block.CreatedOn().UTC()
+	case notionapi.ColumnTypeLastEditedTime:
+		return block.LastEditedOn().UTC()
+	case notionapi.ColumnTypeCreatedBy:
+		return notionapi.GetUserNameByID(c.Page, block.CreatedByUserID())
+	case notionapi.ColumnTypeLastEditedBy:
+		return notionapi.GetUserNameByID(c.Page, block.LastEditedByUserID())
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+	switch schema.Type {
+	case notionapi.ColumnTypeCheckbox:
+		return s == "Yes"
+	case notionapi.ColumnTypeNumber:
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case notionapi.ColumnTypeMultiSelect:
+		return splitMultiSelect(s)
+	case notionapi.ColumnTypeDate:
+		d := spansDate(spans)
+		if d == nil {
+			break
+		}
+		start := toFrontMatterDate(d.StartDate, d.StartTime, d.TimeZone)
+		if start == nil {
+			break
+		}
+		if strings.Contains(d.Type, "range") {
+			if end := toFrontMatterDate(d.EndDate, d.EndTime, d.TimeZone); end != nil {
+				return []interface{}{start, end}
+			}
+		}
+		return start
+	case notionapi.ColumnTypePerson:
+		var names []string
+		for _, id := range spansAttrValues(spans, notionapi.AttrUser) {
+			names = append(names, notionapi.GetUserNameByID(c.Page, id))
+		}
+		return names
+	case notionapi.ColumnTypeRelation:
+		return spansAttrValues(spans, notionapi.AttrPage)
+	case notionapi.ColumnTypeFile:
+		var uris []string
+		for _, ts := range spans {
+			uri := ts.Text
+			for _, attr := range ts.Attrs {
+				if notionapi.AttrGetType(attr) == notionapi.AttrLink {
+					uri = notionapi.AttrGetLink(attr)
+				}
+			}
+			if uri != "" {
+				uris = append(uris, c.pageFileURL(uri, block))
+			}
+		}
+		return uris
+	}
+	return s
+}
+
+// frontMatterFields returns metadata of the page: title, id, url, dates,
+// authors, icon and cover and, for pages that are rows of a collection,
+// values of its properties
+func (c *Converter) frontMatterFields() []*frontMatterField {
+	root := c.Page.Root()
+	var res []*frontMatterField
+	seen := map[string]bool{}
+	add := func(name string, v interface{}) {
+		key := c.frontMatterKey(name)
+		if key == "" || v == nil || v == "" {
+			return
+		}
+		if seen[key] {
+			logf("tomarkdown: skipping duplicate front matter field '%s'\n", key)
+			return
+		}
+		seen[key] = true
+		res = append(res, &frontMatterField{Key: key, Value: v})
+	}
+	add(FieldTitle, root.Title)
+	add(FieldID, notionapi.ToDashID(root.ID))
+	add(FieldNotionURL, c.Page.NotionURL())
+	add(FieldCreated, root.CreatedOn().UTC())
+	add(FieldLastEdited, root.LastEditedOn().UTC())
+
+	var authors []string
+	for _, id := range []string{root.CreatedByUserID(), root.LastEditedByUserID()} {
+		if id == "" {
+			continue
+		}
+		name := notionapi.GetUserNameByID(c.Page, id)
+		if len(authors) == 0 || authors[0] != name {
+			authors = append(authors, name)
+		}
+	}
+	if len(authors) > 0 {
+		add(FieldAuthors, authors)
+	}
+	if icon, _ := root.PropAsString("format.page_icon"); icon != "" {
+		add(FieldIcon, c.pageFileURL(icon, root))
+	}
+	if cover, _ := root.PropAsString("format.page_cover"); cover != "" {
+		add(FieldCover, c.pageFileURL(cover, root))
+	}
+
+	col := c.rowCollection(root)
+	if col == nil {
+		return res
+	}
+	// sort by name for stable output
+	var ids []string
+	for id, schema := range col.Schema {
+		if schema != nil && schema.Type != notionapi.ColumnTypeTitle {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return col.Schema[ids[i]].Name < col.Schema[ids[j]].Name
+	})
+	for _, id := range ids {
+		schema := col.Schema[id]
+		v := c.propertyValue(root, id, schema)
+		if a, ok := v.([]string); ok && len(a) == 0 {
+			continue
+		}
+		add(schema.Name, v)
+	}
+	return res
+}
+
+// quoteFrontMatterString returns a double-quoted string valid both in
+// YAML and TOML
+func quoteFrontMatterString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\r':
+			sb.WriteString(`\r`)
+		default:
+			if r < ' ' || r == 0x7f {
+				fmt.Fprintf(&sb, `\u%04x`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+func isBareKey(s string) bool {
+	for _, r := range s {
+		ok := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == '-'
+		if !ok {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func frontMatterValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return quoteFrontMatterString(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case frontMatterDate:
+		return string(v)
+	case []string:
+		var a []string
+		for _, s := range v {
+			a = append(a, quoteFrontMatterString(s))
+		}
+		return "[" + strings.Join(a, ", ") + "]"
+	case []interface{}:
+		var a []string
+		for _, el := range v {
+			a = append(a, frontMatterValue(el))
+		}
+		return "[" + strings.Join(a, ", ") + "]"
+	}
+	return quoteFrontMatterString(fmt.Sprintf("%v", v))
+}
+
+// frontMatter returns page metadata formatted as FrontMatter
+func (c *Converter) frontMatter() []byte {
+	delim := "---"
+	sep := ": "
+	switch c.FrontMatter {
+	case FrontMatterYAML:
+	case FrontMatterTOML:
+		delim = "+++"
+		sep = " = "
+	default:
+		logf("tomarkdown: unknown FrontMatter '%s', using yaml\n", c.FrontMatter)
+	}
+	var buf bytes.Buffer
+	buf.WriteString(delim + "\n")
+	for _, f := range c.frontMatterFields() {
+		key := f.Key
+		if !isBareKey(key) {
+			key = quoteFrontMatterString(key)
+		}
+		buf.WriteString(key + sep + frontMatterValue(f.Value) + "\n")
+	}
+	buf.WriteString(delim + "\n")
+	return buf.Bytes()
+}
@@ -4,14 +4,20 @@ import (
 	"bytes"
 	"fmt"
 	"strings"
+	"unicode"
 
 	"github.com/kjk/notionapi"
+	"github.com/kjk/notionapi/equation"
 )
 
 func maybePanic(format string, args ...interface{}) {
 	notionapi.MaybePanic(format, args...)
 }
 
+func logf(format string, args ...interface{}) {
+	notionapi.Logf(format, args...)
+}
+
 func markdownFileName(title, pageID string) string {
 	s := notionapi.SafeName(title)
 	return s + "-" + notionapi.ToDashID(pageID) + ".md"
@@ -41,6 +47,11 @@ type Converter struct {
 	// to destination URLs
 	RewriteURL func(url string) string
 
+	// RewriteFileURL allows re-writing URLs of files (images, attachments
+	// etc.) e.g. to download them and link to local copies.
+	// See assets.Bundler
+	RewriteFileURL func(uri string, block *notionapi.Block) string
+
 	// data provided by they caller, useful when providing
 	// RenderBlockOverride
 	Data interface{}
@@ -52,7 +63,44 @@ type Converter struct {
 	Indent string
 	ListNo int
 
+	// if true, renders comments (discussions) as footnotes
+	RenderComments bool
+	// if true, also renders resolved discussions
+	RenderResolvedComments bool
+
+	// EquationRenderer, if set, renders equations as HTML (e.g. with
+	// equation.MathML), for markdown renderers that don't support math.
+	// Otherwise equations are written as $$...$$ blocks
+	EquationRenderer equation.Renderer
+
+	// if true, uses inline HTML for formatting that markdown doesn't
+	// support: colored text and underline
+	InlineHTML bool
+
+	// PageInfoProvider, if set, is used to get titles of mentioned pages
+	// that are not part of Page e.g. notionapi.PageInfoFromPages or
+	// notionapi.PageInfoFetcher
+	PageInfoProvider notionapi.PageInfoProvider
+
+	// Dialect is a flavor of markdown to generate e.g. DialectGFM.
+	// Default is DialectDefault which mirrors Notion's export
+	Dialect string
+
+	// FrontMatter, if set to FrontMatterYAML or FrontMatterTOML, adds
+	// page metadata (title, id, dates, authors, icon, cover and, for rows
+	// of a collection, values of properties) at the top of the file
+	FrontMatter string
+	// FrontMatterKey returns a key of a front matter field. name is one
+	// of Field* constants or a name of a property. Return "" to skip the
+	// field. If not set, DefaultFrontMatterKey is used
+	FrontMatterKey func(name string) string
+
 	bufs []*bytes.Buffer
+
+	// discussions referenced so far, in order of footnotes
+	discussions *notionapi.DiscussionNumbers
+	// references to discussions about the block being rendered
+	pendingDiscussionRefs string
 }
 
 // NewConverter returns customizable Markdown renderer
@@ -241,6 +289,9 @@ func (c *Converter) bufferEndsWith(s string) bool {
 func (c *Converter) InlineToString(b *notionapi.TextSpan) string {
 	text := b.Text
 	var start, end, before, after string
+	if c.isDialect() {
+		text = c.dialectInlineText(b)
+	}
 	for _, attr := range b.Attrs {
 		switch notionapi.AttrGetType(attr) {
 		case notionapi.AttrBold:
@@ -250,36 +301,97 @@ func (c *Converter) InlineToString(b *notionapi.TextSpan) string {
 			start += "*"
 			end = "*" + end
 		case notionapi.AttrStrikeThrought:
+			if c.Dialect == DialectCommonMark {
+				start += "<del>"
+				end = "</del>" + end
+				continue
+			}
 			start += "~~"
 			end = "~~" + end
 		case notionapi.AttrCode:
+			if c.isDialect() {
+				// already a code span
+				continue
+			}
 			start += "`"
 			end = "`" + end
 		case notionapi.AttrPage:
 			pageID := notionapi.AttrGetPageID(attr)
-			// TODO: find the page
-			// TODO: needs to download info when recursively scanning
-			// for pages
-			pageTitle := ""
+			if c.isDialect() {
+				text = c.pageMention(pageID)
+				continue
+			}
+			pageTitle := escapeMarkdownLinkText(notionapi.PageTitle(c.Page, c.PageInfoProvider, pageID))
 			uri := "https://www.notion.so/" + pageID
 			if c.RewriteURL != nil {
 				uri = c.RewriteURL(uri)
 			}
-			start += fmt.Sprintf(`[%s](%s)`, pageTitle, uri)
+			text = fmt.Sprintf(`[%s](%s)`, pageTitle, uri)
 		case notionapi.AttrLink:
 			uri := notionapi.AttrGetLink(attr)
 			if c.RewriteURL != nil {
 				uri = c.RewriteURL(uri)
 			}
+			if c.isDialect() {
+				uri = linkDestination(uri)
+			}
 			before, text, after = shuffleWhitespace(text)
 			// TOOD: if text has "[" or "]" in it, has to escape
 			text = fmt.Sprintf(`%s[%s](%s)%s`, before, text, uri, after)
 		case notionapi.AttrUser:
 			userID := notionapi.AttrGetUserID(attr)
-			text = fmt.Sprintf(`@%s`, notionapi.GetUserNameByID(c.Page, userID))
+			text = fmt.Sprintf(`@%s`, c.escapeText(notionapi.GetUserNameByID(c.Page, userID)))
 		case notionapi.AttrDate:
 			date := notionapi.AttrGetDate(attr)
-			text = c.FormatDate(date)
+			text = c.escapeText(c.FormatDate(date))
+		case notionapi.AttrUnderline:
+			tag := ""
+			switch {
+			case c.InlineHTML:
+				tag = "u"
+			case c.Dialect == DialectGFM:
+				// GitHub strips <u>
+				tag = "ins"
+			case c.Dialect == DialectCommonMark, c.Dialect == DialectObsidian:
+				tag = "u"
+			}
+			if tag != "" {
+				start += "<" + tag + ">"
+				end = "</" + tag + ">" + end
+			}
+		case notionapi.AttrHighlight:
+			css := notionapi.HighlightCSS(notionapi.AttrGetHighlight(attr))
+			if c.InlineHTML && css != "" {
+				start += fmt.Sprintf(`<span style="%s">`, css)
+				end = "</span>" + end
+			} else if c.Dialect == DialectObsidian && css != "" {
+				start += "=="
+				end = "==" + end
+			}
+		case notionapi.AttrEquation:
+			text = c.inlineEquation(notionapi.AttrGetEquation(attr))
+		case notionapi.AttrLinkMention:
+			lm := notionapi.AttrGetLinkMention(attr)
+			if lm == nil {
+				continue
+			}
+			uri := lm.Href
+			if c.RewriteURL != nil {
+				uri = c.RewriteURL(uri)
+			}
+			title := lm.Title
+			if title == "" {
+				title = lm.Href
+			}
+			if c.isDialect() {
+				title = escapeMarkdownText(title)
+				uri = linkDestination(uri)
+			} else {
+				title = escapeMarkdownLinkText(title)
+			}
+			text = fmt.Sprintf(`[%s](%s)`, title, uri)
+		case notionapi.AttrExternalObject:
+			text = c.escapeText(c.externalObjectTitle(notionapi.AttrGetExternalObjectID(attr)))
 		}
 	}
 	// move whitespace from inside style to outside, to match Notion export
@@ -289,6 +401,26 @@ func (c *Converter) InlineToString(b *notionapi.TextSpan) string {
 	return start + text + end
 }
 
+func (c *Converter) inlineEquation(tex string) string {
+	if c.EquationRenderer != nil {
+		s, err := c.EquationRenderer.RenderEquation(tex, false)
+		if err == nil {
+			return s
+		}
+		logf("failed to render equation '%s'. Error: %s\n", tex, err)
+	}
+	return "$" + tex + "$"
+}
+
+func (c *Converter) externalObjectTitle(id string) string {
+	if nid := notionapi.NewNotionID(id); nid != nil && c.Page != nil {
+		if block := c.Page.BlockByID(nid); block != nil && block.Title != "" {
+			return block.Title
+		}
+	}
+	return "External object"
+}
+
 func (c *Converter) RenderInline(b *notionapi.TextSpan) {
 	s := c.InlineToString(b)
 	c.Printf(s)
@@ -297,8 +429,15 @@ func (c *Converter) RenderInline(b *notionapi.TextSpan) {
 // RenderInlines renders inline blocks
 func (c *Converter) RenderInlines(blocks []*notionapi.TextSpan, trimEndSpace bool) {
 	n := c.Buf.Len()
-	for _, block := range blocks {
+	for i, block := range blocks {
 		c.RenderInline(block)
+		if c.RenderComments {
+			c.WriteString(c.inlineDiscussionRefs(blocks, i))
+		}
+	}
+	if len(blocks) > 0 && c.pendingDiscussionRefs != "" {
+		c.WriteString(c.pendingDiscussionRefs)
+		c.pendingDiscussionRefs = ""
 	}
 
 	if trimEndSpace && c.Buf.Len() > n {
@@ -307,10 +446,24 @@ func (c *Converter) RenderInlines(blocks []*notionapi.TextSpan, trimEndSpace boo
 }
 
 // GetInlineContent is like RenderInlines but instead of writing to
-// output buffer, we return it as string
+// output buffer, we return it as string. References to discussions
+// are not rendered
 func (c *Converter) GetInlineContent(blocks []*notionapi.TextSpan, trimeEndSpace bool) string {
+	renderComments := c.RenderComments
+	pendingRefs := c.pendingDiscussionRefs
+	c.RenderComments = false
+	c.pendingDiscussionRefs = ""
+	s := c.inlineContent(blocks, trimeEndSpace)
+	c.RenderComments = renderComments
+	c.pendingDiscussionRefs = pendingRefs
+	return s
+}
+
+// inlineContent returns inline content of a block, including
+// references to discussions
+func (c *Converter) inlineContent(blocks []*notionapi.TextSpan, trimEndSpace bool) string {
 	c.PushNewBuffer()
-	c.RenderInlines(blocks, trimeEndSpace)
+	c.RenderInlines(blocks, trimEndSpace)
 	return c.PopBuffer().String()
 }
 
@@ -329,10 +482,17 @@ func (c *Converter) RenderCode(block *notionapi.Block) {
 }
 
 func (c *Converter) renderRootPage(block *notionapi.Block) {
-	title := c.GetInlineContent(block.InlineContent, false)
+	title := c.inlineContent(block.InlineContent, false)
 	c.Printf("# " + title)
+	if c.RenderComments {
+		c.pendingDiscussionRefs = c.blockDiscussionRefs(block)
+	}
+	c.flushPendingDiscussionRefs()
 	c.Newline()
 	c.RenderChildren(block)
+	if c.RenderComments {
+		c.RenderDiscussions()
+	}
 }
 
 func escapeMarkdownLinkText(s string) string {
@@ -342,19 +502,27 @@ func escapeMarkdownLinkText(s string) string {
 	return s
 }
 
+// pageURL returns url of a markdown file for a page
+func (c *Converter) pageURL(title string, pageID string) string {
+	if c.RewriteURL != nil {
+		return c.RewriteURL("https://notion.so/" + pageID)
+	}
+	return "./" + markdownFileName(title, pageID)
+}
+
 // RenderPage renders BlockPage
 func (c *Converter) RenderPage(block *notionapi.Block) {
 	if c.Page.IsRoot(block) {
 		c.renderRootPage(block)
 		return
 	}
-	title := c.GetInlineContent(block.InlineContent, false)
-	uri := ""
-	if c.RewriteURL != nil {
-		uri = c.RewriteURL("https://notion.so/" + block.ID)
-	} else {
-		uri = "./" + markdownFileName(block.Title, block.ID)
+	if c.Dialect == DialectObsidian {
+		c.Printf("%s", wikiLink(block.Title))
+		c.Eol()
+		return
 	}
+	title := c.GetInlineContent(block.InlineContent, false)
+	uri := c.pageURL(block.Title, block.ID)
 	title = escapeMarkdownLinkText(title)
 	c.Printf("[%s](%s)", title, uri)
 	c.Eol()
@@ -369,6 +537,16 @@ func (c *Converter) RenderText(block *notionapi.Block) {
 
 // RenderToggle renders BlockToggle
 func (c *Converter) RenderToggle(block *notionapi.Block) {
+	switch c.Dialect {
+	case DialectGFM, DialectCommonMark:
+		c.renderToggleDetails(block)
+		return
+	case DialectObsidian:
+		// a foldable callout
+		title := c.inlineContent(block.InlineContent, true)
+		c.renderQuoted(block, "[!note]- "+title, "")
+		return
+	}
 	c.Printf("- ")
 	c.RenderInlines(block.InlineContent, true)
 	c.Eol()
@@ -426,7 +604,7 @@ func (c *Converter) RenderHeaderLevel(block *notionapi.Block, level int) {
 		}
 		s += " "
 	}
-	content := c.GetInlineContent(block.InlineContent, false)
+	content := c.inlineContent(block.InlineContent, false)
 	content = strings.TrimRight(content, " ")
 	c.WriteString(s + content)
 	c.Newline()
@@ -457,9 +635,15 @@ func (c *Converter) Printf(format string, args ...interface{}) {
 
 // RenderTodo renders BlockTodo
 func (c *Converter) RenderTodo(block *notionapi.Block) {
-	text := c.GetInlineContent(block.InlineContent, true)
+	text := c.inlineContent(block.InlineContent, true)
 
-	if block.IsChecked {
+	if c.isDialect() {
+		check := " "
+		if block.IsChecked {
+			check = "x"
+		}
+		c.Printf("- [%s] %s\n", check, text)
+	} else if block.IsChecked {
 		c.Printf("- [x]  %s\n", text)
 	} else {
 		c.Printf("- [ ]  %s\n", text)
@@ -472,7 +656,7 @@ func (c *Converter) RenderTodo(block *notionapi.Block) {
 
 // RenderQuote renders BlockQuote
 func (c *Converter) RenderQuote(block *notionapi.Block) {
-	text := c.GetInlineContent(block.InlineContent, true)
+	text := c.inlineContent(block.InlineContent, true)
 	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
 	for _, line := range lines {
 		s := fmt.Sprintf("> %s\n", line)
@@ -480,10 +664,162 @@ func (c *Converter) RenderQuote(block *notionapi.Block) {
 	}
 }
 
-// RenderCallout renders BlockCallout
+// RenderCallout renders BlockCallout as a quote or, in GFM and Obsidian,
+// as an alert / callout
 func (c *Converter) RenderCallout(block *notionapi.Block) {
-	// TODO: implement me
-	c.WriteString("RenderCallout NYI\n")
+	text := c.inlineContent(block.InlineContent, true)
+	icon, _ := block.PropAsString("format.page_icon")
+	if icon != "" && !strings.HasPrefix(icon, "http") {
+		text = icon + " " + text
+	}
+	c.renderQuoted(block, c.calloutHeader(block), text)
+}
+
+// RenderEquation renders BlockEquation
+func (c *Converter) RenderEquation(block *notionapi.Block) {
+	eq := notionapi.TextSpansToString(block.InlineContent)
+	if c.EquationRenderer != nil {
+		s, err := c.EquationRenderer.RenderEquation(eq, true)
+		if err == nil {
+			c.WriteString(s + "\n")
+			return
+		}
+		logf("failed to render equation '%s'. Error: %s\n", eq, err)
+	}
+	c.WriteString("$$\n")
+	for _, line := range strings.Split(eq, "\n") {
+		c.WriteString(c.Indent + line + "\n")
+	}
+	c.WriteString(c.Indent + "$$\n")
+}
+
+func isHeaderBlock(block *notionapi.Block) bool {
+	switch block.Type {
+	case notionapi.BlockHeader, notionapi.BlockSubHeader, notionapi.BlockSubSubHeader:
+		return true
+	}
+	return false
+}
+
+func getHeaderBlocks(blocks []*notionapi.Block, seen map[string]bool) []*notionapi.Block {
+	var res []*notionapi.Block
+	for _, b := range blocks {
+		if seen[b.ID] {
+			// avoid infinite recursion
+			continue
+		}
+		seen[b.ID] = true
+		if isHeaderBlock(b) {
+			res = append(res, b)
+			continue
+		}
+		if b.Type == notionapi.BlockPage || b.Type == notionapi.BlockCollectionViewPage {
+			continue
+		}
+		res = append(res, getHeaderBlocks(b.Content, seen)...)
+	}
+	return res
+}
+
+func headerLevel(block *notionapi.Block) int {
+	switch block.Type {
+	case notionapi.BlockSubHeader:
+		return 2
+	case notionapi.BlockSubSubHeader:
+		return 3
+	}
+	return 1
+}
+
+// headerAnchor returns an anchor that GitHub (and many other markdown
+// renderers) generate for a header with a given text
+func headerAnchor(s string) string {
+	var res []rune
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r == ' ' || r == '-':
+			res = append(res, '-')
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			res = append(res, r)
+		}
+	}
+	return string(res)
+}
+
+// RenderTableOfContents renders BlockTableOfContents
+func (c *Converter) RenderTableOfContents(block *notionapi.Block) {
+	seen := map[string]bool{}
+	headers := getHeaderBlocks(c.Page.Root().Content, seen)
+	if len(headers) == 0 {
+		return
+	}
+	minLevel := 3
+	for _, b := range headers {
+		if l := headerLevel(b); l < minLevel {
+			minLevel = l
+		}
+	}
+	for i, b := range headers {
+		if i > 0 {
+			c.WriteString(c.Indent)
+		}
+		c.WriteString(strings.Repeat("    ", headerLevel(b)-minLevel))
+		text := notionapi.TextSpansToString(b.InlineContent)
+		title := c.GetInlineContent(b.InlineContent, true)
+		c.Printf("- [%s](#%s)\n", escapeMarkdownLinkText(title), headerAnchor(text))
+	}
+}
+
+// RenderBreadcrumb renders BlockBreadcrumb
+func (c *Converter) RenderBreadcrumb(block *notionapi.Block) {
+	root := c.Page.Root()
+	parts := []string{root.Title}
+	// we only know parents that are part of this page
+	parent := c.Page.BlockByID(notionapi.NewNotionID(root.ParentID))
+	for parent != nil && parent.IsPage() {
+		uri := c.pageURL(parent.Title, parent.ID)
+		s := fmt.Sprintf("[%s](%s)", escapeMarkdownLinkText(parent.Title), uri)
+		parts = append([]string{s}, parts...)
+		parent = c.Page.BlockByID(notionapi.NewNotionID(parent.ParentID))
+	}
+	c.WriteString(strings.Join(parts, " / "))
+	c.Eol()
+}
+
+// RenderAlias renders BlockAlias as a link to a page
+func (c *Converter) RenderAlias(block *notionapi.Block) {
+	format := block.FormatAlias()
+	if format == nil || format.Alias == nil {
+		return
+	}
+	id := format.Alias.ID
+	title := notionapi.PageTitle(c.Page, c.PageInfoProvider, id)
+	if c.Dialect == DialectObsidian && title != "" {
+		c.Printf("%s", wikiLink(title))
+		c.Eol()
+		return
+	}
+	uri := c.pageURL(title, id)
+	if title == "" {
+		title = uri
+	}
+	c.Printf("[%s](%s)", escapeMarkdownLinkText(title), uri)
+	c.Eol()
+}
+
+// RenderTransclusionReference renders BlockTransclusionReference by
+// rendering content of the referenced block, if we have it
+func (c *Converter) RenderTransclusionReference(block *notionapi.Block) {
+	id, _ := block.PropAsString("format.transclusion_reference_pointer.id")
+	nid := notionapi.NewNotionID(id)
+	if nid == nil {
+		return
+	}
+	ref := c.Page.BlockByID(nid)
+	if ref == nil {
+		return
+	}
+	c.RenderChildren(ref)
 }
 
 // RenderDivider renders BlockDivider
@@ -498,6 +834,10 @@ func (c *Converter) RenderBookmark(block *notionapi.Block) {
 	if title == "" && uri == "" {
 		return
 	}
+	if c.isDialect() {
+		title = escapeMarkdownText(title)
+		uri = linkDestination(uri)
+	}
 	c.Printf("[%s](%s)\n", title, uri)
 	c.renderCaption(block)
 }
@@ -559,25 +899,48 @@ func getEmbeddedFileNameAndURL(block *notionapi.Block) (string, string) {
 	return uri, uri
 }
 
+// fileURL returns url of a file (image, attachment etc.) of the block.
+// defURL is used if RewriteFileURL is not set
+func (c *Converter) fileURL(block *notionapi.Block, defURL string) string {
+	if c.RewriteFileURL != nil && block.Source != "" {
+		return c.RewriteFileURL(block.Source, block)
+	}
+	return defURL
+}
+
 // RenderAudio renders BlockAudio
 func (c *Converter) RenderAudio(block *notionapi.Block) {
 	name, uri := getEmbeddedFileNameAndURL(block)
+	if len(block.FileIDs) > 0 {
+		uri = c.fileURL(block, uri)
+	}
 	c.Printf("[%s](%s)\n", name, uri)
 	c.renderCaption(block)
 }
 
 // RenderVideo renders BlockTweet
 func (c *Converter) RenderVideo(block *notionapi.Block) {
+	if len(block.FileIDs) == 0 && c.renderDialectEmbed(block) {
+		return
+	}
 	name, uri := getEmbeddedFileNameAndURL(block)
+	if len(block.FileIDs) > 0 {
+		uri = c.fileURL(block, uri)
+	}
 	c.Printf("[%s](%s)\n", name, uri)
 	c.renderCaption(block)
 }
 
 // RenderFile renders BlockFile
 func (c *Converter) RenderFile(block *notionapi.Block) {
+	name := block.Title
+	if c.RewriteFileURL != nil {
+		c.Printf("[%s](%s)\n", name, c.fileURL(block, block.Source))
+		c.renderCaption(block)
+		return
+	}
 	fileID := block.FileIDs[0]
 	localFileName := localFileNameFromURL(fileID, block.Source)
-	name := block.Title
 	c.Printf("[%s](%s)\n", name, localFileName)
 	c.renderCaption(block)
 }
@@ -593,12 +956,16 @@ func (c *Converter) RenderDrive(block *notionapi.Block) {
 // RenderPDF renders BlockPDF
 func (c *Converter) RenderPDF(block *notionapi.Block) {
 	name, uri := getEmbeddedFileNameAndURL(block)
+	uri = c.fileURL(block, uri)
 	c.Printf("[%s](%s)\n", name, uri)
 	c.renderCaption(block)
 }
 
 // RenderEmbed renders BlockEmbed
 func (c *Converter) RenderEmbed(block *notionapi.Block) {
+	if c.renderDialectEmbed(block) {
+		return
+	}
 	uri := block.Source
 	c.Printf("[%s](%s)\n", uri, uri)
 	c.renderCaption(block)
@@ -611,6 +978,11 @@ func (c *Converter) RenderFigma(block *notionapi.Block) {
 
 // RenderImage renders BlockImage
 func (c *Converter) RenderImage(block *notionapi.Block) {
+	if c.RewriteFileURL != nil {
+		c.Printf("![](%s)\n", c.fileURL(block, block.Source))
+		c.renderCaption(block)
+		return
+	}
 	// TODO: not sure if always has FileIDs
 	if len(block.FileIDs) == 0 {
 		c.WriteString("RenderImage when len(FileIDs) == 0 NYI\n")
@@ -645,16 +1017,6 @@ func (c *Converter) RenderColumn(block *notionapi.Block) {
 	c.RenderChildren(block)
 }
 
-// RenderCollectionView renders BlockCollectionView
-func (c *Converter) RenderCollectionView(block *notionapi.Block) {
-	if len(block.TableViews) == 0 {
-		return
-	}
-	c.WriteString("RendeRenderCollectionViewrCode NYI\n")
-
-	c.RenderChildren(block)
-}
-
 // DefaultRenderFunc returns a defult rendering function for a type of
 // a given block
 func (c *Converter) DefaultRenderFunc(blockType string) func(*notionapi.Block) {
@@ -664,7 +1026,7 @@ func (c *Converter) DefaultRenderFunc(blockType string) func(*notionapi.Block) {
 	case notionapi.BlockText:
 		return c.RenderText
 	case notionapi.BlockEquation:
-		// TODO: NYI
+		return c.RenderEquation
 	case notionapi.BlockNumberedList:
 		return c.RenderNumberedList
 	case notionapi.BlockBulletedList:
@@ -696,7 +1058,7 @@ func (c *Converter) DefaultRenderFunc(blockType string) func(*notionapi.Block) {
 	case notionapi.BlockCollectionView:
 		return c.RenderCollectionView
 	case notionapi.BlockCollectionViewPage:
-		// TODO: NYI
+		return c.RenderCollectionViewPage
 	case notionapi.BlockEmbed:
 		return c.RenderEmbed
 	case notionapi.BlockGist:
@@ -722,13 +1084,13 @@ func (c *Converter) DefaultRenderFunc(blockType string) func(*notionapi.Block) {
 	case notionapi.BlockCallout:
 		return c.RenderCallout
 	case notionapi.BlockTableOfContents:
-		// TODO: NYI
+		return c.RenderTableOfContents
 	case notionapi.BlockBreadcrumb:
-		// TODO: NYI
+		return c.RenderBreadcrumb
 	case notionapi.BlockAlias:
-		// TODO: NYI
+		return c.RenderAlias
 	case notionapi.BlockTransclusionReference:
-		// TODO: NYI
+		return c.RenderTransclusionReference
 	case notionapi.BlockFactory:
 		return nil
 	default:
@@ -784,9 +1146,10 @@ func (c *Converter) AddNewlineBeforeBlock(block *notionapi.Block) {
 	switch block.Type {
 	case notionapi.BlockNumberedList,
 		notionapi.BlockBulletedList,
-		notionapi.BlockToggle,
 		notionapi.BlockTodo:
 		addNl = false
+	case notionapi.BlockToggle:
+		addNl = !c.isToggleList()
 	}
 	if addNl {
 		c.Newline()
@@ -810,10 +1173,18 @@ func (c *Converter) RenderBlock(block *notionapi.Block) {
 	}
 
 	def := c.DefaultRenderFunc(block.Type)
-	if def != nil {
-		c.AddNewlineBeforeBlock(block)
-		def(block)
+	if def == nil {
+		return
 	}
+	// references for the parent block without inline content
+	c.flushPendingDiscussionRefs()
+	c.AddNewlineBeforeBlock(block)
+	// discussions about the root page are rendered after the title
+	if c.RenderComments && !c.Page.IsRoot(block) {
+		c.pendingDiscussionRefs = c.blockDiscussionRefs(block)
+	}
+	def(block)
+	c.flushPendingDiscussionRefs()
 }
 
 func (c *Converter) ToMarkdown() []byte {
@@ -825,6 +1196,11 @@ func (c *Converter) ToMarkdown() []byte {
 	// which adds empty lines at top and bottom
 	d := buf.Bytes()
 	d = bytes.TrimSpace(d)
+	if c.FrontMatter != "" {
+		fm := c.frontMatter()
+		fm = append(fm, '\n')
+		d = append(fm, d...)
+	}
 	return d
 }
 
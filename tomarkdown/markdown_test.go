@@ -1,9 +1,13 @@
 package tomarkdown
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/kjk/common/assert"
+	"github.com/kjk/notionapi"
+	"github.com/kjk/notionapi/internal/testutil"
 )
 
 func TestMarkdownFileNameForPage(t *testing.T) {
@@ -21,3 +25,382 @@ func TestMarkdownFileNameForPage(t *testing.T) {
 		assert.Equal(t, test[2], got)
 	}
 }
+
+func TestEscapeTableCell(t *testing.T) {
+	tests := [][]string{
+		{"foo", "foo"},
+		{" a|b ", `a\|b`},
+		{"line 1\nline 2", "line 1<br>line 2"},
+		{"line 1\r\nline 2", "line 1<br>line 2"},
+	}
+	for _, test := range tests {
+		got := escapeTableCell(test[0])
+		assert.Equal(t, test[1], got)
+	}
+}
+
+func TestHeaderAnchor(t *testing.T) {
+	tests := [][]string{
+		{"Getting started", "getting-started"},
+		{"What's new?", "whats-new"},
+		{"Step 1: install_it", "step-1-install_it"},
+	}
+	for _, test := range tests {
+		got := headerAnchor(test[0])
+		assert.Equal(t, test[1], got)
+	}
+}
+
+func TestDefaultFrontMatterKey(t *testing.T) {
+	tests := [][]string{
+		{FieldLastEdited, "last_edited"},
+		{"Due Date", "due_date"},
+		{" Tags (old) ", "tags_old"},
+		{"Zażółć", "zażółć"},
+	}
+	for _, test := range tests {
+		got := DefaultFrontMatterKey(test[0])
+		assert.Equal(t, test[1], got)
+	}
+}
+
+func TestFrontMatterValue(t *testing.T) {
+	tm := time.Date(2021, 3, 4, 5, 6, 0, 0, time.UTC)
+	tests := []struct {
+		v   interface{}
+		exp string
+	}{
+		{`say "hi"` + "\n", `"say \"hi\"\n"`},
+		{true, "true"},
+		{float64(12), "12"},
+		{1.5, "1.5"},
+		{tm, "2021-03-04T05:06:00Z"},
+		{frontMatterDate("2021-03-04"), "2021-03-04"},
+		{[]string{"a", "b"}, `["a", "b"]`},
+		{[]interface{}{frontMatterDate("2021-03-04"), frontMatterDate("2021-03-05T09:00:00")}, "[2021-03-04, 2021-03-05T09:00:00]"},
+	}
+	for _, test := range tests {
+		got := frontMatterValue(test.v)
+		assert.Equal(t, test.exp, got)
+	}
+}
+
+func TestToFrontMatterDate(t *testing.T) {
+	warsaw := "Europe/Warsaw"
+	bad := "No/Such_Zone"
+	tests := []struct {
+		date     string
+		t        string
+		timeZone *string
+		exp      string
+	}{
+		{"2020-01-02", "", nil, "2020-01-02"},
+		{"2020-01-02", "09:30", nil, "2020-01-02T09:30:00"},
+		{"2020-01-02", "09:30", &warsaw, "2020-01-02T09:30:00+01:00"},
+		{"2020-01-02", "09:30", &bad, "2020-01-02T09:30:00"},
+		// values that are not dates are quoted
+		{"2020-01-02\nx: 1", "", nil, `"2020-01-02\nx: 1"`},
+		{"2020-01-02", "9am", nil, `"2020-01-02 9am"`},
+	}
+	for _, test := range tests {
+		got := frontMatterValue(toFrontMatterDate(test.date, test.t, test.timeZone))
+		assert.Equal(t, test.exp, got)
+	}
+	assert.Nil(t, toFrontMatterDate("", "09:30", nil))
+
+	// a range without an end is a single date
+	block := &notionapi.Block{
+		Properties: map[string]interface{}{
+			"due": []interface{}{[]interface{}{"‣", []interface{}{[]interface{}{"d", map[string]interface{}{
+				"type":       "daterange",
+				"start_date": "2020-01-01",
+				"end_date":   "",
+			}}}}},
+		},
+	}
+	c := NewConverter(nil)
+	v := c.propertyValue(block, "due", &notionapi.ColumnSchema{Type: notionapi.ColumnTypeDate})
+	assert.Equal(t, "2020-01-01", frontMatterValue(v))
+	block.Properties["due"] = []interface{}{[]interface{}{"‣", []interface{}{[]interface{}{"d", map[string]interface{}{
+		"type":       "daterange",
+		"start_date": "2020-01-01",
+		"end_date":   "2020-01-03",
+	}}}}}
+	v = c.propertyValue(block, "due", &notionapi.ColumnSchema{Type: notionapi.ColumnTypeDate})
+	assert.Equal(t, "[2020-01-01, 2020-01-03]", frontMatterValue(v))
+}
+
+func TestFrontMatter(t *testing.T) {
+	cc, err := notionapi.NewCachingClient("../caching_client_testdata", &notionapi.Client{})
+	assert.NoError(t, err)
+	cc.Policy = notionapi.PolicyCacheOnly
+	page, err := cc.DownloadPage("6682351e44bb4f9ca0e149b703265bdb")
+	assert.NoError(t, err)
+
+	c := NewConverter(page)
+	c.FrontMatter = FrontMatterYAML
+	s := string(c.ToMarkdown())
+	exp := "---\ntitle: \"Test headers\"\nid: \"6682351e-44bb-4f9c-a0e1-49b703265bdb\"\nnotion_url: \"https://www.notion.so/6682351e44bb4f9ca0e149b703265bdb\"\n"
+	assert.True(t, strings.HasPrefix(s, exp), "got:\n%s", s)
+	assert.True(t, strings.Contains(s, "\n---\n\n# Test headers"), "got:\n%s", s)
+
+	c = NewConverter(page)
+	c.FrontMatter = FrontMatterTOML
+	c.FrontMatterKey = func(name string) string {
+		switch name {
+		case FieldCreated:
+			return "date"
+		case FieldLastEdited:
+			return "lastmod"
+		case FieldNotionURL:
+			return ""
+		}
+		return name
+	}
+	s = string(c.ToMarkdown())
+	assert.True(t, strings.HasPrefix(s, "+++\ntitle = \"Test headers\"\n"), "got:\n%s", s)
+	assert.True(t, strings.Contains(s, "\ndate = "), "got:\n%s", s)
+	assert.True(t, strings.Contains(s, "\nlastmod = "), "got:\n%s", s)
+	assert.False(t, strings.Contains(s, "notion_url"), "got:\n%s", s)
+}
+
+func TestEscapeMarkdownText(t *testing.T) {
+	tests := [][]string{
+		{"plain text", "plain text"},
+		{"*not bold* and_this", `\*not bold\* and\_this`},
+		{"[link](url) <b> a|b ~x~ `c`", `\[link\](url) \<b\> a\|b \~x\~ \` + "`c\\`"},
+		{"# not a header", `\# not a header`},
+		{"- not a list\n+ nor this - or that", "\\- not a list\n\\+ nor this - or that"},
+		{"1. one\n 12) two 3.5", "1\\. one\n 12\\) two 3.5"},
+		{"#tag ==hl== $x$ %%", `\#tag \=\=hl\=\= \$x\$ \%\%`},
+	}
+	for _, test := range tests {
+		got := escapeMarkdownText(test[0])
+		assert.Equal(t, test[1], got)
+	}
+}
+
+func TestCodeSpan(t *testing.T) {
+	assert.Equal(t, "`a*b`", codeSpan("a*b"))
+	assert.Equal(t, "``a`b``", codeSpan("a`b"))
+	assert.Equal(t, "`` `a ``", codeSpan("`a"))
+}
+
+func TestHugoShortcode(t *testing.T) {
+	tests := [][]string{
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ", "{{< youtube dQw4w9WgXcQ >}}"},
+		{"https://youtu.be/dQw4w9WgXcQ", "{{< youtube dQw4w9WgXcQ >}}"},
+		{"https://vimeo.com/76979871", "{{< vimeo 76979871 >}}"},
+		{"https://twitter.com/kjk/status/1224423424203669504", `{{< tweet user="kjk" id="1224423424203669504" >}}`},
+		{"https://gist.github.com/kjk/7278df5c7b164fce3c949af197c961eb", "{{< gist kjk 7278df5c7b164fce3c949af197c961eb >}}"},
+		{"https://example.com/video.mp4", ""},
+	}
+	for _, test := range tests {
+		got := hugoShortcode(test[0])
+		assert.Equal(t, test[1], got)
+	}
+}
+
+func TestDialects(t *testing.T) {
+	render := func(dialect string, block *notionapi.Block) string {
+		c := NewConverter(nil)
+		c.Dialect = dialect
+		c.PushNewBuffer()
+		c.RenderBlock(block)
+		return strings.TrimSpace(c.PopBuffer().String())
+	}
+	text := func(s string, attrs ...notionapi.TextAttr) []*notionapi.TextSpan {
+		return []*notionapi.TextSpan{{Text: s, Attrs: attrs}}
+	}
+	para := &notionapi.Block{
+		Type: notionapi.BlockText,
+		InlineContent: []*notionapi.TextSpan{
+			{Text: "2*3 "},
+			{Text: "gone", Attrs: []notionapi.TextAttr{{notionapi.AttrStrikeThrought}}},
+			{Text: " "},
+			{Text: "a`b", Attrs: []notionapi.TextAttr{{notionapi.AttrCode}}},
+			{Text: " "},
+			{Text: "hl", Attrs: []notionapi.TextAttr{{notionapi.AttrHighlight, "yellow_background"}}},
+		},
+	}
+	assert.Equal(t, "2*3 ~~gone~~ `a`b` hl", render(DialectDefault, para))
+	assert.Equal(t, "2\\*3 <del>gone</del> ``a`b`` hl", render(DialectCommonMark, para))
+	assert.Equal(t, "2\\*3 ~~gone~~ ``a`b`` hl", render(DialectGFM, para))
+	assert.Equal(t, "2\\*3 ~~gone~~ ``a`b`` ==hl==", render(DialectObsidian, para))
+
+	todo := &notionapi.Block{Type: notionapi.BlockTodo, IsChecked: true, InlineContent: text("done")}
+	assert.Equal(t, "- [x]  done", render(DialectDefault, todo))
+	assert.Equal(t, "- [x] done", render(DialectGFM, todo))
+
+	child := &notionapi.Block{Type: notionapi.BlockText, InlineContent: text("inside")}
+	toggle := &notionapi.Block{Type: notionapi.BlockToggle, InlineContent: text("More <info>"), Content: []*notionapi.Block{child}}
+	assert.Equal(t, "- More <info>\n\n    inside", render(DialectDefault, toggle))
+	assert.Equal(t, "<details>\n<summary>More &lt;info&gt;</summary>\n\ninside\n\n</details>", render(DialectGFM, toggle))
+	assert.Equal(t, "> [!note]- More \\<info\\>\n> inside", render(DialectObsidian, toggle))
+
+	callout := &notionapi.Block{
+		Type:          notionapi.BlockCallout,
+		InlineContent: text("Careful"),
+		RawJSON: map[string]interface{}{
+			"format": map[string]interface{}{
+				"block_color": "red_background",
+				"page_icon":   "⚠️",
+			},
+		},
+	}
+	assert.Equal(t, "> ⚠️ Careful", render(DialectDefault, callout))
+	assert.Equal(t, "> [!CAUTION]\n> ⚠️ Careful", render(DialectGFM, callout))
+	assert.Equal(t, "> [!danger]\n> ⚠️ Careful", render(DialectObsidian, callout))
+
+	mention := &notionapi.Block{
+		Type:          notionapi.BlockText,
+		InlineContent: text(notionapi.TextSpanSpecial, notionapi.TextAttr{notionapi.AttrPage, "3b617da4-0945-4a52-bc3a-920ba8832bf7"}),
+	}
+	assert.Equal(t, "[[3b617da409454a52bc3a920ba8832bf7]]", render(DialectObsidian, mention))
+	assert.Equal(t, "[https://www.notion.so/3b617da409454a52bc3a920ba8832bf7](https://www.notion.so/3b617da409454a52bc3a920ba8832bf7)", render(DialectGFM, mention))
+
+	video := &notionapi.Block{Type: notionapi.BlockVideo, Source: "https://www.youtube.com/watch?v=dQw4w9WgXcQ"}
+	assert.Equal(t, "{{< youtube dQw4w9WgXcQ >}}", render(DialectHugo, video))
+	assert.Equal(t, "![](https://www.youtube.com/watch?v=dQw4w9WgXcQ)", render(DialectObsidian, video))
+}
+
+type testPageInfos map[string]*notionapi.PageInfo
+
+func (p testPageInfos) PageInfo(id string) *notionapi.PageInfo {
+	return p[notionapi.ToNoDashID(id)]
+}
+
+func TestPageMention(t *testing.T) {
+	span := &notionapi.TextSpan{
+		Text:  notionapi.TextSpanSpecial,
+		Attrs: []notionapi.TextAttr{{notionapi.AttrPage, "3b617da4-0945-4a52-bc3a-920ba8832bf7"}},
+	}
+	c := NewConverter(nil)
+	assert.Equal(t, "[](https://www.notion.so/3b617da4-0945-4a52-bc3a-920ba8832bf7)", c.InlineToString(span))
+
+	c.PageInfoProvider = testPageInfos{
+		"3b617da409454a52bc3a920ba8832bf7": {Title: "Blendle's [Employee] Handbook", Icon: "📖"},
+	}
+	assert.Equal(t, `[Blendle's \[Employee\] Handbook](https://www.notion.so/3b617da4-0945-4a52-bc3a-920ba8832bf7)`, c.InlineToString(span))
+	c.Dialect = DialectObsidian
+	assert.Equal(t, "[[Blendle's  Employee  Handbook]]", c.InlineToString(span))
+}
+
+func TestLinkMention(t *testing.T) {
+	span := &notionapi.TextSpan{
+		Text:  notionapi.TextSpanSpecial,
+		Attrs: []notionapi.TextAttr{{notionapi.AttrLinkMention, `{"href": "https://example.com/", "title": "Docs [draft]"}`}},
+	}
+	c := NewConverter(nil)
+	assert.Equal(t, `[Docs \[draft\]](https://example.com/)`, c.InlineToString(span))
+}
+
+func TestTableView(t *testing.T) {
+	page := testutil.LoadPage(t, "page.json")
+	tv := page.TableViews[0]
+	c := NewConverter(page)
+	tests := []string{
+		"[Write tests](./Write-tests-5f4e0b8a-0a5c-4e7f-9ebc-7a5d4c6b8f9e.md)",
+		"[x]",
+		"1,234.5",
+		"Mar 01, 2024",
+		"@<img src=x onerror=alert(1)>",
+		"<img src=x onerror=alert(1)>",
+	}
+	for col, exp := range tests {
+		assert.Equal(t, exp, c.TableCellToString(tv, 0, col))
+	}
+	// rows without content are not linked
+	assert.Equal(t, "Ship", c.TableCellToString(tv, 1, 0))
+	assert.Equal(t, "[ ]", c.TableCellToString(tv, 1, 1))
+
+	c.PushNewBuffer()
+	c.RenderTableView(tv)
+	s := c.PopBuffer().String()
+	exp := `| Name | Done | Estimate | Due | Owner | Created by |
+| --- | --- | ---: | --- | --- | --- |
+| [Write tests](./Write-tests-5f4e0b8a-0a5c-4e7f-9ebc-7a5d4c6b8f9e.md) | [x] | 1,234.5 | Mar 01, 2024 | @<img src=x onerror=alert(1)> | <img src=x onerror=alert(1)> |
+| Ship | [ ] |  |  |  | <img src=x onerror=alert(1)> |
+`
+	assert.Equal(t, exp, s)
+
+	// name of a collection is escaped
+	tv.Collection.Name = []interface{}{[]interface{}{"*Todo* [x]"}}
+	c.PushNewBuffer()
+	c.RenderCollectionView(page.BlockByID(notionapi.NewNotionID("2c1b7e5d-7d2f-4b4c-8b8f-4d2a1f3e5c6b")))
+	s = c.PopBuffer().String()
+	assert.True(t, strings.HasPrefix(s, `**\*Todo\* \[x\]**`), "got:\n%s", s)
+}
+
+func TestEmailCell(t *testing.T) {
+	tv := &notionapi.TableView{
+		Rows: []*notionapi.TableRow{{
+			Page:    &notionapi.Block{},
+			Columns: [][]*notionapi.TextSpan{{{Text: "a_b@x.com](javascript:x) <y>"}}},
+		}},
+	}
+	tv.Columns = []*notionapi.ColumnInfo{{
+		TableView: tv,
+		Schema:    &notionapi.ColumnSchema{Type: notionapi.ColumnTypeEmail},
+	}}
+	c := NewConverter(nil)
+	exp := `[a\_b@x.com\](javascript:x) \<y\>](mailto:a_b@x.com%5D%28javascript:x%29%20%3Cy%3E)`
+	assert.Equal(t, exp, c.TableCellToString(tv, 0, 0))
+}
+
+func TestComments(t *testing.T) {
+	page := testutil.LoadPage(t, "comments.json")
+	c := NewConverter(page)
+	c.RenderComments = true
+	s := string(c.ToMarkdown())
+	exp := []string{
+		// table of contents doesn't reference discussions
+		"- [Introduction](#introduction)\n",
+		"# Introduction[^1]\n",
+		// a discussion about text spanning 2 spans is referenced once.
+		// resolved discussions are not shown
+		"See this **part**[^2], old.\n",
+		// discussions without comments are not shown
+		"Whole block[^3]\n",
+		"[^1]: **Ann <Lee>** (Mar 1, 2024): Nice title\n",
+		"[^2]: **Ann <Lee>** (Mar 1, 2024): Why?\n\n    **Ann <Lee>** (Mar 2, 2024): Because\n",
+		"[^3]: **Ann <Lee>** (Mar 1, 2024): Split it",
+	}
+	for _, e := range exp {
+		assert.True(t, strings.Contains(s, e), "expected '%s' in:\n%s", e, s)
+	}
+	assert.False(t, strings.Contains(s, "Fixed"))
+
+	c = NewConverter(page)
+	c.RenderComments = true
+	c.RenderResolvedComments = true
+	s = string(c.ToMarkdown())
+	exp = []string{
+		"See this **part**[^2], old[^3].\n",
+		"Whole block[^4]\n",
+		"[^3]: (resolved) **Ann <Lee>** (Mar 1, 2024): Fixed\n",
+		"[^4]: **Ann <Lee>** (Mar 1, 2024): Split it",
+	}
+	for _, e := range exp {
+		assert.True(t, strings.Contains(s, e), "expected '%s' in:\n%s", e, s)
+	}
+
+	c = NewConverter(page)
+	s = string(c.ToMarkdown())
+	assert.False(t, strings.Contains(s, "[^"))
+}
+
+func TestGetInlineContentWithComments(t *testing.T) {
+	page := testutil.LoadPage(t, "comments.json")
+	c := NewConverter(page)
+	c.RenderComments = true
+	block := page.BlockByID(notionapi.NewNotionID("30000000-0000-4000-8000-000000000004"))
+	c.pendingDiscussionRefs = c.blockDiscussionRefs(block)
+	assert.Equal(t, "Whole block", c.GetInlineContent(block.InlineContent, true))
+	// references pending for the block are not consumed
+	assert.Equal(t, "[^1]", c.pendingDiscussionRefs)
+
+	header := page.BlockByID(notionapi.NewNotionID("30000000-0000-4000-8000-000000000002"))
+	assert.Equal(t, "Introduction", c.GetInlineContent(header.InlineContent, true))
+	assert.Equal(t, 1, len(c.discussions.Discussions))
+}
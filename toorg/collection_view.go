@@ -0,0 +1,145 @@
+package toorg
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kjk/notionapi"
+)
+
+// escapeTableCell makes a string safe to put inside a cell of a table
+func escapeTableCell(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.Replace(s, "|", `\vert{}`, -1)
+	s = strings.Replace(s, "\r\n", "\n", -1)
+	s = strings.Replace(s, "\n", " ", -1)
+	return s
+}
+
+// TableCellToString returns Org mode text for the content of a table cell
+func (c *Converter) TableCellToString(tv *notionapi.TableView, row, col int) string {
+	ci := tv.Columns[col]
+	spans := tv.CellContent(row, col)
+	text := tv.CellText(row, col)
+	switch ci.Type() {
+	case notionapi.ColumnTypeTitle:
+		title := c.GetInlineContent(spans)
+		if title == "" {
+			title = "Untitled"
+		}
+		rowPage := tv.Rows[row].Page
+		if len(rowPage.ContentIDs) == 0 {
+			// for cosmetic reasons we don't link to empty pages
+			return title
+		}
+		return orgLink(c.pageURL(text, rowPage.ID), title)
+	case notionapi.ColumnTypeCheckbox:
+		if text == "Yes" {
+			return "[X]"
+		}
+		return "[ ]"
+	case notionapi.ColumnTypeURL:
+		if text == "" {
+			return ""
+		}
+		return orgLink(text, "")
+	case notionapi.ColumnTypeEmail:
+		if text == "" {
+			return ""
+		}
+		return orgLink("mailto:"+text, text)
+	}
+	if ci.IsRichText() {
+		return c.GetInlineContent(spans)
+	}
+	return text
+}
+
+// RenderTableView renders a collection view as a table. Columns are
+// aligned, the way Emacs formats tables
+func (c *Converter) RenderTableView(tv *notionapi.TableView) {
+	nCols := tv.ColumnCount()
+	if nCols == 0 {
+		return
+	}
+	var rows [][]string
+	var cells []string
+	for _, ci := range tv.Columns {
+		name := ci.Name()
+		if name == "" {
+			name = ci.ID()
+		}
+		cells = append(cells, escapeTableCell(name))
+	}
+	rows = append(rows, cells)
+	nRows := tv.RowCount()
+	for row := 0; row < nRows; row++ {
+		cells = nil
+		for col := 0; col < nCols; col++ {
+			cells = append(cells, escapeTableCell(c.TableCellToString(tv, row, col)))
+		}
+		rows = append(rows, cells)
+	}
+
+	widths := make([]int, nCols)
+	for _, cells := range rows {
+		for col, s := range cells {
+			if n := utf8.RuneCountInString(s); n > widths[col] {
+				widths[col] = n
+			}
+		}
+	}
+	var lines []string
+	for i, cells := range rows {
+		line := "|"
+		for col, s := range cells {
+			line += " " + s + strings.Repeat(" ", widths[col]-utf8.RuneCountInString(s)) + " |"
+		}
+		lines = append(lines, line)
+		if i == 0 {
+			line = "|"
+			for col := range cells {
+				if col > 0 {
+					line += "+"
+				}
+				line += strings.Repeat("-", widths[col]+2)
+			}
+			lines = append(lines, line+"|")
+		}
+	}
+	c.WriteLines(strings.Join(lines, "\n"))
+}
+
+// RenderCollectionView renders BlockCollectionView
+func (c *Converter) RenderCollectionView(block *notionapi.Block) {
+	if len(block.TableViews) == 0 {
+		return
+	}
+	if name := c.Page.CollectionName(block); name != "" {
+		c.WriteLines("#+CAPTION: " + name)
+	}
+	// render only the first one
+	c.RenderTableView(block.TableViews[0])
+}
+
+// RenderCollectionViewPage renders BlockCollectionViewPage
+func (c *Converter) RenderCollectionViewPage(block *notionapi.Block) {
+	name := c.Page.CollectionName(block)
+	if c.Page.IsRoot(block) {
+		c.Printf("#+TITLE: %s\n", name)
+		if len(block.TableViews) > 0 {
+			c.Newline()
+			c.RenderTableView(block.TableViews[0])
+		}
+		return
+	}
+	if name == "" {
+		name = "Untitled Database"
+	}
+	c.WriteLines(orgLink(c.pageURL(name, block.ID), name))
+}
+
+// RenderLinkToCollection renders BlockLinkToCollection as a link
+func (c *Converter) RenderLinkToCollection(block *notionapi.Block) {
+	c.RenderCollectionViewPage(block)
+}
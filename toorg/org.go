@@ -0,0 +1,803 @@
+// Package toorg converts a Notion page to Emacs Org mode.
+//
+// Headers are headlines and to-dos are check box list items. Toggles are
+// drawers and callouts are special blocks e.g. #+BEGIN_WARNING.
+package toorg
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/kjk/notionapi"
+)
+
+func maybePanic(format string, args ...interface{}) {
+	notionapi.MaybePanic(format, args...)
+}
+
+func orgFileName(title, pageID string) string {
+	s := notionapi.SafeName(title)
+	return s + "-" + notionapi.ToDashID(pageID) + ".org"
+}
+
+// OrgFileNameForPage returns file name for .org file
+func OrgFileNameForPage(page *notionapi.Page) string {
+	rootPage := page.Root()
+	return orgFileName(rootPage.Title, page.ID)
+}
+
+// BlockRenderFunc is a function for rendering a particular block
+type BlockRenderFunc func(block *notionapi.Block) bool
+
+// Converter converts a Page to Org mode
+type Converter struct {
+	Page *notionapi.Page
+
+	// Buf is where Org mode text is being written to
+	Buf *bytes.Buffer
+
+	// allows over-riding rendering of specific blocks
+	// return false for default rendering
+	RenderBlockOverride BlockRenderFunc
+
+	// RewriteURL allows re-writing URLs e.g. to convert inter-notion URLs
+	// to destination URLs
+	RewriteURL func(url string) string
+
+	// RewriteFileURL allows re-writing URLs of files (images, attachments
+	// etc.) e.g. to download them and link to local copies.
+	// See assets.Bundler
+	RewriteFileURL func(uri string, block *notionapi.Block) string
+
+	// data provided by they caller, useful when providing
+	// RenderBlockOverride
+	Data interface{}
+
+	// we need this to properly render ordered and numbered lists
+	CurrBlocks   []*notionapi.Block
+	CurrBlockIdx int
+
+	Indent string
+	ListNo int
+
+	// PageInfoProvider, if set, is used to get titles of mentioned pages
+	// that are not part of Page
+	PageInfoProvider notionapi.PageInfoProvider
+
+	bufs []*bytes.Buffer
+}
+
+// NewConverter returns customizable Org mode renderer
+func NewConverter(page *notionapi.Page) *Converter {
+	return &Converter{
+		Page: page,
+	}
+}
+
+// PushNewBuffer creates a new buffer and sets Buf to it
+func (c *Converter) PushNewBuffer() {
+	c.bufs = append(c.bufs, c.Buf)
+	c.Buf = &bytes.Buffer{}
+}
+
+// PopBuffer pops a buffer
+func (c *Converter) PopBuffer() *bytes.Buffer {
+	res := c.Buf
+	n := len(c.bufs)
+	c.Buf = c.bufs[n-1]
+	c.bufs = c.bufs[:n-1]
+	return res
+}
+
+// Eol writes end-of-line to the buffer. Doesn't write multiple.
+func (c *Converter) Eol() {
+	d := c.Buf.Bytes()
+	n := len(d)
+	if n > 0 && d[n-1] != '\n' {
+		c.Buf.WriteByte('\n')
+	}
+}
+
+// Newline writes a newline to the buffer. It'll suppress multiple newlines.
+func (c *Converter) Newline() {
+	d := c.Buf.Bytes()
+	n := 0
+	idx := len(d) - 1
+	for idx >= 0 && d[idx] == '\n' {
+		n++
+		idx--
+	}
+	switch n {
+	case 0:
+		c.Buf.WriteString("\n\n")
+	case 1:
+		c.Buf.WriteByte('\n')
+	}
+}
+
+// WriteString writes a string to the buffer
+func (c *Converter) WriteString(s string) {
+	c.Buf.WriteString(s)
+}
+
+// Printf writes formatted string to the buffer
+func (c *Converter) Printf(format string, args ...interface{}) {
+	s := format
+	if len(args) > 0 {
+		s = fmt.Sprintf(format, args...)
+	}
+	c.Buf.WriteString(s)
+}
+
+// WriteLines writes lines of s, each prefixed with Indent
+func (c *Converter) WriteLines(s string) {
+	s = strings.Replace(s, "\r\n", "\n", -1)
+	for _, line := range strings.Split(s, "\n") {
+		if line == "" {
+			c.WriteString("\n")
+			continue
+		}
+		c.WriteString(c.Indent + line + "\n")
+	}
+}
+
+// PrevBlock is a block preceding current block
+func (c *Converter) PrevBlock() *notionapi.Block {
+	if c.CurrBlockIdx == 0 {
+		return nil
+	}
+	return c.CurrBlocks[c.CurrBlockIdx-1]
+}
+
+// NextBlock is a block following current block
+func (c *Converter) NextBlock() *notionapi.Block {
+	nextIdx := c.CurrBlockIdx + 1
+	if nextIdx >= len(c.CurrBlocks) {
+		return nil
+	}
+	return c.CurrBlocks[nextIdx]
+}
+
+// IsPrevBlockOfType returns true if previous block is of a given type
+func (c *Converter) IsPrevBlockOfType(t string) bool {
+	b := c.PrevBlock()
+	return b != nil && b.Type == t
+}
+
+// IsNextBlockOfType returns true if next block is of a given type
+func (c *Converter) IsNextBlockOfType(t string) bool {
+	b := c.NextBlock()
+	return b != nil && b.Type == t
+}
+
+// FormatDate formats the date
+func (c *Converter) FormatDate(d *notionapi.Date) string {
+	return notionapi.FormatDate(d)
+}
+
+func isWs(c byte) bool {
+	return c == ' '
+}
+
+// shuffleWhitespace splits text into leading whitespace, text and
+// trailing whitespace, as Org mode markup must be next to text
+func shuffleWhitespace(text string) (string, string, string) {
+	n := 0
+	for n < len(text) && isWs(text[n]) {
+		n++
+	}
+	before := text[:n]
+	text = text[n:]
+	n = len(text)
+	for n > 0 && isWs(text[n-1]) {
+		n--
+	}
+	return before, text[:n], text[n:]
+}
+
+var linkEscaper = strings.NewReplacer(
+	"[", "%5B",
+	"]", "%5D",
+)
+
+// orgLink returns a link to uri with optional description
+func orgLink(uri string, desc string) string {
+	uri = linkEscaper.Replace(uri)
+	if desc == "" {
+		return "[[" + uri + "]]"
+	}
+	// "]]" would end the link
+	desc = strings.Replace(desc, "]]", "] ]", -1)
+	return "[[" + uri + "][" + desc + "]]"
+}
+
+func isLocalFile(uri string) bool {
+	return uri != "" && !strings.Contains(uri, ":")
+}
+
+// fileLink returns a link to a file. Local files must be prefixed
+// with file:
+func fileLink(uri string, desc string) string {
+	if isLocalFile(uri) {
+		uri = "file:" + uri
+	}
+	return orgLink(uri, desc)
+}
+
+// pageURL returns url of an .org file for a page
+func (c *Converter) pageURL(title string, pageID string) string {
+	if c.RewriteURL != nil {
+		return c.RewriteURL("https://notion.so/" + pageID)
+	}
+	return "file:" + orgFileName(title, pageID)
+}
+
+// InlineToString renders inline block
+func (c *Converter) InlineToString(b *notionapi.TextSpan) string {
+	text := b.Text
+	var start, end string
+	for _, attr := range b.Attrs {
+		switch notionapi.AttrGetType(attr) {
+		case notionapi.AttrBold:
+			start += "*"
+			end = "*" + end
+		case notionapi.AttrItalic:
+			start += "/"
+			end = "/" + end
+		case notionapi.AttrStrikeThrought:
+			start += "+"
+			end = "+" + end
+		case notionapi.AttrUnderline:
+			start += "_"
+			end = "_" + end
+		case notionapi.AttrCode:
+			start += "~"
+			end = "~" + end
+		case notionapi.AttrPage:
+			pageID := notionapi.AttrGetPageID(attr)
+			uri := "https://www.notion.so/" + pageID
+			if c.RewriteURL != nil {
+				uri = c.RewriteURL(uri)
+			}
+			text = orgLink(uri, notionapi.PageTitle(c.Page, c.PageInfoProvider, pageID))
+		case notionapi.AttrLink:
+			uri := notionapi.AttrGetLink(attr)
+			if c.RewriteURL != nil {
+				uri = c.RewriteURL(uri)
+			}
+			var before, after string
+			before, text, after = shuffleWhitespace(text)
+			text = before + orgLink(uri, text) + after
+		case notionapi.AttrUser:
+			userID := notionapi.AttrGetUserID(attr)
+			text = "@" + notionapi.GetUserNameByID(c.Page, userID)
+		case notionapi.AttrDate:
+			text = c.FormatDate(notionapi.AttrGetDate(attr))
+		case notionapi.AttrEquation:
+			text = `\(` + notionapi.AttrGetEquation(attr) + `\)`
+		case notionapi.AttrLinkMention:
+			lm := notionapi.AttrGetLinkMention(attr)
+			if lm == nil {
+				continue
+			}
+			uri := lm.Href
+			if c.RewriteURL != nil {
+				uri = c.RewriteURL(uri)
+			}
+			text = orgLink(uri, lm.Title)
+		}
+	}
+	if start == "" {
+		return text
+	}
+	before, text, after := shuffleWhitespace(text)
+	if text == "" {
+		return before + after
+	}
+	return before + start + text + end + after
+}
+
+// GetInlineContent returns Org mode text of inline blocks
+func (c *Converter) GetInlineContent(blocks []*notionapi.TextSpan) string {
+	var sb strings.Builder
+	for _, b := range blocks {
+		sb.WriteString(c.InlineToString(b))
+	}
+	return strings.TrimRight(sb.String(), " ")
+}
+
+// RenderInlines renders inline blocks
+func (c *Converter) RenderInlines(blocks []*notionapi.TextSpan) {
+	c.WriteString(c.GetInlineContent(blocks))
+}
+
+// escapeLineStart prevents lines of a paragraph from being parsed as
+// headlines or keywords by inserting a zero width space, as recommended
+// by Org manual
+func escapeLineStart(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "*") || strings.HasPrefix(line, "#+") {
+			lines[i] = "\u200b" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c *Converter) renderCaption(block *notionapi.Block) {
+	caption := c.GetInlineContent(block.GetCaption())
+	if caption == "" {
+		return
+	}
+	c.WriteLines("#+CAPTION: " + strings.Replace(caption, "\n", " ", -1))
+}
+
+// renderIndentedChildren renders children of a block indented by indent
+func (c *Converter) renderIndentedChildren(block *notionapi.Block, indent string) {
+	listNo := c.ListNo
+	prevIndent := c.Indent
+	c.Indent += indent
+	c.RenderChildren(block)
+	c.Indent = prevIndent
+	c.ListNo = listNo
+}
+
+func (c *Converter) renderRootPage(block *notionapi.Block) {
+	title := c.GetInlineContent(block.InlineContent)
+	c.Printf("#+TITLE: %s\n", strings.Replace(title, "\n", " ", -1))
+	c.RenderChildren(block)
+}
+
+// RenderPage renders BlockPage
+func (c *Converter) RenderPage(block *notionapi.Block) {
+	if c.Page.IsRoot(block) {
+		c.renderRootPage(block)
+		return
+	}
+	title := c.GetInlineContent(block.InlineContent)
+	c.WriteLines(orgLink(c.pageURL(block.Title, block.ID), title))
+}
+
+// RenderText renders BlockText
+func (c *Converter) RenderText(block *notionapi.Block) {
+	if text := c.GetInlineContent(block.InlineContent); text != "" {
+		c.WriteLines(escapeLineStart(text))
+	}
+	c.renderIndentedChildren(block, "  ")
+}
+
+// RenderHeaderLevel renders BlockHeader, SubHeader and SubSubHeader
+// as headlines
+func (c *Converter) RenderHeaderLevel(block *notionapi.Block, level int) {
+	content := c.GetInlineContent(block.InlineContent)
+	c.Printf("%s %s\n", strings.Repeat("*", level), strings.Replace(content, "\n", " ", -1))
+}
+
+// RenderHeader renders BlockHeader
+func (c *Converter) RenderHeader(block *notionapi.Block) {
+	c.RenderHeaderLevel(block, 1)
+}
+
+// RenderSubHeader renders BlockSubHeader
+func (c *Converter) RenderSubHeader(block *notionapi.Block) {
+	c.RenderHeaderLevel(block, 2)
+}
+
+// RenderSubSubHeader renders BlockSubSubHeader
+func (c *Converter) RenderSubSubHeader(block *notionapi.Block) {
+	c.RenderHeaderLevel(block, 3)
+}
+
+// renderListItem renders an item of a list, children are indented to
+// the text of the item
+func (c *Converter) renderListItem(block *notionapi.Block, bullet string) {
+	text := c.GetInlineContent(block.InlineContent)
+	text = strings.Replace(text, "\n", "\n"+strings.Repeat(" ", len(bullet)), -1)
+	c.WriteLines(bullet + text)
+	c.renderIndentedChildren(block, strings.Repeat(" ", len(bullet)))
+}
+
+// RenderBulletedList renders BlockBulletedList
+func (c *Converter) RenderBulletedList(block *notionapi.Block) {
+	c.renderListItem(block, "- ")
+}
+
+// RenderNumberedList renders BlockNumberedList
+func (c *Converter) RenderNumberedList(block *notionapi.Block) {
+	if c.IsPrevBlockOfType(notionapi.BlockNumberedList) {
+		c.ListNo++
+	} else {
+		c.ListNo = 1
+	}
+	c.renderListItem(block, fmt.Sprintf("%d. ", c.ListNo))
+}
+
+// RenderTodo renders BlockTodo as a check box list item
+func (c *Converter) RenderTodo(block *notionapi.Block) {
+	check := "[ ]"
+	if block.IsChecked {
+		check = "[X]"
+	}
+	c.renderListItem(block, "- "+check+" ")
+}
+
+// RenderToggle renders BlockToggle as a text followed by a drawer with
+// its children, which is folded in Emacs
+func (c *Converter) RenderToggle(block *notionapi.Block) {
+	if text := c.GetInlineContent(block.InlineContent); text != "" {
+		c.WriteLines(escapeLineStart(text))
+	}
+	if len(block.Content) == 0 {
+		return
+	}
+	c.WriteLines(":DETAILS:")
+	c.RenderChildren(block)
+	c.Eol()
+	c.WriteLines(":END:")
+}
+
+// RenderQuote renders BlockQuote
+func (c *Converter) RenderQuote(block *notionapi.Block) {
+	c.WriteLines("#+BEGIN_QUOTE")
+	if text := c.GetInlineContent(block.InlineContent); text != "" {
+		c.WriteLines(escapeLineStart(text))
+	}
+	c.RenderChildren(block)
+	c.Eol()
+	c.WriteLines("#+END_QUOTE")
+}
+
+// calloutTypes maps color of a callout to a name of special block
+var calloutTypes = map[string]string{
+	"blue":   "NOTE",
+	"green":  "TIP",
+	"purple": "IMPORTANT",
+	"pink":   "IMPORTANT",
+	"yellow": "WARNING",
+	"orange": "WARNING",
+	"red":    "CAUTION",
+}
+
+// RenderCallout renders BlockCallout as a special block e.g. #+BEGIN_NOTE
+func (c *Converter) RenderCallout(block *notionapi.Block) {
+	col, _ := block.PropAsString("format.block_color")
+	col, _ = notionapi.ParseHighlight(col)
+	kind := calloutTypes[col]
+	if kind == "" {
+		kind = "NOTE"
+	}
+	text := c.GetInlineContent(block.InlineContent)
+	icon, _ := block.PropAsString("format.page_icon")
+	if icon != "" && !strings.HasPrefix(icon, "http") {
+		text = icon + " " + text
+	}
+	c.WriteLines("#+BEGIN_" + kind)
+	c.WriteLines(escapeLineStart(text))
+	c.RenderChildren(block)
+	c.Eol()
+	c.WriteLines("#+END_" + kind)
+}
+
+// RenderEquation renders BlockEquation as LaTeX fragment
+func (c *Converter) RenderEquation(block *notionapi.Block) {
+	eq := strings.TrimSpace(notionapi.TextSpansToString(block.InlineContent))
+	if eq == "" {
+		return
+	}
+	c.WriteLines(`\[` + "\n" + eq + "\n" + `\]`)
+}
+
+// orgLanguages maps names of languages in Notion to names of languages
+// in Org mode, when lower-cased name isn't the name in Org mode
+var orgLanguages = map[string]string{
+	"c":          "C",
+	"c++":        "C++",
+	"c#":         "csharp",
+	"f#":         "fsharp",
+	"javascript": "js",
+	"shell":      "sh",
+	"bash":       "sh",
+	"markup":     "html",
+	"emacs lisp": "emacs-lisp",
+}
+
+func codeLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if l, ok := orgLanguages[lang]; ok {
+		return l
+	}
+	if lang == "plain text" || strings.ContainsAny(lang, " \t") {
+		return ""
+	}
+	return lang
+}
+
+// escapeCode escapes lines of code that would be parsed as headlines or
+// end of the block by prefixing them with a comma
+func escapeCode(code string) string {
+	lines := strings.Split(code, "\n")
+	for i, line := range lines {
+		s := strings.TrimLeft(line, ",")
+		if strings.HasPrefix(s, "*") || strings.HasPrefix(s, "#+") {
+			lines[i] = "," + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RenderCode renders BlockCode as source block or, if it doesn't have a
+// language, as example block
+func (c *Converter) RenderCode(block *notionapi.Block) {
+	c.renderCaption(block)
+	code := strings.TrimRight(strings.Replace(block.Code, "\r\n", "\n", -1), "\n")
+	code = escapeCode(code)
+	lang := codeLanguage(block.CodeLanguage)
+	if lang == "" {
+		c.WriteLines("#+BEGIN_EXAMPLE\n" + code + "\n#+END_EXAMPLE")
+		return
+	}
+	c.WriteLines("#+BEGIN_SRC " + lang + "\n" + code + "\n#+END_SRC")
+}
+
+// RenderTableOfContents renders BlockTableOfContents
+func (c *Converter) RenderTableOfContents(block *notionapi.Block) {
+	c.WriteLines("#+TOC: headlines 3")
+}
+
+// RenderAlias renders BlockAlias as a link to the page
+func (c *Converter) RenderAlias(block *notionapi.Block) {
+	format := block.FormatAlias()
+	if format == nil || format.Alias == nil {
+		return
+	}
+	id := format.Alias.ID
+	title := notionapi.PageTitle(c.Page, c.PageInfoProvider, id)
+	c.WriteLines(orgLink(c.pageURL(title, id), title))
+}
+
+// RenderTransclusionReference renders BlockTransclusionReference by
+// rendering content of the referenced block, if we have it
+func (c *Converter) RenderTransclusionReference(block *notionapi.Block) {
+	id, _ := block.PropAsString("format.transclusion_reference_pointer.id")
+	nid := notionapi.NewNotionID(id)
+	if nid == nil {
+		return
+	}
+	ref := c.Page.BlockByID(nid)
+	if ref == nil {
+		return
+	}
+	c.RenderChildren(ref)
+}
+
+// RenderDivider renders BlockDivider
+func (c *Converter) RenderDivider(block *notionapi.Block) {
+	c.WriteLines("-----")
+}
+
+// RenderBookmark renders BlockBookmark
+func (c *Converter) RenderBookmark(block *notionapi.Block) {
+	if block.Link == "" {
+		return
+	}
+	c.WriteLines(orgLink(block.Link, notionapi.TextSpansToString(block.InlineContent)))
+	c.renderCaption(block)
+}
+
+// fileURL returns url of a file (image, attachment etc.) of the block
+func (c *Converter) fileURL(block *notionapi.Block) string {
+	if c.RewriteFileURL != nil && block.Source != "" {
+		return c.RewriteFileURL(block.Source, block)
+	}
+	return block.Source
+}
+
+func fileName(uri string) string {
+	if idx := strings.IndexAny(uri, "?#"); idx >= 0 {
+		uri = uri[:idx]
+	}
+	parts := strings.Split(uri, "/")
+	return parts[len(parts)-1]
+}
+
+// RenderImage renders BlockImage as a link without description, which
+// Org mode shows as an image
+func (c *Converter) RenderImage(block *notionapi.Block) {
+	uri := c.fileURL(block)
+	if uri == "" {
+		return
+	}
+	c.renderCaption(block)
+	c.WriteLines(fileLink(uri, ""))
+}
+
+// RenderFile renders BlockFile, BlockPDF, BlockAudio and BlockVideo as
+// a link
+func (c *Converter) RenderFile(block *notionapi.Block) {
+	uri := c.fileURL(block)
+	if uri == "" {
+		return
+	}
+	name := block.Title
+	if name == "" {
+		name = fileName(block.Source)
+	}
+	c.WriteLines(fileLink(uri, name))
+	c.renderCaption(block)
+}
+
+// RenderEmbed renders BlockEmbed and other embeds as a link
+func (c *Converter) RenderEmbed(block *notionapi.Block) {
+	if block.Source == "" {
+		return
+	}
+	c.WriteLines(orgLink(block.Source, ""))
+	c.renderCaption(block)
+}
+
+// RenderDrive renders BlockDrive
+func (c *Converter) RenderDrive(block *notionapi.Block) {
+	docURL, _ := block.PropAsString("format.drive_properties.url")
+	title, _ := block.PropAsString("format.drive_properties.title")
+	if docURL == "" {
+		return
+	}
+	c.WriteLines(orgLink(docURL, title))
+}
+
+// RenderColumnList renders BlockColumnList
+// it's children are BlockColumn
+func (c *Converter) RenderColumnList(block *notionapi.Block) {
+	c.RenderChildren(block)
+}
+
+// RenderColumn renders BlockColumn
+// it's parent is BlockColumnList
+func (c *Converter) RenderColumn(block *notionapi.Block) {
+	c.RenderChildren(block)
+}
+
+// DefaultRenderFunc returns a defult rendering function for a type of
+// a given block
+func (c *Converter) DefaultRenderFunc(blockType string) func(*notionapi.Block) {
+	switch blockType {
+	case notionapi.BlockPage:
+		return c.RenderPage
+	case notionapi.BlockText:
+		return c.RenderText
+	case notionapi.BlockEquation:
+		return c.RenderEquation
+	case notionapi.BlockNumberedList:
+		return c.RenderNumberedList
+	case notionapi.BlockBulletedList:
+		return c.RenderBulletedList
+	case notionapi.BlockHeader:
+		return c.RenderHeader
+	case notionapi.BlockSubHeader:
+		return c.RenderSubHeader
+	case notionapi.BlockSubSubHeader:
+		return c.RenderSubSubHeader
+	case notionapi.BlockTodo:
+		return c.RenderTodo
+	case notionapi.BlockToggle:
+		return c.RenderToggle
+	case notionapi.BlockQuote:
+		return c.RenderQuote
+	case notionapi.BlockDivider:
+		return c.RenderDivider
+	case notionapi.BlockCode:
+		return c.RenderCode
+	case notionapi.BlockBookmark:
+		return c.RenderBookmark
+	case notionapi.BlockImage:
+		return c.RenderImage
+	case notionapi.BlockColumnList:
+		return c.RenderColumnList
+	case notionapi.BlockColumn:
+		return c.RenderColumn
+	case notionapi.BlockCollectionView:
+		return c.RenderCollectionView
+	case notionapi.BlockCollectionViewPage:
+		return c.RenderCollectionViewPage
+	case notionapi.BlockLinkToCollection:
+		return c.RenderLinkToCollection
+	case notionapi.BlockEmbed, notionapi.BlockGist, notionapi.BlockMaps,
+		notionapi.BlockCodepen, notionapi.BlockTweet, notionapi.BlockFigma,
+		notionapi.BlockMiro:
+		return c.RenderEmbed
+	case notionapi.BlockVideo, notionapi.BlockAudio, notionapi.BlockFile,
+		notionapi.BlockPDF:
+		return c.RenderFile
+	case notionapi.BlockDrive:
+		return c.RenderDrive
+	case notionapi.BlockCallout:
+		return c.RenderCallout
+	case notionapi.BlockTableOfContents:
+		return c.RenderTableOfContents
+	case notionapi.BlockAlias:
+		return c.RenderAlias
+	case notionapi.BlockTransclusionReference:
+		return c.RenderTransclusionReference
+	case notionapi.BlockBreadcrumb, notionapi.BlockFactory, notionapi.BlockComment,
+		notionapi.BlockCopyIndicator:
+		return nil
+	case notionapi.BlockLinkToPage:
+		// TODO: not sure how to render it
+		return nil
+	default:
+		maybePanic("DefaultRenderFunc: unsupported block type '%s' in %s\n", blockType, c.Page.NotionURL())
+	}
+	return nil
+}
+
+func isListItem(block *notionapi.Block) bool {
+	switch block.Type {
+	case notionapi.BlockNumberedList, notionapi.BlockBulletedList, notionapi.BlockTodo:
+		return true
+	}
+	return false
+}
+
+func (c *Converter) skipChildren(block *notionapi.Block) bool {
+	if len(block.Content) == 0 {
+		return true
+	}
+	if block.Type == notionapi.BlockPage {
+		// we don't want to render content of links to pages
+		return !c.Page.IsRoot(block)
+	}
+	return false
+}
+
+// RenderChildren renders children of the block
+func (c *Converter) RenderChildren(block *notionapi.Block) {
+	if c.skipChildren(block) {
+		return
+	}
+	currIdx := c.CurrBlockIdx
+	currBlocks := c.CurrBlocks
+	c.CurrBlocks = block.Content
+	for i, child := range block.Content {
+		c.CurrBlockIdx = i
+		c.RenderBlock(child)
+	}
+	c.CurrBlockIdx = currIdx
+	c.CurrBlocks = currBlocks
+}
+
+// RenderBlock renders a block to Org mode
+func (c *Converter) RenderBlock(block *notionapi.Block) {
+	if block == nil {
+		// a missing block, can happen if we don't have access to a referenced block
+		return
+	}
+	if c.RenderBlockOverride != nil && c.RenderBlockOverride(block) {
+		return
+	}
+	def := c.DefaultRenderFunc(block.Type)
+	if def == nil {
+		return
+	}
+	// items of a list are not separated by empty lines
+	if isListItem(block) && c.IsPrevBlockOfType(block.Type) {
+		c.Eol()
+	} else {
+		c.Newline()
+	}
+	def(block)
+}
+
+// ToOrg returns Org mode text of the page
+func (c *Converter) ToOrg() []byte {
+	c.PushNewBuffer()
+	c.RenderBlock(c.Page.Root())
+	buf := c.PopBuffer()
+	return bytes.TrimSpace(buf.Bytes())
+}
+
+// ToOrg converts a page to Org mode
+func ToOrg(page *notionapi.Page) []byte {
+	r := NewConverter(page)
+	return r.ToOrg()
+}
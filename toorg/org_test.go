@@ -0,0 +1,41 @@
+package toorg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kjk/common/assert"
+	"github.com/kjk/notionapi/internal/testutil"
+)
+
+func TestToOrg(t *testing.T) {
+	page := testutil.LoadPage(t, "page.json")
+	s := string(ToOrg(page))
+	exp := []string{
+		"#+TITLE: Report <Q1> & Notes\n",
+		"\n* Plan\n",
+		"Costs 50% of *x_1* and +marked+ see [[https://example.com/?a=1&b=2#top][docs]] and run ~make~, ask @<img src=x onerror=alert(1)>\n",
+		// to-dos are check box list items so they don't break the outline
+		"- [X] Write spec\n- [ ] Ship it\n",
+		"- Steps\n\n  1. build\n  2. test\n",
+		"Details\n:DETAILS:\n\nHidden text\n:END:\n",
+		"#+BEGIN_WARNING\n⚠️ Mind the gap\n#+END_WARNING\n",
+		"1. first\n2. second\n\n   - nested\n\nbetween lists\n\n1. restarted\n",
+		"#+BEGIN_SRC python\n,* not a headline\nif a < b:\n\tprint(\"%d\" % 5)\n#+END_SRC\n",
+		"\\[\ne^{i\\pi} + 1 = 0\n\\]\n",
+		"#+CAPTION: Chart\n[[https://example.com/chart.png]]\n",
+		"#+BEGIN_QUOTE\nLess is more\n#+END_QUOTE\n",
+		"[[file:Appendix-6a5f1c9b-1b6d-4f80-8fcd-8b6e5d7c9a0f.org][Appendix]]\n",
+		"#+CAPTION: Todo\n| Name ",
+		"| [[file:Write-tests-5f4e0b8a-0a5c-4e7f-9ebc-7a5d4c6b8f9e.org][Write tests]] | [X]  | 1,234.5  | Mar 01, 2024 | @<img src=x onerror=alert(1)> | <img src=x onerror=alert(1)> |",
+		"| Ship                                                                       | [ ]  |          |              |                               | <img src=x onerror=alert(1)> |",
+	}
+	for _, e := range exp {
+		assert.True(t, strings.Contains(s, e), "expected '%s' in:\n%s", e, s)
+	}
+}
+
+func TestOrgLink(t *testing.T) {
+	assert.Equal(t, "[[https://example.com/a%5Bb%5D][text]]", orgLink("https://example.com/a[b]", "text"))
+	assert.Equal(t, "[[https://example.com]]", orgLink("https://example.com", ""))
+}
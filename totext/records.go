@@ -0,0 +1,132 @@
+package totext
+
+import (
+	"strings"
+
+	"github.com/kjk/notionapi"
+)
+
+// Record is text of a single block, for indexing in full-text search
+type Record struct {
+	BlockID string
+	// Type is type of the block e.g. notionapi.BlockText
+	Type string
+	// Path is a list of headings the block is under, starting with
+	// title of the page
+	Path []string
+	Text string
+}
+
+// recordsBuilder tracks headings while walking blocks
+type recordsBuilder struct {
+	c *Converter
+	// titles of headings at levels 0 (page title) to 3 (BlockSubSubHeader)
+	headings [4]string
+	records  []*Record
+}
+
+func headingLevel(blockType string) int {
+	switch blockType {
+	case notionapi.BlockHeader:
+		return 1
+	case notionapi.BlockSubHeader:
+		return 2
+	case notionapi.BlockSubSubHeader:
+		return 3
+	}
+	return 0
+}
+
+func (b *recordsBuilder) path(level int) []string {
+	var res []string
+	for _, s := range b.headings[:level] {
+		if s != "" {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+func (b *recordsBuilder) add(id string, blockType string, path []string, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	b.records = append(b.records, &Record{
+		BlockID: notionapi.ToDashID(id),
+		Type:    blockType,
+		Path:    path,
+		Text:    text,
+	})
+}
+
+func (b *recordsBuilder) addBlocks(blocks []*notionapi.Block) {
+	for _, block := range blocks {
+		if block != nil {
+			b.addBlock(block)
+		}
+	}
+}
+
+func (b *recordsBuilder) addBlock(block *notionapi.Block) {
+	c := b.c
+	text := c.BlockText(block)
+	if level := headingLevel(block.Type); level > 0 {
+		// path of a heading doesn't include the heading itself
+		b.add(block.ID, block.Type, b.path(level), text)
+		b.headings[level] = text
+		for i := level + 1; i < len(b.headings); i++ {
+			b.headings[i] = ""
+		}
+		return
+	}
+	path := b.path(len(b.headings))
+	switch block.Type {
+	case notionapi.BlockPage:
+		if !c.Page.IsRoot(block) {
+			// sub-pages are indexed on their own
+			b.add(block.ID, block.Type, path, text)
+			return
+		}
+	case notionapi.BlockCollectionView, notionapi.BlockCollectionViewPage:
+		b.add(block.ID, block.Type, path, text)
+		if len(block.TableViews) == 0 {
+			return
+		}
+		tv := block.TableViews[0]
+		rowPath := path
+		if text != "" {
+			rowPath = append(append([]string{}, path...), text)
+		}
+		for i, cells := range c.TableRows(tv)[1:] {
+			b.add(tv.Rows[i].Page.ID, notionapi.BlockPage, rowPath, strings.Join(cells, " | "))
+		}
+		return
+	case notionapi.BlockTransclusionReference:
+		id, _ := block.PropAsString("format.transclusion_reference_pointer.id")
+		if nid := notionapi.NewNotionID(id); nid != nil {
+			if ref := c.Page.BlockByID(nid); ref != nil {
+				b.addBlocks(ref.Content)
+			}
+		}
+		return
+	default:
+		b.add(block.ID, block.Type, path, text)
+	}
+	b.addBlocks(block.Content)
+}
+
+// ToRecords returns text of blocks of the page, one record per block
+// that has text. Rows of collections are one record each
+func (c *Converter) ToRecords() []*Record {
+	root := c.Page.Root()
+	b := &recordsBuilder{c: c}
+	b.headings[0] = c.BlockText(root)
+	b.addBlocks(root.Content)
+	return b.records
+}
+
+// ToRecords returns search index records of a page
+func ToRecords(page *notionapi.Page) []*Record {
+	return NewConverter(page).ToRecords()
+}
@@ -0,0 +1,374 @@
+// Package totext converts a Notion page to plain text, for reading or for
+// indexing in full-text search (see Converter.ToRecords).
+package totext
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kjk/notionapi"
+)
+
+// Converter converts a Page to plain text
+type Converter struct {
+	Page *notionapi.Page
+
+	// Width, if > 0, is the maximum length of a line. Longer lines are
+	// wrapped at spaces. Code is never wrapped
+	Width int
+
+	// PageInfoProvider, if set, is used to get titles of mentioned pages
+	// that are not part of Page
+	PageInfoProvider notionapi.PageInfoProvider
+
+	// Buf is where text is being written to
+	Buf *bytes.Buffer
+
+	// type of the last rendered block, used to decide if we need
+	// an empty line before the next one
+	prevType string
+}
+
+// NewConverter returns a Converter for a page
+func NewConverter(page *notionapi.Page) *Converter {
+	return &Converter{
+		Page: page,
+	}
+}
+
+// SpanText returns text of a span. Mentions of users, pages and dates
+// are replaced with their names
+func (c *Converter) SpanText(ts *notionapi.TextSpan) string {
+	text := ts.Text
+	for _, attr := range ts.Attrs {
+		switch notionapi.AttrGetType(attr) {
+		case notionapi.AttrUser:
+			text = "@" + notionapi.GetUserNameByID(c.Page, notionapi.AttrGetUserID(attr))
+		case notionapi.AttrPage:
+			text = notionapi.PageTitle(c.Page, c.PageInfoProvider, notionapi.AttrGetPageID(attr))
+		case notionapi.AttrDate:
+			text = notionapi.FormatDate(notionapi.AttrGetDate(attr))
+		case notionapi.AttrEquation:
+			text = notionapi.AttrGetEquation(attr)
+		case notionapi.AttrLinkMention:
+			if lm := notionapi.AttrGetLinkMention(attr); lm != nil {
+				text = lm.Title
+				if text == "" {
+					text = lm.Href
+				}
+			}
+		}
+	}
+	return text
+}
+
+// InlineText returns text of spans
+func (c *Converter) InlineText(spans []*notionapi.TextSpan) string {
+	var sb strings.Builder
+	for _, ts := range spans {
+		sb.WriteString(c.SpanText(ts))
+	}
+	return sb.String()
+}
+
+func (c *Converter) caption(block *notionapi.Block) string {
+	return c.InlineText(block.GetCaption())
+}
+
+// BlockText returns text of the block itself, without text of its
+// children. Returns "" for blocks that don't have text
+func (c *Converter) BlockText(block *notionapi.Block) string {
+	switch block.Type {
+	case notionapi.BlockPage:
+		return block.Title
+	case notionapi.BlockCollectionViewPage, notionapi.BlockCollectionView:
+		return c.Page.CollectionName(block)
+	case notionapi.BlockText, notionapi.BlockHeader, notionapi.BlockSubHeader,
+		notionapi.BlockSubSubHeader, notionapi.BlockBulletedList,
+		notionapi.BlockNumberedList, notionapi.BlockTodo, notionapi.BlockToggle,
+		notionapi.BlockQuote:
+		return c.InlineText(block.InlineContent)
+	case notionapi.BlockCallout:
+		text := c.InlineText(block.InlineContent)
+		icon, _ := block.PropAsString("format.page_icon")
+		if icon != "" && !strings.HasPrefix(icon, "http") && !strings.HasPrefix(icon, "/") {
+			text = icon + " " + text
+		}
+		return text
+	case notionapi.BlockCode:
+		return block.Code
+	case notionapi.BlockEquation:
+		return notionapi.TextSpansToString(block.InlineContent)
+	case notionapi.BlockBookmark:
+		title := notionapi.TextSpansToString(block.InlineContent)
+		return joinNonEmpty(" ", title, block.Link, c.caption(block))
+	case notionapi.BlockFile:
+		return joinNonEmpty(" ", block.Title, c.caption(block))
+	case notionapi.BlockDrive:
+		title, _ := block.PropAsString("format.drive_properties.title")
+		return joinNonEmpty(" ", title, c.caption(block))
+	case notionapi.BlockImage, notionapi.BlockVideo, notionapi.BlockAudio,
+		notionapi.BlockPDF, notionapi.BlockEmbed, notionapi.BlockTweet,
+		notionapi.BlockGist, notionapi.BlockMaps, notionapi.BlockCodepen,
+		notionapi.BlockFigma:
+		return c.caption(block)
+	case notionapi.BlockAlias:
+		if format := block.FormatAlias(); format != nil && format.Alias != nil {
+			return notionapi.PageTitle(c.Page, c.PageInfoProvider, format.Alias.ID)
+		}
+	}
+	return ""
+}
+
+func joinNonEmpty(sep string, a ...string) string {
+	var res []string
+	for _, s := range a {
+		if s != "" {
+			res = append(res, s)
+		}
+	}
+	return strings.Join(res, sep)
+}
+
+// TableRows returns text of rows of a collection view. The first row
+// has names of columns
+func (c *Converter) TableRows(tv *notionapi.TableView) [][]string {
+	var header []string
+	for _, ci := range tv.Columns {
+		name := ci.Name()
+		if name == "" {
+			name = ci.ID()
+		}
+		header = append(header, name)
+	}
+	res := [][]string{header}
+	for row := range tv.Rows {
+		var cells []string
+		for col := range tv.Columns {
+			cells = append(cells, c.cellText(tv, row, col))
+		}
+		res = append(res, cells)
+	}
+	return res
+}
+
+func (c *Converter) cellText(tv *notionapi.TableView, row, col int) string {
+	ci := tv.Columns[col]
+	rowPage := tv.Rows[row].Page
+	spans := tv.CellContent(row, col)
+	if ci.Schema == nil {
+		return c.InlineText(spans)
+	}
+	switch ci.Schema.Type {
+	case notionapi.ColumnTypeNumber:
+		return notionapi.FormatNumber(notionapi.TextSpansToString(spans), ci.Schema.NumberFormat)
+	case notionapi.ColumnTypeCreatedTime:
+		return rowPage.CreatedOn().Format("2006-01-02")
+	case notionapi.ColumnTypeLastEditedTime:
+		return rowPage.LastEditedOn().Format("2006-01-02")
+	case notionapi.ColumnTypeCreatedBy:
+		return notionapi.GetUserNameByID(c.Page, rowPage.CreatedBy)
+	case notionapi.ColumnTypeLastEditedBy:
+		return notionapi.GetUserNameByID(c.Page, rowPage.LastEditedBy)
+	case notionapi.ColumnTypeMultiSelect:
+		return strings.Replace(c.InlineText(spans), ",", ", ", -1)
+	}
+	return c.InlineText(spans)
+}
+
+// wrap splits s into lines no longer than width (if possible) by
+// replacing spaces with newlines. Existing newlines are preserved
+func wrap(s string, width int) []string {
+	var res []string
+	for _, para := range strings.Split(s, "\n") {
+		if width <= 0 || utf8.RuneCountInString(para) <= width {
+			res = append(res, para)
+			continue
+		}
+		line, n := "", 0
+		for _, word := range strings.Fields(para) {
+			wn := utf8.RuneCountInString(word)
+			if n > 0 && n+1+wn > width {
+				res = append(res, line)
+				line, n = "", 0
+			}
+			if n > 0 {
+				line += " "
+				n++
+			}
+			line += word
+			n += wn
+		}
+		res = append(res, line)
+	}
+	return res
+}
+
+// writeLines writes text wrapped to Width. The first line starts with
+// prefix, the rest with indent. Returns length of the longest line,
+// without prefix
+func (c *Converter) writeLines(text string, prefix string, indent string, noWrap bool) int {
+	width := c.Width - utf8.RuneCountInString(prefix)
+	if noWrap || c.Width <= 0 {
+		width = 0
+	} else if width < 20 {
+		// don't make it unreadable when deeply nested
+		width = 20
+	}
+	longest := 0
+	for i, line := range wrap(text, width) {
+		if n := utf8.RuneCountInString(line); n > longest {
+			longest = n
+		}
+		if i == 0 {
+			line = prefix + line
+		} else {
+			line = indent + line
+		}
+		c.Buf.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	return longest
+}
+
+func isListType(blockType string) bool {
+	switch blockType {
+	case notionapi.BlockBulletedList, notionapi.BlockNumberedList,
+		notionapi.BlockTodo, notionapi.BlockToggle:
+		return true
+	}
+	return false
+}
+
+// separate writes an empty line between blocks, except between items of
+// a list
+func (c *Converter) separate(block *notionapi.Block) {
+	if c.Buf.Len() > 0 && !(isListType(block.Type) && isListType(c.prevType)) {
+		c.Buf.WriteString("\n")
+	}
+	c.prevType = block.Type
+}
+
+func (c *Converter) renderTable(tv *notionapi.TableView, indent string) {
+	for _, cells := range c.TableRows(tv) {
+		for i, cell := range cells {
+			cells[i] = strings.Replace(strings.TrimSpace(cell), "\n", " ", -1)
+		}
+		c.writeLines(strings.Join(cells, " | "), indent, indent, true)
+	}
+}
+
+// renderBlocks renders blocks, indented by indent
+func (c *Converter) renderBlocks(blocks []*notionapi.Block, indent string) {
+	listNo := 0
+	for _, block := range blocks {
+		if block == nil {
+			continue
+		}
+		if block.Type == notionapi.BlockNumberedList {
+			listNo++
+		} else {
+			listNo = 0
+		}
+		c.renderBlock(block, indent, listNo)
+	}
+}
+
+func (c *Converter) renderBlock(block *notionapi.Block, indent string, listNo int) {
+	text := c.BlockText(block)
+	childIndent := indent
+	switch block.Type {
+	case notionapi.BlockPage:
+		if !c.Page.IsRoot(block) {
+			// a link to a sub-page
+			if text != "" {
+				c.separate(block)
+				c.writeLines(text, indent, indent, false)
+			}
+			return
+		}
+		c.separate(block)
+		n := c.writeLines(text, indent, indent, false)
+		c.Buf.WriteString(indent + strings.Repeat("=", n) + "\n")
+	case notionapi.BlockHeader, notionapi.BlockSubHeader:
+		c.separate(block)
+		n := c.writeLines(text, indent, indent, false)
+		r := "="
+		if block.Type == notionapi.BlockSubHeader {
+			r = "-"
+		}
+		c.Buf.WriteString(indent + strings.Repeat(r, n) + "\n")
+	case notionapi.BlockBulletedList, notionapi.BlockToggle:
+		c.separate(block)
+		c.writeLines(text, indent+"- ", indent+"  ", false)
+		childIndent = indent + "  "
+	case notionapi.BlockNumberedList:
+		c.separate(block)
+		prefix := fmt.Sprintf("%d. ", listNo)
+		c.writeLines(text, indent+prefix, indent+strings.Repeat(" ", len(prefix)), false)
+		childIndent = indent + strings.Repeat(" ", len(prefix))
+	case notionapi.BlockTodo:
+		c.separate(block)
+		prefix := "[ ] "
+		if block.IsChecked {
+			prefix = "[x] "
+		}
+		c.writeLines(text, indent+prefix, indent+"    ", false)
+		childIndent = indent + "    "
+	case notionapi.BlockQuote, notionapi.BlockCallout:
+		c.separate(block)
+		c.writeLines(text, indent+"> ", indent+"> ", false)
+		childIndent = indent + "> "
+	case notionapi.BlockCode, notionapi.BlockEquation:
+		c.separate(block)
+		c.writeLines(text, indent+"    ", indent+"    ", true)
+		if caption := c.caption(block); caption != "" {
+			c.writeLines(caption, indent, indent, false)
+		}
+	case notionapi.BlockDivider:
+		c.separate(block)
+		n := c.Width - len(indent)
+		if n <= 0 || n > 40 {
+			n = 40
+		}
+		c.Buf.WriteString(indent + strings.Repeat("-", n) + "\n")
+	case notionapi.BlockCollectionView, notionapi.BlockCollectionViewPage:
+		c.separate(block)
+		if text != "" {
+			c.writeLines(text, indent, indent, false)
+		}
+		if len(block.TableViews) > 0 {
+			c.renderTable(block.TableViews[0], indent)
+		}
+		return
+	case notionapi.BlockTransclusionReference:
+		id, _ := block.PropAsString("format.transclusion_reference_pointer.id")
+		if nid := notionapi.NewNotionID(id); nid != nil {
+			if ref := c.Page.BlockByID(nid); ref != nil {
+				c.renderBlocks(ref.Content, indent)
+			}
+		}
+		return
+	default:
+		if text != "" {
+			c.separate(block)
+			c.writeLines(text, indent, indent, false)
+		}
+	}
+	c.renderBlocks(block.Content, childIndent)
+}
+
+// ToText converts a page to plain text
+func (c *Converter) ToText() []byte {
+	c.Buf = &bytes.Buffer{}
+	c.prevType = ""
+	c.renderBlock(c.Page.Root(), "", 0)
+	d := bytes.TrimSpace(c.Buf.Bytes())
+	return append(d, '\n')
+}
+
+// ToText converts a page to plain text
+func ToText(page *notionapi.Page) []byte {
+	return NewConverter(page).ToText()
+}
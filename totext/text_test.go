@@ -0,0 +1,103 @@
+package totext
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kjk/common/assert"
+	"github.com/kjk/notionapi"
+)
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		exp   []string
+	}{
+		{"", 10, []string{""}},
+		{"short", 10, []string{"short"}},
+		{"one two three four", 0, []string{"one two three four"}},
+		{"one two three four", 9, []string{"one two", "three", "four"}},
+		{"one\ntwo three", 5, []string{"one", "two", "three"}},
+		{"verylongword x", 4, []string{"verylongword", "x"}},
+		{"zażółć gęślą", 6, []string{"zażółć", "gęślą"}},
+	}
+	for _, test := range tests {
+		got := wrap(test.s, test.width)
+		assert.Equal(t, test.exp, got)
+	}
+}
+
+func TestRenderBlocks(t *testing.T) {
+	text := func(s string) []*notionapi.TextSpan {
+		return []*notionapi.TextSpan{{Text: s}}
+	}
+	blocks := []*notionapi.Block{
+		{Type: notionapi.BlockHeader, InlineContent: text("Intro")},
+		{Type: notionapi.BlockText, InlineContent: text("The quick brown fox jumps over the lazy dog")},
+		{Type: notionapi.BlockNumberedList, InlineContent: text("first"), Content: []*notionapi.Block{
+			{Type: notionapi.BlockBulletedList, InlineContent: text("nested")},
+		}},
+		{Type: notionapi.BlockNumberedList, InlineContent: text("second")},
+		{Type: notionapi.BlockTodo, InlineContent: text("done"), IsChecked: true},
+		{Type: notionapi.BlockCode, Code: "if (a && b) {\n\treturn\n}"},
+		{Type: notionapi.BlockQuote, InlineContent: text("to be or not to be")},
+		{Type: notionapi.BlockDivider},
+		{Type: notionapi.BlockImage, Properties: map[string]interface{}{
+			"caption": []interface{}{[]interface{}{"A picture"}},
+		}},
+	}
+	c := NewConverter(nil)
+	c.Width = 24
+	c.Buf = &bytes.Buffer{}
+	c.renderBlocks(blocks, "")
+	exp := `
+Intro
+=====
+
+The quick brown fox
+jumps over the lazy dog
+
+1. first
+   - nested
+2. second
+[x] done
+
+    if (a && b) {
+    	return
+    }
+
+> to be or not to be
+
+------------------------
+
+A picture
+`
+	assert.Equal(t, strings.TrimPrefix(exp, "\n"), c.Buf.String())
+}
+
+func TestToRecords(t *testing.T) {
+	cc, err := notionapi.NewCachingClient("../caching_client_testdata", &notionapi.Client{})
+	assert.NoError(t, err)
+	cc.Policy = notionapi.PolicyCacheOnly
+	page, err := cc.DownloadPage("6682351e44bb4f9ca0e149b703265bdb")
+	assert.NoError(t, err)
+
+	s := string(ToText(page))
+	assert.True(t, strings.HasPrefix(s, "Test headers\n============\n\n"), "got:\n%s", s)
+	assert.True(t, strings.Contains(s, "\nThis is a sub-header\n--------------------\n\nWith text.\n"), "got:\n%s", s)
+
+	records := ToRecords(page)
+	assert.Equal(t, 5, len(records))
+	r := records[0]
+	assert.Equal(t, notionapi.BlockHeader, r.Type)
+	assert.Equal(t, []string{"Test headers"}, r.Path)
+	header := r.Text
+
+	r = records[4]
+	assert.Equal(t, "c40f0c8d-4cac-42b8-8ca4-91aa201b29c7", r.BlockID)
+	assert.Equal(t, notionapi.BlockText, r.Type)
+	assert.Equal(t, "More text.", r.Text)
+	assert.Equal(t, []string{"Test headers", header, "This is a sub-header", "This is a sub-sub-header"}, r.Path)
+}
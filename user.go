@@ -15,19 +15,20 @@ type NotionUser struct {
 	RawJSON map[string]interface{} `json:"-"`
 }
 
+// SpaceViewPointer points to a SpaceView of a space user belongs to
+type SpaceViewPointer struct {
+	ID      string `json:"id"`
+	Table   string `json:"table"`
+	SpaceID string `json:"spaceId"`
+}
+
+// UserRoot links a user to spaces (workspaces) user belongs to
 type UserRoot struct {
-	Role  string `json:"role"`
-	Value struct {
-		ID                string   `json:"id"`
-		Version           int      `json:"version"`
-		SpaceViews        []string `json:"space_views"`
-		LeftSpaces        []string `json:"left_spaces"`
-		SpaceViewPointers []struct {
-			ID      string `json:"id"`
-			Table   string `json:"table"`
-			SpaceID string `json:"spaceId"`
-		} `json:"space_view_pointers"`
-	} `json:"value"`
+	ID                string              `json:"id"`
+	Version           int                 `json:"version"`
+	SpaceViews        []string            `json:"space_views"`
+	LeftSpaces        []string            `json:"left_spaces"`
+	SpaceViewPointers []*SpaceViewPointer `json:"space_view_pointers"`
 
 	RawJSON map[string]interface{} `json:"-"`
 }
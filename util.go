@@ -117,6 +117,15 @@ func isIDEqual(id1, id2 string) bool {
 	return id1 == id2
 }
 
+func hasString(a []string, s string) bool {
+	for _, s2 := range a {
+		if s == s2 {
+			return true
+		}
+	}
+	return false
+}
+
 func isSafeChar(r rune) bool {
 	if r >= '0' && r <= '9' {
 		return true